@@ -2,15 +2,21 @@ package main
 
 import (
 	"crypto/subtle"
+	"errors"
 	"github.com/alaingilbert/ogame/pkg/device"
+	"github.com/alaingilbert/ogame/pkg/intel"
+	"github.com/alaingilbert/ogame/pkg/storage"
+	"github.com/alaingilbert/ogame/pkg/utils"
 	"github.com/alaingilbert/ogame/pkg/wrapper"
 	"github.com/alaingilbert/ogame/pkg/wrapper/solvers"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"gopkg.in/urfave/cli.v2"
 	"log"
+	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 )
 
 var version = "0.0.0"
@@ -158,6 +164,72 @@ func main() {
 			Value:   "device_name",
 			EnvVars: []string{"OGAMED_DEVICENAME"},
 		},
+		&cli.StringFlag{
+			Name:    "intel-file",
+			Usage:   "Save espionage reports, galaxy and highscore results in this bbolt database file (disabled if empty)",
+			Value:   "",
+			EnvVars: []string{"OGAMED_INTEL_FILE"},
+		},
+		&cli.DurationFlag{
+			Name:    "intel-retention",
+			Usage:   "Results older than this are dropped from the intel database, the latest one of each planet and system is kept (0 keeps everything)",
+			Value:   intel.DefaultRetention,
+			EnvVars: []string{"OGAMED_INTEL_RETENTION"},
+		},
+		&cli.StringFlag{
+			Name:    "log-format",
+			Usage:   "Format of the logs (text | json)",
+			Value:   "text",
+			EnvVars: []string{"OGAMED_LOG_FORMAT"},
+		},
+		&cli.StringFlag{
+			Name:    "log-level",
+			Usage:   "Minimum level of the logs, for all or per subsystem (login, chat, requests, extractor) eg: info,requests=warn",
+			Value:   "",
+			EnvVars: []string{"OGAMED_LOG_LEVEL"},
+		},
+		&cli.IntFlag{
+			Name:    "retry-max-attempts",
+			Usage:   "Attempts of a page request before it fails (1 disables the retries)",
+			Value:   wrapper.DefaultRetryPolicy.MaxAttempts,
+			EnvVars: []string{"OGAMED_RETRY_MAX_ATTEMPTS"},
+		},
+		&cli.IntFlag{
+			Name:    "circuit-breaker-failures",
+			Usage:   "Consecutive failed requests, after all their retries, that stop the requests to the server for the cooldown (0 disables the circuit breaker)",
+			Value:   wrapper.DefaultCircuitBreakerFailures,
+			EnvVars: []string{"OGAMED_CIRCUIT_BREAKER_FAILURES"},
+		},
+		&cli.DurationFlag{
+			Name:    "circuit-breaker-cooldown",
+			Usage:   "Time without requests once the circuit breaker is open",
+			Value:   wrapper.DefaultCircuitBreakerCooldown,
+			EnvVars: []string{"OGAMED_CIRCUIT_BREAKER_COOLDOWN"},
+		},
+		&cli.DurationFlag{
+			Name:    "maintenance-poll-interval",
+			Usage:   "Time between two checks of the server during a maintenance",
+			Value:   wrapper.DefaultMaintenancePollInterval,
+			EnvVars: []string{"OGAMED_MAINTENANCE_POLL_INTERVAL"},
+		},
+		&cli.StringFlag{
+			Name:    "storage",
+			Usage:   "Where cookies, bearer token, fingerprint and debug dumps are kept (file | memory | encrypted-file)",
+			Value:   "file",
+			EnvVars: []string{"OGAMED_STORAGE"},
+		},
+		&cli.StringFlag{
+			Name:    "storage-dir",
+			Usage:   "Directory of the file and encrypted-file storages (default: ~/.ogame)",
+			Value:   "",
+			EnvVars: []string{"OGAMED_STORAGE_DIR"},
+		},
+		&cli.StringFlag{
+			Name:    "storage-passphrase",
+			Usage:   "Passphrase of the encrypted-file storage",
+			Value:   "",
+			EnvVars: []string{"OGAMED_STORAGE_PASSPHRASE"},
+		},
 	}
 	app.Action = start
 	if err := app.Run(os.Args); err != nil {
@@ -165,6 +237,55 @@ func main() {
 	}
 }
 
+func newStore(kind, dir, passphrase string) (storage.Store, error) {
+	if dir == "" {
+		dir = storage.DefaultDir()
+	}
+	switch kind {
+	case "file":
+		return storage.NewFileStore(dir), nil
+	case "memory":
+		return storage.NewMemoryStore(), nil
+	case "encrypted-file":
+		return storage.NewEncryptedFileStore(dir, passphrase)
+	}
+	return nil, errors.New("invalid storage " + kind)
+}
+
+func newLogHandler(format string) (slog.Handler, error) {
+	switch format {
+	case "text":
+		return nil, nil // Default coloured text handler of the wrapper
+	case "json":
+		return wrapper.NewJSONLogHandler(os.Stdout), nil
+	}
+	return nil, errors.New("invalid log format " + format)
+}
+
+// Parse "level" or "level,subsystem=level,...", a level without subsystem applies to all of them
+func parseLogLevels(s string) (map[wrapper.LogSubsystem]slog.Level, error) {
+	levels := make(map[wrapper.LogSubsystem]slog.Level)
+	subsystems := []wrapper.LogSubsystem{wrapper.GeneralLog, wrapper.LoginLog, wrapper.ChatLog, wrapper.RequestsLog, wrapper.ExtractorLog}
+	for _, part := range strings.Split(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		subsystem, levelStr, found := strings.Cut(part, "=")
+		level, err := wrapper.ParseLogLevel(utils.Ternary(found, levelStr, subsystem))
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			for _, subsystem := range subsystems {
+				levels[subsystem] = level
+			}
+			continue
+		}
+		levels[wrapper.LogSubsystem(strings.TrimSpace(subsystem))] = level
+	}
+	return levels, nil
+}
+
 func start(c *cli.Context) error {
 	universe := c.String("universe")
 	username := c.String("username")
@@ -188,6 +309,19 @@ func start(c *cli.Context) error {
 	corsEnabled := c.Bool("cors-enabled")
 	njaApiKey := c.String("nja-api-key")
 	deviceName := c.String("device-name")
+	intelFile := c.String("intel-file")
+	logHandler, err := newLogHandler(c.String("log-format"))
+	if err != nil {
+		return err
+	}
+	logLevels, err := parseLogLevels(c.String("log-level"))
+	if err != nil {
+		return err
+	}
+	store, err := newStore(c.String("storage"), c.String("storage-dir"), c.String("storage-passphrase"))
+	if err != nil {
+		return err
+	}
 	// TODO: put device config in flags & env variables
 	deviceInst, err := device.NewBuilder(deviceName).
 		SetOsName(device.Windows).
@@ -199,6 +333,7 @@ func start(c *cli.Context) error {
 		SetScreenHeight(900).
 		SetTimezone("America/Los_Angeles").
 		SetLanguages("en-US,en").
+		SetStorage(store).
 		Build()
 	if err != nil {
 		panic(err)
@@ -218,15 +353,34 @@ func start(c *cli.Context) error {
 		ProxyLoginOnly: proxyLoginOnly,
 		Lobby:          lobby,
 		APINewHostname: apiNewHostname,
+		LogHandler:     logHandler,
+		LogLevels:      logLevels,
 	}
+	retryPolicy := wrapper.DefaultRetryPolicy
+	retryPolicy.MaxAttempts = c.Int("retry-max-attempts")
+	params.RetryPolicy = &retryPolicy
+	params.MaintenancePoll = c.Duration("maintenance-poll-interval")
 	if njaApiKey != "" {
 		params.CaptchaCallback = solvers.NinjaSolver(njaApiKey)
 	}
+	if intelFile != "" {
+		intelStore, err := intel.NewBoltStoreWithConfig(intelFile, intel.BoltStoreConfig{Retention: c.Duration("intel-retention")})
+		if err != nil {
+			return err
+		}
+		defer intelStore.Close()
+		params.IntelStore = intelStore
+	}
 
 	bot, err := wrapper.NewWithParams(params)
 	if err != nil {
 		return err
 	}
+	if failures := c.Int("circuit-breaker-failures"); failures > 0 {
+		bot.SetCircuitBreaker(wrapper.NewCircuitBreaker(failures, c.Duration("circuit-breaker-cooldown")))
+	} else {
+		bot.SetCircuitBreaker(nil)
+	}
 
 	e := echo.New()
 	if corsEnabled {
@@ -257,6 +411,7 @@ func start(c *cli.Context) error {
 	e.Debug = false
 	e.GET("/", wrapper.HomeHandler)
 	e.GET("/tasks", wrapper.TasksHandler)
+	e.GET("/metrics", wrapper.MetricsHandler)
 
 	// CAPTCHA Handler
 	e.GET("/bot/captcha", wrapper.GetCaptchaHandler)
@@ -278,6 +433,7 @@ func start(c *cli.Context) error {
 	e.GET("/bot/server/speed", wrapper.GetUniverseSpeedHandler)
 	e.GET("/bot/server/speed-fleet", wrapper.GetUniverseSpeedFleetHandler)
 	e.GET("/bot/server/version", wrapper.ServerVersionHandler)
+	e.GET("/bot/server/status", wrapper.ServerStatusHandler)
 	e.GET("/bot/server/time", wrapper.ServerTimeHandler)
 	e.GET("/bot/is-under-attack", wrapper.IsUnderAttackHandler)
 	e.GET("/bot/is-vacation-mode", wrapper.IsVacationModeHandler)
@@ -289,10 +445,23 @@ func start(c *cli.Context) error {
 	e.GET("/bot/has-geologist", wrapper.HasGeologistHandler)
 	e.GET("/bot/has-technocrat", wrapper.HasTechnocratHandler)
 	e.POST("/bot/send-message", wrapper.SendMessageHandler)
+	e.GET("/bot/alliance", wrapper.GetAllianceOverviewHandler)
+	e.GET("/bot/alliance/applications", wrapper.GetAllianceApplicationsHandler)
+	e.POST("/bot/alliance/applications/:applicationID/accept", wrapper.AcceptAllianceApplicationHandler)
+	e.POST("/bot/alliance/applications/:applicationID/deny", wrapper.DenyAllianceApplicationHandler)
+	e.POST("/bot/alliance/broadcast", wrapper.SendAllianceBroadcastHandler)
+	e.GET("/bot/buddies", wrapper.GetBuddiesHandler)
+	e.GET("/bot/buddies/requests", wrapper.GetBuddyRequestsHandler)
+	e.POST("/bot/buddies/requests", wrapper.SendBuddyRequestHandler)
+	e.POST("/bot/buddies/requests/:requestID/accept", wrapper.AcceptBuddyRequestHandler)
+	e.POST("/bot/buddies/requests/:requestID/reject", wrapper.RejectBuddyRequestHandler)
+	e.GET("/bot/conversations", wrapper.GetConversationsHandler)
+	e.GET("/bot/conversations/:playerID", wrapper.GetConversationHandler)
 	e.GET("/bot/fleets", wrapper.GetFleetsHandler)
 	e.GET("/bot/fleets/slots", wrapper.GetSlotsHandler)
 	e.POST("/bot/fleets/:fleetID/cancel", wrapper.CancelFleetHandler)
 	e.GET("/bot/espionage-report/:msgid", wrapper.GetEspionageReportHandler)
+	e.GET("/bot/combat-report/:msgid", wrapper.GetCombatReportHandler)
 	e.GET("/bot/espionage-report/:galaxy/:system/:position", wrapper.GetEspionageReportForHandler)
 	e.GET("/bot/espionage-report", wrapper.GetEspionageReportMessagesHandler)
 	e.POST("/bot/delete-report/:messageID", wrapper.DeleteMessageHandler)
@@ -326,6 +495,7 @@ func start(c *cli.Context) error {
 	e.GET("/bot/planets/:planetID/defence", wrapper.GetDefenseHandler)
 	e.GET("/bot/planets/:planetID/ships", wrapper.GetShipsHandler)
 	e.GET("/bot/planets/:planetID/facilities", wrapper.GetFacilitiesHandler)
+	e.GET("/bot/planets/:planetID/unions", wrapper.GetUnionsHandler)
 	e.POST("/bot/planets/:planetID/build/:ogameID/:nbr", wrapper.BuildHandler)
 	e.POST("/bot/planets/:planetID/build/cancelable/:ogameID", wrapper.BuildCancelableHandler)
 	e.POST("/bot/planets/:planetID/build/production/:ogameID/:nbr", wrapper.BuildProductionHandler)
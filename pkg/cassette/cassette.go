@@ -0,0 +1,132 @@
+// Package cassette records the game traffic seen by the wrapper html interceptor into a file,
+// and replays it with an http.RoundTripper.
+//
+// Session ids, tokens and cookies are redacted before anything is stored, so a cassette can be shared to reproduce
+// an extractor regression without giving away the credentials of the account.
+//
+//	rec := cassette.NewRecorder()
+//	bot.RegisterHTMLInterceptor(rec.Record)
+//	...
+//	_ = rec.Save("ogame.cassette.json")
+//
+//	c, _ := cassette.Load("ogame.cassette.json")
+//	dev.GetClient().SetTransport(cassette.NewRoundTripper(c))
+package cassette
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"time"
+)
+
+// Version of the cassette format, bumped on breaking changes
+const Version = 1
+
+// Redacted replaces every secret. It only contains word characters so the pages are still seen as logged in.
+const Redacted = "REDACTED"
+
+// Cassette recorded game traffic
+type Cassette struct {
+	Version      int           `json:"version"`
+	OGameVersion string        `json:"ogameVersion"` // Version of the game, from the first recorded page that has it
+	RecordedAt   time.Time     `json:"recordedAt"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction one request and its response body
+type Interaction struct {
+	Method  string     `json:"method"`
+	URL     string     `json:"url"`
+	Params  url.Values `json:"params,omitempty"`
+	Payload url.Values `json:"payload,omitempty"`
+	Body    string     `json:"body"`
+}
+
+// Load reads a cassette file
+func Load(path string) (*Cassette, error) {
+	by, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(by)
+}
+
+// Parse decodes a cassette
+func Parse(by []byte) (*Cassette, error) {
+	var c Cassette
+	if err := json.Unmarshal(by, &c); err != nil {
+		return nil, err
+	}
+	if c.Version < 1 || c.Version > Version {
+		return nil, fmt.Errorf("unsupported cassette version %d", c.Version)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to a file
+func (c *Cassette) Save(path string) error {
+	by, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, by, 0644)
+}
+
+// Names of the query/form parameters that are redacted
+var secretParams = map[string]bool{"token": true, "sid": true, "session": true, "sessionId": true}
+
+var (
+	// <meta name="ogame-session" content="..."/>
+	sessionMetaRgx = regexp.MustCompile(`(<meta name="ogame-session" content=")[^"]*(")`)
+	// var token = "...", "newAjaxToken":"...", ajaxChatToken='...', var session="..."
+	secretVarRgx = regexp.MustCompile(`(?i)(\w*(?:token|session)\b["']?\s*[:=]\s*\\?["'])[^"'\\]*(\\?["'])`)
+	// ...&amp;token=...
+	secretParamRgx = regexp.MustCompile(`([?&;](?:token|sid|session)=)[^&"'\s#\\]+`)
+	// PHPSESSID=..., prsess_123=..., gf-token-production=...
+	cookieRgx = regexp.MustCompile(`((?:PHPSESSID|prsess_\d+|gf-token-production)=)[^;"'&\s]+`)
+	// <meta name="ogame-version" content="11.15.5"/>
+	ogameVersionRgx = regexp.MustCompile(`<meta name="ogame-version" content="([^"]+)"`)
+)
+
+// RedactBody removes session ids, tokens and cookies from a page
+func RedactBody(body string) string {
+	body = sessionMetaRgx.ReplaceAllString(body, "${1}"+Redacted+"${2}")
+	body = secretVarRgx.ReplaceAllString(body, "${1}"+Redacted+"${2}")
+	body = secretParamRgx.ReplaceAllString(body, "${1}"+Redacted)
+	body = cookieRgx.ReplaceAllString(body, "${1}"+Redacted)
+	return body
+}
+
+// RedactURL removes the secret query parameters of an url
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return secretParamRgx.ReplaceAllString(rawURL, "${1}"+Redacted)
+	}
+	u.User = nil
+	u.RawQuery = RedactValues(u.Query()).Encode()
+	return u.String()
+}
+
+// RedactValues returns a copy of the values with the secret parameters redacted
+func RedactValues(vals url.Values) url.Values {
+	if vals == nil {
+		return nil
+	}
+	out := make(url.Values, len(vals))
+	for k, v := range vals {
+		if secretParams[k] {
+			redacted := make([]string, len(v))
+			for i := range redacted {
+				redacted[i] = Redacted
+			}
+			out[k] = redacted
+			continue
+		}
+		out[k] = append([]string(nil), v...)
+	}
+	return out
+}
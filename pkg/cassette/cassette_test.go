@@ -0,0 +1,98 @@
+package cassette
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alaingilbert/ogame/pkg/extractor/v11_13_0"
+	"github.com/alaingilbert/ogame/pkg/httpclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactBody(t *testing.T) {
+	assert.Equal(t, `<meta name="ogame-session" content="REDACTED"/>`, RedactBody(`<meta name="ogame-session" content="a635929d1427de5cb5c396580b84adbff3a6562b"/>`))
+	assert.Equal(t, `var token = "REDACTED";`, RedactBody(`var token = "53eabe9b910c4d7d921baf102cc9c997";`))
+	assert.Equal(t, `window.token = 'REDACTED'`, RedactBody(`window.token = '53eabe9b910c4d7d921baf102cc9c997'`))
+	assert.Equal(t, `{"status":"success","newAjaxToken":"REDACTED"}`, RedactBody(`{"status":"success","newAjaxToken":"4f8b3e1c0b1e4a9f"}`))
+	assert.Equal(t, `var ajaxChatToken='REDACTED';var session="REDACTED";`, RedactBody(`var ajaxChatToken='38941f249ff87c69947baccfc192ad3d';var session="eb273dda2fcc";`))
+	assert.Equal(t, `href="index.php?page=ingame&amp;component=movement&amp;return=1&amp;token=REDACTED"`,
+		RedactBody(`href="index.php?page=ingame&amp;component=movement&amp;return=1&amp;token=9d899f03a837e66e"`))
+	assert.Equal(t, `document.cookie = "PHPSESSID=REDACTED; path=/"`, RedactBody(`document.cookie = "PHPSESSID=e5c1f0b2a7; path=/"`))
+	assert.Equal(t, `var currentPage = "overview";`, RedactBody(`var currentPage = "overview";`))
+}
+
+func TestRedactURL(t *testing.T) {
+	assert.Equal(t, "https://s252-en.ogame.gameforge.com/game/lobbylogin.php?id=1&token=REDACTED",
+		RedactURL("https://s252-en.ogame.gameforge.com/game/lobbylogin.php?id=1&token=4f8b3e1c"))
+	assert.Equal(t, url.Values{"token": {Redacted}, "metal": {"10"}}, RedactValues(url.Values{"token": {"4f8b3e1c"}, "metal": {"10"}}))
+	assert.Nil(t, RedactValues(nil))
+}
+
+func TestRecorder_SaveLoad(t *testing.T) {
+	pageHTML, _ := os.ReadFile("../../samples/v11.13.0/en/overview.html")
+	rec := NewRecorder()
+	rec.Record(http.MethodGet, "https://s252-en.ogame.gameforge.com/game/index.php?page=ingame&component=overview", url.Values{"page": {"ingame"}, "component": {"overview"}}, nil, pageHTML)
+	rec.Record(http.MethodPost, "https://s252-en.ogame.gameforge.com/game/index.php?page=ingame&component=fleetdispatch&action=checkTarget&ajax=1&asJson=1",
+		url.Values{"page": {"ingame"}}, url.Values{"token": {"53eabe9b910c4d7d921baf102cc9c997"}, "galaxy": {"1"}}, []byte(`{"targetOk":true,"newAjaxToken":"d2c1e0f9a8b7"}`))
+
+	c := rec.Cassette()
+	assert.Equal(t, Version, c.Version)
+	assert.Equal(t, "11.13.0", c.OGameVersion)
+	assert.Equal(t, 2, len(c.Interactions))
+	assert.False(t, strings.Contains(c.Interactions[0].Body, "a635929d1427de5cb5c396580b84adbff3a6562b"))
+	assert.False(t, strings.Contains(c.Interactions[0].Body, "53eabe9b910c4d7d921baf102cc9c997"))
+	assert.Equal(t, url.Values{"token": {Redacted}, "galaxy": {"1"}}, c.Interactions[1].Payload)
+
+	path := filepath.Join(t.TempDir(), "ogame.cassette.json")
+	assert.NoError(t, rec.Save(path))
+	loaded, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, c.Interactions, loaded.Interactions)
+	assert.Equal(t, "11.13.0", loaded.OGameVersion)
+
+	_, err = Parse([]byte(`{"version":99,"interactions":[]}`))
+	assert.Error(t, err)
+}
+
+func TestRoundTripper(t *testing.T) {
+	pageHTML, _ := os.ReadFile("../../samples/v11.13.0/en/overview.html")
+	rec := NewRecorder()
+	rec.Record(http.MethodGet, "https://s252-en.ogame.gameforge.com/game/index.php?page=ingame&component=overview", nil, nil, pageHTML)
+	rec.Record(http.MethodGet, "https://s252-en.ogame.gameforge.com/game/index.php?page=fetchResources&ajax=1", nil, nil, []byte(`{"n":1}`))
+	rec.Record(http.MethodGet, "https://s252-en.ogame.gameforge.com/game/index.php?page=fetchResources&ajax=1", nil, nil, []byte(`{"n":2}`))
+
+	client := httpclient.NewClient("test")
+	client.SetTransport(NewRoundTripper(rec.Cassette()))
+	get := func(rawURL string) (string, error) {
+		resp, err := client.Get(rawURL)
+		if err != nil {
+			return "", err
+		}
+		by, _ := io.ReadAll(resp.Body)
+		return string(by), nil
+	}
+
+	// Query order does not matter
+	body, err := get("https://s252-en.ogame.gameforge.com/game/index.php?component=overview&page=ingame")
+	assert.NoError(t, err)
+	infos, err := v11_13_0.NewExtractor().ExtractUserInfos([]byte(body))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100538), infos.PlayerID)
+	assert.Equal(t, "Mogul Euler", infos.PlayerName)
+
+	// Repeated requests are served in order, the last one is repeated
+	body, _ = get("https://s252-en.ogame.gameforge.com/game/index.php?page=fetchResources&ajax=1")
+	assert.Equal(t, `{"n":1}`, body)
+	body, _ = get("https://s252-en.ogame.gameforge.com/game/index.php?page=fetchResources&ajax=1")
+	assert.Equal(t, `{"n":2}`, body)
+	body, _ = get("https://s252-en.ogame.gameforge.com/game/index.php?page=fetchResources&ajax=1")
+	assert.Equal(t, `{"n":2}`, body)
+
+	_, err = get("https://s252-en.ogame.gameforge.com/game/index.php?page=ingame&component=galaxy")
+	assert.ErrorIs(t, err, ErrNoInteraction)
+}
@@ -0,0 +1,52 @@
+package cassette
+
+import (
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Recorder collects the traffic of the html interceptor, it is safe for concurrent use
+type Recorder struct {
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder creates a recorder with an empty cassette
+func NewRecorder() *Recorder {
+	return &Recorder{cassette: Cassette{Version: Version, RecordedAt: time.Now(), Interactions: make([]Interaction, 0)}}
+}
+
+// Record redacts and appends an interaction. It has the signature expected by RegisterHTMLInterceptor.
+func (r *Recorder) Record(method, rawURL string, params, payload url.Values, pageHTML []byte) {
+	body := string(pageHTML)
+	interaction := Interaction{
+		Method:  method,
+		URL:     RedactURL(rawURL),
+		Params:  RedactValues(params),
+		Payload: RedactValues(payload),
+		Body:    RedactBody(body),
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cassette.OGameVersion == "" {
+		if m := ogameVersionRgx.FindStringSubmatch(body); len(m) == 2 {
+			r.cassette.OGameVersion = m[1]
+		}
+	}
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+}
+
+// Cassette returns a copy of what has been recorded so far
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.cassette
+	c.Interactions = append([]Interaction(nil), r.cassette.Interactions...)
+	return &c
+}
+
+// Save writes what has been recorded so far to a file
+func (r *Recorder) Save(path string) error {
+	return r.Cassette().Save(path)
+}
@@ -0,0 +1,89 @@
+package cassette
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNoInteraction returned by the RoundTripper when the cassette has nothing recorded for a request
+var ErrNoInteraction = errors.New("no recorded interaction for request")
+
+// RoundTripper serves the responses of a cassette.
+// Requests are matched on method, path and query (secrets redacted), the payload is ignored.
+// When a request has been recorded several times, the responses are served in the recorded order,
+// and the last one is repeated once exhausted.
+type RoundTripper struct {
+	mu        sync.Mutex
+	responses map[string][]Interaction
+	served    map[string]int
+}
+
+// NewRoundTripper creates a RoundTripper that replays the cassette.
+// Set it on the client of the device with SetTransport.
+func NewRoundTripper(c *Cassette) *RoundTripper {
+	rt := &RoundTripper{responses: make(map[string][]Interaction), served: make(map[string]int)}
+	for _, interaction := range c.Interactions {
+		key := interactionKey(interaction.Method, interaction.URL)
+		rt.responses[key] = append(rt.responses[key], interaction)
+	}
+	return rt
+}
+
+// RoundTrip implements http.RoundTripper
+func (rt *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	key := interactionKey(req.Method, req.URL.String())
+	rt.mu.Lock()
+	interactions := rt.responses[key]
+	idx := rt.served[key]
+	if idx < len(interactions)-1 {
+		rt.served[key]++
+	}
+	rt.mu.Unlock()
+	if len(interactions) == 0 {
+		return nil, ErrNoInteraction
+	}
+	body := interactions[idx].Body
+	contentType := "text/html; charset=UTF-8"
+	if trimmed := strings.TrimSpace(body); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		contentType = "application/json"
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {contentType}},
+		Body:          io.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Key of a request: method, host, path and the sorted query, with the secrets redacted
+func interactionKey(method, rawURL string) string {
+	u, err := url.Parse(RedactURL(rawURL))
+	if err != nil {
+		return method + " " + rawURL
+	}
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+strings.Join(query[k], ","))
+	}
+	return method + " " + u.Host + u.Path + "?" + strings.Join(parts, "&")
+}
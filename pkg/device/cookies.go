@@ -0,0 +1,96 @@
+package device
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/storage"
+	cookiejar "github.com/orirawlings/persistent-cookiejar"
+)
+
+// Cookie as serialized by persistent-cookiejar, keeps the files written before the storage was introduced readable
+type storedCookie struct {
+	Name          string
+	Value         string
+	Domain        string
+	Path          string
+	Secure        bool
+	HttpOnly      bool
+	Persistent    bool
+	HostOnly      bool
+	Expires       time.Time
+	CanonicalHost string
+}
+
+// Merge the cookies saved in the store into the jar
+func loadCookies(jar *cookiejar.Jar, store storage.Store, deviceName string) error {
+	by, err := store.Get(storage.CookiesKey(deviceName))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var cookies []storedCookie
+	if err := json.Unmarshal(by, &cookies); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, c := range cookies {
+		if c.Persistent && !c.Expires.After(now) {
+			continue
+		}
+		host := c.CanonicalHost
+		if host == "" {
+			host = strings.TrimPrefix(c.Domain, ".")
+		}
+		cookie := &http.Cookie{Name: c.Name, Value: c.Value, Path: c.Path, Secure: c.Secure, HttpOnly: c.HttpOnly}
+		if !c.HostOnly {
+			cookie.Domain = c.Domain
+		}
+		if c.Persistent {
+			cookie.Expires = c.Expires
+		}
+		jar.SetCookies(&url.URL{Scheme: "https", Host: host, Path: c.Path}, []*http.Cookie{cookie})
+	}
+	return nil
+}
+
+// SaveCookies saves the cookie jar of the client into the storage of the device
+func (d *Device) SaveCookies() error {
+	jar, ok := d.client.Jar.(*cookiejar.Jar)
+	if !ok {
+		return nil
+	}
+	by, err := jar.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return d.store.Set(storage.CookiesKey(d.name), by)
+}
+
+// SetStorage moves the session of the device to another store.
+// The cookies already in that store are merged into the jar,
+// then the cookies and the fingerprint of the device are saved into it.
+func (d *Device) SetStorage(store storage.Store) error {
+	if jar, ok := d.client.Jar.(*cookiejar.Jar); ok {
+		if err := loadCookies(jar, store, d.name); err != nil {
+			return err
+		}
+	}
+	if p, ok := d.persistor.(*StorePersistor); ok {
+		fprt, err := p.Load()
+		if err != nil {
+			return err
+		}
+		p.store = store
+		if err := p.Save(fprt); err != nil {
+			return err
+		}
+	}
+	d.store = store
+	return d.SaveCookies()
+}
@@ -0,0 +1,61 @@
+package device
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/storage"
+	cookiejar "github.com/orirawlings/persistent-cookiejar"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestDevice(t *testing.T, store storage.Store) *Device {
+	d, err := NewBuilder("test").
+		SetOsName(Windows).
+		SetBrowserName(Chrome).
+		SetMemory(8).
+		SetHardwareConcurrency(16).
+		ScreenColorDepth(24).
+		SetScreenWidth(1900).
+		SetScreenHeight(900).
+		SetTimezone("America/Los_Angeles").
+		SetLanguages("en-US,en").
+		SetStorage(store).
+		Build()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return d
+}
+
+func TestDevice_Cookies(t *testing.T) {
+	dir := t.TempDir()
+
+	// Cookie file written by persistent-cookiejar, before the storage existed
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "storage", "test"), 0755))
+	legacy, _ := cookiejar.New(&cookiejar.Options{Filename: filepath.Join(dir, "storage", "test", "cookies"), PersistSessionCookies: true})
+	lobby, _ := url.Parse("https://lobby.ogame.gameforge.com/")
+	legacy.SetCookies(lobby, []*http.Cookie{
+		{Name: "gf-token-production", Value: "token", Domain: ".gameforge.com", Path: "/", Expires: time.Now().Add(time.Hour)},
+		{Name: "host_only", Value: "1", Path: "/"},
+	})
+	assert.NoError(t, legacy.Save())
+
+	d := newTestDevice(t, storage.NewFileStore(dir))
+	other, _ := url.Parse("https://s252-en.ogame.gameforge.com/")
+	assert.Equal(t, 2, len(d.GetClient().Jar.Cookies(lobby)))
+	cookies := d.GetClient().Jar.Cookies(other)
+	assert.Equal(t, 1, len(cookies))
+	assert.Equal(t, "gf-token-production", cookies[0].Name)
+
+	// Moving to another store keeps the session and the fingerprint
+	store := storage.NewMemoryStore()
+	assert.NoError(t, d.SetStorage(store))
+	d2 := newTestDevice(t, store)
+	assert.Equal(t, 2, len(d2.GetClient().Jar.Cookies(lobby)))
+	assert.Equal(t, d.GetClient().UserAgent(), d2.GetClient().UserAgent())
+}
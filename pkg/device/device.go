@@ -10,13 +10,13 @@ import (
 	"math/rand"
 	"net/http"
 	"net/url"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/alaingilbert/ogame/pkg/httpclient"
+	"github.com/alaingilbert/ogame/pkg/storage"
 	"github.com/alaingilbert/ogame/pkg/utils"
 	"github.com/martinlindhe/base36"
 	cookiejar "github.com/orirawlings/persistent-cookiejar"
@@ -67,6 +67,7 @@ type Builder struct {
 	canvas2DInfo        int
 	client              *httpclient.Client
 	persistor           Persistor
+	store               storage.Store
 }
 
 type Persistor interface {
@@ -75,8 +76,20 @@ type Persistor interface {
 }
 
 type Device struct {
+	name      string
 	client    *httpclient.Client
 	persistor Persistor
+	store     storage.Store
+}
+
+// GetName returns the name of the device
+func (d *Device) GetName() string {
+	return d.name
+}
+
+// GetStorage returns the store where the device keeps its cookies and fingerprint
+func (d *Device) GetStorage() storage.Store {
+	return d.store
 }
 
 func (d *Device) GetClient() *httpclient.Client {
@@ -121,6 +134,12 @@ func (d *Builder) SetPersistor(persistor Persistor) *Builder {
 	return d
 }
 
+// SetStorage sets where the cookies and the fingerprint are kept, defaults to a file store in ~/.ogame
+func (d *Builder) SetStorage(store storage.Store) *Builder {
+	d.store = store
+	return d
+}
+
 func (d *Builder) SetOsName(osName Os) *Builder {
 	d.osName = osName
 	return d
@@ -202,42 +221,39 @@ func (d *Builder) SetLanguages(languages string) *Builder {
 }
 
 func DefaultStoragePath() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		panic(err)
-	}
-	return filepath.Join(home, ".ogame", "storage")
+	return filepath.Join(storage.DefaultDir(), "storage")
+}
+
+// StorePersistor default persistor for the javascript fingerprint
+// save/load the fingerprint from the storage of the device, key storage/<device_name>/fingerprint
+type StorePersistor struct {
+	store storage.Store
+	key   string
 }
 
-// FilePersistor default persistor for the javascript fingerprint
-// save/load the fingerprint from ~/.ogame/storage/<device_name>/fingerprint
-type FilePersistor struct {
-	deviceStorageDir string
+// NewStorePersistor creates a fingerprint persistor backed by store
+func NewStorePersistor(store storage.Store, deviceName string) *StorePersistor {
+	return &StorePersistor{store: store, key: storage.FingerprintKey(deviceName)}
 }
 
-func (f FilePersistor) Load() (*JsFingerprint, error) {
-	fingerprintFilePath := filepath.Join(f.deviceStorageDir, "fingerprint")
-	diskFpBy, err := os.ReadFile(fingerprintFilePath)
+func (f *StorePersistor) Load() (*JsFingerprint, error) {
+	by, err := f.store.Get(f.key)
 	if err != nil {
 		return nil, err
 	}
-	fingerprint, err := ParseBlackbox(string(diskFpBy))
+	fingerprint, err := ParseBlackbox(string(by))
 	if err != nil {
 		return nil, err
 	}
 	return fingerprint, nil
 }
 
-func (f FilePersistor) Save(fprt *JsFingerprint) error {
-	fingerprintFilePath := filepath.Join(f.deviceStorageDir, "fingerprint")
+func (f *StorePersistor) Save(fprt *JsFingerprint) error {
 	by, err := json.Marshal(fprt)
 	if err != nil {
 		return err
 	}
-	if err := os.WriteFile(fingerprintFilePath, by, 0644); err != nil {
-		return err
-	}
-	return nil
+	return f.store.Set(f.key, by)
 }
 
 func (d *Builder) newFingerprint() (*JsFingerprint, error) {
@@ -364,14 +380,12 @@ func (d *Builder) newFingerprint() (*JsFingerprint, error) {
 }
 
 func (d *Builder) Build() (*Device, error) {
-	defaultStoragePath := DefaultStoragePath()
-	deviceStorageDir := filepath.Join(defaultStoragePath, d.name)
-	if err := os.MkdirAll(deviceStorageDir, 0755); err != nil {
-		return nil, err
+	if d.store == nil {
+		d.store = storage.NewDefaultFileStore()
 	}
 
 	if d.persistor == nil {
-		d.persistor = &FilePersistor{deviceStorageDir: deviceStorageDir}
+		d.persistor = NewStorePersistor(d.store, d.name)
 	}
 
 	fprt, err := d.persistor.Load()
@@ -388,12 +402,15 @@ func (d *Builder) Build() (*Device, error) {
 
 	if d.client == nil {
 		jar, err := cookiejar.New(&cookiejar.Options{
-			Filename:              filepath.Join(defaultStoragePath, d.name, "cookies"),
+			NoPersist:             true,
 			PersistSessionCookies: true,
 		})
 		if err != nil {
 			return nil, err
 		}
+		if err := loadCookies(jar, d.store, d.name); err != nil {
+			return nil, err
+		}
 
 		// Ensure we remove any cookies that would set the mobile view
 		cookies := jar.AllCookies()
@@ -408,8 +425,10 @@ func (d *Builder) Build() (*Device, error) {
 	}
 
 	return &Device{
+		name:      d.name,
 		persistor: d.persistor,
 		client:    d.client,
+		store:     d.store,
 	}, nil
 }
 
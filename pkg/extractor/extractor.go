@@ -106,12 +106,12 @@ type FleetsExtractorDoc interface {
 
 type MovementExtractorBytes interface {
 	FleetsExtractorBytes
-	ExtractFleets(pageHTML []byte) (res []ogame.Fleet)
+	ExtractFleets(pageHTML []byte) ([]ogame.Fleet, error)
 }
 
 type MovementExtractorDoc interface {
 	FleetsExtractorDoc
-	ExtractFleetsFromDoc(doc *goquery.Document) (res []ogame.Fleet)
+	ExtractFleetsFromDoc(doc *goquery.Document) ([]ogame.Fleet, error)
 }
 
 type MovementExtractorBytesDoc interface {
@@ -154,12 +154,12 @@ type ShipyardExtractorBytesDoc interface {
 }
 
 type ResearchExtractorBytes interface {
-	ExtractResearch(pageHTML []byte) ogame.Researches
+	ExtractResearch(pageHTML []byte) (ogame.Researches, error)
 	ExtractUpgradeToken(pageHTML []byte) (string, error)
 }
 
 type ResearchExtractorDoc interface {
-	ExtractResearchFromDoc(doc *goquery.Document) ogame.Researches
+	ExtractResearchFromDoc(doc *goquery.Document) (ogame.Researches, error)
 }
 
 type ResearchExtractorBytesDoc interface {
@@ -265,6 +265,22 @@ type TraderAuctioneerExtractorBytes interface {
 
 type AllianceOverviewExtractorBytes interface {
 	ExtractAllianceClass(pageHTML []byte) (ogame.AllianceClass, error)
+	ExtractAllianceOverview(pageHTML []byte) (ogame.AllianceOverview, error)
+}
+
+type AllianceApplicationsExtractorBytes interface {
+	ExtractAllianceApplications(pageHTML []byte) ([]ogame.AllianceApplication, error)
+}
+
+type BuddiesExtractorBytes interface {
+	ExtractBuddies(pageHTML []byte) ([]ogame.Buddy, error)
+	ExtractBuddyRequests(pageHTML []byte) ([]ogame.BuddyRequest, error)
+}
+
+// ChatExtractorBytes chat inbox and conversations
+type ChatExtractorBytes interface {
+	ExtractConversations(pageHTML []byte) ([]ogame.Conversation, error)
+	ExtractConversationMessages(pageHTML []byte, playerID int64) ([]ogame.ChatMsg, int64, error)
 }
 
 // BuffActivationExtractorBytes BuffActivation is the popups that shows up when clicking the icon
@@ -286,6 +302,20 @@ type MessagesCombatReportExtractorBytesDoc interface {
 	MessagesCombatReportExtractorDoc
 }
 
+// CombatReportExtractorBytes popup that shows the full combat report
+type CombatReportExtractorBytes interface {
+	ExtractCombatReport(pageHTML []byte) (ogame.CombatReport, error)
+}
+
+type CombatReportExtractorDoc interface {
+	ExtractCombatReportFromDoc(doc *goquery.Document) (ogame.CombatReport, error)
+}
+
+type CombatReportExtractorBytesDoc interface {
+	CombatReportExtractorBytes
+	CombatReportExtractorDoc
+}
+
 // DestroyRocketsExtractorBytes popups that shows up when clicking to destroy rockets on the defenses page.
 type DestroyRocketsExtractorBytes interface {
 	ExtractDestroyRockets(pageHTML []byte) (abm, ipm int64, token string, err error)
@@ -514,6 +544,7 @@ type Extractor interface {
 	GetLifeformEnabled() bool
 	SetLifeformEnabled(lifeformEnabled bool)
 
+	CombatReportExtractorBytesDoc
 	DefensesExtractorBytesDoc
 	EspionageReportExtractorBytesDoc
 	EventListExtractorBytesDoc
@@ -552,6 +583,9 @@ type Extractor interface {
 	TraderAuctioneerExtractorBytes
 	TraderImportExportExtractorBytes
 	AllianceOverviewExtractorBytes
+	AllianceApplicationsExtractorBytes
+	BuddiesExtractorBytes
+	ChatExtractorBytes
 
 	PlanetLayerExtractorDoc
 	TraderImportExportExtractorDoc
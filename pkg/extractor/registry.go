@@ -0,0 +1,121 @@
+package extractor
+
+import (
+	"sort"
+	"sync"
+
+	v10 "github.com/alaingilbert/ogame/pkg/extractor/v10"
+	v104 "github.com/alaingilbert/ogame/pkg/extractor/v104"
+	v11 "github.com/alaingilbert/ogame/pkg/extractor/v11"
+	"github.com/alaingilbert/ogame/pkg/extractor/v11_13_0"
+	"github.com/alaingilbert/ogame/pkg/extractor/v11_15_0"
+	"github.com/alaingilbert/ogame/pkg/extractor/v11_9_0"
+	"github.com/alaingilbert/ogame/pkg/extractor/v12_0_0"
+	v6 "github.com/alaingilbert/ogame/pkg/extractor/v6"
+	v7 "github.com/alaingilbert/ogame/pkg/extractor/v7"
+	v71 "github.com/alaingilbert/ogame/pkg/extractor/v71"
+	v8 "github.com/alaingilbert/ogame/pkg/extractor/v8"
+	v874 "github.com/alaingilbert/ogame/pkg/extractor/v874"
+	v9 "github.com/alaingilbert/ogame/pkg/extractor/v9"
+	"github.com/hashicorp/go-version"
+)
+
+// Registration extractor used for the servers from MinVersion, up to the MinVersion of the next registration
+type Registration struct {
+	Name       string // Name of the extractor (eg: "v12_0_0")
+	MinVersion *version.Version
+	New        func() Extractor
+}
+
+// Registry maps the ranges of versions of the game to their extractor
+type Registry struct {
+	sync.RWMutex
+	registrations []Registration // Newest first
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// DefaultRegistry extractors of every supported version of the game.
+// A new extractor can be registered at runtime, it is used at the next login.
+var DefaultRegistry = newDefaultRegistry()
+
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.MustRegister("v6", "6.0.0", func() Extractor { return v6.NewExtractor() })
+	r.MustRegister("v7", "7.0.0", func() Extractor { return v7.NewExtractor() })
+	r.MustRegister("v71", "7.1.0-rc0", func() Extractor { return v71.NewExtractor() })
+	r.MustRegister("v8", "8.0.0", func() Extractor { return v8.NewExtractor() })
+	r.MustRegister("v874", "8.7.4-pl3", func() Extractor { return v874.NewExtractor() })
+	r.MustRegister("v9", "9.0.0", func() Extractor { return v9.NewExtractor() })
+	r.MustRegister("v10", "10.0.0", func() Extractor { return v10.NewExtractor() })
+	r.MustRegister("v104", "10.4.0-beta2", func() Extractor { return v104.NewExtractor() })
+	r.MustRegister("v11", "11.0.0-beta25", func() Extractor { return v11.NewExtractor() })
+	r.MustRegister("v11_9_0", "11.9.0", func() Extractor { return v11_9_0.NewExtractor() })
+	r.MustRegister("v11_13_0", "11.13.0", func() Extractor { return v11_13_0.NewExtractor() })
+	r.MustRegister("v11_15_0", "11.15.0", func() Extractor { return v11_15_0.NewExtractor() })
+	r.MustRegister("v12_0_0", "12.0.0", func() Extractor { return v12_0_0.NewExtractor() })
+	return r
+}
+
+// Register adds an extractor for the servers from minVersion, it replaces the registration with the same name
+func (r *Registry) Register(name, minVersion string, factory func() Extractor) error {
+	v, err := version.NewVersion(minVersion)
+	if err != nil {
+		return err
+	}
+	r.Lock()
+	defer r.Unlock()
+	registrations := make([]Registration, 0, len(r.registrations)+1)
+	for _, registration := range r.registrations {
+		if registration.Name != name {
+			registrations = append(registrations, registration)
+		}
+	}
+	registrations = append(registrations, Registration{Name: name, MinVersion: v, New: factory})
+	sort.SliceStable(registrations, func(i, j int) bool {
+		return registrations[i].MinVersion.GreaterThan(registrations[j].MinVersion)
+	})
+	r.registrations = registrations
+	return nil
+}
+
+// MustRegister same as Register, panics if minVersion is invalid
+func (r *Registry) MustRegister(name, minVersion string, factory func() Extractor) {
+	if err := r.Register(name, minVersion, factory); err != nil {
+		panic(err)
+	}
+}
+
+// Registrations returns the registered extractors, newest first
+func (r *Registry) Registrations() []Registration {
+	r.RLock()
+	defer r.RUnlock()
+	return append([]Registration(nil), r.registrations...)
+}
+
+// Lookup returns the extractor of a version of the game.
+// The newest extractor is returned if the version is nil or older than every registration.
+func (r *Registry) Lookup(v *version.Version) (Registration, bool) {
+	chain := r.Chain(v)
+	if len(chain) == 0 {
+		return Registration{}, false
+	}
+	return chain[0], true
+}
+
+// Chain returns the extractor of a version of the game, followed by the older ones to fall back on
+func (r *Registry) Chain(v *version.Version) []Registration {
+	r.RLock()
+	defer r.RUnlock()
+	if v != nil {
+		for i, registration := range r.registrations {
+			if v.GreaterThanOrEqual(registration.MinVersion) {
+				return append([]Registration(nil), r.registrations[i:]...)
+			}
+		}
+	}
+	return append([]Registration(nil), r.registrations...)
+}
@@ -0,0 +1,64 @@
+package extractor
+
+import (
+	"testing"
+
+	"github.com/alaingilbert/ogame/pkg/extractor/v11_15_0"
+	"github.com/alaingilbert/ogame/pkg/extractor/v12_0_0"
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/assert"
+)
+
+func names(registrations []Registration) (out []string) {
+	for _, registration := range registrations {
+		out = append(out, registration.Name)
+	}
+	return
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	for v, expected := range map[string]string{
+		"12.3.1":        "v12_0_0",
+		"12.0.0":        "v12_0_0",
+		"11.16.18":      "v11_15_0",
+		"11.13.2":       "v11_13_0",
+		"11.0.0-beta25": "v11",
+		"11.0.0-beta24": "v104",
+		"10.4.0-beta2":  "v104",
+		"8.7.4-pl3":     "v874",
+		"8.7.4":         "v874",
+		"7.0.1":         "v7",
+		"6.5.0":         "v6",
+	} {
+		registration, found := DefaultRegistry.Lookup(version.Must(version.NewVersion(v)))
+		assert.True(t, found, v)
+		assert.Equal(t, expected, registration.Name, v)
+	}
+	registration, _ := DefaultRegistry.Lookup(nil)
+	assert.Equal(t, "v12_0_0", registration.Name)
+	assert.IsType(t, &v12_0_0.Extractor{}, registration.New())
+}
+
+func TestRegistry_Chain(t *testing.T) {
+	chain := DefaultRegistry.Chain(version.Must(version.NewVersion("11.13.0")))
+	assert.Equal(t, []string{"v11_13_0", "v11_9_0", "v11", "v104", "v10", "v9", "v874", "v8", "v71", "v7", "v6"}, names(chain))
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+	_, found := r.Lookup(nil)
+	assert.False(t, found)
+	assert.Error(t, r.Register("bad", "not a version", nil))
+
+	r.MustRegister("v12_0_0", "12.0.0", func() Extractor { return v12_0_0.NewExtractor() })
+	r.MustRegister("v11_15_0", "11.15.0", func() Extractor { return v11_15_0.NewExtractor() })
+	r.MustRegister("v12_1_0", "12.1.0", func() Extractor { return v12_0_0.NewExtractor() })
+	assert.Equal(t, []string{"v12_1_0", "v12_0_0", "v11_15_0"}, names(r.Registrations()))
+
+	// Same name, the registration is replaced
+	r.MustRegister("v12_1_0", "12.2.0", func() Extractor { return v12_0_0.NewExtractor() })
+	assert.Equal(t, []string{"v12_1_0", "v12_0_0", "v11_15_0"}, names(r.Registrations()))
+	assert.Equal(t, "12.2.0", r.Registrations()[0].MinVersion.String())
+	registration, _ := r.Lookup(version.Must(version.NewVersion("12.1.5")))
+	assert.Equal(t, "v12_0_0", registration.Name)
+}
@@ -192,6 +192,9 @@ func extractGalaxyInfos(pageHTML []byte, botPlayerName string, botPlayerID, botP
 	if err := json.Unmarshal(pageHTML, &tmp); err != nil {
 		return res, err
 	}
+	if tmp.System.Galaxy.Int64() == 0 || tmp.System.System.Int64() == 0 {
+		return res, ogame.NewSelectorNotFoundError("system.galaxy")
+	}
 	res.OverlayToken = tmp.Token
 	res.SetGalaxy(tmp.System.Galaxy.Int64())
 	res.SetSystem(tmp.System.System.Int64())
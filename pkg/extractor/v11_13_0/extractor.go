@@ -38,20 +38,20 @@ func (e Extractor) ExtractOverviewShipSumCountdownFromBytes(pageHTML []byte) int
 }
 
 // ExtractFleets ...
-func (e *Extractor) ExtractFleets(pageHTML []byte) (res []ogame.Fleet) {
+func (e *Extractor) ExtractFleets(pageHTML []byte) ([]ogame.Fleet, error) {
 	return e.extractFleets(pageHTML, e.GetLocation())
 }
 
-func (e *Extractor) extractFleets(pageHTML []byte, location *time.Location) (res []ogame.Fleet) {
+func (e *Extractor) extractFleets(pageHTML []byte, location *time.Location) ([]ogame.Fleet, error) {
 	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
 	return e.extractFleetsFromDoc(doc, location)
 }
 
 // ExtractFleetsFromDoc ...
-func (e *Extractor) ExtractFleetsFromDoc(doc *goquery.Document) (res []ogame.Fleet) {
+func (e *Extractor) ExtractFleetsFromDoc(doc *goquery.Document) ([]ogame.Fleet, error) {
 	return e.extractFleetsFromDoc(doc, e.GetLocation())
 }
 
-func (e *Extractor) extractFleetsFromDoc(doc *goquery.Document, location *time.Location) (res []ogame.Fleet) {
+func (e *Extractor) extractFleetsFromDoc(doc *goquery.Document, location *time.Location) ([]ogame.Fleet, error) {
 	return extractFleetsFromDoc(doc, location, e.GetLifeformEnabled())
 }
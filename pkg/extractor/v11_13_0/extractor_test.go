@@ -40,7 +40,8 @@ func TestExtractFleet(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/v11.13.0/en/movement.html")
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, 1, len(fleets))
 	assert.Equal(t, int64(10495), fleets[0].ArriveIn)
 	assert.Equal(t, int64(20995), fleets[0].BackIn)
@@ -57,7 +58,8 @@ func TestExtractFleet2(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/v11.13.0/en/movement2.html")
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, 1, len(fleets))
 	assert.Equal(t, int64(3416), fleets[0].ArriveIn)
 	assert.Equal(t, int64(3908), fleets[0].BackIn)
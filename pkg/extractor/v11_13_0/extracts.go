@@ -55,8 +55,12 @@ func extractOverviewShipSumCountdownFromBytes(pageHTML []byte) int64 {
 	return shipSumCountdown
 }
 
-func extractFleetsFromDoc(doc *goquery.Document, location *time.Location, lifeformEnabled bool) (res []ogame.Fleet) {
-	res = make([]ogame.Fleet, 0)
+func extractFleetsFromDoc(doc *goquery.Document, location *time.Location, lifeformEnabled bool) ([]ogame.Fleet, error) {
+	// The game shows the fleet dispatch page instead of the movement page when no fleet is flying
+	if err := v6.RequireSelector(doc, "#movement, #fleet1"); err != nil {
+		return []ogame.Fleet{}, err
+	}
+	res := make([]ogame.Fleet, 0)
 	script := doc.Find("body script").Text()
 	doc.Find("div.fleetDetails").Each(func(i int, s *goquery.Selection) {
 		originText := s.Find("span.originCoords a").Text()
@@ -161,5 +165,5 @@ func extractFleetsFromDoc(doc *goquery.Document, location *time.Location, lifefo
 
 		res = append(res, fleet)
 	})
-	return
+	return res, nil
 }
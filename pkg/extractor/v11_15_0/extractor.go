@@ -5,6 +5,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"github.com/alaingilbert/ogame/pkg/extractor/v11_13_0"
 	"github.com/alaingilbert/ogame/pkg/ogame"
+	"time"
 )
 
 // Extractor ...
@@ -50,6 +51,17 @@ func (e *Extractor) ExtractCombatReportMessagesFromDoc(doc *goquery.Document) ([
 	return extractCombatReportMessagesFromDoc(doc)
 }
 
+// ExtractCombatReport ...
+func (e *Extractor) ExtractCombatReport(pageHTML []byte) (ogame.CombatReport, error) {
+	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
+	return e.ExtractCombatReportFromDoc(doc)
+}
+
+// ExtractCombatReportFromDoc ...
+func (e *Extractor) ExtractCombatReportFromDoc(doc *goquery.Document) (ogame.CombatReport, error) {
+	return extractCombatReportFromDoc(doc, e.Extractor.ExtractCombatReportFromDoc)
+}
+
 // ExtractExpeditionMessages ...
 func (e *Extractor) ExtractExpeditionMessages(pageHTML []byte) ([]ogame.ExpeditionMessage, int64, error) {
 	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
@@ -83,6 +95,72 @@ func (e Extractor) ExtractAllianceClassFromDoc(doc *goquery.Document) (ogame.All
 	return extractAllianceClassFromDoc(doc)
 }
 
+// ExtractAllianceOverview ...
+func (e *Extractor) ExtractAllianceOverview(pageHTML []byte) (ogame.AllianceOverview, error) {
+	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
+	return e.ExtractAllianceOverviewFromDoc(doc)
+}
+
+// ExtractAllianceOverviewFromDoc ...
+func (e *Extractor) ExtractAllianceOverviewFromDoc(doc *goquery.Document) (ogame.AllianceOverview, error) {
+	return extractAllianceOverviewFromDoc(doc, e.GetLocation())
+}
+
+// ExtractAllianceApplications ...
+func (e *Extractor) ExtractAllianceApplications(pageHTML []byte) ([]ogame.AllianceApplication, error) {
+	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
+	return e.ExtractAllianceApplicationsFromDoc(doc)
+}
+
+// ExtractAllianceApplicationsFromDoc ...
+func (e *Extractor) ExtractAllianceApplicationsFromDoc(doc *goquery.Document) ([]ogame.AllianceApplication, error) {
+	return extractAllianceApplicationsFromDoc(doc, e.GetLocation())
+}
+
+// ExtractBuddies ...
+func (e *Extractor) ExtractBuddies(pageHTML []byte) ([]ogame.Buddy, error) {
+	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
+	return e.ExtractBuddiesFromDoc(doc)
+}
+
+// ExtractBuddiesFromDoc ...
+func (e *Extractor) ExtractBuddiesFromDoc(doc *goquery.Document) ([]ogame.Buddy, error) {
+	return extractBuddiesFromDoc(doc)
+}
+
+// ExtractBuddyRequests ...
+func (e *Extractor) ExtractBuddyRequests(pageHTML []byte) ([]ogame.BuddyRequest, error) {
+	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
+	return e.ExtractBuddyRequestsFromDoc(doc)
+}
+
+// ExtractBuddyRequestsFromDoc ...
+func (e *Extractor) ExtractBuddyRequestsFromDoc(doc *goquery.Document) ([]ogame.BuddyRequest, error) {
+	return extractBuddyRequestsFromDoc(doc, e.GetLocation())
+}
+
+// ExtractConversations ...
+func (e *Extractor) ExtractConversations(pageHTML []byte) ([]ogame.Conversation, error) {
+	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
+	return e.ExtractConversationsFromDoc(doc)
+}
+
+// ExtractConversationsFromDoc ...
+func (e *Extractor) ExtractConversationsFromDoc(doc *goquery.Document) ([]ogame.Conversation, error) {
+	return extractConversationsFromDoc(doc, e.GetLocation(), time.Now())
+}
+
+// ExtractConversationMessages ...
+func (e *Extractor) ExtractConversationMessages(pageHTML []byte, playerID int64) ([]ogame.ChatMsg, int64, error) {
+	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
+	return e.ExtractConversationMessagesFromDoc(doc, playerID)
+}
+
+// ExtractConversationMessagesFromDoc ...
+func (e *Extractor) ExtractConversationMessagesFromDoc(doc *goquery.Document, playerID int64) ([]ogame.ChatMsg, int64, error) {
+	return extractConversationMessagesFromDoc(doc, playerID, e.GetLocation(), time.Now())
+}
+
 // ExtractPhalanxNewToken ...
 func (e *Extractor) ExtractPhalanxNewToken(pageHTML []byte) (string, error) {
 	return extractPhalanxNewToken(pageHTML)
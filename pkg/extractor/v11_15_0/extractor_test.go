@@ -1,10 +1,13 @@
 package v11_15_0
 
 import (
+	"bytes"
+	"github.com/PuerkitoBio/goquery"
 	"github.com/alaingilbert/ogame/pkg/ogame"
 	"github.com/alaingilbert/ogame/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -44,6 +47,158 @@ func TestExtractAllianceClass(t *testing.T) {
 	assert.Equal(t, ogame.Researcher, c)
 }
 
+func TestExtractAllianceOverview(t *testing.T) {
+	pageHTMLBytes, _ := os.ReadFile("../../../samples/v11.15.5/en/allianceOverviewTab.html")
+	res, err := NewExtractor().ExtractAllianceOverview(pageHTMLBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(500650), res.ID)
+	assert.Equal(t, "Obozavatelji WINDa", res.Name)
+	assert.Equal(t, "WIND", res.Tag)
+	assert.Equal(t, "23.11.2023", res.CreatedAt.Format("02.01.2006"))
+	assert.Equal(t, int64(2), res.NbMembers)
+	assert.Equal(t, ogame.Researcher, res.Class)
+	assert.Equal(t, "Founder", res.Rank)
+	assert.Equal(t, "", res.Homepage)
+	assert.Equal(t, 2, len(res.Members))
+
+	founder := res.Members[0]
+	assert.Equal(t, int64(100538), founder.PlayerID)
+	assert.Equal(t, "Mogul Euler", founder.Name)
+	assert.Equal(t, "Founder", founder.Rank)
+	assert.Equal(t, int64(0), founder.RankID)
+	assert.Equal(t, int64(10735308), founder.Points)
+	assert.Equal(t, int64(304), founder.Position)
+	assert.Equal(t, ogame.Coordinate{Galaxy: 1, System: 106, Position: 10, Type: ogame.PlanetType}, founder.Coordinate)
+	assert.Equal(t, "23.11.2023 21:26:37", founder.JoinedAt.Format("02.01.2006 15:04:05"))
+	assert.True(t, founder.Online)
+	assert.False(t, founder.Inactive)
+
+	member := res.Members[1]
+	assert.Equal(t, int64(108920), member.PlayerID)
+	assert.Equal(t, "General wolf", member.Name)
+	assert.Equal(t, "Newcomer", member.Rank)
+	assert.Equal(t, int64(1475), member.RankID)
+	assert.Equal(t, int64(4437329), member.Points)
+	assert.Equal(t, int64(431), member.Position)
+	assert.Equal(t, ogame.Coordinate{Galaxy: 5, System: 43, Position: 4, Type: ogame.PlanetType}, member.Coordinate)
+	assert.False(t, member.Online)
+	assert.Equal(t, 167*24*time.Hour, member.OfflineFor)
+	assert.True(t, member.Inactive)
+	assert.True(t, member.LongInactive)
+	assert.True(t, member.Vacation)
+}
+
+func TestExtractAllianceApplications(t *testing.T) {
+	pageHTMLBytes, _ := os.ReadFile("../../../samples/synthetic/allianceApplicationsTab.html")
+	res, err := NewExtractor().ExtractAllianceApplications(pageHTMLBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, int64(8841), res[0].ID)
+	assert.Equal(t, int64(101245), res[0].PlayerID)
+	assert.Equal(t, "Admiral Tarvos", res[0].PlayerName)
+	assert.Equal(t, int64(1204), res[0].Position)
+	assert.Equal(t, "02.12.2023 18:04:51", res[0].Date.Format("02.01.2006 15:04:05"))
+	assert.Equal(t, "Hello, active miner from 1:105, I can help with defense.", res[0].Message)
+	assert.Equal(t, int64(8845), res[1].ID)
+	assert.Equal(t, "Captain Ursa", res[1].PlayerName)
+	assert.Equal(t, "", res[1].Message)
+}
+
+func TestExtractBuddies(t *testing.T) {
+	pageHTMLBytes, _ := os.ReadFile("../../../samples/synthetic/buddies.html")
+	res, err := NewExtractor().ExtractBuddies(pageHTMLBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, int64(7741), res[0].ID)
+	assert.Equal(t, int64(100538), res[0].PlayerID)
+	assert.Equal(t, "Mogul Euler", res[0].Name)
+	assert.Equal(t, int64(10735308), res[0].Points)
+	assert.Equal(t, int64(304), res[0].Position)
+	assert.Equal(t, "WIND", res[0].AllianceTag)
+	assert.Equal(t, ogame.Coordinate{Galaxy: 1, System: 106, Position: 10, Type: ogame.PlanetType}, res[0].Coordinate)
+	assert.True(t, res[0].Online)
+	assert.Equal(t, int64(1203554), res[1].Points)
+	assert.Equal(t, int64(1057), res[1].Position)
+	assert.Equal(t, "", res[1].AllianceTag)
+	assert.False(t, res[1].Online)
+	assert.Equal(t, 42*time.Minute, res[1].OfflineFor)
+}
+
+func TestExtractBuddies_SelectorNotFound(t *testing.T) {
+	pageHTMLBytes, _ := os.ReadFile("../../../samples/v12.0.0/en/overview.html")
+	res, err := NewExtractor().ExtractBuddies(pageHTMLBytes)
+	assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+	assert.ErrorIs(t, err, ogame.ErrParse)
+	assert.Equal(t, 0, len(res))
+}
+
+func TestExtractBuddyRequests(t *testing.T) {
+	pageHTMLBytes, _ := os.ReadFile("../../../samples/synthetic/buddies.html")
+	res, err := NewExtractor().ExtractBuddyRequests(pageHTMLBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, int64(3310), res[0].ID)
+	assert.Equal(t, int64(101245), res[0].PlayerID)
+	assert.Equal(t, "Admiral Tarvos", res[0].PlayerName)
+	assert.Equal(t, "Neighbours in 1:105, let's share intel", res[0].Message)
+	assert.Equal(t, "04.12.2023 10:02:33", res[0].Date.Format("02.01.2006 15:04:05"))
+	assert.True(t, res[0].Received)
+	assert.Equal(t, int64(3318), res[1].ID)
+	assert.False(t, res[1].Received)
+}
+
+func TestExtractConversations(t *testing.T) {
+	now := time.Date(2023, 12, 3, 10, 0, 0, 0, time.UTC)
+	pageHTMLBytes, _ := os.ReadFile("../../../samples/v11.6.2/overview_cancels.html")
+	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTMLBytes))
+	res, err := extractConversationsFromDoc(doc, time.UTC, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, int64(117340), res[0].PlayerID)
+	assert.Equal(t, int64(0), res[0].AssociationID)
+	assert.Equal(t, "Consul Mimas", res[0].Name)
+	assert.Equal(t, "nice", res[0].LastMessage)
+	assert.Equal(t, time.Date(2023, 12, 3, 0, 40, 47, 0, time.UTC), res[0].LastMessageAt)
+	assert.Equal(t, int64(2), res[0].Unread)
+
+	pageHTMLBytes, _ = os.ReadFile("../../../samples/v11.15.4/en/lfbonuses.html")
+	res, err = NewExtractor().ExtractConversations(pageHTMLBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, int64(0), res[0].PlayerID)
+	assert.Equal(t, int64(500650), res[0].AssociationID)
+	assert.Equal(t, "Alliance Chat", res[0].Name)
+	assert.Equal(t, int64(0), res[0].Unread)
+	assert.True(t, res[0].LastMessageAt.IsZero())
+
+	pageHTMLBytes, _ = os.ReadFile("../../../samples/v12.0.0/en/overview.html")
+	res, err = NewExtractor().ExtractConversations(pageHTMLBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(res))
+}
+
+func TestExtractConversationMessages(t *testing.T) {
+	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/fleets_1.html")
+	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTMLBytes))
+	res, oldestID, err := extractConversationMessagesFromDoc(doc, 107009, time.UTC, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(266478), oldestID)
+	assert.Equal(t, 7, len(res))
+	assert.Equal(t, ogame.ChatMsg{ID: 266478, SenderID: 107009, SenderName: "Constable Telesto", Text: "sup", Date: time.Date(2018, 7, 8, 6, 0, 30, 0, time.UTC).Unix()}, res[0])
+	assert.Equal(t, int64(106734), res[2].SenderID)
+	assert.Equal(t, "Commodore Nomad", res[2].SenderName)
+	assert.Equal(t, "got it", res[6].Text)
+
+	// No conversation with this player
+	res, oldestID, err = NewExtractor().ExtractConversationMessages(pageHTMLBytes, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), oldestID)
+	assert.Equal(t, 0, len(res))
+
+	_, _, err = NewExtractor().ExtractConversationMessages([]byte(`<div class="ajaxContent"></div>`), 107009)
+	assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+}
+
 func TestExtractPhalanx(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/v11.15.5/pl/phalanx_acs.html")
 	res, err := NewExtractor().ExtractPhalanx(pageHTMLBytes)
@@ -58,3 +213,47 @@ func TestExtractPhalanx(t *testing.T) {
 	assert.Equal(t, ogame.DoParseCoord("M:6:228:7"), res[18].Origin)
 	assert.Equal(t, int64(997), res[18].Ships.Battleship)
 }
+
+// No combat report of this version is captured in the samples yet, the raw message data is written by hand
+const combatReportRawMessageData = `<div class="detail_msg" data-msg-id="13097868"><div class="rawMessageData"
+	data-raw-coordinates="1:79:7" data-raw-targetplanettype="3" data-raw-datetime="1710611480"
+	data-raw-hashcode="cr-en-252-3c67a362b6ebee071bd2bd3651f6698ee8dee6ec"
+	data-raw-result='{"winner":"attacker","loot":{"percentage":75,"resources":[{"resource":"metal","amount":89694},{"resource":"crystal","amount":35856},{"resource":"deuterium","amount":13501},{"resource":"food","amount":0}]},"debris":{"resources":[{"resource":"metal","amount":3000},{"resource":"crystal","amount":1000}]}}'></div></div>`
+
+func TestExtractCombatReport(t *testing.T) {
+	// The summary is parsed, the participants and the rounds are reported missing
+	report, err := NewExtractor().ExtractCombatReport([]byte(combatReportRawMessageData))
+	assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+	assert.Equal(t, int64(13097868), report.ID)
+	assert.Equal(t, "cr-en-252-3c67a362b6ebee071bd2bd3651f6698ee8dee6ec", report.APIKey)
+	assert.Equal(t, int64(1710611480), report.CreatedAt.Unix())
+	assert.Equal(t, ogame.Coordinate{Galaxy: 1, System: 79, Position: 7, Type: ogame.MoonType}, report.Destination)
+	assert.Equal(t, "attacker", report.Result)
+	assert.Equal(t, int64(75), report.LootPercentage)
+	assert.Equal(t, ogame.Resources{Metal: 89694, Crystal: 35856, Deuterium: 13501}, report.Loot)
+	assert.Equal(t, ogame.Resources{Metal: 3000, Crystal: 1000}, report.Debris)
+	assert.Empty(t, report.Attackers)
+}
+
+func TestExtractCombatReport_CombatData(t *testing.T) {
+	// The participants and the rounds come from the combat data script of a captured report
+	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/combat_reports_msg.html")
+	pageHTML := strings.Replace(string(pageHTMLBytes), `<div class="detail_msg" data-msg-id="6971200" data-message-type="25">`, combatReportRawMessageData, 1)
+	report, err := NewExtractor().ExtractCombatReport([]byte(pageHTML))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(13097868), report.ID)
+	assert.Equal(t, ogame.Resources{Metal: 3000, Crystal: 1000}, report.Debris)
+	assert.Equal(t, 1, len(report.Attackers))
+	assert.Equal(t, "Renagade Andy", report.Attackers[0].PlayerName)
+	assert.Equal(t, 1, len(report.Defenders))
+	assert.Equal(t, 2, len(report.Rounds))
+	assert.Equal(t, int64(2000), report.DefenderLostUnits)
+	assert.Equal(t, int64(1), report.RepairedDefenses.RocketLauncher)
+}
+
+func TestExtractCombatReport_SelectorNotFound(t *testing.T) {
+	// The combat report of the older versions is parsed by the older extractors
+	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/combat_reports_msg.html")
+	_, err := NewExtractor().ExtractCombatReport(pageHTMLBytes)
+	assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+}
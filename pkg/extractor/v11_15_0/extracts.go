@@ -9,6 +9,7 @@ import (
 	"github.com/alaingilbert/ogame/pkg/ogame"
 	"github.com/alaingilbert/ogame/pkg/utils"
 	"golang.org/x/net/html"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -123,6 +124,9 @@ func extractEspionageReportMessageIDsFromDoc(doc *goquery.Document) ([]ogame.Esp
 
 func extractEspionageReportFromDoc(doc *goquery.Document, location *time.Location) (ogame.EspionageReport, error) {
 	report := ogame.EspionageReport{}
+	if err := v6.RequireSelector(doc, "div.rawMessageData"); err != nil {
+		return report, err
+	}
 	report.ID = utils.DoParseI64(doc.Find("div.detail_msg").AttrOr("data-msg-id", "0"))
 	rawMessageData := doc.Find("div.rawMessageData").First()
 	txt := rawMessageData.AttrOr("data-raw-coordinates", "")
@@ -437,7 +441,11 @@ func extractExpeditionMessagesFromDoc(doc *goquery.Document, location *time.Loca
 
 func extractLfBonusesFromDoc(doc *goquery.Document) (ogame.LfBonuses, error) {
 	b := ogame.NewLfBonuses()
-	doc.Find("bonus-item-content[data-toggable-target^=category]").Each(func(_ int, s *goquery.Selection) {
+	categories := doc.Find("bonus-item-content[data-toggable-target^=category]")
+	if categories.Length() == 0 {
+		return *b, ogame.NewSelectorNotFoundError("bonus-item-content[data-toggable-target^=category]")
+	}
+	categories.Each(func(_ int, s *goquery.Selection) {
 		category := s.AttrOr("data-toggable-target", "")
 		if category == "categoryShips" || category == "categoryCostAndTime" {
 			s.Find("inner-bonus-item-heading[data-toggable^=subcategory]").Each(func(_ int, g *goquery.Selection) {
@@ -550,8 +558,280 @@ func extractAllianceClassFromDoc(doc *goquery.Document) (ogame.AllianceClass, er
 	return ogame.NoAllianceClass, errors.New("alliance class not found")
 }
 
+func extractAllianceOverviewFromDoc(doc *goquery.Document, location *time.Location) (ogame.AllianceOverview, error) {
+	var res ogame.AllianceOverview
+	infoRows := doc.Find("div#allyData table.members tr")
+	if infoRows.Length() == 0 {
+		return res, ogame.NewSelectorNotFoundError("div#allyData table.members")
+	}
+	infoValue := func(idx int) string {
+		return strings.TrimSpace(infoRows.Eq(idx).Find("td.value").Text())
+	}
+	res.Name = infoValue(0)
+	res.Tag = infoValue(1)
+	res.CreatedAt, _ = time.ParseInLocation("02.01.2006", infoValue(2), location)
+	res.NbMembers = utils.ParseInt(infoValue(3))
+	res.Class, _ = extractAllianceClassFromDoc(doc)
+	res.Rank = infoValue(5)
+	if m := regexp.MustCompile(`url=([^&]*)`).FindStringSubmatch(doc.Find("a[data-homepage-link]").AttrOr("href", "")); len(m) == 2 {
+		res.Homepage, _ = url.QueryUnescape(m[1])
+	}
+	if m := regexp.MustCompile(`allianceId=(\d+)`).FindStringSubmatch(doc.Find("div#allyData a[href*='allianceId=']").AttrOr("href", "")); len(m) == 2 {
+		res.ID = utils.DoParseI64(m[1])
+	}
+	res.Members = make([]ogame.AllianceMember, 0)
+	doc.Find("table#member-list tbody tr").Each(func(i int, s *goquery.Selection) {
+		tds := s.Find("td")
+		if tds.Length() < 7 {
+			return
+		}
+		var member ogame.AllianceMember
+		nameSpan := tds.Eq(0).Find("span").First()
+		nameNode := nameSpan.Contents().FilterFunction(func(_ int, c *goquery.Selection) bool {
+			return c.Get(0).Type == html.TextNode
+		}).First()
+		member.Name = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(nameNode.Text()), "("))
+		member.LongInactive = nameSpan.Find("span.status_abbr_longinactive").Length() > 0
+		member.Inactive = member.LongInactive || nameSpan.Find("span.status_abbr_inactive").Length() > 0
+		member.Vacation = nameSpan.Find("span.status_abbr_vacation").Length() > 0
+		if selected := tds.Eq(2).Find("select option[selected]"); selected.Length() > 0 {
+			member.Rank = strings.TrimSpace(selected.Text())
+			member.RankID = utils.DoParseI64(selected.AttrOr("value", ""))
+		} else {
+			member.Rank = strings.TrimSpace(tds.Eq(2).Text())
+		}
+		scoreTd := tds.Eq(3)
+		member.Points = utils.ParseInt(regexp.MustCompile(`[\d.,]+`).FindString(scoreTd.AttrOr("title", "")))
+		member.Position = utils.ParseInt(scoreTd.Find("a").Text())
+		if m := regexp.MustCompile(`searchRelId=(\d+)`).FindStringSubmatch(scoreTd.Find("a").AttrOr("href", "")); len(m) == 2 {
+			member.PlayerID = utils.DoParseI64(m[1])
+		}
+		member.Coordinate = v6.ExtractCoord(tds.Eq(4).Text())
+		member.Coordinate.Type = ogame.PlanetType
+		member.JoinedAt, _ = time.ParseInLocation("02.01.2006 15:04:05", strings.TrimSpace(tds.Eq(5).Text()), location)
+		member.Online, member.OfflineFor = extractOnlineStatus(tds.Eq(6).Find("span"))
+		res.Members = append(res.Members, member)
+	})
+	return res, nil
+}
+
+// Parses an online status cell: "On", "Off", "15 min" or "3d"
+func extractOnlineStatus(s *goquery.Selection) (online bool, offlineFor time.Duration) {
+	txt := strings.TrimSpace(s.Text())
+	if m := regexp.MustCompile(`(\d+)\s*min`).FindStringSubmatch(txt); len(m) == 2 {
+		return false, time.Duration(utils.DoParseI64(m[1])) * time.Minute
+	} else if m := regexp.MustCompile(`(\d+)\s*d`).FindStringSubmatch(txt); len(m) == 2 {
+		return false, time.Duration(utils.DoParseI64(m[1])) * 24 * time.Hour
+	}
+	return s.HasClass("undermark"), 0
+}
+
+func extractAllianceApplicationsFromDoc(doc *goquery.Document, location *time.Location) ([]ogame.AllianceApplication, error) {
+	res := make([]ogame.AllianceApplication, 0)
+	table := doc.Find("table#applications-list")
+	if table.Length() == 0 {
+		return res, ogame.NewSelectorNotFoundError("table#applications-list")
+	}
+	table.Find("tr.application").Each(func(i int, s *goquery.Selection) {
+		var application ogame.AllianceApplication
+		application.ID = utils.DoParseI64(s.AttrOr("data-application-id", ""))
+		application.PlayerName = strings.TrimSpace(s.Find("span.playername").Text())
+		scoreLink := s.Find("td.member_score a")
+		application.Position = utils.ParseInt(scoreLink.Text())
+		if m := regexp.MustCompile(`searchRelId=(\d+)`).FindStringSubmatch(scoreLink.AttrOr("href", "")); len(m) == 2 {
+			application.PlayerID = utils.DoParseI64(m[1])
+		}
+		application.Date, _ = time.ParseInLocation("02.01.2006 15:04:05", strings.TrimSpace(s.Find("td.application_date").Text()), location)
+		application.Message = strings.TrimSpace(s.Next().Filter("tr.applicationText").Find("div.application_text").Text())
+		res = append(res, application)
+	})
+	return res, nil
+}
+
+func extractBuddiesFromDoc(doc *goquery.Document) ([]ogame.Buddy, error) {
+	res := make([]ogame.Buddy, 0)
+	table := doc.Find("table#buddylist")
+	if table.Length() == 0 {
+		return res, ogame.NewSelectorNotFoundError("table#buddylist")
+	}
+	table.Find("tr.buddy").Each(func(i int, s *goquery.Selection) {
+		var buddy ogame.Buddy
+		buddy.ID = utils.DoParseI64(s.AttrOr("data-buddyid", ""))
+		buddy.PlayerID = utils.DoParseI64(s.AttrOr("data-playerid", ""))
+		buddy.Name = strings.TrimSpace(s.Find("span.playername").Text())
+		pointsTd := s.Find("td.points")
+		buddy.Points = utils.ParseInt(regexp.MustCompile(`[\d.,]+`).FindString(pointsTd.AttrOr("title", "")))
+		buddy.Position = utils.ParseInt(pointsTd.Find("a").Text())
+		buddy.AllianceTag = strings.TrimSpace(s.Find("td.ally").Text())
+		buddy.Coordinate = v6.ExtractCoord(s.Find("td.coords").Text())
+		buddy.Coordinate.Type = ogame.PlanetType
+		buddy.Online, buddy.OfflineFor = extractOnlineStatus(s.Find("td.online span"))
+		res = append(res, buddy)
+	})
+	return res, nil
+}
+
+func extractBuddyRequestsFromDoc(doc *goquery.Document, location *time.Location) ([]ogame.BuddyRequest, error) {
+	res := make([]ogame.BuddyRequest, 0)
+	table := doc.Find("table#buddyRequests")
+	if table.Length() == 0 {
+		return res, ogame.NewSelectorNotFoundError("table#buddyRequests")
+	}
+	table.Find("tr.request").Each(func(i int, s *goquery.Selection) {
+		var request ogame.BuddyRequest
+		request.ID = utils.DoParseI64(s.AttrOr("data-requestid", ""))
+		request.PlayerID = utils.DoParseI64(s.AttrOr("data-playerid", ""))
+		request.PlayerName = strings.TrimSpace(s.Find("span.playername").Text())
+		request.Message = strings.TrimSpace(s.Find("td.text").Text())
+		request.Date, _ = time.ParseInLocation("02.01.2006 15:04:05", strings.TrimSpace(s.Find("td.date").Text()), location)
+		request.Received = s.HasClass("received")
+		res = append(res, request)
+	})
+	return res, nil
+}
+
+// The conversations are the items of the chat bar, present at the bottom of every full page
+func extractConversationsFromDoc(doc *goquery.Document, location *time.Location, now time.Time) ([]ogame.Conversation, error) {
+	res := make([]ogame.Conversation, 0)
+	list := doc.Find("ul.chat_bar_list")
+	if list.Length() == 0 {
+		return res, ogame.NewSelectorNotFoundError("ul.chat_bar_list")
+	}
+	list.Find("li.chat_bar_list_item").Each(func(i int, s *goquery.Selection) {
+		var conversation ogame.Conversation
+		conversation.PlayerID = utils.DoParseI64(s.AttrOr("data-playerid", ""))
+		conversation.AssociationID = utils.DoParseI64(s.AttrOr("data-associationid", ""))
+		conversation.Name = strings.TrimSpace(s.Find("span.cb_playername").Text())
+		conversation.Unread = utils.DoParseI64(s.Find("span.new_msg_count").AttrOr("data-new-messages", ""))
+		if lastMsg := s.Find("ul.chat li.chat_msg").Last(); lastMsg.Length() > 0 {
+			conversation.LastMessage = strings.TrimSpace(lastMsg.Find(".msg_content").Text())
+			conversation.LastMessageAt = parseChatMsgDate(lastMsg.Find("span.msg_date").Text(), location, now)
+		}
+		res = append(res, conversation)
+	})
+	return res, nil
+}
+
+// Returns the messages exchanged with a player, oldest first, and the id of the oldest message to load the previous ones (0 if none).
+// The messages are taken from the chat box of the player in the chat bar, or from the page itself when it only holds messages.
+// The messages of the bot have the "odd" class, their sender is the player of the page when known.
+func extractConversationMessagesFromDoc(doc *goquery.Document, playerID int64, location *time.Location, now time.Time) ([]ogame.ChatMsg, int64, error) {
+	res := make([]ogame.ChatMsg, 0)
+	box := doc.Find(`ul.chat[data-foreign-player-id="` + utils.FI64(playerID) + `"]`)
+	if box.Length() == 0 {
+		if doc.Find("ul.chat_bar_list").Length() > 0 {
+			return res, 0, nil // No conversation with the player
+		}
+		box = doc.Selection
+	}
+	msgs := box.Find("li.chat_msg")
+	if msgs.Length() == 0 && box == doc.Selection {
+		return res, 0, ogame.NewSelectorNotFoundError("li.chat_msg")
+	}
+	ownID := utils.DoParseI64(doc.Find("meta[name=ogame-player-id]").AttrOr("content", ""))
+	msgs.Each(func(i int, s *goquery.Selection) {
+		msg := ogame.ChatMsg{
+			ID:         utils.DoParseI64(s.AttrOr("data-chat-id", "")),
+			SenderID:   utils.Ternary(s.HasClass("odd"), ownID, playerID),
+			SenderName: strings.TrimSpace(s.Find(".msg_title").Text()),
+			Text:       strings.TrimSpace(s.Find(".msg_content").Text()),
+		}
+		if date := parseChatMsgDate(s.Find("span.msg_date").Text(), location, now); !date.IsZero() {
+			msg.Date = date.Unix()
+		}
+		res = append(res, msg)
+	})
+	var oldestID int64
+	if len(res) > 0 {
+		oldestID = res[0].ID
+	}
+	return res, oldestID, nil
+}
+
+// Parses the date of a chat message, "02.01.2006 15:04:05", or only the time for the messages of the day
+func parseChatMsgDate(txt string, location *time.Location, now time.Time) time.Time {
+	txt = strings.TrimSpace(txt)
+	if t, err := time.ParseInLocation("02.01.2006 15:04:05", txt, location); err == nil {
+		return t
+	}
+	if t, err := time.ParseInLocation("15:04:05", txt, location); err == nil {
+		now = now.In(location)
+		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, location)
+	}
+	return time.Time{}
+}
+
 func extractPhalanxNewToken(pageHTML []byte) (string, error) {
 	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
 	token := doc.Find("a.refreshPhalanxLink").AttrOr("data-overlay-token", "")
 	return token, nil
 }
+
+// Since the new messages, the combat report is in the attributes of div.rawMessageData, like the espionage report.
+// The participants and the rounds are not parsed from this format yet.
+// The raw message data only has the summary of the combat (winner, loot, debris). The participants and the rounds
+// are read by extractCombatData from the combat data script of the older versions. No captured report of this
+// version shows where they moved, so a report without that script is returned with a SelectorNotFoundError.
+func extractCombatReportFromDoc(doc *goquery.Document, extractCombatData func(*goquery.Document) (ogame.CombatReport, error)) (ogame.CombatReport, error) {
+	report := ogame.CombatReport{}
+	rawMessageData := doc.Find("div.rawMessageData").First()
+	resultStr, exists := rawMessageData.Attr("data-raw-result")
+	if !exists {
+		return report, ogame.NewSelectorNotFoundError("div.rawMessageData[data-raw-result]")
+	}
+	type resourceAmount struct {
+		Resource string
+		Amount   int64
+	}
+	var result struct {
+		Winner string
+		Loot   struct {
+			Percentage int64
+			Resources  []resourceAmount
+		}
+		Debris struct {
+			Resources []resourceAmount
+		}
+	}
+	if err := json.Unmarshal([]byte(resultStr), &result); err != nil {
+		return report, err
+	}
+	toResources := func(amounts []resourceAmount) (res ogame.Resources) {
+		for _, resource := range amounts {
+			switch {
+			case ogame.IsStrMetal(resource.Resource):
+				res.Metal = resource.Amount
+			case ogame.IsStrCrystal(resource.Resource):
+				res.Crystal = resource.Amount
+			case ogame.IsStrDeuterium(resource.Resource):
+				res.Deuterium = resource.Amount
+			case strings.EqualFold(resource.Resource, "food"):
+				res.Food = resource.Amount
+			}
+		}
+		return
+	}
+	combatDataErr := v6.RequireSelector(doc, "script:contains('combatData')")
+	if combatDataErr == nil {
+		detailed, err := extractCombatData(doc)
+		if err != nil {
+			return report, err
+		}
+		report = detailed
+	}
+	report.ID = utils.DoParseI64(doc.Find("div.detail_msg").AttrOr("data-msg-id", "0"))
+	report.APIKey = rawMessageData.AttrOr("data-raw-hashcode", "")
+	if timestamp, err := utils.ParseI64(rawMessageData.AttrOr("data-raw-datetime", "")); err == nil {
+		report.CreatedAt = time.Unix(timestamp, 0)
+	}
+	report.Destination = ogame.DoParseCoord(rawMessageData.AttrOr("data-raw-coordinates", ""))
+	report.Destination.Type = ogame.PlanetType
+	if rawMessageData.AttrOr("data-raw-targetplanettype", "1") == "3" {
+		report.Destination.Type = ogame.MoonType
+	}
+	report.Result = result.Winner
+	report.Loot = toResources(result.Loot.Resources)
+	report.LootPercentage = result.Loot.Percentage
+	report.Debris = toResources(result.Debris.Resources)
+	return report, combatDataErr
+}
@@ -189,11 +189,11 @@ func (e *Extractor) ExtractIPM(pageHTML []byte) (duration, max int64, token stri
 }
 
 // ExtractFleets ...
-func (e *Extractor) ExtractFleets(pageHTML []byte) (res []ogame.Fleet) {
+func (e *Extractor) ExtractFleets(pageHTML []byte) ([]ogame.Fleet, error) {
 	return e.extractFleets(pageHTML, e.loc)
 }
 
-func (e *Extractor) extractFleets(pageHTML []byte, location *time.Location) (res []ogame.Fleet) {
+func (e *Extractor) extractFleets(pageHTML []byte, location *time.Location) ([]ogame.Fleet, error) {
 	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
 	return e.extractFleetsFromDoc(doc, location)
 }
@@ -274,7 +274,7 @@ func (e *Extractor) ExtractTearDownToken(pageHTML []byte) (string, error) {
 }
 
 // ExtractResearch ...
-func (e *Extractor) ExtractResearch(pageHTML []byte) ogame.Researches {
+func (e *Extractor) ExtractResearch(pageHTML []byte) (ogame.Researches, error) {
 	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
 	return e.ExtractResearchFromDoc(doc)
 }
@@ -319,6 +319,12 @@ func (e *Extractor) ExtractEspionageReport(pageHTML []byte) (ogame.EspionageRepo
 	return e.ExtractEspionageReportFromDoc(doc)
 }
 
+// ExtractCombatReport ...
+func (e *Extractor) ExtractCombatReport(pageHTML []byte) (ogame.CombatReport, error) {
+	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
+	return e.ExtractCombatReportFromDoc(doc)
+}
+
 // ExtractResourcesProductions ...
 func (e *Extractor) ExtractResourcesProductions(pageHTML []byte) (ogame.Resources, error) {
 	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
@@ -454,6 +460,9 @@ func (e *Extractor) ExtractMoonFromDoc(doc *goquery.Document, v any) (ogame.Moon
 
 // ExtractCelestialsFromDoc ...
 func (e *Extractor) ExtractCelestialsFromDoc(doc *goquery.Document) ([]ogame.Celestial, error) {
+	if err := RequireSelector(doc, "div.smallplanet"); err != nil {
+		return []ogame.Celestial{}, err
+	}
 	return extractCelestialsFromDoc(doc), nil
 }
 
@@ -483,7 +492,7 @@ func (e *Extractor) ExtractFacilitiesFromDoc(doc *goquery.Document) (ogame.Facil
 }
 
 // ExtractResearchFromDoc ...
-func (e *Extractor) ExtractResearchFromDoc(doc *goquery.Document) ogame.Researches {
+func (e *Extractor) ExtractResearchFromDoc(doc *goquery.Document) (ogame.Researches, error) {
 	return extractResearchFromDoc(doc)
 }
 
@@ -541,6 +550,11 @@ func (e *Extractor) ExtractEspionageReportFromDoc(doc *goquery.Document) (ogame.
 	return extractEspionageReportFromDoc(doc, e.loc)
 }
 
+// ExtractCombatReportFromDoc ...
+func (e *Extractor) ExtractCombatReportFromDoc(doc *goquery.Document) (ogame.CombatReport, error) {
+	return extractCombatReportFromDoc(doc)
+}
+
 // ExtractResourcesProductionsFromDoc ...
 func (e *Extractor) ExtractResourcesProductionsFromDoc(doc *goquery.Document) (ogame.Resources, error) {
 	return extractResourcesProductionsFromDoc(doc)
@@ -567,11 +581,11 @@ func (e *Extractor) ExtractIPMFromDoc(doc *goquery.Document) (duration, max int6
 }
 
 // ExtractFleetsFromDoc ...
-func (e *Extractor) ExtractFleetsFromDoc(doc *goquery.Document) (res []ogame.Fleet) {
+func (e *Extractor) ExtractFleetsFromDoc(doc *goquery.Document) ([]ogame.Fleet, error) {
 	return e.extractFleetsFromDoc(doc, e.loc)
 }
 
-func (e *Extractor) extractFleetsFromDoc(doc *goquery.Document, location *time.Location) (res []ogame.Fleet) {
+func (e *Extractor) extractFleetsFromDoc(doc *goquery.Document, location *time.Location) ([]ogame.Fleet, error) {
 	return extractFleetsFromDoc(doc, location, e.lifeformEnabled)
 }
 
@@ -741,6 +755,36 @@ func (e *Extractor) ExtractAllianceClassFromDoc(doc *goquery.Document) (ogame.Al
 	return 0, errors.New("alliance class not supported")
 }
 
+// ExtractAllianceOverview ...
+func (e *Extractor) ExtractAllianceOverview(pageHTML []byte) (ogame.AllianceOverview, error) {
+	return ogame.AllianceOverview{}, errors.New("alliance overview not supported")
+}
+
+// ExtractAllianceApplications ...
+func (e *Extractor) ExtractAllianceApplications(pageHTML []byte) ([]ogame.AllianceApplication, error) {
+	return nil, errors.New("alliance applications not supported")
+}
+
+// ExtractBuddies ...
+func (e *Extractor) ExtractBuddies(pageHTML []byte) ([]ogame.Buddy, error) {
+	return nil, errors.New("buddies not supported")
+}
+
+// ExtractBuddyRequests ...
+func (e *Extractor) ExtractBuddyRequests(pageHTML []byte) ([]ogame.BuddyRequest, error) {
+	return nil, errors.New("buddy requests not supported")
+}
+
+// ExtractConversations ...
+func (e *Extractor) ExtractConversations(pageHTML []byte) ([]ogame.Conversation, error) {
+	return nil, errors.New("conversations not supported")
+}
+
+// ExtractConversationMessages ...
+func (e *Extractor) ExtractConversationMessages(pageHTML []byte, playerID int64) ([]ogame.ChatMsg, int64, error) {
+	return nil, 0, errors.New("conversation messages not supported")
+}
+
 // ExtractCommanderFromDoc ...
 func (e *Extractor) ExtractCommanderFromDoc(doc *goquery.Document) bool {
 	return extractCommanderFromDoc(doc)
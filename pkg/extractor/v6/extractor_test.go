@@ -414,7 +414,8 @@ func TestExtractPhalanx_noDeut(t *testing.T) {
 
 func TestExtractResearch(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/research_bonus.html")
-	res := NewExtractor().ExtractResearch(pageHTMLBytes)
+	res, err := NewExtractor().ExtractResearch(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, int64(12), res.EnergyTechnology)
 	assert.Equal(t, int64(12), res.LaserTechnology)
 	assert.Equal(t, int64(7), res.IonTechnology)
@@ -975,6 +976,66 @@ func TestExtractCombatReportMessagesSummary(t *testing.T) {
 	assert.Equal(t, ogame.Coordinate{Galaxy: 4, System: 127, Position: 9, Type: ogame.MoonType}, *msgs[1].Origin)
 }
 
+func TestExtractCombatReport(t *testing.T) {
+	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/combat_reports_msg.html")
+	report, err := NewExtractor().ExtractCombatReport(pageHTMLBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6971200), report.ID)
+	assert.Equal(t, "765965", report.CombatID)
+	assert.Equal(t, "89ed658c6c7ed092a1c874b5c762abfb0acd768f", report.APIKey)
+	assert.Equal(t, int64(1532565400), report.CreatedAt.Unix())
+	assert.Equal(t, ogame.Coordinate{Galaxy: 4, System: 212, Position: 8, Type: ogame.PlanetType}, report.Destination)
+	assert.Equal(t, int64(33707619), report.DefenderPlanetID)
+	assert.True(t, report.AttackerWon())
+	assert.Equal(t, ogame.Resources{Metal: 203449, Crystal: 222894, Deuterium: 40038}, report.Loot)
+	assert.Equal(t, int64(50), report.LootPercentage)
+	assert.Equal(t, int64(2000), report.DefenderLostUnits)
+	assert.Equal(t, int64(1), report.RepairedDefenses.RocketLauncher)
+	assert.Equal(t, 1, len(report.Attackers))
+	attacker := report.Attackers[0]
+	assert.Equal(t, int64(4850746), attacker.FleetID)
+	assert.Equal(t, int64(106921), attacker.PlayerID)
+	assert.Equal(t, "Renagade Andy", attacker.PlayerName)
+	assert.Equal(t, "ENL", attacker.AllianceTag)
+	assert.Equal(t, ogame.Coordinate{Galaxy: 4, System: 184, Position: 10, Type: ogame.PlanetType}, attacker.Origin)
+	assert.Equal(t, int64(100), attacker.WeaponPercentage)
+	assert.Equal(t, int64(90), attacker.ShieldPercentage)
+	assert.Equal(t, int64(100), attacker.ArmourPercentage)
+	assert.Equal(t, ogame.ShipsInfos{SmallCargo: 116, HeavyFighter: 20, Cruiser: 8}, attacker.Ships)
+	assert.Equal(t, int64(0), attacker.LostShips.Cruiser)
+	assert.Equal(t, 1, len(report.Defenders))
+	defender := report.Defenders[0]
+	assert.Equal(t, "Constable Telesto", defender.PlayerName)
+	assert.Equal(t, int64(1), defender.Defenses.RocketLauncher)
+	assert.Equal(t, int64(1), defender.LostDefenses.RocketLauncher)
+	assert.Equal(t, 2, len(report.Rounds))
+	assert.Equal(t, int64(1), report.Rounds[0].Defenders[0].Defenses.RocketLauncher)
+	assert.Equal(t, int64(0), report.Rounds[1].Defenders[0].Defenses.RocketLauncher)
+	assert.Equal(t, int64(1), report.Rounds[1].Defenders[0].LostDefenseInRound.RocketLauncher)
+	assert.Equal(t, int64(263), report.Rounds[1].HitsAttacker)
+
+	pageHTMLBytes, _ = os.ReadFile("../../../samples/unversioned/combat_reports_msg_defending_win.html")
+	report, _ = NewExtractor().ExtractCombatReport(pageHTMLBytes)
+	assert.Equal(t, ogame.CombatResultDefender, report.Result)
+	assert.Equal(t, int64(20), report.MoonChance)
+	assert.False(t, report.MoonCreated)
+	assert.Equal(t, ogame.Resources{Metal: 3854900, Crystal: 2988300}, report.Debris)
+	assert.Equal(t, int64(61), report.RepairedDefenses.RocketLauncher)
+	assert.Equal(t, 4, len(report.Rounds))
+	assert.Equal(t, int64(92), report.Attackers[0].LostShips.Cruiser)
+	assert.Equal(t, int64(5), report.Rounds[3].Attackers[0].LostShipsInRound.Bomber)
+	assert.Equal(t, int64(96), report.Defenders[0].LostDefenses.RocketLauncher)
+	assert.Equal(t, int64(56), report.Defenders[0].LostShips.LargeCargo)
+
+	pageHTMLBytes, _ = os.ReadFile("../../../samples/unversioned/combat_reports_msg_attacking_win.html")
+	report, _ = NewExtractor().ExtractCombatReport(pageHTMLBytes)
+	assert.True(t, report.MoonExists)
+
+	pageHTMLBytes, _ = os.ReadFile("../../../samples/unversioned/combat_reports_msgs.html")
+	_, err = NewExtractor().ExtractCombatReport(pageHTMLBytes)
+	assert.Error(t, err)
+}
+
 func TestExtractResourcesProductions(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/resource_settings.html")
 	prods, _ := NewExtractor().ExtractResourcesProductions(pageHTMLBytes)
@@ -1920,7 +1981,8 @@ func TestExtractFleetV71(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/v7.1/en/movement.html")
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, 1, len(fleets))
 	assert.Equal(t, int64(8271), fleets[0].ArriveIn)
 	assert.Equal(t, int64(16545), fleets[0].BackIn)
@@ -1939,7 +2001,8 @@ func TestExtractFleetV72(t *testing.T) {
 	clock := clockwork.NewFakeClockAt(time.Date(2020, 3, 6, 11, 43, 15, 0, time.UTC))
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, clock.Now().Add(-5031*time.Second), fleets[0].StartTime.UTC())
 	assert.Equal(t, clock.Now().Add(-5041*time.Second), fleets[1].StartTime.UTC())
 }
@@ -1949,7 +2012,8 @@ func TestExtractFleetV71_2(t *testing.T) {
 	clock := clockwork.NewFakeClockAt(time.Date(2020, 1, 12, 1, 45, 34, 0, time.UTC))
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 0))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, 2, len(fleets))
 	assert.Equal(t, int64(621), fleets[0].ArriveIn)
 	assert.Equal(t, int64(1245), fleets[0].BackIn)
@@ -1982,7 +2046,8 @@ func TestExtractFleetV767(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/v7.6.7/en/movement.html")
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, 1, len(fleets))
 	assert.Equal(t, time.Date(2021, 6, 1, 9, 28, 2, 0, time.UTC), fleets[0].StartTime.UTC())
 	assert.Equal(t, time.Date(2021, 6, 1, 9, 51, 10, 0, time.UTC), fleets[0].ArrivalTime.UTC())
@@ -1993,7 +2058,8 @@ func TestExtractFleetV7(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/v7/movement.html")
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, 1, len(fleets))
 	assert.Equal(t, int64(1010), fleets[0].ArriveIn)
 	assert.Equal(t, int64(2030), fleets[0].BackIn)
@@ -2010,7 +2076,8 @@ func TestExtractFleet(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/fleets_1.html")
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, 1, len(fleets))
 	assert.Equal(t, int64(4134), fleets[0].ArriveIn)
 	assert.Equal(t, int64(8277), fleets[0].BackIn)
@@ -2031,7 +2098,8 @@ func TestExtractFleet_expedition(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/fleets_expedition.html")
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, 2, len(fleets))
 	assert.Equal(t, int64(2), fleets[1].Ships.LargeCargo)
 	assert.Equal(t, ogame.Expedition, fleets[1].Mission)
@@ -2044,7 +2112,8 @@ func TestExtractFleet_harvest(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/fleets_harvest.html")
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, ogame.Coordinate{Galaxy: 4, System: 116, Position: 12, Type: ogame.PlanetType}, fleets[5].Origin)
 	assert.Equal(t, ogame.Coordinate{Galaxy: 4, System: 116, Position: 9, Type: ogame.DebrisType}, fleets[5].Destination)
 }
@@ -2053,7 +2122,8 @@ func TestExtractFleet_returningTransport(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/fleets_2.html")
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, 1, len(fleets))
 	assert.Equal(t, int64(-1), fleets[0].ArriveIn)
 	assert.Equal(t, int64(36), fleets[0].BackIn)
@@ -2063,7 +2133,8 @@ func TestExtractFleet_deployment(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/fleets_moon_to_moon.html")
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, int64(210), fleets[0].ArriveIn)
 	assert.Equal(t, int64(426), fleets[0].BackIn)
 }
@@ -2072,7 +2143,8 @@ func TestExtractFleetThousands(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/fleets_thousands.html")
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, ogame.Transport, fleets[0].Mission)
 	assert.Equal(t, int64(210), fleets[0].Ships.LargeCargo)
 	assert.Equal(t, ogame.Resources{Metal: 207862, Crystal: 78903, Deuterium: 42956}, fleets[0].Resources)
@@ -2082,7 +2154,8 @@ func TestExtractFleet_returning(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/fleets_2.html")
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, 1, len(fleets))
 	assert.Equal(t, ogame.Coordinate{Galaxy: 4, System: 116, Position: 12, Type: ogame.PlanetType}, fleets[0].Origin)
 	assert.Equal(t, ogame.Coordinate{Galaxy: 4, System: 117, Position: 9, Type: ogame.PlanetType}, fleets[0].Destination)
@@ -2101,7 +2174,8 @@ func TestExtractFleet_deepspace(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/v7.2/en/fleets_expeditions.html")
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, 5, len(fleets))
 	assert.False(t, fleets[0].InDeepSpace)
 	assert.False(t, fleets[1].InDeepSpace)
@@ -2114,7 +2188,8 @@ func TestExtractFleet_targetPlanetID(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/fleets_moon_to_moon.html")
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, int64(0), fleets[0].TargetPlanetID)
 	assert.Equal(t, int64(0), fleets[1].TargetPlanetID)
 	assert.Equal(t, int64(33702114), fleets[2].TargetPlanetID)
@@ -2125,13 +2200,15 @@ func TestExtractFleet_unionID(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/unversioned/fleets_no_union.html")
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, int64(0), fleets[0].UnionID)
 
 	pageHTMLBytes, _ = os.ReadFile("../../../samples/unversioned/fleets_union_alone.html")
 	e = NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
-	fleets = e.ExtractFleets(pageHTMLBytes)
+	fleets, err = e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, int64(13558), fleets[0].UnionID)
 }
 
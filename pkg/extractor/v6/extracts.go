@@ -113,6 +113,15 @@ func ExtractBodyIDFromDoc(doc *goquery.Document) string {
 	return bodyID
 }
 
+// RequireSelector returns a SelectorNotFoundError when no element of the page matches selector,
+// so a page whose markup changed is not read as a planet without any building, ship or defense
+func RequireSelector(doc *goquery.Document, selector string) error {
+	if doc.Find(selector).Length() == 0 {
+		return ogame.NewSelectorNotFoundError(selector)
+	}
+	return nil
+}
+
 func extractCelestialByIDFromDoc(doc *goquery.Document, celestialID ogame.CelestialID) (ogame.Celestial, error) {
 	celestials := extractCelestialsFromDoc(doc)
 	for _, celestial := range celestials {
@@ -247,6 +256,9 @@ func extractResourcesBuildingsFromDoc(doc *goquery.Document) (ogame.ResourcesBui
 	if bodyID == "overview" {
 		return ogame.ResourcesBuildings{}, ogame.ErrInvalidPlanetID
 	}
+	if err := RequireSelector(doc, "div.supply1 span.level"); err != nil {
+		return ogame.ResourcesBuildings{}, err
+	}
 	res := ogame.ResourcesBuildings{}
 	res.MetalMine = utils.GetNbr(doc, "supply1")
 	res.CrystalMine = utils.GetNbr(doc, "supply2")
@@ -266,6 +278,9 @@ func extractDefenseFromDoc(doc *goquery.Document) (ogame.DefensesInfos, error) {
 		return ogame.DefensesInfos{}, ogame.ErrInvalidPlanetID
 	}
 	doc.Find("span.textlabel").Remove()
+	if err := RequireSelector(doc, "div.defense401"); err != nil {
+		return ogame.DefensesInfos{}, err
+	}
 	res := ogame.DefensesInfos{}
 	res.RocketLauncher = utils.GetNbr(doc, "defense401")
 	res.LightLaser = utils.GetNbr(doc, "defense402")
@@ -286,6 +301,12 @@ func extractShipsFromDoc(doc *goquery.Document) (ogame.ShipsInfos, error) {
 	if bodyID == "overview" {
 		return ogame.ShipsInfos{}, ogame.ErrInvalidPlanetID
 	}
+	// No ship is listed in the fleet page when the planet has none
+	if bodyID != "fleet1" {
+		if err := RequireSelector(doc, "div.military204"); err != nil {
+			return ogame.ShipsInfos{}, err
+		}
+	}
 	res := ogame.ShipsInfos{}
 	res.LightFighter = utils.GetNbrShips(doc, "military204")
 	res.HeavyFighter = utils.GetNbrShips(doc, "military205")
@@ -311,6 +332,9 @@ func extractFacilitiesFromDoc(doc *goquery.Document) (ogame.Facilities, error) {
 	if bodyID == "overview" {
 		return ogame.Facilities{}, ogame.ErrInvalidPlanetID
 	}
+	if err := RequireSelector(doc, "div.station14 span.level"); err != nil {
+		return ogame.Facilities{}, err
+	}
 	res := ogame.Facilities{}
 	res.RoboticsFactory = utils.GetNbr(doc, "station14")
 	res.Shipyard = utils.GetNbr(doc, "station21")
@@ -326,7 +350,10 @@ func extractFacilitiesFromDoc(doc *goquery.Document) (ogame.Facilities, error) {
 	return res, nil
 }
 
-func extractResearchFromDoc(doc *goquery.Document) ogame.Researches {
+func extractResearchFromDoc(doc *goquery.Document) (ogame.Researches, error) {
+	if err := RequireSelector(doc, "div.research113 span.level"); err != nil {
+		return ogame.Researches{}, err
+	}
 	doc.Find("span.textlabel").Remove()
 	res := ogame.Researches{}
 	res.EnergyTechnology = utils.GetNbr(doc, "research113")
@@ -345,7 +372,7 @@ func extractResearchFromDoc(doc *goquery.Document) ogame.Researches {
 	res.WeaponsTechnology = utils.GetNbr(doc, "research109")
 	res.ShieldingTechnology = utils.GetNbr(doc, "research110")
 	res.ArmourTechnology = utils.GetNbr(doc, "research111")
-	return res
+	return res, nil
 }
 
 func ExtractOGameSessionFromDoc(doc *goquery.Document) string {
@@ -704,6 +731,9 @@ func extractCombatReportMessagesFromDoc(doc *goquery.Document) ([]ogame.CombatRe
 
 func extractEspionageReportFromDoc(doc *goquery.Document, location *time.Location) (ogame.EspionageReport, error) {
 	report := ogame.EspionageReport{}
+	if err := RequireSelector(doc, "span.msg_title a"); err != nil {
+		return report, err
+	}
 	report.ID = utils.DoParseI64(doc.Find("div.detail_msg").AttrOr("data-msg-id", "0"))
 	spanLink := doc.Find("span.msg_title a").First()
 	txt := spanLink.Text()
@@ -1110,8 +1140,12 @@ func extractIPMFromDoc(doc *goquery.Document) (duration, max int64, token string
 	return
 }
 
-func extractFleetsFromDoc(doc *goquery.Document, location *time.Location, lifeformEnabled bool) (res []ogame.Fleet) {
-	res = make([]ogame.Fleet, 0)
+func extractFleetsFromDoc(doc *goquery.Document, location *time.Location, lifeformEnabled bool) ([]ogame.Fleet, error) {
+	// The game shows the fleet dispatch page instead of the movement page when no fleet is flying
+	if err := RequireSelector(doc, "#movement, #fleet1"); err != nil {
+		return []ogame.Fleet{}, err
+	}
+	res := make([]ogame.Fleet, 0)
 	script := doc.Find("body script").Text()
 	doc.Find("div.fleetDetails").Each(func(i int, s *goquery.Selection) {
 		originText := s.Find("span.originCoords a").Text()
@@ -1216,18 +1250,24 @@ func extractFleetsFromDoc(doc *goquery.Document, location *time.Location, lifefo
 
 		res = append(res, fleet)
 	})
-	return
+	return res, nil
 }
 
 func extractSlotsFromDoc(doc *goquery.Document) (ogame.Slots, error) {
 	slots := ogame.Slots{}
 	page := ExtractBodyIDFromDoc(doc)
 	if page == "movement" {
+		if err := RequireSelector(doc, "span.fleetSlots"); err != nil {
+			return slots, err
+		}
 		slots.InUse = utils.ParseInt(doc.Find("span.fleetSlots > span.current").Text())
 		slots.Total = utils.ParseInt(doc.Find("span.fleetSlots > span.all").Text())
 		slots.ExpInUse = utils.ParseInt(doc.Find("span.expSlots > span.current").Text())
 		slots.ExpTotal = utils.ParseInt(doc.Find("span.expSlots > span.all").Text())
 	} else if page == "fleetdispatch" || page == "fleet1" {
+		if err := RequireSelector(doc, "div#slots>div"); err != nil {
+			return slots, err
+		}
 		r := regexp.MustCompile(`(\d+)/(\d+)`)
 		txt := doc.Find("div#slots>div").Eq(0).Text()
 		m := r.FindStringSubmatch(txt)
@@ -1668,6 +1708,9 @@ func extractResourcesDetails(pageHTML []byte) (out ogame.ResourcesDetails, err e
 		}
 		return
 	}
+	if res.Metal.Tooltip == "" {
+		return out, ogame.NewSelectorNotFoundError("metal.tooltip")
+	}
 	out.Metal.Available = res.Metal.Resources.Actual
 	out.Metal.StorageCapacity = res.Metal.Resources.Max
 	out.Crystal.Available = res.Crystal.Resources.Actual
@@ -1725,6 +1768,9 @@ func extractGalaxyInfos(pageHTML []byte, botPlayerName string, botPlayerID, botP
 	if err := json.Unmarshal(pageHTML, &tmp); err != nil {
 		return res, ogame.ErrNotLogged
 	}
+	if tmp.Galaxy == "" {
+		return res, ogame.NewSelectorNotFoundError("galaxy")
+	}
 
 	overlayTokenRgx := regexp.MustCompile(`data-overlay-token="([^"]+)"`)
 	m := overlayTokenRgx.FindStringSubmatch(tmp.Galaxy)
@@ -2536,3 +2582,300 @@ func extractAuctionFromDoc(doc *goquery.Document) (ogame.Auction, error) {
 
 	return auction, nil
 }
+
+// Numbers of the combat report json are sometimes strings, floats or booleans
+type combatInt64 int64
+
+func (n *combatInt64) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	switch s {
+	case "", "null", "false":
+		*n = 0
+	case "true":
+		*n = 1
+	default:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		*n = combatInt64(f)
+	}
+	return nil
+}
+
+type combatEntry struct {
+	key   string
+	value json.RawMessage
+}
+
+// Participants/units are either a json object (keyed by fleet id) or a json array (keyed by index).
+// Returns the entries in the order they appear in the json.
+func combatEntries(raw json.RawMessage) ([]combatEntry, error) {
+	out := make([]combatEntry, 0)
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	tok, err := dec.Token()
+	if err != nil || tok == nil {
+		return out, nil
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return out, nil
+	}
+	for i := 0; dec.More(); i++ {
+		key := strconv.Itoa(i)
+		if delim == '{' {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ = keyTok.(string)
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		out = append(out, combatEntry{key: key, value: value})
+	}
+	return out, nil
+}
+
+// Returns the units (ships and defenses) of a {"id": count} json object
+func combatUnits(raw json.RawMessage, fn func(entry json.RawMessage) (int64, error)) (ships ogame.ShipsInfos, defenses ogame.DefensesInfos, err error) {
+	entries, err := combatEntries(raw)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		id := ogame.ID(utils.DoParseI64(entry.key))
+		nbr, err := fn(entry.value)
+		if err != nil {
+			return ships, defenses, err
+		}
+		if id.IsShip() {
+			ships.Set(id, nbr)
+		} else if id.IsDefense() {
+			defenses.Set(id, nbr)
+		}
+	}
+	return
+}
+
+func combatUnitsCount(raw json.RawMessage) (ogame.ShipsInfos, ogame.DefensesInfos, error) {
+	return combatUnits(raw, func(entry json.RawMessage) (int64, error) {
+		var nbr combatInt64
+		err := json.Unmarshal(entry, &nbr)
+		return int64(nbr), err
+	})
+}
+
+// Returns the units of a participant of a round, identified by its key
+func combatRoundUnits(raw json.RawMessage, key string) (ogame.ShipsInfos, ogame.DefensesInfos, error) {
+	entries, err := combatEntries(raw)
+	if err != nil {
+		return ogame.ShipsInfos{}, ogame.DefensesInfos{}, err
+	}
+	for _, entry := range entries {
+		if entry.key == key {
+			return combatUnitsCount(entry.value)
+		}
+	}
+	return ogame.ShipsInfos{}, ogame.DefensesInfos{}, nil
+}
+
+func extractCombatReportFromDoc(doc *goquery.Document) (ogame.CombatReport, error) {
+	report := ogame.CombatReport{}
+	scripts := doc.Find("script").Text()
+	m := regexp.MustCompile(`combatData = jQuery\.parseJSON\('(.+)'\);`).FindStringSubmatch(scripts)
+	if len(m) != 2 {
+		return report, errors.New("combat data not found")
+	}
+	type participant struct {
+		OwnerName        string
+		OwnerID          combatInt64
+		OwnerCoordinates string
+		OwnerPlanetType  combatInt64
+		OwnerHomePlanet  string
+		PlanetID         combatInt64 `json:"planetId"`
+		FleetID          combatInt64
+		OwnerAlliance    string
+		OwnerAllianceTag string
+		ArmorPercentage  combatInt64
+		WeaponPercentage combatInt64
+		ShieldPercentage combatInt64
+		ShipDetails      json.RawMessage
+	}
+	var data struct {
+		EventTimestamp   combatInt64 `json:"event_timestamp"`
+		DefenderPlanetID combatInt64 `json:"defenderPlanetId"`
+		Coordinates      struct {
+			Galaxy     combatInt64
+			System     combatInt64
+			Position   combatInt64
+			PlanetType combatInt64
+		}
+		Attacker     json.RawMessage
+		Defender     json.RawMessage
+		CombatRounds []struct {
+			AttackerShips             json.RawMessage
+			DefenderShips             json.RawMessage
+			AttackerLosses            json.RawMessage
+			DefenderLosses            json.RawMessage
+			AttackerLossesInThisRound json.RawMessage
+			DefenderLossesInThisRound json.RawMessage
+			Statistic                 struct {
+				HitsAttacker           combatInt64
+				HitsDefender           combatInt64
+				AbsorbedDamageAttacker combatInt64
+				AbsorbedDamageDefender combatInt64
+				FullStrengthAttacker   combatInt64
+				FullStrengthDefender   combatInt64
+			}
+		}
+		Statistic struct {
+			LostUnitsAttacker combatInt64
+			LostUnitsDefender combatInt64
+		}
+		Result string
+		Debris struct {
+			Metal      combatInt64
+			Crystal    combatInt64
+			Deuterium  combatInt64
+			DarkMatter combatInt64
+		}
+		Loot struct {
+			Metal     combatInt64
+			Crystal   combatInt64
+			Deuterium combatInt64
+			Food      combatInt64
+		}
+		RepairedDefense json.RawMessage
+		Moon            struct {
+			Genesis combatInt64
+			Chance  combatInt64
+			Size    combatInt64
+			Exists  combatInt64
+		}
+		TacticalRetreat struct {
+			Active combatInt64
+		}
+		LootPercentage combatInt64
+		Hashcode       string
+		CombatID       string `json:"combatId"`
+	}
+	if err := json.Unmarshal([]byte(m[1]), &data); err != nil {
+		return report, err
+	}
+	report.ID = utils.DoParseI64(doc.Find("div.detail_msg").AttrOr("data-msg-id", "0"))
+	report.CombatID = data.CombatID
+	report.APIKey = data.Hashcode
+	report.CreatedAt = time.Unix(int64(data.EventTimestamp), 0)
+	report.Destination = ogame.Coordinate{
+		Galaxy:   int64(data.Coordinates.Galaxy),
+		System:   int64(data.Coordinates.System),
+		Position: int64(data.Coordinates.Position),
+		Type:     ogame.CelestialType(data.Coordinates.PlanetType),
+	}
+	report.DefenderPlanetID = int64(data.DefenderPlanetID)
+	report.Result = data.Result
+	report.Loot = ogame.Resources{Metal: int64(data.Loot.Metal), Crystal: int64(data.Loot.Crystal), Deuterium: int64(data.Loot.Deuterium), Food: int64(data.Loot.Food)}
+	report.LootPercentage = int64(data.LootPercentage)
+	report.Debris = ogame.Resources{Metal: int64(data.Debris.Metal), Crystal: int64(data.Debris.Crystal), Deuterium: int64(data.Debris.Deuterium), Darkmatter: int64(data.Debris.DarkMatter)}
+	report.AttackerLostUnits = int64(data.Statistic.LostUnitsAttacker)
+	report.DefenderLostUnits = int64(data.Statistic.LostUnitsDefender)
+	report.MoonChance = int64(data.Moon.Chance)
+	report.MoonCreated = data.Moon.Genesis != 0
+	report.MoonSize = int64(data.Moon.Size)
+	report.MoonExists = data.Moon.Exists != 0
+	report.TacticalRetreat = data.TacticalRetreat.Active != 0
+	if _, defenses, err := combatUnitsCount(data.RepairedDefense); err == nil {
+		report.RepairedDefenses = defenses
+	}
+
+	parseParticipants := func(raw json.RawMessage) ([]ogame.CombatParticipant, []string, error) {
+		entries, err := combatEntries(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		participants := make([]ogame.CombatParticipant, 0, len(entries))
+		keys := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			var p participant
+			if err := json.Unmarshal(entry.value, &p); err != nil {
+				return nil, nil, err
+			}
+			origin, _ := ogame.ParseCoord(p.OwnerCoordinates)
+			if p.OwnerPlanetType != 0 {
+				origin.Type = ogame.CelestialType(p.OwnerPlanetType)
+			}
+			ships, defenses, err := combatUnits(p.ShipDetails, func(entry json.RawMessage) (int64, error) {
+				var details struct{ Count combatInt64 }
+				err := json.Unmarshal(entry, &details)
+				return int64(details.Count), err
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			participants = append(participants, ogame.CombatParticipant{
+				FleetID:          int64(p.FleetID),
+				PlayerID:         int64(p.OwnerID),
+				PlayerName:       p.OwnerName,
+				AllianceName:     p.OwnerAlliance,
+				AllianceTag:      p.OwnerAllianceTag,
+				PlanetID:         int64(p.PlanetID),
+				PlanetName:       p.OwnerHomePlanet,
+				Origin:           origin,
+				WeaponPercentage: int64(p.WeaponPercentage),
+				ShieldPercentage: int64(p.ShieldPercentage),
+				ArmourPercentage: int64(p.ArmorPercentage),
+				Ships:            ships,
+				Defenses:         defenses,
+			})
+			keys = append(keys, entry.key)
+		}
+		return participants, keys, nil
+	}
+	var attackerKeys, defenderKeys []string
+	var err error
+	if report.Attackers, attackerKeys, err = parseParticipants(data.Attacker); err != nil {
+		return report, err
+	}
+	if report.Defenders, defenderKeys, err = parseParticipants(data.Defender); err != nil {
+		return report, err
+	}
+
+	roundUnits := func(remaining, lost json.RawMessage, key string) (out ogame.CombatRoundUnits) {
+		out.Ships, out.Defenses, _ = combatRoundUnits(remaining, key)
+		out.LostShipsInRound, out.LostDefenseInRound, _ = combatRoundUnits(lost, key)
+		return
+	}
+	report.Rounds = make([]ogame.CombatRound, 0, len(data.CombatRounds))
+	for _, r := range data.CombatRounds {
+		round := ogame.CombatRound{
+			HitsAttacker:           int64(r.Statistic.HitsAttacker),
+			HitsDefender:           int64(r.Statistic.HitsDefender),
+			AbsorbedDamageAttacker: int64(r.Statistic.AbsorbedDamageAttacker),
+			AbsorbedDamageDefender: int64(r.Statistic.AbsorbedDamageDefender),
+			FullStrengthAttacker:   int64(r.Statistic.FullStrengthAttacker),
+			FullStrengthDefender:   int64(r.Statistic.FullStrengthDefender),
+		}
+		for _, key := range attackerKeys {
+			round.Attackers = append(round.Attackers, roundUnits(r.AttackerShips, r.AttackerLossesInThisRound, key))
+		}
+		for _, key := range defenderKeys {
+			round.Defenders = append(round.Defenders, roundUnits(r.DefenderShips, r.DefenderLossesInThisRound, key))
+		}
+		report.Rounds = append(report.Rounds, round)
+	}
+
+	// Losses are cumulative, the last round holds the total losses
+	if len(data.CombatRounds) > 0 {
+		last := data.CombatRounds[len(data.CombatRounds)-1]
+		for i, key := range attackerKeys {
+			report.Attackers[i].LostShips, report.Attackers[i].LostDefenses, _ = combatRoundUnits(last.AttackerLosses, key)
+		}
+		for i, key := range defenderKeys {
+			report.Defenders[i].LostShips, report.Defenders[i].LostDefenses, _ = combatRoundUnits(last.DefenderLosses, key)
+		}
+	}
+	return report, nil
+}
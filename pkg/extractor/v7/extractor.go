@@ -61,7 +61,7 @@ func (e Extractor) ExtractFacilities(pageHTML []byte) (ogame.Facilities, error)
 }
 
 // ExtractResearch ...
-func (e Extractor) ExtractResearch(pageHTML []byte) ogame.Researches {
+func (e Extractor) ExtractResearch(pageHTML []byte) (ogame.Researches, error) {
 	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
 	return e.ExtractResearchFromDoc(doc)
 }
@@ -178,7 +178,7 @@ func (e Extractor) ExtractFacilitiesFromDoc(doc *goquery.Document) (ogame.Facili
 }
 
 // ExtractResearchFromDoc ...
-func (e Extractor) ExtractResearchFromDoc(doc *goquery.Document) ogame.Researches {
+func (e Extractor) ExtractResearchFromDoc(doc *goquery.Document) (ogame.Researches, error) {
 	return extractResearchFromDoc(doc)
 }
 
@@ -129,7 +129,8 @@ func TestExtractShips_build(t *testing.T) {
 
 func TestExtractResearch(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/v7/researches.html")
-	res := NewExtractor().ExtractResearch(pageHTMLBytes)
+	res, err := NewExtractor().ExtractResearch(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, int64(2), res.EnergyTechnology)
 	assert.Equal(t, int64(4), res.LaserTechnology)
 	assert.Equal(t, int64(0), res.IonTechnology)
@@ -150,7 +151,8 @@ func TestExtractResearch(t *testing.T) {
 
 func TestExtractResearch_2(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../../samples/v7/researches2.html")
-	res := NewExtractor().ExtractResearch(pageHTMLBytes)
+	res, err := NewExtractor().ExtractResearch(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, int64(1), res.EnergyTechnology)
 	assert.Equal(t, int64(0), res.LaserTechnology)
 	assert.Equal(t, int64(0), res.IonTechnology)
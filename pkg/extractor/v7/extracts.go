@@ -61,6 +61,9 @@ func extractResourcesDetailsFromFullPageFromDoc(doc *goquery.Document) ogame.Res
 }
 
 func ExtractFacilitiesFromDoc(doc *goquery.Document) (ogame.Facilities, error) {
+	if err := v6.RequireSelector(doc, "span.roboticsFactory span.level"); err != nil {
+		return ogame.Facilities{}, err
+	}
 	res := ogame.Facilities{}
 	res.RoboticsFactory = GetNbr(doc, "roboticsFactory")
 	res.Shipyard = GetNbr(doc, "shipyard")
@@ -77,6 +80,9 @@ func ExtractFacilitiesFromDoc(doc *goquery.Document) (ogame.Facilities, error) {
 }
 
 func extractDefenseFromDoc(doc *goquery.Document) (ogame.DefensesInfos, error) {
+	if err := v6.RequireSelector(doc, "span.rocketLauncher span.amount"); err != nil {
+		return ogame.DefensesInfos{}, err
+	}
 	res := ogame.DefensesInfos{}
 	res.RocketLauncher = getNbrShips(doc, "rocketLauncher")
 	res.LightLaser = getNbrShips(doc, "laserCannonLight")
@@ -91,7 +97,10 @@ func extractDefenseFromDoc(doc *goquery.Document) (ogame.DefensesInfos, error) {
 	return res, nil
 }
 
-func extractResearchFromDoc(doc *goquery.Document) ogame.Researches {
+func extractResearchFromDoc(doc *goquery.Document) (ogame.Researches, error) {
+	if err := v6.RequireSelector(doc, "span.energyTechnology span.level"); err != nil {
+		return ogame.Researches{}, err
+	}
 	doc.Find("span.textlabel").Remove()
 	res := ogame.Researches{}
 	res.EnergyTechnology = GetNbr(doc, "energyTechnology")
@@ -110,10 +119,16 @@ func extractResearchFromDoc(doc *goquery.Document) ogame.Researches {
 	res.WeaponsTechnology = GetNbr(doc, "weaponsTechnology")
 	res.ShieldingTechnology = GetNbr(doc, "shieldingTechnology")
 	res.ArmourTechnology = GetNbr(doc, "armorTechnology")
-	return res
+	return res, nil
 }
 
 func extractShipsFromDoc(doc *goquery.Document) (ogame.ShipsInfos, error) {
+	// No ship is listed in the fleet dispatch page when the planet has none
+	if v6.ExtractBodyIDFromDoc(doc) != "fleetdispatch" {
+		if err := v6.RequireSelector(doc, "span.fighterLight span.amount"); err != nil {
+			return ogame.ShipsInfos{}, err
+		}
+	}
 	res := ogame.ShipsInfos{}
 	res.LightFighter = getNbrShips(doc, "fighterLight")
 	res.HeavyFighter = getNbrShips(doc, "fighterHeavy")
@@ -136,6 +151,9 @@ func extractShipsFromDoc(doc *goquery.Document) (ogame.ShipsInfos, error) {
 }
 
 func extractResourcesBuildingsFromDoc(doc *goquery.Document) (ogame.ResourcesBuildings, error) {
+	if err := v6.RequireSelector(doc, "span.metalMine span.level"); err != nil {
+		return ogame.ResourcesBuildings{}, err
+	}
 	res := ogame.ResourcesBuildings{}
 	res.MetalMine = GetNbr(doc, "metalMine")
 	res.CrystalMine = GetNbr(doc, "crystalMine")
@@ -197,6 +215,9 @@ func extractResourcesDetails(pageHTML []byte) (out ogame.ResourcesDetails, err e
 		}
 		return
 	}
+	if res.Metal.Tooltip == "" {
+		return out, ogame.NewSelectorNotFoundError("metal.tooltip")
+	}
 	out.Metal.Available = res.Metal.Actual
 	out.Metal.StorageCapacity = res.Metal.Max
 	out.Crystal.Available = res.Crystal.Actual
@@ -324,6 +345,9 @@ func extractCombatReportMessagesFromDoc(doc *goquery.Document) ([]ogame.CombatRe
 
 func extractEspionageReportFromDoc(doc *goquery.Document, location *time.Location) (ogame.EspionageReport, error) {
 	report := ogame.EspionageReport{}
+	if err := v6.RequireSelector(doc, "span.msg_title a"); err != nil {
+		return report, err
+	}
 	report.ID = utils.DoParseI64(doc.Find("div.detail_msg").AttrOr("data-msg-id", "0"))
 	spanLink := doc.Find("span.msg_title a").First()
 	txt := spanLink.Text()
@@ -172,6 +172,9 @@ func extractResourcesDetails(pageHTML []byte) (out ogame.ResourcesDetails, err e
 		}
 		return
 	}
+	if res.Resources.Metal.Tooltip == "" {
+		return out, ogame.NewSelectorNotFoundError("resources.metal.tooltip")
+	}
 	out.Metal.Available = int64(res.Resources.Metal.Amount)
 	out.Metal.StorageCapacity = int64(res.Resources.Metal.Storage)
 	out.Crystal.Available = int64(res.Resources.Crystal.Amount)
@@ -660,6 +663,9 @@ func GetCharacterClass(characterClassStr string) ogame.CharacterClass {
 
 func extractEspionageReportFromDoc(doc *goquery.Document, location *time.Location) (ogame.EspionageReport, error) {
 	report := ogame.EspionageReport{}
+	if err := v6.RequireSelector(doc, "span.msg_title a"); err != nil {
+		return report, err
+	}
 	report.ID = utils.DoParseI64(doc.Find("div.detail_msg").AttrOr("data-msg-id", "0"))
 	spanLink := doc.Find("span.msg_title a").First()
 	txt := spanLink.Text()
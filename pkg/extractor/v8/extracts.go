@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	v6 "github.com/alaingilbert/ogame/pkg/extractor/v6"
 	v71 "github.com/alaingilbert/ogame/pkg/extractor/v71"
 	"github.com/alaingilbert/ogame/pkg/ogame"
 	"github.com/alaingilbert/ogame/pkg/utils"
@@ -32,6 +33,9 @@ func extractIsInVacationFromDoc(doc *goquery.Document) bool {
 
 func extractEspionageReportFromDoc(doc *goquery.Document, location *time.Location) (ogame.EspionageReport, error) {
 	report := ogame.EspionageReport{}
+	if err := v6.RequireSelector(doc, "span.msg_title a"); err != nil {
+		return report, err
+	}
 	report.ID = utils.DoParseI64(doc.Find("div.detail_msg").AttrOr("data-msg-id", "0"))
 	spanLink := doc.Find("span.msg_title a").First()
 	txt := spanLink.Text()
@@ -144,7 +144,8 @@ func TestExtractFleetResources(t *testing.T) {
 	e := NewExtractor()
 	e.SetLocation(time.FixedZone("OGT", 3600))
 	e.SetLifeformEnabled(true)
-	fleets := e.ExtractFleets(pageHTMLBytes)
+	fleets, err := e.ExtractFleets(pageHTMLBytes)
+	assert.NoError(t, err)
 	assert.Equal(t, int64(1), fleets[0].Resources.Metal)
 	assert.Equal(t, int64(2), fleets[0].Resources.Crystal)
 	assert.Equal(t, int64(3), fleets[0].Resources.Deuterium)
@@ -48,6 +48,11 @@ func (e CaptchaRequiredError) Error() string {
 	return fmt.Sprintf("captcha required, %s", e.ChallengeID)
 }
 
+// Is reports whether target is ogame.ErrCaptchaRequired
+func (e CaptchaRequiredError) Is(target error) bool {
+	return target == ogame.ErrCaptchaRequired
+}
+
 type RegisterError struct{ ErrorString string }
 
 func (e *RegisterError) Error() string { return e.ErrorString }
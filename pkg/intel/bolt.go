@@ -0,0 +1,259 @@
+package intel
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	espionageReportEntry = "espionage_report"
+	systemInfosEntry     = "system_infos"
+	highscoreEntry       = "highscore"
+)
+
+// Bucket holding the saved results, keyed by insertion order
+var resultsBucket = []byte("results")
+
+// One saved result
+type resultEntry struct {
+	Kind            string                 `json:"kind"`
+	At              time.Time              `json:"at"`
+	EspionageReport *ogame.EspionageReport `json:"espionageReport,omitempty"`
+	SystemInfos     *resultSystemInfos     `json:"systemInfos,omitempty"`
+	Highscore       *ogame.Highscore       `json:"highscore,omitempty"`
+}
+
+// ogame.SystemInfos keeps its fields private, and cannot be unmarshalled directly
+type resultSystemInfos struct {
+	Galaxy  int64
+	System  int64
+	Planets [15]*ogame.PlanetInfos
+}
+
+// DefaultRetention results older than this are dropped from the store when it is pruned
+const DefaultRetention = 30 * 24 * time.Hour
+
+// DefaultPruneEvery interval between two prunings of the store
+const DefaultPruneEvery = time.Hour
+
+// BoltStoreConfig configuration of a BoltStore
+type BoltStoreConfig struct {
+	// Results older than Retention are dropped when the store is pruned.
+	// The latest result of each system, coordinate and highscore page is always kept. 0 keeps everything.
+	Retention time.Duration
+	// The store is pruned when it is opened, then in the background every PruneEvery (default: DefaultPruneEvery)
+	PruneEvery time.Duration
+}
+
+// BoltStore intel store persisted in a bbolt database.
+// Every saved result is written to the database, which is loaded in memory when the store is opened.
+// The results older than the retention are pruned in the background, saving a result never waits for a pruning.
+type BoltStore struct {
+	*MemoryStore
+	db   *bolt.DB
+	cfg  BoltStoreConfig
+	done chan struct{}
+}
+
+// NewBoltStore opens (or creates) the intel database, results are kept for DefaultRetention
+func NewBoltStore(filename string) (*BoltStore, error) {
+	return NewBoltStoreWithConfig(filename, BoltStoreConfig{Retention: DefaultRetention})
+}
+
+// NewBoltStoreWithConfig opens (or creates) the intel database
+func NewBoltStoreWithConfig(filename string, cfg BoltStoreConfig) (*BoltStore, error) {
+	if cfg.PruneEvery <= 0 {
+		cfg.PruneEvery = DefaultPruneEvery
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, err
+	}
+	// Another process holding the database would block forever without a timeout
+	db, err := bolt.Open(filename, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	s := &BoltStore{MemoryStore: NewMemoryStore(), db: db, cfg: cfg, done: make(chan struct{})}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(resultsBucket)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if err := s.prune(time.Now()); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	go s.pruneLoop()
+	return s, nil
+}
+
+func (s *BoltStore) pruneLoop() {
+	ticker := time.NewTicker(s.cfg.PruneEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// A failed pruning is tried again on the next tick, the saved results are not affected
+			_ = s.prune(time.Now())
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (entry resultEntry) apply(store *MemoryStore) error {
+	switch entry.Kind {
+	case espionageReportEntry:
+		if entry.EspionageReport != nil {
+			return store.SaveEspionageReport(*entry.EspionageReport)
+		}
+	case systemInfosEntry:
+		if entry.SystemInfos != nil {
+			var systemInfos ogame.SystemInfos
+			systemInfos.SetGalaxy(entry.SystemInfos.Galaxy)
+			systemInfos.SetSystem(entry.SystemInfos.System)
+			for i, planet := range entry.SystemInfos.Planets {
+				systemInfos.SetPlanet(i, planet)
+			}
+			return store.SaveSystemInfos(systemInfos, entry.At)
+		}
+	case highscoreEntry:
+		if entry.Highscore != nil {
+			return store.SaveHighscore(*entry.Highscore, entry.At)
+		}
+	}
+	return nil
+}
+
+// Identifies what the entry is a result of, the latest entry of each key is kept by the pruning
+func (entry resultEntry) key() string {
+	switch {
+	case entry.EspionageReport != nil:
+		return entry.Kind + ":" + entry.EspionageReport.Coordinate.String()
+	case entry.SystemInfos != nil:
+		return fmt.Sprintf("%s:%d:%d", entry.Kind, entry.SystemInfos.Galaxy, entry.SystemInfos.System)
+	case entry.Highscore != nil:
+		return fmt.Sprintf("%s:%d:%d:%d", entry.Kind, entry.Highscore.Category, entry.Highscore.Type, entry.Highscore.CurrPage)
+	}
+	return entry.Kind
+}
+
+// Deletes the results older than the retention, then loads the remaining ones in memory.
+// Saves wait for the write transaction, and cannot be missed by the new memory store.
+func (s *BoltStore) prune(now time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(resultsBucket)
+		type result struct {
+			k     []byte
+			entry resultEntry
+		}
+		var results []result
+		latest := make(map[string]time.Time)
+		if err := bucket.ForEach(func(k, v []byte) error {
+			var entry resultEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return fmt.Errorf("invalid intel result %d : %w", binary.BigEndian.Uint64(k), err)
+			}
+			if key := entry.key(); entry.At.After(latest[key]) || latest[key].IsZero() {
+				latest[key] = entry.At
+			}
+			results = append(results, result{k: append([]byte(nil), k...), entry: entry})
+			return nil
+		}); err != nil {
+			return err
+		}
+		store := NewMemoryStore()
+		for _, r := range results {
+			if s.cfg.Retention > 0 && r.entry.At.Before(now.Add(-s.cfg.Retention)) && r.entry.At.Before(latest[r.entry.key()]) {
+				if err := bucket.Delete(r.k); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := r.entry.apply(store); err != nil {
+				return err
+			}
+		}
+		s.MemoryStore.replaceWith(store)
+		return nil
+	})
+}
+
+// Prune deletes the results older than the retention now, instead of waiting for the background pruning
+func (s *BoltStore) Prune() error {
+	return s.closedErr(s.prune(time.Now()))
+}
+
+// Writes the entry to the database and applies it in memory, in the same transaction
+func (s *BoltStore) save(entry resultEntry, apply func() error) error {
+	by, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.closedErr(s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(resultsBucket)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		k := make([]byte, 8)
+		binary.BigEndian.PutUint64(k, seq)
+		if err := bucket.Put(k, by); err != nil {
+			return err
+		}
+		return apply()
+	}))
+}
+
+func (s *BoltStore) closedErr(err error) error {
+	if errors.Is(err, bolt.ErrDatabaseNotOpen) {
+		return os.ErrClosed
+	}
+	return err
+}
+
+// SaveEspionageReport ...
+func (s *BoltStore) SaveEspionageReport(report ogame.EspionageReport) error {
+	return s.save(resultEntry{Kind: espionageReportEntry, At: report.Date, EspionageReport: &report}, func() error {
+		return s.MemoryStore.SaveEspionageReport(report)
+	})
+}
+
+// SaveSystemInfos ...
+func (s *BoltStore) SaveSystemInfos(systemInfos ogame.SystemInfos, at time.Time) error {
+	entry := &resultSystemInfos{Galaxy: systemInfos.Galaxy(), System: systemInfos.System()}
+	for i := range entry.Planets {
+		entry.Planets[i] = systemInfos.Position(int64(i + 1))
+	}
+	return s.save(resultEntry{Kind: systemInfosEntry, At: at, SystemInfos: entry}, func() error {
+		return s.MemoryStore.SaveSystemInfos(systemInfos, at)
+	})
+}
+
+// SaveHighscore ...
+func (s *BoltStore) SaveHighscore(highscore ogame.Highscore, at time.Time) error {
+	return s.save(resultEntry{Kind: highscoreEntry, At: at, Highscore: &highscore}, func() error {
+		return s.MemoryStore.SaveHighscore(highscore, at)
+	})
+}
+
+// Close stops the background pruning and closes the database
+func (s *BoltStore) Close() error {
+	select {
+	case <-s.done:
+		return nil
+	default:
+		close(s.done)
+	}
+	return s.db.Close()
+}
@@ -0,0 +1,115 @@
+// Package intel keeps what the bot learned about the universe (espionage reports, galaxy scans, highscores),
+// so that farming and targeting code does not have to scan again what was already seen.
+package intel
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+)
+
+// ErrNotFound returned when the store has no information for the query
+var ErrNotFound = errors.New("not found in intel store")
+
+// Store persistence layer for the intelligence gathered by the bot
+type Store interface {
+	SaveEspionageReport(report ogame.EspionageReport) error
+	SaveSystemInfos(systemInfos ogame.SystemInfos, at time.Time) error
+	SaveHighscore(highscore ogame.Highscore, at time.Time) error
+
+	// LatestEspionageReport returns the most recent espionage report for a coordinate
+	LatestEspionageReport(coord ogame.Coordinate) (ogame.EspionageReport, error)
+	// EspionageReports returns all the espionage reports for a coordinate, oldest first
+	EspionageReports(coord ogame.Coordinate) ([]ogame.EspionageReport, error)
+	// Planet returns the last known state of the planet at a coordinate
+	Planet(coord ogame.Coordinate) (PlanetRecord, error)
+	// PlayerPlanets returns the planets of a player, sorted by coordinate
+	PlayerPlanets(playerID int64) ([]PlanetRecord, error)
+	// Player returns the last known state of a player
+	Player(playerID int64) (PlayerRecord, error)
+	// InactivePlayers returns the inactive players having a planet in galaxy (0 for any galaxy)
+	// and a rank lower than maxRank (0 for no limit), sorted by rank
+	InactivePlayers(galaxy, maxRank int64) ([]PlayerRecord, error)
+	// ActivityHistory returns the activity observed on a coordinate since a given time, oldest first
+	ActivityHistory(coord ogame.Coordinate, since time.Time) ([]ActivityRecord, error)
+	// HighscoreOf returns the last known highscore line of a player (or alliance)
+	HighscoreOf(category, typ, id int64) (HighscoreRecord, error)
+	// SystemScannedAt returns when a system was last seen in the galaxy
+	SystemScannedAt(galaxy, system int64) (time.Time, error)
+
+	Close() error
+}
+
+// Compile time checks
+var _ Store = (*MemoryStore)(nil)
+var _ Store = (*BoltStore)(nil)
+
+// PlanetRecord last known state of a planet, as seen in the galaxy
+type PlanetRecord struct {
+	Coordinate ogame.Coordinate
+	ID         int64
+	Name       string
+	PlayerID   int64
+	PlayerName string
+	AllianceID int64
+	Moon       *ogame.MoonInfos
+	Debris     ogame.Resources
+	SeenAt     time.Time
+}
+
+// PlayerRecord last known state of a player
+type PlayerRecord struct {
+	ID           int64
+	Name         string
+	Rank         int64 // 0 when unknown
+	Score        int64 // Total points, 0 when unknown
+	AllianceID   int64
+	Inactive     bool
+	LongInactive bool // Only known from espionage reports
+	Vacation     bool
+	Banned       bool
+	SeenAt       time.Time
+}
+
+// ActivitySource where an activity was observed
+type ActivitySource string
+
+// Activity sources
+const (
+	GalaxyActivitySource    ActivitySource = "galaxy"
+	EspionageActivitySource ActivitySource = "espionage"
+)
+
+// ActivityRecord activity of a coordinate at a given time
+type ActivityRecord struct {
+	Coordinate ogame.Coordinate
+	At         time.Time
+	Activity   int64 // no activity: 0, active: 15, inactive: [16, 59] (minutes)
+	Source     ActivitySource
+}
+
+// IsActive returns true if the coordinate was active in the last 15 minutes
+func (r ActivityRecord) IsActive() bool {
+	return r.Activity == 15
+}
+
+// HighscoreRecord a line of the highscore, for a category and type
+type HighscoreRecord struct {
+	ogame.HighscorePlayer
+	Category int64
+	Type     int64
+	At       time.Time
+}
+
+// DiffLatestEspionageReports compares the two most recent espionage reports of a coordinate
+func DiffLatestEspionageReports(store Store, coord ogame.Coordinate, universeSpeed int64, temp ogame.Temperature) (ogame.EspionageReportDiff, error) {
+	reports, err := store.EspionageReports(coord)
+	if err != nil {
+		return ogame.EspionageReportDiff{}, err
+	}
+	if len(reports) < 2 {
+		return ogame.EspionageReportDiff{}, ErrNotFound
+	}
+	return ogame.DiffEspionageReports(reports[len(reports)-2], reports[len(reports)-1], universeSpeed, temp)
+}
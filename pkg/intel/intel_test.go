@@ -0,0 +1,168 @@
+package intel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPlanetInfos(coord ogame.Coordinate, playerID, rank, activity int64, playerName string, inactive bool) *ogame.PlanetInfos {
+	p := &ogame.PlanetInfos{ID: coord.Position + 1000*coord.System, Coordinate: coord, Activity: activity, Inactive: inactive}
+	p.Player.ID = playerID
+	p.Player.Name = playerName
+	p.Player.Rank = rank
+	return p
+}
+
+func newSystemInfos(galaxy, system int64, planets ...*ogame.PlanetInfos) ogame.SystemInfos {
+	var s ogame.SystemInfos
+	s.SetGalaxy(galaxy)
+	s.SetSystem(system)
+	for _, p := range planets {
+		s.SetPlanet(int(p.Coordinate.Position-1), p)
+	}
+	return s
+}
+
+func fillStore(t *testing.T, s Store, now time.Time) {
+	c1 := ogame.Coordinate{Galaxy: 1, System: 10, Position: 4, Type: ogame.PlanetType}
+	c2 := ogame.Coordinate{Galaxy: 1, System: 10, Position: 8, Type: ogame.PlanetType}
+	c3 := ogame.Coordinate{Galaxy: 2, System: 20, Position: 8, Type: ogame.PlanetType}
+	c4 := ogame.Coordinate{Galaxy: 1, System: 11, Position: 1, Type: ogame.PlanetType}
+	c5 := ogame.Coordinate{Galaxy: 1, System: 12, Position: 5, Type: ogame.PlanetType}
+	bob := newPlanetInfos(c1, 2, 150, 15, "Bob", false)
+	bob.Moon = &ogame.MoonInfos{ID: 99, Activity: 0}
+	assert.NoError(t, s.SaveSystemInfos(newSystemInfos(1, 10,
+		bob,
+		newPlanetInfos(c2, 3, 500, 0, "Sleepy", true),
+	), now.Add(-time.Hour)))
+	assert.NoError(t, s.SaveSystemInfos(newSystemInfos(2, 20, newPlanetInfos(c3, 4, 20, 0, "Lazy", true)), now.Add(-time.Hour)))
+	assert.NoError(t, s.SaveSystemInfos(newSystemInfos(1, 11, newPlanetInfos(c4, 2, 150, 0, "Bob", false)), now.Add(-time.Hour)))
+	assert.NoError(t, s.SaveSystemInfos(newSystemInfos(1, 12, newPlanetInfos(c5, 3, 500, 0, "Sleepy", true)), now.Add(-time.Hour)))
+	assert.NoError(t, s.SaveEspionageReport(ogame.EspionageReport{ID: 1, Coordinate: c2, Date: now.Add(-2 * time.Hour), Username: "Sleepy", Resources: ogame.Resources{Metal: 1}}))
+	assert.NoError(t, s.SaveEspionageReport(ogame.EspionageReport{ID: 2, Coordinate: c2, Date: now.Add(-30 * time.Minute), Username: "Sleepy", Resources: ogame.Resources{Metal: 2}, IsLongInactive: true}))
+	assert.NoError(t, s.SaveEspionageReport(ogame.EspionageReport{ID: 2, Coordinate: c2, Date: now.Add(-30 * time.Minute), Username: "Sleepy"}))
+	assert.NoError(t, s.SaveSystemInfos(newSystemInfos(1, 10, newPlanetInfos(c1, 2, 150, 37, "Bob", false)), now))
+	assert.NoError(t, s.SaveHighscore(ogame.Highscore{Category: 1, Type: 0, Players: []ogame.HighscorePlayer{
+		{Position: 480, ID: 3, Name: "Sleepy", Score: 1000},
+	}}, now))
+}
+
+func checkStore(t *testing.T, s Store, now time.Time) {
+	c1 := ogame.Coordinate{Galaxy: 1, System: 10, Position: 4, Type: ogame.PlanetType}
+	c2 := ogame.Coordinate{Galaxy: 1, System: 10, Position: 8, Type: ogame.PlanetType}
+
+	report, err := s.LatestEspionageReport(c2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), report.ID)
+	assert.Equal(t, int64(2), report.Metal)
+	reports, _ := s.EspionageReports(c2)
+	assert.Equal(t, 2, len(reports))
+	_, err = s.LatestEspionageReport(c1)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// The second scan of 1:10 no longer has Sleepy's planet
+	_, err = s.Planet(c2)
+	assert.ErrorIs(t, err, ErrNotFound)
+	planets, _ := s.PlayerPlanets(2)
+	assert.Equal(t, 2, len(planets))
+	assert.Equal(t, c1, planets[0].Coordinate)
+	assert.Nil(t, planets[0].Moon)
+	assert.Equal(t, int64(1), planets[1].Coordinate.System-planets[0].Coordinate.System)
+
+	inactives, _ := s.InactivePlayers(2, 100)
+	assert.Equal(t, 1, len(inactives))
+	assert.Equal(t, "Lazy", inactives[0].Name)
+	inactives, _ = s.InactivePlayers(0, 0)
+	assert.Equal(t, 2, len(inactives))
+	inactives, _ = s.InactivePlayers(1, 0)
+	assert.Equal(t, 1, len(inactives))
+	assert.Equal(t, "Sleepy", inactives[0].Name)
+	inactives, _ = s.InactivePlayers(1, 480)
+	assert.Equal(t, 0, len(inactives))
+
+	sleepy, err := s.Player(3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(480), sleepy.Rank)
+	assert.True(t, sleepy.LongInactive)
+	record, err := s.HighscoreOf(1, 0, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), record.Score)
+
+	history, _ := s.ActivityHistory(c1, time.Time{})
+	assert.Equal(t, 2, len(history))
+	assert.True(t, history[0].IsActive())
+	assert.Equal(t, int64(37), history[1].Activity)
+	history, _ = s.ActivityHistory(c1, now.Add(-time.Minute))
+	assert.Equal(t, 1, len(history))
+	history, _ = s.ActivityHistory(c1.Moon(), time.Time{})
+	assert.Equal(t, 1, len(history))
+	history, _ = s.ActivityHistory(c2, time.Time{})
+	assert.Equal(t, 3, len(history))
+	assert.Equal(t, EspionageActivitySource, history[0].Source)
+
+	scannedAt, err := s.SystemScannedAt(1, 10)
+	assert.NoError(t, err)
+	assert.True(t, scannedAt.Equal(now))
+	_, err = s.SystemScannedAt(3, 10)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	fillStore(t, s, now)
+	checkStore(t, s, now)
+}
+
+func TestBoltStore(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	filename := filepath.Join(t.TempDir(), "intel", "intel.db")
+	s, err := NewBoltStoreWithConfig(filename, BoltStoreConfig{})
+	assert.NoError(t, err)
+	fillStore(t, s, now)
+	checkStore(t, s, now)
+	assert.NoError(t, s.Close())
+	assert.ErrorIs(t, s.SaveHighscore(ogame.Highscore{}, now), os.ErrClosed)
+
+	// Everything is restored from the database
+	s, err = NewBoltStoreWithConfig(filename, BoltStoreConfig{})
+	assert.NoError(t, err)
+	defer s.Close()
+	checkStore(t, s, now)
+}
+
+func TestBoltStore_Prune(t *testing.T) {
+	now := time.Now()
+	filename := filepath.Join(t.TempDir(), "intel.db")
+	s, err := NewBoltStoreWithConfig(filename, BoltStoreConfig{Retention: 24 * time.Hour})
+	assert.NoError(t, err)
+	c1 := ogame.Coordinate{Galaxy: 1, System: 10, Position: 4, Type: ogame.PlanetType}
+	for i := 4; i >= 1; i-- {
+		assert.NoError(t, s.SaveSystemInfos(newSystemInfos(1, 10, newPlanetInfos(c1, 2, 150, 15, "Bob", false)), now.Add(-time.Duration(i)*12*time.Hour)))
+	}
+	assert.NoError(t, s.SaveEspionageReport(ogame.EspionageReport{ID: 1, Coordinate: c1, Date: now.Add(-48 * time.Hour), Username: "Bob"}))
+	// Saving does not prune
+	history, _ := s.ActivityHistory(c1, time.Time{})
+	assert.Equal(t, 5, len(history))
+
+	// The scans older than a day are dropped (only the latest one remains)
+	assert.NoError(t, s.Prune())
+	history, _ = s.ActivityHistory(c1, time.Time{})
+	assert.Equal(t, 2, len(history))
+	// The latest report of a coordinate is kept, even if it is older than the retention
+	_, err = s.LatestEspionageReport(c1)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Close())
+	assert.ErrorIs(t, s.Prune(), os.ErrClosed)
+
+	s, err = NewBoltStoreWithConfig(filename, BoltStoreConfig{})
+	assert.NoError(t, err)
+	defer s.Close()
+	history, _ = s.ActivityHistory(c1, time.Time{})
+	assert.Equal(t, 2, len(history))
+}
@@ -0,0 +1,319 @@
+package intel
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+)
+
+// Maximum number of activity records kept for a coordinate, older ones are dropped
+const maxActivityRecords = 2000
+
+type systemKey struct {
+	galaxy int64
+	system int64
+}
+
+type highscoreKey struct {
+	category int64
+	typ      int64
+	id       int64
+}
+
+// MemoryStore in memory intel store, everything is lost when the program exits
+type MemoryStore struct {
+	mu         sync.RWMutex
+	reports    map[ogame.Coordinate][]ogame.EspionageReport
+	planets    map[ogame.Coordinate]PlanetRecord
+	players    map[int64]PlayerRecord
+	activities map[ogame.Coordinate][]ActivityRecord
+	highscores map[highscoreKey]HighscoreRecord
+	scannedAt  map[systemKey]time.Time
+}
+
+// NewMemoryStore creates a new in memory intel store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		reports:    make(map[ogame.Coordinate][]ogame.EspionageReport),
+		planets:    make(map[ogame.Coordinate]PlanetRecord),
+		players:    make(map[int64]PlayerRecord),
+		activities: make(map[ogame.Coordinate][]ActivityRecord),
+		highscores: make(map[highscoreKey]HighscoreRecord),
+		scannedAt:  make(map[systemKey]time.Time),
+	}
+}
+
+// SaveEspionageReport ...
+func (s *MemoryStore) SaveEspionageReport(report ogame.EspionageReport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reports := s.reports[report.Coordinate]
+	for _, r := range reports {
+		if report.ID != 0 && r.ID == report.ID {
+			return nil
+		}
+	}
+	reports = append(reports, report)
+	sort.SliceStable(reports, func(i, j int) bool { return reports[i].Date.Before(reports[j].Date) })
+	s.reports[report.Coordinate] = reports
+	s.addActivity(ActivityRecord{Coordinate: report.Coordinate, At: report.Date, Activity: report.LastActivity, Source: EspionageActivitySource})
+
+	// The report does not have the player id, use the galaxy to find who owns the planet
+	if planet, ok := s.planets[report.Coordinate.Planet()]; ok && planet.PlayerName == report.Username {
+		if player, ok := s.players[planet.PlayerID]; ok && !report.Date.Before(player.SeenAt) {
+			player.Inactive = report.IsInactive || report.IsLongInactive
+			player.LongInactive = report.IsLongInactive
+			player.SeenAt = report.Date
+			s.players[player.ID] = player
+		}
+	}
+	return nil
+}
+
+// SaveSystemInfos ...
+func (s *MemoryStore) SaveSystemInfos(systemInfos ogame.SystemInfos, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := systemKey{systemInfos.Galaxy(), systemInfos.System()}
+	if at.Before(s.scannedAt[key]) {
+		return nil
+	}
+	s.scannedAt[key] = at
+	for position := int64(1); position <= 15; position++ {
+		coord := ogame.Coordinate{Galaxy: key.galaxy, System: key.system, Position: position, Type: ogame.PlanetType}
+		planetInfos := systemInfos.Position(position)
+		if planetInfos == nil || planetInfos.Destroyed {
+			delete(s.planets, coord)
+			continue
+		}
+		planet := PlanetRecord{
+			Coordinate: coord,
+			ID:         planetInfos.ID,
+			Name:       planetInfos.Name,
+			PlayerID:   planetInfos.Player.ID,
+			PlayerName: planetInfos.Player.Name,
+			Moon:       planetInfos.Moon,
+			Debris:     ogame.Resources{Metal: planetInfos.Debris.Metal, Crystal: planetInfos.Debris.Crystal, Deuterium: planetInfos.Debris.Deuterium},
+			SeenAt:     at,
+		}
+		if planetInfos.Alliance != nil {
+			planet.AllianceID = planetInfos.Alliance.ID
+		}
+		s.planets[coord] = planet
+		s.addActivity(ActivityRecord{Coordinate: coord, At: at, Activity: planetInfos.Activity, Source: GalaxyActivitySource})
+		if planetInfos.Moon != nil {
+			s.addActivity(ActivityRecord{Coordinate: coord.Moon(), At: at, Activity: planetInfos.Moon.Activity, Source: GalaxyActivitySource})
+		}
+
+		if planetInfos.Player.ID == 0 {
+			continue
+		}
+		player := s.players[planetInfos.Player.ID]
+		if at.Before(player.SeenAt) {
+			continue
+		}
+		player.ID = planetInfos.Player.ID
+		player.Name = planetInfos.Player.Name
+		if planetInfos.Player.Rank > 0 {
+			player.Rank = planetInfos.Player.Rank
+		}
+		player.AllianceID = planet.AllianceID
+		player.Inactive = planetInfos.Inactive
+		if !planetInfos.Inactive {
+			player.LongInactive = false
+		}
+		player.Vacation = planetInfos.Vacation
+		player.Banned = planetInfos.Banned
+		player.SeenAt = at
+		s.players[player.ID] = player
+	}
+	return nil
+}
+
+// SaveHighscore ...
+func (s *MemoryStore) SaveHighscore(highscore ogame.Highscore, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range highscore.Players {
+		key := highscoreKey{highscore.Category, highscore.Type, p.ID}
+		if at.Before(s.highscores[key].At) {
+			continue
+		}
+		s.highscores[key] = HighscoreRecord{HighscorePlayer: p, Category: highscore.Category, Type: highscore.Type, At: at}
+		if highscore.Category != 1 || highscore.Type != 0 {
+			continue
+		}
+		player := s.players[p.ID]
+		player.ID = p.ID
+		player.Name = p.Name
+		player.Rank = p.Position
+		player.Score = p.Score
+		player.AllianceID = p.AllianceID
+		if player.SeenAt.Before(at) {
+			player.SeenAt = at
+		}
+		s.players[p.ID] = player
+	}
+	return nil
+}
+
+func (s *MemoryStore) addActivity(record ActivityRecord) {
+	records := append(s.activities[record.Coordinate], record)
+	sort.SliceStable(records, func(i, j int) bool { return records[i].At.Before(records[j].At) })
+	if len(records) > maxActivityRecords {
+		records = records[len(records)-maxActivityRecords:]
+	}
+	s.activities[record.Coordinate] = records
+}
+
+// LatestEspionageReport ...
+func (s *MemoryStore) LatestEspionageReport(coord ogame.Coordinate) (ogame.EspionageReport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	reports := s.reports[coord]
+	if len(reports) == 0 {
+		return ogame.EspionageReport{}, ErrNotFound
+	}
+	return reports[len(reports)-1], nil
+}
+
+// EspionageReports ...
+func (s *MemoryStore) EspionageReports(coord ogame.Coordinate) ([]ogame.EspionageReport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]ogame.EspionageReport{}, s.reports[coord]...), nil
+}
+
+// Planet ...
+func (s *MemoryStore) Planet(coord ogame.Coordinate) (PlanetRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	planet, ok := s.planets[coord.Planet()]
+	if !ok {
+		return PlanetRecord{}, ErrNotFound
+	}
+	return planet, nil
+}
+
+// PlayerPlanets ...
+func (s *MemoryStore) PlayerPlanets(playerID int64) ([]PlanetRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]PlanetRecord, 0)
+	for _, planet := range s.planets {
+		if planet.PlayerID == playerID {
+			out = append(out, planet)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return lessCoordinate(out[i].Coordinate, out[j].Coordinate) })
+	return out, nil
+}
+
+// Player ...
+func (s *MemoryStore) Player(playerID int64) (PlayerRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	player, ok := s.players[playerID]
+	if !ok {
+		return PlayerRecord{}, ErrNotFound
+	}
+	return player, nil
+}
+
+// InactivePlayers ...
+func (s *MemoryStore) InactivePlayers(galaxy, maxRank int64) ([]PlayerRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	inGalaxy := make(map[int64]bool)
+	for _, planet := range s.planets {
+		if galaxy == 0 || planet.Coordinate.Galaxy == galaxy {
+			inGalaxy[planet.PlayerID] = true
+		}
+	}
+	out := make([]PlayerRecord, 0)
+	for _, player := range s.players {
+		if !player.Inactive || !inGalaxy[player.ID] {
+			continue
+		}
+		if maxRank > 0 && (player.Rank == 0 || player.Rank >= maxRank) {
+			continue
+		}
+		out = append(out, player)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Rank != out[j].Rank {
+			return out[i].Rank < out[j].Rank
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+// ActivityHistory ...
+func (s *MemoryStore) ActivityHistory(coord ogame.Coordinate, since time.Time) ([]ActivityRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]ActivityRecord, 0)
+	for _, record := range s.activities[coord] {
+		if !record.At.Before(since) {
+			out = append(out, record)
+		}
+	}
+	return out, nil
+}
+
+// HighscoreOf ...
+func (s *MemoryStore) HighscoreOf(category, typ, id int64) (HighscoreRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.highscores[highscoreKey{category, typ, id}]
+	if !ok {
+		return HighscoreRecord{}, ErrNotFound
+	}
+	return record, nil
+}
+
+// SystemScannedAt ...
+func (s *MemoryStore) SystemScannedAt(galaxy, system int64) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	at, ok := s.scannedAt[systemKey{galaxy, system}]
+	if !ok {
+		return time.Time{}, ErrNotFound
+	}
+	return at, nil
+}
+
+// Close ...
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+func lessCoordinate(a, b ogame.Coordinate) bool {
+	if a.Galaxy != b.Galaxy {
+		return a.Galaxy < b.Galaxy
+	}
+	if a.System != b.System {
+		return a.System < b.System
+	}
+	if a.Position != b.Position {
+		return a.Position < b.Position
+	}
+	return a.Type < b.Type
+}
+
+// Replaces the content of the store by the content of other
+func (s *MemoryStore) replaceWith(other *MemoryStore) {
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports = other.reports
+	s.planets = other.planets
+	s.players = other.players
+	s.activities = other.activities
+	s.highscores = other.highscores
+	s.scannedAt = other.scannedAt
+}
@@ -0,0 +1,303 @@
+// Package metrics is a small registry of counters, gauges and histograms,
+// served in the prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets default histogram buckets, in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultRegistry registry used by the wrapper when none is specified
+var DefaultRegistry = NewRegistry()
+
+type metricType string
+
+const (
+	counterType   metricType = "counter"
+	gaugeType     metricType = "gauge"
+	histogramType metricType = "histogram"
+)
+
+// Registry holds the metric families. It is safe for concurrent use.
+type Registry struct {
+	mu         sync.Mutex
+	families   map[string]*family
+	hooks      map[int]func()
+	nextHookID int
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family), hooks: make(map[int]func())}
+}
+
+type family struct {
+	mu         sync.Mutex
+	name       string
+	help       string
+	typ        metricType
+	labelNames []string
+	buckets    []float64
+	series     map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	counts      []uint64 // histogram, one per bucket (not cumulative)
+	count       uint64
+	sum         float64
+}
+
+func (r *Registry) family(name, help string, typ metricType, buckets []float64, labelNames []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		if f.typ != typ || strings.Join(f.labelNames, ",") != strings.Join(labelNames, ",") {
+			panic(fmt.Sprintf("metric %s already registered with another type or labels", name))
+		}
+		return f
+	}
+	f := &family{name: name, help: help, typ: typ, labelNames: labelNames, buckets: buckets, series: make(map[string]*series)}
+	r.families[name] = f
+	return f
+}
+
+// Counter returns the counter family with this name, it is created on the first call
+func (r *Registry) Counter(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{r.family(name, help, counterType, nil, labelNames)}
+}
+
+// Gauge returns the gauge family with this name, it is created on the first call
+func (r *Registry) Gauge(name, help string, labelNames ...string) *GaugeVec {
+	return &GaugeVec{r.family(name, help, gaugeType, nil, labelNames)}
+}
+
+// Histogram returns the histogram family with this name, it is created on the first call.
+// buckets are the upper bounds, sorted, DefaultBuckets if nil.
+func (r *Registry) Histogram(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	return &HistogramVec{r.family(name, help, histogramType, buckets, labelNames)}
+}
+
+// OnCollect registers a function called before every collection, to update the gauges that are read on demand.
+// The returned function unregisters it.
+func (r *Registry) OnCollect(fn func()) (remove func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := r.nextHookID
+	r.nextHookID++
+	r.hooks[id] = fn
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.hooks, id)
+	}
+}
+
+func (f *family) get(labelValues []string) *series {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", f.name, len(f.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if f.typ == histogramType {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// CounterVec counter partitioned by labels
+type CounterVec struct{ f *family }
+
+// Add adds delta (must be positive) to the counter having these label values
+func (v *CounterVec) Add(delta float64, labelValues ...string) {
+	v.f.mu.Lock()
+	defer v.f.mu.Unlock()
+	v.f.get(labelValues).value += delta
+}
+
+// Inc increments the counter having these label values
+func (v *CounterVec) Inc(labelValues ...string) {
+	v.Add(1, labelValues...)
+}
+
+// Value returns the current value of the counter having these label values
+func (v *CounterVec) Value(labelValues ...string) float64 {
+	v.f.mu.Lock()
+	defer v.f.mu.Unlock()
+	return v.f.get(labelValues).value
+}
+
+// GaugeVec gauge partitioned by labels
+type GaugeVec struct{ f *family }
+
+// Set sets the gauge having these label values
+func (v *GaugeVec) Set(value float64, labelValues ...string) {
+	v.f.mu.Lock()
+	defer v.f.mu.Unlock()
+	v.f.get(labelValues).value = value
+}
+
+// Add adds delta to the gauge having these label values
+func (v *GaugeVec) Add(delta float64, labelValues ...string) {
+	v.f.mu.Lock()
+	defer v.f.mu.Unlock()
+	v.f.get(labelValues).value += delta
+}
+
+// Value returns the current value of the gauge having these label values
+func (v *GaugeVec) Value(labelValues ...string) float64 {
+	v.f.mu.Lock()
+	defer v.f.mu.Unlock()
+	return v.f.get(labelValues).value
+}
+
+// HistogramVec histogram partitioned by labels
+type HistogramVec struct{ f *family }
+
+// Observe adds an observation to the histogram having these label values
+func (v *HistogramVec) Observe(value float64, labelValues ...string) {
+	v.f.mu.Lock()
+	defer v.f.mu.Unlock()
+	s := v.f.get(labelValues)
+	if idx := sort.SearchFloat64s(v.f.buckets, value); idx < len(s.counts) {
+		s.counts[idx]++
+	}
+	s.count++
+	s.sum += value
+}
+
+// Count returns the number of observations of the histogram having these label values
+func (v *HistogramVec) Count(labelValues ...string) uint64 {
+	v.f.mu.Lock()
+	defer v.f.mu.Unlock()
+	return v.f.get(labelValues).count
+}
+
+// WriteTo writes all the metrics in the prometheus text format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	hooks := make([]func(), 0, len(r.hooks))
+	for _, hook := range r.hooks {
+		hooks = append(hooks, hook)
+	}
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	for _, f := range families {
+		f.write(cw)
+	}
+	if err := cw.w.Flush(); err != nil {
+		return cw.n, err
+	}
+	return cw.n, cw.err
+}
+
+// Handler serves the metrics, to be mounted on /metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = r.WriteTo(w)
+	})
+}
+
+func (f *family) write(w *countingWriter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	all := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return strings.Join(all[i].labelValues, "\xff") < strings.Join(all[j].labelValues, "\xff")
+	})
+	leNames := append(append([]string(nil), f.labelNames...), "le")
+	w.printf("# HELP %s %s\n", f.name, escapeHelp(f.help))
+	w.printf("# TYPE %s %s\n", f.name, f.typ)
+	for _, s := range all {
+		if f.typ != histogramType {
+			w.printf("%s%s %s\n", f.name, labels(f.labelNames, s.labelValues), formatFloat(s.value))
+			continue
+		}
+		leValues := append(append([]string(nil), s.labelValues...), "")
+		var cumulative uint64
+		for i, bucket := range f.buckets {
+			cumulative += s.counts[i]
+			leValues[len(leValues)-1] = formatFloat(bucket)
+			w.printf("%s_bucket%s %d\n", f.name, labels(leNames, leValues), cumulative)
+		}
+		leValues[len(leValues)-1] = "+Inf"
+		w.printf("%s_bucket%s %d\n", f.name, labels(leNames, leValues), s.count)
+		w.printf("%s_sum%s %s\n", f.name, labels(f.labelNames, s.labelValues), formatFloat(s.sum))
+		w.printf("%s_count%s %d\n", f.name, labels(f.labelNames, s.labelValues), s.count)
+	}
+}
+
+func labels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + `="` + escapeLabelValue(values[i]) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeLabelValue(s string) string { return labelValueReplacer.Replace(s) }
+func escapeHelp(s string) string       { return helpReplacer.Replace(s) }
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) printf(format string, args ...any) {
+	if c.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(c.w, format, args...)
+	c.n += int64(n)
+	c.err = err
+}
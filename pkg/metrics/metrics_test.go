@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	r := NewRegistry()
+	requests := r.Counter("requests_total", "Requests sent", "page", "status")
+	requests.Inc("overview", "200")
+	requests.Inc("overview", "200")
+	requests.Add(3, "fleet\"1", "500")
+	assert.Equal(t, float64(2), requests.Value("overview", "200"))
+	assert.Same(t, requests.f, r.Counter("requests_total", "Requests sent", "page", "status").f)
+
+	queue := r.Gauge("queue_depth", "Tasks waiting")
+	calls := 0
+	remove := r.OnCollect(func() {
+		calls++
+		queue.Set(4)
+	})
+	buf := new(bytes.Buffer)
+	_, err := r.WriteTo(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, `# HELP queue_depth Tasks waiting
+# TYPE queue_depth gauge
+queue_depth 4
+# HELP requests_total Requests sent
+# TYPE requests_total counter
+requests_total{page="fleet\"1",status="500"} 3
+requests_total{page="overview",status="200"} 2
+`, buf.String())
+
+	remove()
+	_, _ = r.WriteTo(new(bytes.Buffer))
+	assert.Equal(t, 1, calls)
+}
+
+func TestRegistry_Histogram(t *testing.T) {
+	r := NewRegistry()
+	h := r.Histogram("wait_seconds", "Wait", []float64{1, 5}, "priority")
+	h.Observe(0.5, "low")
+	h.Observe(1, "low")
+	h.Observe(3, "low")
+	h.Observe(10, "low")
+	assert.Equal(t, uint64(4), h.Count("low"))
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `# HELP wait_seconds Wait
+# TYPE wait_seconds histogram
+wait_seconds_bucket{priority="low",le="1"} 2
+wait_seconds_bucket{priority="low",le="5"} 3
+wait_seconds_bucket{priority="low",le="+Inf"} 4
+wait_seconds_sum{priority="low"} 14.5
+wait_seconds_count{priority="low"} 4
+`, rec.Body.String())
+}
+
+func TestRegistry_Conflict(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("requests_total", "Requests sent", "page")
+	assert.Panics(t, func() { r.Gauge("requests_total", "Requests sent", "page") })
+	assert.Panics(t, func() { r.Counter("requests_total", "Requests sent", "page", "status") })
+	assert.Panics(t, func() { r.Counter("requests_total", "Requests sent", "page").Inc() })
+}
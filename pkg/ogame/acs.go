@@ -0,0 +1,66 @@
+package ogame
+
+import (
+	"time"
+)
+
+// ACSMaxDelayPercent a fleet joining a union can delay the union by at most
+// this percentage of the union remaining flight time
+const ACSMaxDelayPercent = 30
+
+// ACSJoinDeadline returns the latest arrival time of a fleet joining a union
+func ACSJoinDeadline(now, unionArrival time.Time) time.Time {
+	remaining := unionArrival.Sub(now)
+	if remaining <= 0 {
+		return unionArrival
+	}
+	return unionArrival.Add(remaining * ACSMaxDelayPercent / 100)
+}
+
+// ACSJoinPlan speed to use to join a union
+type ACSJoinPlan struct {
+	Speed       Speed
+	FlightTime  int64 // seconds
+	Fuel        int64
+	ArrivalTime time.Time
+	Delay       time.Duration // Delay added to the union arrival time
+}
+
+// PlanACSJoin finds the speed to use for a fleet to join a union arriving at unionArrival.
+// The slowest speed (less fuel) that does not delay the union is preferred,
+// otherwise the fastest speed is used if it arrives before the deadline (see ACSJoinDeadline).
+func PlanACSJoin(p FlightParams, now, unionArrival time.Time) (ACSJoinPlan, error) {
+	speeds := AvailableSpeeds(p.CharacterClass)
+	plan := func(speed Speed) ACSJoinPlan {
+		p.Speed = speed
+		secs, fuel := CalcFlight(p)
+		arrival := now.Add(time.Duration(secs) * time.Second)
+		delay := arrival.Sub(unionArrival)
+		if delay < 0 {
+			delay = 0
+		}
+		return ACSJoinPlan{Speed: speed, FlightTime: secs, Fuel: fuel, ArrivalTime: arrival, Delay: delay}
+	}
+	for _, speed := range speeds {
+		if pl := plan(speed); pl.Delay == 0 {
+			return pl, nil
+		}
+	}
+	fastest := plan(speeds[len(speeds)-1])
+	if fastest.ArrivalTime.After(ACSJoinDeadline(now, unionArrival)) {
+		return fastest, ErrCannotArriveInTime
+	}
+	return fastest, nil
+}
+
+// AvailableSpeeds returns the fleet speeds available for a character class, slowest first
+func AvailableSpeeds(characterClass CharacterClass) []Speed {
+	if characterClass == General {
+		return []Speed{FivePercent, TenPercent, FifteenPercent, TwentyPercent, TwentyFivePercent, ThirtyPercent,
+			ThirtyFivePercent, FourtyPercent, FourtyFivePercent, FiftyPercent, FiftyFivePercent, SixtyPercent,
+			SixtyFivePercent, SeventyPercent, SeventyFivePercent, EightyPercent, EightyFivePercent, NinetyPercent,
+			NinetyFivePercent, HundredPercent}
+	}
+	return []Speed{TenPercent, TwentyPercent, ThirtyPercent, FourtyPercent, FiftyPercent, SixtyPercent,
+		SeventyPercent, EightyPercent, NinetyPercent, HundredPercent}
+}
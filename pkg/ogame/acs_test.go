@@ -0,0 +1,49 @@
+package ogame
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestACSJoinDeadline(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, now.Add(130*time.Minute), ACSJoinDeadline(now, now.Add(100*time.Minute)))
+	assert.Equal(t, now.Add(-time.Minute), ACSJoinDeadline(now, now.Add(-time.Minute)))
+}
+
+func TestPlanACSJoin(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := FlightParams{
+		Origin:      Coordinate{Galaxy: 1, System: 1, Position: 1, Type: PlanetType},
+		Destination: Coordinate{Galaxy: 1, System: 5, Position: 3, Type: PlanetType},
+		Ships:       ShipsInfos{LightFighter: 16, HeavyFighter: 8, Cruiser: 4},
+		Mission:     GroupedAttack,
+		Researches:  Researches{CombustionDrive: 10, ImpulseDrive: 7},
+		Universe:    FlightUniverse{Galaxies: 1, Systems: 499, SpeedFleetPeaceful: 1, SpeedFleetWar: 1, FleetDeutSaveFactor: 1},
+	}
+	p.Speed = HundredPercent
+	fastest, _ := CalcFlight(p)
+
+	// Union arrives late enough, slowest speed is used
+	plan, err := PlanACSJoin(p, now, now.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, TenPercent, plan.Speed)
+	assert.Equal(t, time.Duration(0), plan.Delay)
+
+	// Union arrives before our fastest flight, but within the 30% slack
+	unionArrival := now.Add(time.Duration(fastest-60) * time.Second)
+	plan, err = PlanACSJoin(p, now, unionArrival)
+	assert.NoError(t, err)
+	assert.Equal(t, HundredPercent, plan.Speed)
+	assert.Equal(t, 60*time.Second, plan.Delay)
+
+	// Union arrives too soon
+	_, err = PlanACSJoin(p, now, now.Add(time.Duration(fastest/2)*time.Second))
+	assert.ErrorIs(t, err, ErrCannotArriveInTime)
+
+	// General has detailed speeds
+	assert.Equal(t, 20, len(AvailableSpeeds(General)))
+	assert.Equal(t, 10, len(AvailableSpeeds(Collector)))
+}
@@ -0,0 +1,43 @@
+package ogame
+
+import "time"
+
+// AllianceOverview information about the alliance of the player, from the alliance overview tab
+type AllianceOverview struct {
+	ID        int64
+	Name      string
+	Tag       string
+	CreatedAt time.Time
+	NbMembers int64
+	Class     AllianceClass
+	Rank      string // Rank of the player in the alliance
+	Homepage  string
+	Members   []AllianceMember
+}
+
+// AllianceMember member of the alliance
+type AllianceMember struct {
+	PlayerID     int64
+	Name         string
+	Rank         string
+	RankID       int64 // Only set when the player can assign ranks
+	Points       int64
+	Position     int64 // Position in the highscore
+	Coordinate   Coordinate
+	JoinedAt     time.Time
+	Online       bool
+	OfflineFor   time.Duration // 0 if online or unknown
+	Inactive     bool
+	LongInactive bool
+	Vacation     bool
+}
+
+// AllianceApplication application of a player to join the alliance
+type AllianceApplication struct {
+	ID         int64
+	PlayerID   int64
+	PlayerName string
+	Position   int64 // Position in the highscore
+	Date       time.Time
+	Message    string
+}
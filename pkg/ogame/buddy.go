@@ -0,0 +1,26 @@
+package ogame
+
+import "time"
+
+// Buddy player in the buddy list
+type Buddy struct {
+	ID          int64 // Buddy relation id, used to remove the buddy
+	PlayerID    int64
+	Name        string
+	Points      int64
+	Position    int64 // Position in the highscore
+	AllianceTag string
+	Coordinate  Coordinate // Homeworld
+	Online      bool
+	OfflineFor  time.Duration // 0 if online or unknown
+}
+
+// BuddyRequest pending buddy request, either received or sent
+type BuddyRequest struct {
+	ID         int64
+	PlayerID   int64
+	PlayerName string
+	Message    string
+	Date       time.Time
+	Received   bool // true if the request was sent to the player, false if the player sent it
+}
@@ -0,0 +1,101 @@
+package ogame
+
+import (
+	"time"
+)
+
+// Combat results
+const (
+	CombatResultAttacker = "attacker"
+	CombatResultDefender = "defender"
+	CombatResultDraw     = "draw"
+)
+
+// CombatReport detailed combat report
+type CombatReport struct {
+	ID                int64
+	CombatID          string
+	APIKey            string
+	CreatedAt         time.Time
+	Destination       Coordinate
+	DefenderPlanetID  int64
+	Attackers         []CombatParticipant
+	Defenders         []CombatParticipant
+	Rounds            []CombatRound // Round 0 is the initial state, before the first shot
+	Result            string        // attacker | defender | draw
+	Loot              Resources
+	LootPercentage    int64
+	Debris            Resources
+	RepairedDefenses  DefensesInfos
+	AttackerLostUnits int64 // Value (in resources) of the units lost by the attackers
+	DefenderLostUnits int64 // Value (in resources) of the units lost by the defenders
+	MoonChance        int64 // Chance (percentage) to create a moon
+	MoonCreated       bool
+	MoonSize          int64
+	MoonExists        bool // A moon already existed before the combat
+	TacticalRetreat   bool
+}
+
+// CombatParticipant attacker or defender of a combat
+type CombatParticipant struct {
+	FleetID          int64
+	PlayerID         int64
+	PlayerName       string
+	AllianceName     string
+	AllianceTag      string
+	PlanetID         int64
+	PlanetName       string
+	Origin           Coordinate
+	WeaponPercentage int64
+	ShieldPercentage int64
+	ArmourPercentage int64
+	Ships            ShipsInfos    // Units at the beginning of the combat
+	Defenses         DefensesInfos // Units at the beginning of the combat
+	LostShips        ShipsInfos
+	LostDefenses     DefensesInfos
+}
+
+// CombatRound state of the units at the end of a round
+type CombatRound struct {
+	Attackers              []CombatRoundUnits // Same order as CombatReport.Attackers
+	Defenders              []CombatRoundUnits // Same order as CombatReport.Defenders
+	HitsAttacker           int64
+	HitsDefender           int64
+	AbsorbedDamageAttacker int64
+	AbsorbedDamageDefender int64
+	FullStrengthAttacker   int64
+	FullStrengthDefender   int64
+}
+
+// CombatRoundUnits units of a participant in a round
+type CombatRoundUnits struct {
+	Ships              ShipsInfos    // Remaining units
+	Defenses           DefensesInfos // Remaining units
+	LostShipsInRound   ShipsInfos
+	LostDefenseInRound DefensesInfos
+}
+
+// AttackerWon returns true if the attackers won the combat
+func (r CombatReport) AttackerWon() bool {
+	return r.Result == CombatResultAttacker
+}
+
+// HasDefender returns true if the player is one of the defenders
+func (r CombatReport) HasDefender(playerID int64) bool {
+	for _, defender := range r.Defenders {
+		if defender.PlayerID == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAttacker returns true if the player is one of the attackers
+func (r CombatReport) HasAttacker(playerID int64) bool {
+	for _, attacker := range r.Attackers {
+		if attacker.PlayerID == playerID {
+			return true
+		}
+	}
+	return false
+}
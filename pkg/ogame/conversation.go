@@ -0,0 +1,13 @@
+package ogame
+
+import "time"
+
+// Conversation entry of the chat inbox, either with a player or with an alliance
+type Conversation struct {
+	PlayerID      int64 // 0 for an alliance conversation
+	AssociationID int64 // 0 for a player conversation
+	Name          string
+	LastMessage   string
+	LastMessageAt time.Time
+	Unread        int64
+}
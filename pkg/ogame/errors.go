@@ -1,6 +1,119 @@
 package ogame
 
-import "errors"
+import (
+	"errors"
+	"strconv"
+)
+
+// Error categories. Every error returned for one of these reasons matches its category with errors.Is,
+// the errors caused by an expired session match ErrNotLogged.
+var (
+	ErrNotEnoughResources = errors.New("not enough resources")
+	ErrNoFreeSlots        = errors.New("no free slots")
+	ErrQueueBusy          = errors.New("queue is busy")
+	ErrInvalidTarget      = errors.New("invalid target")
+	ErrVacationMode       = errors.New("vacation mode")
+	ErrCaptchaRequired    = errors.New("captcha required")
+	ErrParse              = errors.New("failed to parse page")
+	ErrServerMaintenance  = errors.New("server is in maintenance")
+)
+
+// Error an error of a category, with the error code of the game server when it comes from it
+type Error struct {
+	Kind    error // Category of the error, nil if unknown
+	Message string
+	Code    int64 // Error code of the game server, 0 if none
+}
+
+// NewError creates an error of a category
+func NewError(kind error, message string) error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Error ...
+func (e *Error) Error() string {
+	if e.Code != 0 {
+		return e.Message + " (" + strconv.FormatInt(e.Code, 10) + ")"
+	}
+	return e.Message
+}
+
+// Is reports whether target is the category of the error
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+// Categories of the error codes returned by the game server
+var serverErrorKinds = map[int64]error{
+	4013: ErrInvalidTarget,      // Recyclers must be sent to recycle this debris field
+	4028: ErrNotEnoughResources, // Not enough fuel
+	4038: ErrInvalidTarget,      // Colony ships must be sent to colonise this planet
+	4049: ErrInvalidTarget,      // You have to select a valid target
+	4053: ErrInvalidTarget,      // Planet is already inhabited
+	4060: ErrNotEnoughResources, // Insufficient resources
+	4061: ErrQueueBusy,          // There is already a construction in progress
+	// errorCodeMap of the fleetdispatch page
+	602: ErrInvalidTarget,      // Error, there is no moon
+	603: ErrInvalidTarget,      // Error, player can`t be approached because of newbie protection
+	604: ErrInvalidTarget,      // Player is too strong to be attacked
+	605: ErrInvalidTarget,      // Error, player is in vacation mode
+	606: ErrVacationMode,       // No fleets can be sent from vacation mode!
+	612: ErrNoFreeSlots,        // Error, no free fleet slots available
+	613: ErrNotEnoughResources, // Error, you don`t have enough deuterium
+	614: ErrInvalidTarget,      // Error, there is no planet there
+	617: ErrInvalidTarget,      // Admin or GM
+}
+
+// NewServerError creates the error of a message returned by the game server, its category comes from the code
+func NewServerError(message string, code int64) error {
+	return &Error{Kind: serverErrorKinds[code], Message: message, Code: code}
+}
+
+// ParseError returned when the extractor fails to parse a page
+type ParseError struct {
+	Page             string
+	ExtractorVersion string
+	Err              error
+}
+
+// Error ...
+func (e *ParseError) Error() string {
+	return "failed to parse page " + e.Page + " (extractor " + e.ExtractorVersion + "): " + e.Err.Error()
+}
+
+// Is reports whether target is ErrParse
+func (e *ParseError) Is(target error) bool {
+	return target == ErrParse
+}
+
+// Unwrap ...
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ErrSelectorNotFound returned by an extractor when an element is missing from the page,
+// the page probably comes from another version of the game
+var ErrSelectorNotFound = errors.New("selector not found")
+
+// SelectorNotFoundError the element of a selector (or the key of a json response) is missing from the page
+type SelectorNotFoundError struct {
+	Selector string
+}
+
+// NewSelectorNotFoundError creates the error of a selector that matches nothing
+func NewSelectorNotFoundError(selector string) error {
+	return &SelectorNotFoundError{Selector: selector}
+}
+
+// Error ...
+func (e *SelectorNotFoundError) Error() string {
+	return "selector not found: " + e.Selector
+}
+
+// Is reports whether target is ErrSelectorNotFound or ErrParse
+func (e *SelectorNotFoundError) Is(target error) bool {
+	return target == ErrSelectorNotFound || target == ErrParse
+}
 
 // ErrNotLogged returned when the bot is not logged
 var ErrNotLogged = errors.New("not logged")
@@ -30,7 +143,7 @@ var ErrLoginLink = errors.New("failed to get login link")
 var ErrInvalidPlanetID = errors.New("invalid planet id")
 
 // ErrAllSlotsInUse returned when all slots are in use
-var ErrAllSlotsInUse = errors.New("all slots are in use")
+var ErrAllSlotsInUse = NewError(ErrNoFreeSlots, "all slots are in use")
 
 // ErrBotInactive returned when the bot is not active
 var ErrBotInactive = errors.New("bot is not active")
@@ -47,25 +160,42 @@ var ErrDeactivateHidePictures = errors.New("deactivate 'Hide pictures in reports
 // ErrEventsBoxNotDisplayed returned when trying to get attacks from a full page without event box
 var ErrEventsBoxNotDisplayed = errors.New("eventList box is not displayed")
 
+// ErrCannotArriveInTime returned when no speed gets a fleet to its destination before the requested time,
+// or before the deadline to join a union
+var ErrCannotArriveInTime = errors.New("cannot arrive in time")
+
 // Send fleet errors
 var (
 	ErrUnionNotFound                      = errors.New("union not found")
-	ErrAccountInVacationMode              = errors.New("account in vacation mode")
+	ErrAccountInVacationMode              = NewError(ErrVacationMode, "account in vacation mode")
 	ErrNoShipSelected                     = errors.New("no ships to send")
-	ErrUninhabitedPlanet                  = errors.New("uninhabited planet")
-	ErrNoDebrisField                      = errors.New("no debris field")
-	ErrPlayerInVacationMode               = errors.New("player in vacation mode")
-	ErrAdminOrGM                          = errors.New("admin or GM")
+	ErrUninhabitedPlanet                  = NewError(ErrInvalidTarget, "uninhabited planet")
+	ErrNoDebrisField                      = NewError(ErrInvalidTarget, "no debris field")
+	ErrPlayerInVacationMode               = NewError(ErrInvalidTarget, "player in vacation mode")
+	ErrAdminOrGM                          = NewError(ErrInvalidTarget, "admin or GM")
 	ErrNoAstrophysics                     = errors.New("you have to research Astrophysics first")
-	ErrNoobProtection                     = errors.New("noob protection")
-	ErrPlayerTooStrong                    = errors.New("this planet can not be attacked as the player is to strong")
+	ErrNoobProtection                     = NewError(ErrInvalidTarget, "noob protection")
+	ErrPlayerTooStrong                    = NewError(ErrInvalidTarget, "this planet can not be attacked as the player is to strong")
 	ErrNoMoonAvailable                    = errors.New("no moon available")
 	ErrNoRecyclerAvailable                = errors.New("no recycler available")
 	ErrNoEventsRunning                    = errors.New("there are currently no events running")
 	ErrPlanetAlreadyReservedForRelocation = errors.New("this planet has already been reserved for a relocation")
-	ErrNotEnoughFuel                      = errors.New("not enough fuel")                          // 4028 Not enough fuel
+	ErrNotEnoughFuel                      = NewError(ErrNotEnoughResources, "not enough fuel")     // 4028 Not enough fuel
 	ErrAttackBannedUntil                  = errors.New("attack ban until")                         // 4050 Attack ban until
 	ErrNotEnoughCargoSpace                = errors.New("not enough cargo space")                   // 140028 Not enough cargo space
 	ErrNotEnoughShips                     = errors.New("not enough ships to send")                 // 140054 No ships available
 	ErrEngagedInCombat                    = errors.New("the fleet is currently engaged in combat") // 140068 The fleet is currently engaged in combat
 )
+
+// Cancel fleet errors
+var (
+	ErrFleetNotFound      = NewError(ErrInvalidTarget, "fleet not found")
+	ErrFleetNotRecallable = NewError(ErrInvalidTarget, "fleet cannot be recalled")
+	ErrFleetNotRecalled   = errors.New("fleet was not recalled")
+)
+
+// ErrReportsCoordinateMismatch returned when comparing espionage reports of different coordinates
+var ErrReportsCoordinateMismatch = errors.New("espionage reports are not for the same coordinate")
+
+// ErrNotInAlliance returned when the player is not a member of any alliance
+var ErrNotInAlliance = errors.New("player is not in an alliance")
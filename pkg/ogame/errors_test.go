@@ -0,0 +1,49 @@
+package ogame
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	err := fmt.Errorf("failed to send fleet: %w", ErrNoobProtection)
+	assert.ErrorIs(t, err, ErrNoobProtection)
+	assert.ErrorIs(t, err, ErrInvalidTarget)
+	assert.NotErrorIs(t, err, ErrNotEnoughResources)
+	assert.Equal(t, "failed to send fleet: noob protection", err.Error())
+	assert.ErrorIs(t, ErrAllSlotsInUse, ErrNoFreeSlots)
+	assert.ErrorIs(t, ErrAccountInVacationMode, ErrVacationMode)
+
+	err = NewServerError("Not enough resources!", 4060)
+	assert.ErrorIs(t, err, ErrNotEnoughResources)
+	assert.Equal(t, "Not enough resources! (4060)", err.Error())
+	var ogameErr *Error
+	assert.True(t, errors.As(err, &ogameErr))
+	assert.Equal(t, int64(4060), ogameErr.Code)
+
+	assert.ErrorIs(t, NewServerError("Error, no free fleet slots available", 612), ErrNoFreeSlots)
+	assert.ErrorIs(t, NewServerError("No fleets can be sent from vacation mode!", 606), ErrVacationMode)
+	assert.ErrorIs(t, NewServerError("Error, player is in vacation mode", 605), ErrInvalidTarget)
+	assert.ErrorIs(t, ErrFleetNotRecallable, ErrInvalidTarget)
+
+	err = NewServerError("Unknown error", 1)
+	for _, kind := range []error{ErrNotEnoughResources, ErrNoFreeSlots, ErrQueueBusy, ErrInvalidTarget, ErrVacationMode} {
+		assert.NotErrorIs(t, err, kind)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	inner := errors.New("unexpected end of JSON input")
+	err := error(&ParseError{Page: "fleetdispatch", ExtractorVersion: "v12_0_0", Err: inner})
+	assert.ErrorIs(t, err, ErrParse)
+	assert.ErrorIs(t, err, inner)
+	assert.Equal(t, "failed to parse page fleetdispatch (extractor v12_0_0): unexpected end of JSON input", err.Error())
+
+	err = &ParseError{Page: "buddies", ExtractorVersion: "v12_0_0", Err: NewSelectorNotFoundError("table#buddylist")}
+	assert.ErrorIs(t, err, ErrParse)
+	assert.ErrorIs(t, err, ErrSelectorNotFound)
+	assert.Equal(t, "failed to parse page buddies (extractor v12_0_0): selector not found: table#buddylist", err.Error())
+}
@@ -0,0 +1,176 @@
+package ogame
+
+import (
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+// LevelChange level (or quantity) of an ogame object that changed between two espionage reports
+type LevelChange struct {
+	ID     ID
+	Before int64
+	After  int64
+}
+
+// Diff returns After - Before
+func (c LevelChange) Diff() int64 {
+	return c.After - c.Before
+}
+
+// EspionageReportDiff what changed on a planet between two espionage reports.
+// Changes lists are nil when one of the reports does not have the information.
+type EspionageReportDiff struct {
+	Coordinate         Coordinate
+	From               time.Time
+	To                 time.Time
+	Resources          Resources // Resources difference between the two reports
+	ExpectedProduction Resources // Resources the mines were expected to produce between the two reports
+	Unexplained        Resources // Resources - ExpectedProduction, negative: spent, looted or sent away, positive: delivered
+	Buildings          []LevelChange
+	Researches         []LevelChange
+	Ships              []LevelChange
+	Defenses           []LevelChange
+	ShipsArrived       ShipsInfos // Ships that arrived (or were built) between the two reports
+	ShipsLeft          ShipsInfos // Ships that left (or were destroyed) between the two reports
+	DefensesBuilt      DefensesInfos
+	DefensesLost       DefensesInfos
+}
+
+// HourlyProduction estimates the hourly production of the spied planet, assuming 100% resource settings.
+// The temperature of the planet is not part of the report and must be provided.
+// Returns false if the report does not have the buildings information.
+func (r EspionageReport) HourlyProduction(universeSpeed int64, temp Temperature) (Resources, bool) {
+	resBuildings := r.ResourcesBuildings()
+	if resBuildings == nil {
+		return Resources{}, false
+	}
+	return r.hourlyProduction(*resBuildings, universeSpeed, temp), true
+}
+
+func (r EspionageReport) hourlyProduction(resBuildings ResourcesBuildings, universeSpeed int64, temp Temperature) Resources {
+	if r.Coordinate.IsMoon() {
+		return Resources{}
+	}
+	var researches Researches
+	if r.HasResearchesInformation {
+		researches = *r.Researches()
+	}
+	resSettings := ResourceSettings{MetalMine: 100, CrystalMine: 100, DeuteriumSynthesizer: 100, SolarPlant: 100, FusionReactor: 100, SolarSatellite: 100}
+	ratio := ProductionRatio(temp, resBuildings, resSettings, researches.EnergyTechnology)
+	production := Productions(resBuildings, resSettings, researches, universeSpeed, temp, ratio)
+	bonus := ClassProductionBonus(r.CharacterClass, resBuildings, universeSpeed, temp, ratio)
+	production.Metal += bonus.Metal
+	production.Crystal += bonus.Crystal
+	production.Deuterium += bonus.Deuterium
+	return production
+}
+
+// ResourcesAt extrapolates the resources of the spied planet at a given time,
+// from the mines levels and the time elapsed since the report. Production stops when the storage is full.
+// Returns the resources of the report if the production cannot be estimated.
+func (r EspionageReport) ResourcesAt(at time.Time, universeSpeed int64, temp Temperature) Resources {
+	resBuildings := r.ResourcesBuildings()
+	if resBuildings == nil {
+		return r.Resources
+	}
+	return r.resourcesAt(at, *resBuildings, universeSpeed, temp)
+}
+
+func (r EspionageReport) resourcesAt(at time.Time, resBuildings ResourcesBuildings, universeSpeed int64, temp Temperature) Resources {
+	out := r.Resources
+	hours := at.Sub(r.Date).Hours()
+	if hours <= 0 {
+		return out
+	}
+	production := r.hourlyProduction(resBuildings, universeSpeed, temp)
+	extrapolate := func(current, hourly int64, storage int64) int64 {
+		if hourly <= 0 || current >= storage {
+			return current
+		}
+		return utils.MinInt(current+int64(float64(hourly)*hours), storage)
+	}
+	out.Metal = extrapolate(r.Metal, production.Metal, MetalStorage.Capacity(resBuildings.MetalStorage))
+	out.Crystal = extrapolate(r.Crystal, production.Crystal, CrystalStorage.Capacity(resBuildings.CrystalStorage))
+	out.Deuterium = extrapolate(r.Deuterium, production.Deuterium, DeuteriumTank.Capacity(resBuildings.DeuteriumTank))
+	return out
+}
+
+// DiffEspionageReports returns what changed between two espionage reports of the same coordinate.
+// Reports can be given in any order. universeSpeed and temp are used to estimate the production of the planet.
+func DiffEspionageReports(a, b EspionageReport, universeSpeed int64, temp Temperature) (EspionageReportDiff, error) {
+	if !a.Coordinate.Equal(b.Coordinate) {
+		return EspionageReportDiff{}, ErrReportsCoordinateMismatch
+	}
+	prev, curr := a, b
+	if prev.Date.After(curr.Date) {
+		prev, curr = curr, prev
+	}
+	diff := EspionageReportDiff{Coordinate: curr.Coordinate, From: prev.Date, To: curr.Date}
+	diff.Resources = resourcesDelta(curr.Resources, prev.Resources)
+	diff.ExpectedProduction = resourcesDelta(prev.ResourcesAt(curr.Date, universeSpeed, temp), prev.Resources)
+	diff.Unexplained = resourcesDelta(diff.Resources, diff.ExpectedProduction)
+
+	if prev.HasBuildingsInformation && curr.HasBuildingsInformation {
+		prevResBuildings, currResBuildings := prev.ResourcesBuildings(), curr.ResourcesBuildings()
+		prevFacilities, currFacilities := prev.Facilities(), curr.Facilities()
+		diff.Buildings = make([]LevelChange, 0)
+		for _, building := range Buildings {
+			id := building.GetID()
+			if id == SolarSatelliteID {
+				continue // Reported with the ships
+			}
+			before, after := prevResBuildings.ByID(id)+prevFacilities.ByID(id), currResBuildings.ByID(id)+currFacilities.ByID(id)
+			diff.Buildings = appendLevelChange(diff.Buildings, id, before, after)
+		}
+	}
+	if prev.HasResearchesInformation && curr.HasResearchesInformation {
+		prevResearches, currResearches := prev.Researches(), curr.Researches()
+		diff.Researches = make([]LevelChange, 0)
+		for _, technology := range Technologies {
+			id := technology.GetID()
+			diff.Researches = appendLevelChange(diff.Researches, id, prevResearches.ByID(id), currResearches.ByID(id))
+		}
+	}
+	if prev.HasFleetInformation && curr.HasFleetInformation {
+		prevShips, currShips := prev.ShipsInfos(), curr.ShipsInfos()
+		diff.Ships = make([]LevelChange, 0)
+		for _, ship := range Ships {
+			id := ship.GetID()
+			before, after := prevShips.ByID(id), currShips.ByID(id)
+			diff.Ships = appendLevelChange(diff.Ships, id, before, after)
+			if after > before {
+				diff.ShipsArrived.Set(id, after-before)
+			} else if before > after {
+				diff.ShipsLeft.Set(id, before-after)
+			}
+		}
+	}
+	if prev.HasDefensesInformation && curr.HasDefensesInformation {
+		prevDefenses, currDefenses := prev.DefensesInfos(), curr.DefensesInfos()
+		diff.Defenses = make([]LevelChange, 0)
+		for _, defense := range Defenses {
+			id := defense.GetID()
+			before, after := prevDefenses.ByID(id), currDefenses.ByID(id)
+			diff.Defenses = appendLevelChange(diff.Defenses, id, before, after)
+			if after > before {
+				diff.DefensesBuilt.Set(id, after-before)
+			} else if before > after {
+				diff.DefensesLost.Set(id, before-after)
+			}
+		}
+	}
+	return diff, nil
+}
+
+func appendLevelChange(changes []LevelChange, id ID, before, after int64) []LevelChange {
+	if before == after {
+		return changes
+	}
+	return append(changes, LevelChange{ID: id, Before: before, After: after})
+}
+
+// Returns a - b for metal, crystal and deuterium, unlike Resources.Sub the result can be negative
+func resourcesDelta(a, b Resources) Resources {
+	return Resources{Metal: a.Metal - b.Metal, Crystal: a.Crystal - b.Crystal, Deuterium: a.Deuterium - b.Deuterium}
+}
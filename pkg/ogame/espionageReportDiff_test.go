@@ -0,0 +1,90 @@
+package ogame
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEspionageReport_ResourcesAt(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	temp := Temperature{Min: -23, Max: 17}
+	report := EspionageReport{
+		Date:                    now,
+		Coordinate:              Coordinate{Galaxy: 1, System: 1, Position: 8, Type: PlanetType},
+		Resources:               Resources{Metal: 1000, Crystal: 1000, Deuterium: 1000},
+		HasBuildingsInformation: true,
+		MetalMine:               utils.I64Ptr(20),
+		CrystalMine:             utils.I64Ptr(15),
+		DeuteriumSynthesizer:    utils.I64Ptr(10),
+		SolarPlant:              utils.I64Ptr(30),
+	}
+	production, ok := report.HourlyProduction(1, temp)
+	assert.True(t, ok)
+	assert.Equal(t, MetalMine.Production(1, 1, 1, 0, 20), production.Metal)
+	assert.Equal(t, CrystalMine.Production(1, 1, 1, 0, 15), production.Crystal)
+
+	assert.Equal(t, report.Resources, report.ResourcesAt(now, 1, temp))
+	at := report.ResourcesAt(now.Add(2*time.Hour), 1, temp)
+	assert.Equal(t, 1000+2*production.Metal, at.Metal)
+	assert.Equal(t, 1000+2*production.Deuterium, at.Deuterium)
+
+	// Capped by the storage capacity (level 0: 10000)
+	at = report.ResourcesAt(now.Add(100*time.Hour), 1, temp)
+	assert.Equal(t, int64(10000), at.Metal)
+
+	// No buildings information
+	report.HasBuildingsInformation = false
+	_, ok = report.HourlyProduction(1, temp)
+	assert.False(t, ok)
+	assert.Equal(t, report.Resources, report.ResourcesAt(now.Add(time.Hour), 1, temp))
+}
+
+func TestDiffEspionageReports(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	coord := Coordinate{Galaxy: 1, System: 1, Position: 8, Type: PlanetType}
+	prev := EspionageReport{
+		Date:                     now.Add(-time.Hour),
+		Coordinate:               coord,
+		Resources:                Resources{Metal: 1000},
+		HasBuildingsInformation:  true,
+		HasFleetInformation:      true,
+		HasDefensesInformation:   true,
+		HasResearchesInformation: false,
+		MetalMine:                utils.I64Ptr(10),
+		SolarPlant:               utils.I64Ptr(10),
+		Shipyard:                 utils.I64Ptr(2),
+		SmallCargo:               utils.I64Ptr(10),
+		LightFighter:             utils.I64Ptr(5),
+		RocketLauncher:           utils.I64Ptr(3),
+	}
+	curr := prev
+	curr.Date = now
+	curr.Resources = Resources{Metal: 500}
+	curr.MetalMine = utils.I64Ptr(11)
+	curr.SmallCargo = nil
+	curr.LightFighter = utils.I64Ptr(7)
+	curr.RocketLauncher = utils.I64Ptr(10)
+	curr.HasResearchesInformation = true
+
+	diff, err := DiffEspionageReports(curr, prev, 1, Temperature{})
+	assert.NoError(t, err)
+	assert.Equal(t, prev.Date, diff.From)
+	assert.Equal(t, Resources{Metal: -500}, diff.Resources)
+	expected := Resources{Metal: MetalMine.Production(1, 1, 1, 0, 10), Crystal: CrystalMine.Production(1, 1, 1, 0, 0)}
+	assert.Equal(t, expected, diff.ExpectedProduction)
+	assert.Equal(t, Resources{Metal: -500 - expected.Metal, Crystal: -expected.Crystal}, diff.Unexplained)
+	assert.Equal(t, []LevelChange{{ID: MetalMineID, Before: 10, After: 11}}, diff.Buildings)
+	assert.Nil(t, diff.Researches)
+	assert.Equal(t, int64(2), diff.ShipsArrived.LightFighter)
+	assert.Equal(t, int64(10), diff.ShipsLeft.SmallCargo)
+	assert.Equal(t, int64(0), diff.ShipsLeft.LightFighter)
+	assert.Equal(t, 2, len(diff.Ships))
+	assert.Equal(t, int64(7), diff.DefensesBuilt.RocketLauncher)
+	assert.Equal(t, int64(7), diff.Defenses[0].Diff())
+
+	_, err = DiffEspionageReports(prev, EspionageReport{Coordinate: coord.Moon()}, 1, Temperature{})
+	assert.ErrorIs(t, err, ErrReportsCoordinateMismatch)
+}
@@ -0,0 +1,169 @@
+package ogame
+
+import (
+	"math"
+
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+// FlightUniverse universe settings used to calculate flight time and fuel consumption
+type FlightUniverse struct {
+	Galaxies            int64
+	Systems             int64
+	DonutGalaxy         bool
+	DonutSystem         bool
+	SpeedFleetPeaceful  int64
+	SpeedFleetWar       int64
+	FleetDeutSaveFactor float64 // server setting globalDeuteriumSaveFactor
+}
+
+// FleetSpeedForMission returns the universe fleet speed for a mission
+func (u FlightUniverse) FleetSpeedForMission(missionID MissionID) int64 {
+	if missionID == Attack ||
+		missionID == GroupedAttack ||
+		missionID == Destroy ||
+		missionID == MissileAttack ||
+		missionID == RecycleDebrisField ||
+		missionID == Spy {
+		return u.SpeedFleetWar
+	}
+	return u.SpeedFleetPeaceful
+}
+
+// FlightParams all the information needed to calculate a flight without a connected bot
+type FlightParams struct {
+	Origin         Coordinate
+	Destination    Coordinate
+	Ships          ShipsInfos
+	Speed          Speed // 1 -> 10% | 10 -> 100%
+	Mission        MissionID
+	Researches     Researches
+	LfBonuses      LfBonuses
+	CharacterClass CharacterClass
+	AllianceClass  AllianceClass
+	Universe       FlightUniverse
+	SystemsSkip    int64 // Empty/inactive systems ignored by the server (fleetIgnoreEmptySystems, fleetIgnoreInactiveSystems)
+}
+
+// CalcFlight calculates the flight time (one way) and the fuel consumption of a fleet
+func CalcFlight(p FlightParams) (secs, fuel int64) {
+	u := p.Universe
+	return CalcFlightTime(p.Origin, p.Destination, u.Galaxies, u.Systems, u.DonutGalaxy, u.DonutSystem,
+		u.FleetDeutSaveFactor, p.Speed.Float64()/10, u.FleetSpeedForMission(p.Mission), p.Ships, p.Researches, p.LfBonuses,
+		p.CharacterClass, p.AllianceClass, p.SystemsSkip)
+}
+
+// CalcFlightTime ...
+// Systems that are empty/inactive can be skipped for distance calculation
+// (server settings: fleetIgnoreEmptySystems, fleetIgnoreInactiveSystems)
+// https://board.en.ogame.gameforge.com/index.php?thread/838751-flight-time-consumption-ignores-empty-inactive-systems
+// speed: 1 -> 100% | 0.5 -> 50% | 0.05 -> 5%
+func CalcFlightTime(origin, destination Coordinate, universeSize, nbSystems int64, donutGalaxy, donutSystem bool,
+	fleetDeutSaveFactor, speed float64, universeSpeedFleet int64, ships ShipsInfos, techs Researches, lfBonuses LfBonuses,
+	characterClass CharacterClass, allianceClass AllianceClass, systemsSkip int64) (secs, fuel int64) {
+	if !ships.HasShips() {
+		return
+	}
+	v := findSlowestSpeed(ships, techs, lfBonuses, characterClass, allianceClass)
+	secs = CalcFlightTimeWithBaseSpeed(origin, destination, universeSize, nbSystems, donutGalaxy, donutSystem, speed, v, universeSpeedFleet, systemsSkip)
+	d := float64(Distance(origin, destination, universeSize, nbSystems, systemsSkip, donutGalaxy, donutSystem))
+	fuel = calcFuel(ships, int64(d), secs, float64(universeSpeedFleet), fleetDeutSaveFactor, techs, lfBonuses, characterClass, allianceClass)
+	return
+}
+
+// CalcFlightTimeWithBaseSpeed ...
+// baseSpeed is the speed of the slowest ship in a fleet
+// speed: 1 -> 100% | 0.5 -> 50% | 0.05 -> 5%
+func CalcFlightTimeWithBaseSpeed(origin, destination Coordinate, universeSize, nbSystems int64, donutGalaxy, donutSystem bool, speed float64, baseSpeed, universeSpeedFleet, systemsSkip int64) (secs int64) {
+	s := speed
+	v := float64(baseSpeed)
+	a := float64(universeSpeedFleet)
+	d := float64(Distance(origin, destination, universeSize, nbSystems, systemsSkip, donutGalaxy, donutSystem))
+	return int64(math.Round(((3500/s)*math.Sqrt(d*10/v) + 10) / a))
+}
+
+// Returns the distance between two galaxy
+func galaxyDistance(galaxy1, galaxy2, universeSize int64, donutGalaxy bool) (distance int64) {
+	if !donutGalaxy {
+		return int64(20000 * math.Abs(float64(galaxy2-galaxy1)))
+	}
+	if galaxy1 > galaxy2 {
+		galaxy1, galaxy2 = galaxy2, galaxy1
+	}
+	val := math.Min(float64(galaxy2-galaxy1), float64((galaxy1+universeSize)-galaxy2))
+	return int64(20000 * val)
+}
+
+// SystemDistance returns the number of systems between two systems
+func SystemDistance(nbSystems, system1, system2 int64, donutSystem bool) (distance int64) {
+	if !donutSystem {
+		return int64(math.Abs(float64(system2 - system1)))
+	}
+	if system1 > system2 {
+		system1, system2 = system2, system1
+	}
+	return utils.MinInt(system2-system1, (system1+nbSystems)-system2)
+}
+
+// Returns the distance between two systems
+func flightSystemDistance(nbSystems, system1, system2, systemsSkip int64, donutSystem bool) (distance int64) {
+	dist := utils.MaxInt(SystemDistance(nbSystems, system1, system2, donutSystem)-systemsSkip, 0)
+	return 2700 + 95*dist
+}
+
+// Returns the distance between two planets
+func planetDistance(planet1, planet2 int64) (distance int64) {
+	return int64(1000 + 5*math.Abs(float64(planet2-planet1)))
+}
+
+// Distance returns the distance between two coordinates
+func Distance(c1, c2 Coordinate, universeSize, nbSystems, systemsSkip int64, donutGalaxy, donutSystem bool) (distance int64) {
+	if c1.Galaxy != c2.Galaxy {
+		return galaxyDistance(c1.Galaxy, c2.Galaxy, universeSize, donutGalaxy)
+	}
+	if c1.System != c2.System {
+		return flightSystemDistance(nbSystems, c1.System, c2.System, systemsSkip, donutSystem)
+	}
+	if c1.Position != c2.Position {
+		return planetDistance(c1.Position, c2.Position)
+	}
+	return 5
+}
+
+func findSlowestSpeed(ships ShipsInfos, techs Researches, lfBonuses LfBonuses, characterClass CharacterClass, allianceClass AllianceClass) int64 {
+	var minSpeed int64 = math.MaxInt64
+	for _, ship := range Ships {
+		shipID := ship.GetID()
+		if shipID == SolarSatelliteID || shipID == CrawlerID {
+			continue
+		}
+		shipSpeed := ship.GetSpeed(techs, lfBonuses, characterClass, allianceClass)
+		if ships.ByID(shipID) > 0 && shipSpeed < minSpeed {
+			minSpeed = shipSpeed
+		}
+	}
+	return minSpeed
+}
+
+func calcFuel(ships ShipsInfos, dist, duration int64, universeSpeedFleet, fleetDeutSaveFactor float64, techs Researches,
+	lfBonuses LfBonuses, characterClass CharacterClass, allianceClass AllianceClass) (fuel int64) {
+	tmpFn := func(baseFuel, nbr, shipSpeed int64) float64 {
+		tmpSpeed := (35000 / (float64(duration)*universeSpeedFleet - 10)) * math.Sqrt(float64(dist)*10/float64(shipSpeed))
+		return float64(baseFuel*nbr*dist) / 35000 * math.Pow(tmpSpeed/10+1, 2)
+	}
+	tmpFuel := 0.0
+	for _, ship := range Ships {
+		shipID := ship.GetID()
+		if shipID == SolarSatelliteID || shipID == CrawlerID {
+			continue
+		}
+		nbr := ships.ByID(shipID)
+		if nbr > 0 {
+			getFuelConsumption := ship.GetFuelConsumption(techs, lfBonuses, characterClass, fleetDeutSaveFactor)
+			speed := ship.GetSpeed(techs, lfBonuses, characterClass, allianceClass)
+			tmpFuel += tmpFn(getFuelConsumption, nbr, speed)
+		}
+	}
+	fuel = int64(1 + math.Round(tmpFuel))
+	return
+}
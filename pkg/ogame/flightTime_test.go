@@ -0,0 +1,179 @@
+package ogame
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGalaxyDistance(t *testing.T) {
+	assert.Equal(t, int64(60000), galaxyDistance(6, 3, 6, false))
+	assert.Equal(t, int64(20000), galaxyDistance(1, 2, 6, false))
+	assert.Equal(t, int64(40000), galaxyDistance(1, 3, 6, false))
+	assert.Equal(t, int64(60000), galaxyDistance(1, 4, 6, false))
+	assert.Equal(t, int64(80000), galaxyDistance(1, 5, 6, false))
+	assert.Equal(t, int64(100000), galaxyDistance(1, 6, 6, false))
+
+	assert.Equal(t, int64(20000), galaxyDistance(1, 2, 6, true))
+	assert.Equal(t, int64(40000), galaxyDistance(1, 3, 6, true))
+	assert.Equal(t, int64(60000), galaxyDistance(1, 4, 6, true))
+	assert.Equal(t, int64(40000), galaxyDistance(1, 5, 6, true))
+	assert.Equal(t, int64(20000), galaxyDistance(1, 6, 6, true))
+	assert.Equal(t, int64(20000), galaxyDistance(6, 1, 6, true))
+}
+
+func TestFlightSystemDistance(t *testing.T) {
+	assert.Equal(t, int64(3175), flightSystemDistance(499, 35, 30, 0, false))
+	assert.Equal(t, int64(2795), flightSystemDistance(499, 1, 2, 0, true))
+	assert.Equal(t, int64(2795), flightSystemDistance(499, 1, 499, 0, true))
+	assert.Equal(t, int64(2890), flightSystemDistance(499, 1, 3, 0, true))
+	assert.Equal(t, int64(2890), flightSystemDistance(499, 1, 498, 0, true))
+	assert.Equal(t, int64(2890), flightSystemDistance(499, 498, 1, 0, true))
+}
+
+func TestSystemDistance(t *testing.T) {
+	assert.Equal(t, int64(5), SystemDistance(499, 35, 30, false))
+	assert.Equal(t, int64(498), SystemDistance(499, 1, 499, false))
+	assert.Equal(t, int64(1), SystemDistance(499, 1, 2, true))
+	assert.Equal(t, int64(1), SystemDistance(499, 1, 499, true))
+	assert.Equal(t, int64(2), SystemDistance(499, 1, 3, true))
+	assert.Equal(t, int64(2), SystemDistance(499, 1, 498, true))
+	assert.Equal(t, int64(2), SystemDistance(499, 498, 1, true))
+}
+
+func TestPlanetDistance(t *testing.T) {
+	assert.Equal(t, int64(1015), planetDistance(6, 3))
+}
+
+func TestFindSlowestSpeed(t *testing.T) {
+	assert.Equal(t, int64(8000), findSlowestSpeed(ShipsInfos{SmallCargo: 1, LargeCargo: 1}, Researches{CombustionDrive: 6}, LfBonuses{}, NoClass, NoAllianceClass))
+}
+
+func TestFlightUniverse_FleetSpeedForMission(t *testing.T) {
+	u := FlightUniverse{SpeedFleetPeaceful: 3, SpeedFleetWar: 2}
+	assert.Equal(t, int64(2), u.FleetSpeedForMission(Attack))
+	assert.Equal(t, int64(2), u.FleetSpeedForMission(Spy))
+	assert.Equal(t, int64(2), u.FleetSpeedForMission(RecycleDebrisField))
+	assert.Equal(t, int64(3), u.FleetSpeedForMission(Transport))
+	assert.Equal(t, int64(3), u.FleetSpeedForMission(Expedition))
+}
+
+// Fixtures are the values displayed by the fleet dispatch page for the given fleet/universe
+func TestCalcFlight(t *testing.T) {
+	fixtures := []struct {
+		name   string
+		params FlightParams
+		secs   int64
+		fuel   int64
+	}{
+		{
+			name: "peaceful speed x1, no donut",
+			params: FlightParams{
+				Origin:      Coordinate{Galaxy: 1, System: 1, Position: 1, Type: PlanetType},
+				Destination: Coordinate{Galaxy: 1, System: 5, Position: 3, Type: PlanetType},
+				Ships:       ShipsInfos{LightFighter: 16, HeavyFighter: 8, Cruiser: 4},
+				Speed:       EightyPercent,
+				Mission:     Transport,
+				Researches:  Researches{CombustionDrive: 10, ImpulseDrive: 7},
+				Universe:    FlightUniverse{Galaxies: 1, Systems: 499, SpeedFleetPeaceful: 1, SpeedFleetWar: 1, FleetDeutSaveFactor: 1},
+			},
+			secs: 4966,
+			fuel: 550,
+		},
+		{
+			name: "discoverer, deuterium save factor 0.5",
+			params: FlightParams{
+				Origin:         Coordinate{Galaxy: 4, System: 116, Position: 12, Type: PlanetType},
+				Destination:    Coordinate{Galaxy: 3, System: 116, Position: 12, Type: PlanetType},
+				Ships:          ShipsInfos{LargeCargo: 1931},
+				Speed:          HundredPercent,
+				Mission:        Transport,
+				Researches:     Researches{CombustionDrive: 18, ImpulseDrive: 15, HyperspaceDrive: 13},
+				CharacterClass: Discoverer,
+				Universe:       FlightUniverse{Galaxies: 6, Systems: 499, DonutGalaxy: true, DonutSystem: true, SpeedFleetPeaceful: 2, SpeedFleetWar: 1, FleetDeutSaveFactor: 0.5},
+			},
+			secs: 5406,
+			fuel: 110336,
+		},
+		{
+			name: "solar satellites are ignored",
+			params: FlightParams{
+				Origin:      Coordinate{Galaxy: 1, System: 1, Position: 1, Type: PlanetType},
+				Destination: Coordinate{Galaxy: 1, System: 1, Position: 15, Type: PlanetType},
+				Ships:       ShipsInfos{LargeCargo: 100, SolarSatellite: 50},
+				Speed:       HundredPercent,
+				Mission:     Transport,
+				Researches:  Researches{CombustionDrive: 16, ImpulseDrive: 13, HyperspaceDrive: 15},
+				Universe:    FlightUniverse{Galaxies: 6, Systems: 499, SpeedFleetPeaceful: 4, SpeedFleetWar: 1, FleetDeutSaveFactor: 1},
+			},
+			secs: 651,
+			fuel: 612,
+		},
+		{
+			name: "war speed is used for attacks",
+			params: FlightParams{
+				Origin:         Coordinate{Galaxy: 2, System: 68, Position: 4, Type: MoonType},
+				Destination:    Coordinate{Galaxy: 1, System: 313, Position: 9, Type: PlanetType},
+				Ships:          ShipsInfos{LightFighter: 1, HeavyFighter: 1, Cruiser: 1, Battleship: 1, SmallCargo: 1, LargeCargo: 1, Recycler: 1, ColonyShip: 1, EspionageProbe: 1},
+				Speed:          HundredPercent,
+				Mission:        Attack,
+				Researches:     Researches{CombustionDrive: 7, ImpulseDrive: 5, HyperspaceDrive: 0},
+				CharacterClass: Discoverer,
+				Universe:       FlightUniverse{Galaxies: 5, Systems: 499, DonutGalaxy: true, DonutSystem: true, SpeedFleetPeaceful: 6, SpeedFleetWar: 2, FleetDeutSaveFactor: 1},
+			},
+			secs: 13427,
+			fuel: 3808,
+		},
+		{
+			name: "pathfinder",
+			params: FlightParams{
+				Origin:         Coordinate{Galaxy: 1, System: 230, Position: 7, Type: MoonType},
+				Destination:    Coordinate{Galaxy: 1, System: 318, Position: 4, Type: MoonType},
+				Ships:          ShipsInfos{LightFighter: 1, HeavyFighter: 1, Cruiser: 1, Battleship: 1, SmallCargo: 1, LargeCargo: 1, Recycler: 1, EspionageProbe: 1, Pathfinder: 1},
+				Speed:          HundredPercent,
+				Mission:        Park,
+				Researches:     Researches{CombustionDrive: 10, ImpulseDrive: 6, HyperspaceDrive: 4},
+				CharacterClass: Discoverer,
+				Universe:       FlightUniverse{Galaxies: 5, Systems: 499, DonutGalaxy: true, DonutSystem: true, SpeedFleetPeaceful: 6, SpeedFleetWar: 6, FleetDeutSaveFactor: 0.5},
+			},
+			secs: 3069,
+			fuel: 584,
+		},
+		{
+			name: "espionage probes, general",
+			params: FlightParams{
+				Origin:         Coordinate{Galaxy: 1, System: 230, Position: 7, Type: MoonType},
+				Destination:    Coordinate{Galaxy: 1, System: 318, Position: 4, Type: MoonType},
+				Ships:          ShipsInfos{EspionageProbe: 9000},
+				Speed:          HundredPercent,
+				Mission:        Spy,
+				Researches:     Researches{CombustionDrive: 10, ImpulseDrive: 6, HyperspaceDrive: 4},
+				CharacterClass: General,
+				Universe:       FlightUniverse{Galaxies: 5, Systems: 499, DonutGalaxy: true, DonutSystem: true, SpeedFleetPeaceful: 1, SpeedFleetWar: 6, FleetDeutSaveFactor: 1},
+			},
+			secs: 15,
+			fuel: 1,
+		},
+		{
+			name: "empty/inactive systems skipped",
+			params: FlightParams{
+				Origin:         Coordinate{Galaxy: 1, System: 381, Position: 8, Type: MoonType},
+				Destination:    Coordinate{Galaxy: 1, System: 424, Position: 10, Type: PlanetType},
+				Ships:          ShipsInfos{Cruiser: 1},
+				Speed:          HundredPercent,
+				Mission:        Transport,
+				Researches:     Researches{CombustionDrive: 17, ImpulseDrive: 15, HyperspaceDrive: 10},
+				CharacterClass: Discoverer,
+				Universe:       FlightUniverse{Galaxies: 4, Systems: 499, DonutGalaxy: true, DonutSystem: true, SpeedFleetPeaceful: 2, SpeedFleetWar: 1, FleetDeutSaveFactor: 0.7},
+				SystemsSkip:    24,
+			},
+			secs: 1521,
+			fuel: 109,
+		},
+	}
+	for _, f := range fixtures {
+		secs, fuel := CalcFlight(f.params)
+		assert.Equal(t, f.secs, secs, f.name)
+		assert.Equal(t, f.fuel, fuel, f.name)
+	}
+}
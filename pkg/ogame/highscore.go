@@ -5,6 +5,24 @@ import (
 	"strconv"
 )
 
+// Highscore categories
+const (
+	PlayerHighscoreCategory   int64 = 1
+	AllianceHighscoreCategory int64 = 2
+)
+
+// Highscore types
+const (
+	TotalHighscore             int64 = 0
+	EconomyHighscore           int64 = 1
+	ResearchHighscore          int64 = 2
+	MilitaryHighscore          int64 = 3
+	MilitaryBuiltHighscore     int64 = 4
+	MilitaryDestroyedHighscore int64 = 5
+	MilitaryLostHighscore      int64 = 6
+	HonorHighscore             int64 = 7
+)
+
 // Highscore ...
 type Highscore struct {
 	NbPage   int64
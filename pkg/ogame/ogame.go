@@ -268,6 +268,11 @@ type ACSValues struct {
 	Union         int64
 }
 
+// Coordinate returns the coordinate targeted by the union
+func (a ACSValues) Coordinate() Coordinate {
+	return Coordinate{Galaxy: a.Galaxy, System: a.System, Position: a.Position, Type: a.CelestialType}
+}
+
 type Relocation struct {
 	MoveLink             string
 	PlanetMovePossible   bool
@@ -0,0 +1,67 @@
+package ogame
+
+// EnergyProduced returns the energy produced by the solar plant, fusion reactor and solar satellites
+func EnergyProduced(temp Temperature, resourcesBuildings ResourcesBuildings, resSettings ResourceSettings, energyTechnology int64) int64 {
+	energyProduced := int64(float64(SolarPlant.Production(resourcesBuildings.SolarPlant)) * (float64(resSettings.SolarPlant) / 100))
+	energyProduced += int64(float64(FusionReactor.Production(energyTechnology, resourcesBuildings.FusionReactor)) * (float64(resSettings.FusionReactor) / 100))
+	energyProduced += int64(float64(SolarSatellite.Production(temp, resourcesBuildings.SolarSatellite, false)) * (float64(resSettings.SolarSatellite) / 100))
+	return energyProduced
+}
+
+// EnergyNeeded returns the energy consumed by the mines
+func EnergyNeeded(resourcesBuildings ResourcesBuildings, resSettings ResourceSettings) int64 {
+	energyNeeded := int64(float64(MetalMine.EnergyConsumption(resourcesBuildings.MetalMine)) * (float64(resSettings.MetalMine) / 100))
+	energyNeeded += int64(float64(CrystalMine.EnergyConsumption(resourcesBuildings.CrystalMine)) * (float64(resSettings.CrystalMine) / 100))
+	energyNeeded += int64(float64(DeuteriumSynthesizer.EnergyConsumption(resourcesBuildings.DeuteriumSynthesizer)) * (float64(resSettings.DeuteriumSynthesizer) / 100))
+	return energyNeeded
+}
+
+// ProductionRatio returns the ratio applied to the mines production when there is not enough energy
+func ProductionRatio(temp Temperature, resourcesBuildings ResourcesBuildings, resSettings ResourceSettings, energyTechnology int64) float64 {
+	energyProduced := EnergyProduced(temp, resourcesBuildings, resSettings, energyTechnology)
+	energyNeeded := EnergyNeeded(resourcesBuildings, resSettings)
+	ratio := 1.0
+	if energyNeeded > energyProduced {
+		ratio = float64(energyProduced) / float64(energyNeeded)
+	}
+	return ratio
+}
+
+// Productions returns the hourly production of a planet
+func Productions(resBuildings ResourcesBuildings, resSettings ResourceSettings, researches Researches, universeSpeed int64,
+	temp Temperature, globalRatio float64) Resources {
+	energyProduced := EnergyProduced(temp, resBuildings, resSettings, researches.EnergyTechnology)
+	energyNeeded := EnergyNeeded(resBuildings, resSettings)
+	metalSetting := float64(resSettings.MetalMine) / 100
+	crystalSetting := float64(resSettings.CrystalMine) / 100
+	deutSetting := float64(resSettings.DeuteriumSynthesizer) / 100
+	return Resources{
+		Metal:     MetalMine.Production(universeSpeed, metalSetting, globalRatio, researches.PlasmaTechnology, resBuildings.MetalMine),
+		Crystal:   CrystalMine.Production(universeSpeed, crystalSetting, globalRatio, researches.PlasmaTechnology, resBuildings.CrystalMine),
+		Deuterium: DeuteriumSynthesizer.Production(universeSpeed, temp.Mean(), deutSetting, globalRatio, researches.PlasmaTechnology, resBuildings.DeuteriumSynthesizer) - FusionReactor.GetFuelConsumption(universeSpeed, float64(resSettings.FusionReactor)/100, resBuildings.FusionReactor),
+		Energy:    energyProduced - energyNeeded,
+	}
+}
+
+// ClassProductionBonus returns the extra hourly production of the mines given by the character class.
+// Collectors get +25% of the mines production (basic income and plasma technology bonus excluded).
+// This is a collector-only approximation: the geologist and commanding staff bonuses, the crawlers
+// (and the extra crawler bonus of collectors) and the lifeform bonuses are not taken into account,
+// an espionage report does not show the officers nor the crawlers production setting.
+func ClassProductionBonus(characterClass CharacterClass, resBuildings ResourcesBuildings, universeSpeed int64, temp Temperature, globalRatio float64) Resources {
+	if !characterClass.IsCollector() {
+		return Resources{}
+	}
+	const collectorBonus = 0.25
+	bonus := func(levelProduction, basicIncome int64) int64 {
+		return int64(float64(levelProduction-basicIncome) * collectorBonus)
+	}
+	return Resources{
+		Metal: bonus(MetalMine.Production(universeSpeed, 1, globalRatio, 0, resBuildings.MetalMine),
+			MetalMine.Production(universeSpeed, 1, globalRatio, 0, 0)),
+		Crystal: bonus(CrystalMine.Production(universeSpeed, 1, globalRatio, 0, resBuildings.CrystalMine),
+			CrystalMine.Production(universeSpeed, 1, globalRatio, 0, 0)),
+		Deuterium: bonus(DeuteriumSynthesizer.Production(universeSpeed, temp.Mean(), 1, globalRatio, 0, resBuildings.DeuteriumSynthesizer),
+			DeuteriumSynthesizer.Production(universeSpeed, temp.Mean(), 1, globalRatio, 0, 0)), // No basic income, always 0
+	}
+}
@@ -0,0 +1,35 @@
+package ogame
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductionRatio(t *testing.T) {
+	ratio := ProductionRatio(
+		Temperature{Min: -23, Max: 17},
+		ResourcesBuildings{MetalMine: 29, CrystalMine: 16, DeuteriumSynthesizer: 26, SolarPlant: 29, FusionReactor: 13, SolarSatellite: 51},
+		ResourceSettings{MetalMine: 100, CrystalMine: 100, DeuteriumSynthesizer: 100, SolarPlant: 100, FusionReactor: 100, SolarSatellite: 100},
+		12,
+	)
+	assert.Equal(t, 1.0, ratio)
+}
+
+func TestEnergyNeeded(t *testing.T) {
+	needed := EnergyNeeded(
+		ResourcesBuildings{MetalMine: 29, CrystalMine: 16, DeuteriumSynthesizer: 26},
+		ResourceSettings{MetalMine: 100, CrystalMine: 100, DeuteriumSynthesizer: 100},
+	)
+	assert.Equal(t, int64(4601+736+6198), needed)
+}
+
+func TestEnergyProduced(t *testing.T) {
+	produced := EnergyProduced(
+		Temperature{Min: -23, Max: 17},
+		ResourcesBuildings{SolarPlant: 29, FusionReactor: 13, SolarSatellite: 51},
+		ResourceSettings{SolarPlant: 100, FusionReactor: 100, SolarSatellite: 100},
+		12,
+	)
+	assert.Equal(t, int64(9200+3002+1326), produced)
+}
@@ -0,0 +1,73 @@
+package ogame
+
+import (
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+// Highest metal mine level considered when guessing buildings from points
+const maxGuessedMineLevel = 60
+
+// GuessResourcesBuildings guesses the resources buildings of a planet from the points spent on it.
+// It assumes a typical development where the crystal mine is 2 levels behind the metal mine,
+// the deuterium synthesizer 4 levels behind, and the solar plant high enough to power the mines.
+func GuessResourcesBuildings(points int64) ResourcesBuildings {
+	var guess ResourcesBuildings
+	for lvl := int64(1); lvl <= maxGuessedMineLevel; lvl++ {
+		next := guessedResourcesBuildings(lvl)
+		if resourcesBuildingsCost(next).Total() > points*1000 {
+			break
+		}
+		guess = next
+	}
+	return guess
+}
+
+func guessedResourcesBuildings(metalMineLevel int64) ResourcesBuildings {
+	b := ResourcesBuildings{
+		MetalMine:            metalMineLevel,
+		CrystalMine:          utils.MaxInt(metalMineLevel-2, 0),
+		DeuteriumSynthesizer: utils.MaxInt(metalMineLevel-4, 0),
+		MetalStorage:         metalMineLevel / 4,
+		CrystalStorage:       metalMineLevel / 5,
+		DeuteriumTank:        metalMineLevel / 6,
+	}
+	resSettings := ResourceSettings{MetalMine: 100, CrystalMine: 100, DeuteriumSynthesizer: 100, SolarPlant: 100}
+	for EnergyProduced(Temperature{}, b, resSettings, 0) < EnergyNeeded(b, resSettings) {
+		b.SolarPlant++
+	}
+	return b
+}
+
+// Total price of all the levels of the resources buildings
+func resourcesBuildingsCost(b ResourcesBuildings) Resources {
+	var cost Resources
+	for _, building := range []Building{MetalMine, CrystalMine, DeuteriumSynthesizer, SolarPlant, MetalStorage, CrystalStorage, DeuteriumTank} {
+		for lvl := int64(1); lvl <= b.ByID(building.GetID()); lvl++ {
+			cost = cost.Add(building.GetPrice(lvl, LfBonuses{}))
+		}
+	}
+	return cost
+}
+
+// ProjectedResources estimates the resources of the spied planet at a given time, typically the arrival time of a fleet.
+// The temperature of the planet is deduced from its position. When the report has no buildings information,
+// the mines levels are guessed from points, the economy points the player spent on that planet (0 if unknown).
+// The production bonuses are approximated, see ClassProductionBonus, so the projection is a lower bound.
+func (r EspionageReport) ProjectedResources(at time.Time, universeSpeed, points int64) Resources {
+	temp := TemperatureFromPosition(r.Coordinate.Position)
+	if resBuildings := r.ResourcesBuildings(); resBuildings != nil {
+		return r.resourcesAt(at, *resBuildings, universeSpeed, temp)
+	}
+	if points <= 0 {
+		return r.Resources
+	}
+	return r.resourcesAt(at, GuessResourcesBuildings(points), universeSpeed, temp)
+}
+
+// ProjectedLoot returns the possible loot at a given time, see ProjectedResources
+func (r EspionageReport) ProjectedLoot(at time.Time, universeSpeed, points int64, characterClass CharacterClass) Resources {
+	r.Resources = r.ProjectedResources(at, universeSpeed, points)
+	return r.Loot(characterClass)
+}
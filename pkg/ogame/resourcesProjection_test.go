@@ -0,0 +1,69 @@
+package ogame
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemperatureFromPosition(t *testing.T) {
+	assert.Equal(t, Temperature{Min: 200, Max: 240}, TemperatureFromPosition(1))
+	assert.Equal(t, Temperature{Min: 10, Max: 50}, TemperatureFromPosition(8))
+	assert.Equal(t, Temperature{Min: -150, Max: -110}, TemperatureFromPosition(15))
+	assert.Equal(t, Temperature{}, TemperatureFromPosition(16))
+}
+
+func TestGuessResourcesBuildings(t *testing.T) {
+	assert.Equal(t, ResourcesBuildings{}, GuessResourcesBuildings(0))
+	guess := GuessResourcesBuildings(1000)
+	assert.Equal(t, guess.MetalMine-2, guess.CrystalMine)
+	assert.Equal(t, guess.MetalMine-4, guess.DeuteriumSynthesizer)
+	assert.LessOrEqual(t, resourcesBuildingsCost(guess).Total(), int64(1000*1000))
+	assert.Greater(t, resourcesBuildingsCost(guessedResourcesBuildings(guess.MetalMine+1)).Total(), int64(1000*1000))
+	assert.GreaterOrEqual(t, ProductionRatio(Temperature{}, guess, ResourceSettings{MetalMine: 100, CrystalMine: 100, DeuteriumSynthesizer: 100, SolarPlant: 100}, 0), 1.0)
+	assert.Greater(t, GuessResourcesBuildings(10000).MetalMine, guess.MetalMine)
+}
+
+func TestClassProductionBonus(t *testing.T) {
+	resBuildings := ResourcesBuildings{MetalMine: 20, CrystalMine: 15, DeuteriumSynthesizer: 10}
+	temp := Temperature{Min: 10, Max: 50}
+	assert.Equal(t, Resources{}, ClassProductionBonus(General, resBuildings, 1, temp, 1))
+	bonus := ClassProductionBonus(Collector, resBuildings, 1, temp, 1)
+	assert.Equal(t, int64(float64(MetalMine.Production(1, 1, 1, 0, 20)-30)*0.25), bonus.Metal)
+	assert.Equal(t, int64(float64(CrystalMine.Production(1, 1, 1, 0, 15)-15)*0.25), bonus.Crystal)
+	assert.Equal(t, int64(float64(DeuteriumSynthesizer.Production(1, 30, 1, 1, 0, 10))*0.25), bonus.Deuterium)
+}
+
+func TestEspionageReport_ProjectedResources(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	report := EspionageReport{
+		Date:                    now,
+		Coordinate:              Coordinate{Galaxy: 1, System: 1, Position: 8, Type: PlanetType},
+		Resources:               Resources{Metal: 1000, Crystal: 1000, Deuterium: 1000},
+		HasBuildingsInformation: true,
+		MetalMine:               utils.I64Ptr(20),
+		CrystalMine:             utils.I64Ptr(15),
+		DeuteriumSynthesizer:    utils.I64Ptr(10),
+		SolarPlant:              utils.I64Ptr(30),
+		IsInactive:              true,
+	}
+	arrival := now.Add(time.Hour)
+	temp := TemperatureFromPosition(8)
+	assert.Equal(t, report.ResourcesAt(arrival, 1, temp), report.ProjectedResources(arrival, 1, 0))
+	loot := report.ProjectedLoot(arrival, 1, 0, NoClass)
+	assert.Equal(t, (1000+MetalMine.Production(1, 1, 1, 0, 20))/2, loot.Metal)
+
+	// Collector class bonus
+	collector := report
+	collector.CharacterClass = Collector
+	assert.Greater(t, collector.ProjectedResources(arrival, 1, 0).Metal, report.ProjectedResources(arrival, 1, 0).Metal)
+
+	// Mines guessed from points
+	report.HasBuildingsInformation = false
+	assert.Equal(t, report.Resources, report.ProjectedResources(arrival, 1, 0))
+	projected := report.ProjectedResources(arrival, 1, 1000)
+	guess := GuessResourcesBuildings(1000)
+	assert.Equal(t, 1000+MetalMine.Production(1, 1, 1, 0, guess.MetalMine), projected.Metal)
+}
@@ -12,3 +12,17 @@ type Temperature struct {
 func (t Temperature) Mean() int64 {
 	return int64(math.Round(float64(t.Min+t.Max) / 2))
 }
+
+// Middle of the max temperature range of a planet for each position (1-15)
+var maxTemperatureByPosition = [...]int64{240, 190, 140, 90, 80, 70, 60, 50, 40, 30, 20, 10, -30, -70, -110}
+
+// TemperatureFromPosition returns the expected temperature of a planet at the given position.
+// The temperature of a planet is random within a range that only depends on its position,
+// the middle of that range is returned. Returns a zero Temperature for an invalid position.
+func TemperatureFromPosition(position int64) Temperature {
+	if position < 1 || position > int64(len(maxTemperatureByPosition)) {
+		return Temperature{}
+	}
+	maxTemp := maxTemperatureByPosition[position-1]
+	return Temperature{Min: maxTemp - 40, Max: maxTemp}
+}
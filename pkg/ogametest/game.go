@@ -0,0 +1,257 @@
+package ogametest
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+const sessionCookieName = "PHPSESSID"
+
+// Pages rendered from a fixture
+var fullPages = []string{"overview", "supplies", "facilities", "research", "shipyard", "defenses", "fleetdispatch", "movement", "lfbonuses", "preferences"}
+
+// Serves /game/index.php, the server is locked for the whole request
+func (s *Server) gameHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maintenance {
+		writeMaintenance(w)
+		return
+	}
+	now := s.cfg.Now()
+	s.state.Update(now)
+
+	sess := s.getSession(r)
+	if sess == nil {
+		// Logged out, the wrapper detects it with the missing ogame-session meta
+		if r.Header.Get("X-Requested-With") == "XMLHttpRequest" {
+			writeJSON(w, http.StatusOK, map[string]any{"status": "failure", "errors": []ajaxError{{Message: "Not logged in", Code: 1}}})
+			return
+		}
+		writeHTML(w, []byte(`<!DOCTYPE html><html><head><title>OGame</title></head><body id="login"></body></html>`))
+		return
+	}
+
+	_ = r.ParseForm()
+	vals := r.URL.Query()
+	if cp := ogame.PlanetID(utils.DoParseI64(vals.Get("cp"))); cp != 0 && s.state.Planet(cp) != nil {
+		sess.planetID = cp
+	}
+	if s.state.Planet(sess.planetID) == nil {
+		sess.planetID = s.state.Planets[0].ID
+	}
+
+	page := vals.Get("page")
+	component := vals.Get("component")
+	switch {
+	case page == "logout":
+		s.deleteSession(r)
+		http.Redirect(w, r, "https://lobby.ogame.gameforge.com/", http.StatusFound)
+	case page == "fetchResources":
+		writeJSON(w, http.StatusOK, s.fetchResourcesJSON(sess))
+	case page == "fetchTechs":
+		writeJSON(w, http.StatusOK, s.state.techs(s.state.Planet(sess.planetID)))
+	case page == "componentOnly" && component == "buildlistactions" && vals.Get("action") == "scheduleEntry":
+		s.scheduleEntryHandler(w, r, sess)
+	case page == "ingame" && component == "fleetdispatch" && vals.Get("action") == "checkTarget":
+		s.checkTargetHandler(w, r, sess)
+	case page == "ingame" && component == "fleetdispatch" && vals.Get("action") == "sendFleet":
+		s.sendFleetHandler(w, r, sess)
+	case page == "ingame" && component == "movement" && vals.Get("return") != "":
+		s.recallFleetHandler(w, r, sess)
+	case page == "ingame" && utils.InArr(component, fullPages):
+		pageHTML, err := s.render(component, sess, now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeHTML(w, pageHTML)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) getSession(r *http.Request) *session {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return nil
+	}
+	return s.sessions[cookie.Value]
+}
+
+func (s *Server) deleteSession(r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		delete(s.sessions, cookie.Value)
+	}
+}
+
+// Validates the token of an action, and rotates it. The new token is sent back as newAjaxToken.
+func (s *Server) checkToken(sess *session, token string) bool {
+	if token != sess.token {
+		return false
+	}
+	sess.token = randomToken()
+	return true
+}
+
+var errInvalidToken = ajaxError{Message: "Invalid token", Code: 140024}
+
+func (s *Server) scheduleEntryHandler(w http.ResponseWriter, r *http.Request, sess *session) {
+	if !s.checkToken(sess, r.PostForm.Get("token")) {
+		writeAjaxFailure(w, sess, errInvalidToken)
+		return
+	}
+	planetID := ogame.PlanetID(utils.DoParseI64(r.PostForm.Get("planetId")))
+	id := ogame.ID(utils.DoParseI64(r.PostForm.Get("technologyId")))
+	amount := utils.DoParseI64(r.PostForm.Get("amount"))
+	if _, err := s.state.Schedule(planetID, id, amount, s.serverData.Speed, s.cfg.Now()); err != nil {
+		writeAjaxFailure(w, sess, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status": "success", "errors": []ajaxError{}, "components": []any{}, "newAjaxToken": sess.token})
+}
+
+func (s *Server) checkTargetHandler(w http.ResponseWriter, r *http.Request, sess *session) {
+	if !s.checkToken(sess, r.PostForm.Get("token")) {
+		writeJSON(w, http.StatusOK, map[string]any{"status": "failure", "targetOk": false, "errors": []ajaxError{errInvalidToken}, "newAjaxToken": sess.token})
+		return
+	}
+	order := parseFleetOrder(r.PostForm, sess)
+	if err := s.checkTarget(order); err != nil {
+		writeJSON(w, http.StatusOK, map[string]any{"status": "failure", "targetOk": false, "errors": []ajaxError{toAjaxError(err)}, "newAjaxToken": sess.token})
+		return
+	}
+	dest := s.state.PlanetAt(order.Destination)
+	destName := ""
+	if dest != nil {
+		destName = dest.Name
+	}
+	orders := map[string]bool{"1": dest == nil, "3": true, "4": dest != nil, "6": dest == nil, "15": order.Destination.Position == 16}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":          "success",
+		"targetOk":        true,
+		"orders":          orders,
+		"targetInhabited": dest != nil,
+		"targetPlanet": map[string]any{
+			"galaxy": order.Destination.Galaxy, "system": order.Destination.System, "position": order.Destination.Position,
+			"type": order.Destination.Type, "name": destName,
+		},
+		"errors":       []ajaxError{},
+		"components":   []any{},
+		"newAjaxToken": sess.token,
+	})
+}
+
+func (s *Server) sendFleetHandler(w http.ResponseWriter, r *http.Request, sess *session) {
+	if !s.checkToken(sess, r.PostForm.Get("token")) {
+		writeJSON(w, http.StatusOK, map[string]any{"success": false, "errors": []ajaxError{{Message: "Fleet launch failure: The fleet could not be launched. Please try again later.", Code: 4047}},
+			"fleetSendingToken": sess.token, "components": []any{}, "newAjaxToken": sess.token})
+		return
+	}
+	order := parseFleetOrder(r.PostForm, sess)
+	err := s.checkTarget(order)
+	if err == nil {
+		_, err = s.state.SendFleet(order, s.serverData, s.cfg.Now())
+	}
+	if err != nil {
+		writeJSON(w, http.StatusOK, map[string]any{"success": false, "errors": []ajaxError{toAjaxError(err)},
+			"fleetSendingToken": sess.token, "components": []any{}, "newAjaxToken": sess.token})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "message": "Your fleet has been successfully sent.",
+		"redirectUrl": s.gameURL() + "/game/index.php?page=ingame&component=fleetdispatch", "components": []any{}, "newAjaxToken": sess.token})
+}
+
+// Recalls a fleet then renders the movement page, like the game does
+func (s *Server) recallFleetHandler(w http.ResponseWriter, r *http.Request, sess *session) {
+	vals := r.URL.Query()
+	if s.checkToken(sess, vals.Get("token")) {
+		_ = s.state.RecallFleet(ogame.FleetID(utils.DoParseI64(vals.Get("return"))), s.cfg.Now())
+	}
+	pageHTML, err := s.render("movement", sess, s.cfg.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeHTML(w, pageHTML)
+}
+
+// Only the missions the state knows how to resolve are accepted
+func (s *Server) checkTarget(order FleetOrder) error {
+	dest := order.Destination
+	if dest.Galaxy < 1 || dest.Galaxy > s.serverData.Galaxies || dest.System < 1 || dest.System > s.serverData.Systems ||
+		dest.Position < 1 || dest.Position > 16 || dest.Type != ogame.PlanetType {
+		return errInvalidTarget
+	}
+	origin := s.state.Planet(order.Origin)
+	if origin == nil || origin.Coordinate.Equal(dest) {
+		return errInvalidTarget
+	}
+	switch order.Mission {
+	case ogame.Transport, ogame.Park:
+		if s.state.PlanetAt(dest) == nil {
+			return errInvalidTarget
+		}
+	case ogame.Expedition:
+		if dest.Position != 16 {
+			return errInvalidTarget
+		}
+	case 0:
+	default:
+		return errInvalidTarget
+	}
+	return nil
+}
+
+func parseFleetOrder(form url.Values, sess *session) FleetOrder {
+	order := FleetOrder{
+		Origin: sess.planetID,
+		Destination: ogame.Coordinate{
+			Galaxy:   utils.DoParseI64(form.Get("galaxy")),
+			System:   utils.DoParseI64(form.Get("system")),
+			Position: utils.DoParseI64(form.Get("position")),
+			Type:     ogame.CelestialType(utils.DoParseI64(form.Get("type"))),
+		},
+		Mission: ogame.MissionID(utils.DoParseI64(form.Get("mission"))),
+		Speed:   utils.DoParseI64(form.Get("speed")),
+		Resources: ogame.Resources{
+			Metal:     utils.DoParseI64(form.Get("metal")),
+			Crystal:   utils.DoParseI64(form.Get("crystal")),
+			Deuterium: utils.DoParseI64(form.Get("deuterium")),
+		},
+	}
+	for key := range form {
+		if strings.HasPrefix(key, "am") {
+			order.Ships.Set(ogame.ID(utils.DoParseI64(strings.TrimPrefix(key, "am"))), utils.DoParseI64(form.Get(key)))
+		}
+	}
+	return order
+}
+
+func toAjaxError(err error) ajaxError {
+	var ajaxErr ajaxError
+	if errors.As(err, &ajaxErr) {
+		return ajaxErr
+	}
+	return ajaxError{Message: err.Error(), Code: 1}
+}
+
+func writeAjaxFailure(w http.ResponseWriter, sess *session, err error) {
+	writeJSON(w, http.StatusOK, map[string]any{"status": "failure", "errors": []ajaxError{toAjaxError(err)}, "components": []any{}, "newAjaxToken": sess.token})
+}
+
+func writeMaintenance(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = w.Write([]byte(`<!DOCTYPE html><html><head><title>OGame - Maintenance</title></head><body>The universe is in maintenance.</body></html>`))
+}
+
+func writeHTML(w http.ResponseWriter, pageHTML []byte) {
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	_, _ = w.Write(pageHTML)
+}
@@ -0,0 +1,163 @@
+package ogametest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strings"
+
+	"github.com/alaingilbert/ogame/pkg/gameforge"
+)
+
+// Used by the device to compute the blackbox, only the Date header matters
+func (s *Server) game1Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	_, _ = w.Write([]byte("/* game1 */"))
+}
+
+func (s *Server) configurationHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	_, _ = w.Write([]byte(`window.configuration = {"gameEnvironmentId":"0a31d605-ffaf-43e7-aa02-d06df7116fc8","platformGameId":"1dfd8e7e-6e1a-4eb1-8c64-03c3b62efd2f"};`))
+}
+
+// Gameforge login with email/password, returns the bearer token
+func (s *Server) postSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var payload struct {
+		Identity string `json:"identity"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if payload.Identity != s.cfg.Username || payload.Password != s.cfg.Password {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"reason":"INVALID_CREDENTIALS"}`))
+		return
+	}
+	token := randomToken()
+	s.mu.Lock()
+	s.bearerTokens[token] = true
+	s.mu.Unlock()
+	writeJSON(w, http.StatusCreated, gameforge.GFLoginRes{Token: token, IsPlatformLogin: true, IsGameAccountMigrated: true})
+}
+
+func (s *Server) checkBearer(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bearerTokens[token]
+}
+
+func (s *Server) accountsHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.checkBearer(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "not authorized"})
+		return
+	}
+	s.mu.Lock()
+	account := gameforge.Account{ID: s.state.PlayerID, Name: s.state.PlayerName}
+	s.mu.Unlock()
+	account.Server.Language = s.cfg.Lang
+	account.Server.Number = s.cfg.ServerNumber
+	writeJSON(w, http.StatusOK, []gameforge.Account{account})
+}
+
+func (s *Server) serversHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	server := gameforge.Server{
+		Language:      s.cfg.Lang,
+		Number:        s.cfg.ServerNumber,
+		AccountGroup:  s.cfg.Lang + "_1",
+		Name:          s.cfg.Universe,
+		PlayerCount:   1,
+		PlayersOnline: 1,
+	}
+	server.Settings.AKS = 1
+	server.Settings.FleetSpeedWar = s.serverData.SpeedFleetWar
+	server.Settings.FleetSpeedPeaceful = s.serverData.SpeedFleetPeaceful
+	server.Settings.FleetSpeedHolding = s.serverData.SpeedFleetHolding
+	server.Settings.EconomySpeed = s.serverData.Speed
+	server.Settings.UniverseSize = s.serverData.Galaxies
+	if s.maintenance {
+		server.ServerClosed = 1
+	}
+	writeJSON(w, http.StatusOK, []gameforge.Server{server})
+}
+
+// Returns a one-time link that opens a game session
+func (s *Server) loginLinkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !s.checkBearer(r) {
+		writeJSON(w, http.StatusBadRequest, []string{})
+		return
+	}
+	token := randomToken()
+	s.mu.Lock()
+	s.loginTokens[token] = true
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]string{"url": s.gameURL() + "/game/lobbylogin.php?id=1&token=" + token})
+}
+
+// Each code can be redeemed once
+func (s *Server) redeemCodeHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Token string `json:"token"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&payload)
+	s.mu.Lock()
+	redeemed := s.redeemedCodes[payload.Token]
+	s.redeemedCodes[payload.Token] = true
+	s.mu.Unlock()
+	if !s.checkBearer(r) || payload.Token == "" || redeemed {
+		writeJSON(w, http.StatusBadRequest, []string{})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"tokenType": "accountTrading"})
+}
+
+func (s *Server) serverDataHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	by, _ := xml.Marshal(struct {
+		XMLName xml.Name `xml:"serverData"`
+		gameforge.ServerData
+	}{ServerData: s.serverData})
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write(by)
+}
+
+// Exchanges the one-time login link token for a game session cookie, then redirects to the overview
+func (s *Server) lobbyLoginHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	s.mu.Lock()
+	if s.maintenance {
+		s.mu.Unlock()
+		writeMaintenance(w)
+		return
+	}
+	valid := s.loginTokens[token]
+	delete(s.loginTokens, token)
+	var sessionID string
+	if valid {
+		sessionID = randomToken()
+		s.sessions[sessionID] = &session{ogameSession: randomToken() + randomToken()[:8], token: randomToken(), planetID: s.state.Planets[0].ID}
+	}
+	s.mu.Unlock()
+	if !valid {
+		http.Redirect(w, r, "https://lobby.ogame.gameforge.com/", http.StatusFound)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookieName, Value: sessionID, Path: "/", HttpOnly: true})
+	http.Redirect(w, r, s.gameURL()+"/game/index.php?page=ingame&component=overview", http.StatusFound)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	by, _ := json.Marshal(v)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(by)
+}
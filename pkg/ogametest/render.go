@@ -0,0 +1,415 @@
+package ogametest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/alaingilbert/ogame/pkg/extractor/v11_15_0"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+// Fixture used to render each page, relative to the samples directory
+var fixtureFiles = map[string]string{
+	"overview":      "v11.13.0/en/overview.html",
+	"movement":      "v11.13.0/en/movement.html",
+	"shipyard":      "v11.13.0/en/shipyard.html",
+	"fleetdispatch": "v11.15.8/en/fleetdispatch_acs.html",
+	"lfbonuses":     "v11.15.4/en/lfbonuses.html",
+	"supplies":      "v7/supplies.html",
+	"facilities":    "v7/facilities.html",
+	"research":      "v7/researches.html",
+	"defenses":      "v7/defenses.html",
+	"preferences":   "unversioned/preferences.html",
+}
+
+func defaultSamplesDir() string {
+	_, filename, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(filename), "..", "..", "samples")
+}
+
+// Lazily loaded fixtures, shared by every request
+type fixtures struct {
+	dir   string
+	mu    sync.Mutex
+	cache map[string][]byte
+}
+
+func newFixtures(dir string) *fixtures {
+	return &fixtures{dir: dir, cache: make(map[string][]byte)}
+}
+
+func (f *fixtures) get(page string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if by, ok := f.cache[page]; ok {
+		return by, nil
+	}
+	file, ok := fixtureFiles[page]
+	if !ok {
+		return nil, fmt.Errorf("no fixture for page %s", page)
+	}
+	by, err := os.ReadFile(filepath.Join(f.dir, file))
+	if err != nil {
+		return nil, err
+	}
+	f.cache[page] = by
+	return by, nil
+}
+
+// Bonuses of the lfbonuses fixture, so the server and the wrapper compute the same cargo, prices and durations
+func (f *fixtures) lfBonuses() (ogame.LfBonuses, error) {
+	by, err := f.get("lfbonuses")
+	if err != nil {
+		return ogame.LfBonuses{}, err
+	}
+	return v11_15_0.NewExtractor().ExtractLfBonuses(by)
+}
+
+var (
+	tokenRgx         = regexp.MustCompile(`var token = "[^"]*";?`)
+	nodeURLRgx       = regexp.MustCompile(`var nodeUrl\s?=\s?"[^"]*";?`)
+	shipsOnPlanetRgx = regexp.MustCompile(`var shipsOnPlanet = [^;]+;`)
+	textContentRgx   = regexp.MustCompile(`textContent\[(\d)] = "[^\n]*";`)
+)
+
+// Renders a full page of the current planet of the session, from its fixture patched with the state
+func (s *Server) render(page string, sess *session, now time.Time) ([]byte, error) {
+	by, err := s.fixtures.get(page)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(by))
+	if err != nil {
+		return nil, err
+	}
+	planet := s.state.Planet(sess.planetID)
+	s.patchCommon(doc, sess, planet, now)
+	switch page {
+	case "overview":
+		s.patchOverview(doc, planet, now)
+	case "supplies", "facilities", "research", "shipyard", "defenses":
+		s.patchTechnologies(doc, planet)
+	case "fleetdispatch":
+		s.patchFleetdispatch(doc)
+	case "movement":
+		s.patchMovement(doc, sess, now)
+	}
+	pageHTML, err := goquery.OuterHtml(doc.Selection)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s.patchScripts(pageHTML, page, sess, planet)), nil
+}
+
+// Metas, clock, planets list and character class, present on every full page
+func (s *Server) patchCommon(doc *goquery.Document, sess *session, planet *Planet, now time.Time) {
+	metas := map[string]string{
+		"ogame-session":            sess.ogameSession,
+		"ogame-version":            s.serverData.Version,
+		"ogame-timestamp":          utils.FI64(now.Unix()),
+		"ogame-universe":           s.gameHost(),
+		"ogame-universe-name":      s.cfg.Universe,
+		"ogame-universe-speed":     utils.FI64(s.serverData.Speed),
+		"ogame-language":           s.cfg.Lang,
+		"ogame-player-id":          utils.FI64(s.state.PlayerID),
+		"ogame-player-name":        s.state.PlayerName,
+		"ogame-planet-id":          utils.FI64(planet.ID),
+		"ogame-planet-name":        planet.Name,
+		"ogame-planet-coordinates": fmt.Sprintf("%d:%d:%d", planet.Coordinate.Galaxy, planet.Coordinate.System, planet.Coordinate.Position),
+		"ogame-planet-type":        "planet",
+	}
+	for name, content := range metas {
+		doc.Find(`meta[name="`+name+`"]`).SetAttr("content", content)
+	}
+	doc.Find(`meta[name^="ogame-alliance"]`).Remove()
+
+	doc.Find("li.OGameClock").SetHtml(now.UTC().Format("02.01.2006") + " <span>" + now.UTC().Format("15:04:05") + "</span>")
+
+	var planetList strings.Builder
+	for _, p := range s.state.Planets {
+		active := p.ID == planet.ID
+		coord := fmt.Sprintf("[%d:%d:%d]", p.Coordinate.Galaxy, p.Coordinate.System, p.Coordinate.Position)
+		title := fmt.Sprintf("<b>%s %s</b><br/>%skm (%d/%d)<br>%d°C to %d°C<br/>", p.Name, coord, humanizeInt(p.Diameter),
+			p.Fields.Built, p.Fields.Total, p.Temperature.Min, p.Temperature.Max)
+		link := s.gameURL() + "/game/index.php?page=ingame&component=overview&cp=" + utils.FI64(p.ID)
+		fmt.Fprintf(&planetList, `<div class="smallplanet smaller %s" id="planet-%d">`+
+			`<a href="%s" data-link="%s" title="%s" class="planetlink %s tooltipRight tooltipClose js_hideTipOnMobile">`+
+			`<img class="planetPic js_replace2x" alt="%s" src="https://gf2.geo.gfsrv.net/cdnd7/c1d7ff5df61fe7f5279047f786320b.png" width="30" height="30"/>`+
+			`<span class="planet-name ">%s</span><span class="planet-koords ">%s</span></a></div>`,
+			utils.Ternary(active, "hightlightPlanet", ""), p.ID, html.EscapeString(link), html.EscapeString(link), html.EscapeString(title),
+			utils.Ternary(active, "active", ""), html.EscapeString(p.Name), html.EscapeString(p.Name), coord)
+	}
+	doc.Find("div#planetList").SetHtml(planetList.String())
+
+	classDiv := doc.Find("div#characterclass a div")
+	classDiv.RemoveClass("miner", "warrior", "explorer", "none")
+	switch s.state.CharacterClass {
+	case ogame.Collector:
+		classDiv.AddClass("miner")
+	case ogame.General:
+		classDiv.AddClass("warrior")
+	case ogame.Discoverer:
+		classDiv.AddClass("explorer")
+	default:
+		classDiv.AddClass("none")
+	}
+}
+
+// Constructions boxes of the overview, lifeforms boxes are always idle
+func (s *Server) patchOverview(doc *goquery.Document, planet *Planet, now time.Time) {
+	var building, research, shipyard *QueueItem
+	var shipyardEnd time.Time
+	for _, item := range s.state.Queue {
+		switch {
+		case item.ID.IsTech():
+			research = item
+		case item.PlanetID != planet.ID:
+		case item.ID.IsShip() || item.ID.IsDefense():
+			if shipyard == nil {
+				shipyard = item
+			}
+			shipyardEnd = item.FinishAt
+		default:
+			building = item
+		}
+	}
+	idle := func(msg string) string {
+		return `<table cellspacing="0" cellpadding="0" class="construction active"><tbody><tr><td colspan="2" class="idle">` + msg + `</td></tr></tbody></table>`
+	}
+	active := func(item *QueueItem, countdownClass, cancelFn string) string {
+		name := html.EscapeString(ogame.Objs.ByID(item.ID).GetName())
+		return fmt.Sprintf(`<table cellspacing="0" cellpadding="0" class="construction active"><tbody>`+
+			`<tr><th colspan="2">%s</th></tr>`+
+			`<tr class="data"><td class="first" rowspan="3"><div><a href="javascript:void(0);" class="tooltip js_hideTipOnMobile abortNow" onclick="%s(%d, %d, null); return false;"></a></div></td>`+
+			`<td class="desc ausbau">Improve to <span class="level">Level %d</span></td></tr>`+
+			`<tr class="data"><td class="desc">Duration:</td></tr>`+
+			`<tr class="data"><td class="desc timer"><time class="countdown %s" data-start="%d" data-end="%d" data-segments="2"></time></td></tr>`+
+			`</tbody></table>`, name, cancelFn, item.ID, item.ListID, item.Nbr, countdownClass, item.StartAt.Unix(), item.FinishAt.Unix())
+	}
+	content := func(component string) *goquery.Selection {
+		return doc.Find("div#productionbox" + component + "component div.content").First()
+	}
+	if building != nil {
+		content("building").SetHtml(active(building, "buildingCountdown", "cancelbuilding"))
+	} else {
+		content("building").SetHtml(idle("No buildings in construction."))
+	}
+	if research != nil {
+		content("research").SetHtml(active(research, "researchCountdown", "cancelresearch"))
+	} else {
+		content("research").SetHtml(idle("There is no research in progress at the moment."))
+	}
+	content("lfbuilding").SetHtml(idle("No buildings in construction."))
+	content("lfresearch").SetHtml(idle("There is no research in progress at the moment."))
+	if shipyard != nil {
+		content("shipyard").SetHtml(fmt.Sprintf(`<table cellspacing="0" cellpadding="0" class="construction active"><tbody>`+
+			`<tr><th colspan="2">%s</th></tr><tr class="data"><td class="first"><div class="shipSumCount">%d</div></td></tr>`+
+			`<tr class="data"><td class="desc timer"><span id="shipyardCountdown"></span></td></tr></tbody></table>`+
+			`<script type="text/javascript">new CountdownTimer('shipyardCountdown', %d, '', null, true, 3)</script>`,
+			html.EscapeString(ogame.Objs.ByID(shipyard.ID).GetName()), shipyard.Nbr, utils.MaxInt(int64(shipyardEnd.Sub(now).Seconds()), 0)))
+	} else {
+		content("shipyard").SetHtml(idle("No ships/defence in construction."))
+	}
+}
+
+// Levels of the buildings/researches and amounts of ships/defenses of the page
+func (s *Server) patchTechnologies(doc *goquery.Document, planet *Planet) {
+	techs := s.state.techs(planet)
+	doc.Find("li.technology[data-technology]").Each(func(i int, li *goquery.Selection) {
+		value := utils.FI64(techs[ogame.ID(utils.DoParseI64(li.AttrOr("data-technology", "")))])
+		li.Find("span.level, span.amount").Each(func(i int, span *goquery.Selection) {
+			span.SetAttr("data-value", value)
+			if child := span.Children().First(); child.Length() > 0 {
+				child.SetText(value)
+			} else {
+				span.SetText(value)
+			}
+		})
+	})
+}
+
+func (s *Server) patchFleetdispatch(doc *goquery.Document) {
+	slots := s.state.Slots()
+	divs := doc.Find("div#slots").First().Children()
+	divs.Eq(0).Find("span.advice").SetHtml(fmt.Sprintf("<span>Fleets:</span> %d/%d", slots.InUse, slots.Total))
+	divs.Eq(1).Find("span.advice").SetHtml(fmt.Sprintf("<span>Expeditions:</span> %d/%d", slots.ExpInUse, slots.ExpTotal))
+}
+
+// Replaces the fleets of the fixture with the fleets of the state
+func (s *Server) patchMovement(doc *goquery.Document, sess *session, now time.Time) {
+	slots := s.state.Slots()
+	doc.Find("span.fleetSlots span.current").SetText(utils.FI64(slots.InUse))
+	doc.Find("span.fleetSlots span.all").SetText(utils.FI64(slots.Total))
+	doc.Find("span.expSlots span.current").SetText(utils.FI64(slots.ExpInUse))
+	doc.Find("span.expSlots span.all").SetText(utils.FI64(slots.ExpTotal))
+	doc.Find("div.fleetDetails").Remove()
+	var fleets strings.Builder
+	for _, f := range s.state.Fleets {
+		fleets.WriteString(s.renderFleet(f, sess, now))
+	}
+	doc.Find("div.fleetStatus").AfterHtml(fleets.String())
+}
+
+func (s *Server) renderFleet(f *Fleet, sess *session, now time.Time) string {
+	nextTime := f.BackTime
+	if f.ReturnFlight {
+		nextTime = time.Time{}
+	}
+	arriveIn := utils.MaxInt(int64(f.ArrivalTime.Sub(now).Seconds()), 0)
+	if f.ReturnFlight {
+		arriveIn = utils.MaxInt(int64(f.BackTime.Sub(now).Seconds()), 0)
+	}
+	backIn := utils.MaxInt(int64(nextTime.Sub(now).Seconds()), 0)
+	coord := func(c ogame.Coordinate) string {
+		return fmt.Sprintf("[%d:%d:%d]", c.Galaxy, c.System, c.Position)
+	}
+	startTime := func() string {
+		return "Start time:| " + f.StartTime.UTC().Format("02.01.2006") + "<br>" + f.StartTime.UTC().Format("15:04:05")
+	}
+	originTitle, destinationTitle := startTime(), "Time of arrival:| "
+	if f.ReturnFlight {
+		originTitle, destinationTitle = "Time of arrival:| ", startTime()
+	}
+	var ships strings.Builder
+	f.Ships.Each(func(shipID ogame.ID, nb int64) {
+		fmt.Fprintf(&ships, `<tr><td>%s:</td><td class="value">%d</td></tr>`, html.EscapeString(ogame.Objs.ByID(shipID).GetName()), nb)
+	})
+	var recall string
+	if !f.ReturnFlight {
+		recallURL := s.gameURL() + "/game/index.php?page=ingame&component=movement&return=" + utils.FI64(f.ID) + "&token=" + sess.token
+		recall = `<span class="reversal reversal_time" ref="` + utils.FI64(f.ID) + `"><a class="icon_link tooltipHTML" href="` + html.EscapeString(recallURL) + `"></a></span>`
+	}
+	return fmt.Sprintf(`<div id="fleet%[1]d" class="fleetDetails detailsOpened" data-mission-type="%[2]d" data-return-flight="%[3]s" data-arrival-time="%[4]d">`+
+		`<span class="timer tooltip" id="timer_%[1]d">load...</span>`+
+		`<span class="originData"><span class="originCoords tooltip"><a href="#">%[5]s</a></span><span class="originPlanet"><figure class="planetIcon planet"></figure></span></span>`+
+		`<span class="fleetDetailButton"><a href="#bl%[1]d" class="tooltipRel tooltipClose fleet_icon_forward"></a></span>`+
+		`%[6]s`+
+		`<span class="starStreak"><div style="position: relative;">`+
+		`<div class="origin fixed"><img class="tooltipHTML" height="30" width="30" title="%[7]s" alt=""/></div>`+
+		`<div class="route fixed"><div style="display:none;" id="bl%[1]d"><div class="htmlTooltip"><table cellpadding="0" cellspacing="0" class="fleetinfo">`+
+		`<tr><th colspan="2">Ships:</th></tr>%[8]s<tr><td colspan="2">&nbsp;</td></tr><tr><th colspan="2">Shipment:</th></tr>`+
+		`<tr><td>Metal:</td><td class="value">%[9]d</td></tr><tr><td>Crystal:</td><td class="value">%[10]d</td></tr>`+
+		`<tr><td>Deuterium:</td><td class="value">%[11]d</td></tr><tr><td>Food:</td><td class="value">0</td></tr>`+
+		`</table></div></div></div>`+
+		`<div class="destination fixed"><img class="tooltipHTML" height="30" width="30" title="%[12]s" alt=""/></div>`+
+		`</div></span>`+
+		`<span class="destinationData"><span class="destinationPlanet"><span><figure class="planetIcon planet"></figure></span></span>`+
+		`<span class="destinationCoords tooltip"><a href="#">%[13]s</a></span></span>`+
+		`<span class="nextTimer tooltip" id="timerNext_%[1]d">load...</span>`+
+		`<span class="openDetails"><a href="javascript:void(0);" class="openCloseDetails" data-mission-id="%[1]d" data-end-time="%[14]d"></a></span>`+
+		`<script type="text/javascript">new SimpleCountdownTimer("#timer_%[1]d", %[15]d, ""); new simpleCountdown(getElementByIdWithCache("timerNext_%[1]d"), %[16]d);</script>`+
+		`</div>`,
+		f.ID, f.Mission, utils.Ternary(f.ReturnFlight, "1", ""), f.BackTime.Unix(), coord(f.Origin), recall, html.EscapeString(originTitle),
+		ships.String(), f.Resources.Metal, f.Resources.Crystal, f.Resources.Deuterium, html.EscapeString(destinationTitle), coord(f.Destination),
+		f.ArrivalTime.Unix(), arriveIn, backIn)
+}
+
+// Patches done on the rendered html: scripts are raw text for goquery
+func (s *Server) patchScripts(pageHTML, page string, sess *session, planet *Planet) string {
+	tokenJS := `var token = "` + sess.token + `";`
+	if tokenRgx.MatchString(pageHTML) {
+		pageHTML = tokenRgx.ReplaceAllLiteralString(pageHTML, tokenJS)
+	} else {
+		pageHTML = strings.Replace(pageHTML, "</head>", `<script type="text/javascript">`+tokenJS+`</script></head>`, 1)
+	}
+
+	// The chat of the wrapper connects to the fake server, and fails since it does not speak tls
+	nodeURLJS := `var nodeUrl = "https:\/\/127.0.0.1:` + utils.FI64(s.port()) + `\/socket.io\/socket.io.js";`
+	if nodeURLRgx.MatchString(pageHTML) {
+		pageHTML = nodeURLRgx.ReplaceAllLiteralString(pageHTML, nodeURLJS)
+	} else {
+		pageHTML = strings.Replace(pageHTML, "</head>", `<script type="text/javascript">`+nodeURLJS+`</script></head>`, 1)
+	}
+
+	switch page {
+	case "fleetdispatch":
+		type shipOnPlanet struct {
+			ID     ogame.ID `json:"id"`
+			Number int64    `json:"number"`
+		}
+		shipsOnPlanet := make([]shipOnPlanet, 0)
+		for _, ship := range ogame.Ships {
+			if nb := planet.Techs[ship.GetID()]; nb > 0 {
+				shipsOnPlanet = append(shipsOnPlanet, shipOnPlanet{ID: ship.GetID(), Number: nb})
+			}
+		}
+		by, _ := json.Marshal(shipsOnPlanet)
+		pageHTML = shipsOnPlanetRgx.ReplaceAllLiteralString(pageHTML, "var shipsOnPlanet = "+string(by)+";")
+	case "overview":
+		coord := fmt.Sprintf("[%d:%d:%d]", planet.Coordinate.Galaxy, planet.Coordinate.System, planet.Coordinate.Position)
+		pageHTML = textContentRgx.ReplaceAllStringFunc(pageHTML, func(line string) string {
+			var value string
+			switch textContentRgx.FindStringSubmatch(line)[1] {
+			case "1":
+				value = fmt.Sprintf(`%skm (<span>%d<\/span>\/<span>%d<\/span>)`, humanizeInt(planet.Diameter), planet.Fields.Built, planet.Fields.Total)
+			case "3":
+				value = fmt.Sprintf(`%d°C to %d°C`, planet.Temperature.Min, planet.Temperature.Max)
+			case "5":
+				value = `<a href=\"#\">` + coord + `<\/a>`
+			default:
+				return line
+			}
+			return "textContent[" + textContentRgx.FindStringSubmatch(line)[1] + `] = "` + value + `";`
+		})
+	}
+	return pageHTML
+}
+
+// Resources of the current planet, the way the fetchResources ajax page returns them
+func (s *Server) fetchResourcesJSON(sess *session) any {
+	planet := s.state.Planet(sess.planetID)
+	tooltip := func(values ...int64) string {
+		var sb strings.Builder
+		sb.WriteString("<table>")
+		for _, v := range values {
+			sb.WriteString(`<tr><th></th><td><span>` + humanizeInt(v) + `</span></td></tr>`)
+		}
+		sb.WriteString("</table>")
+		return sb.String()
+	}
+	resource := func(amount, storage int64) map[string]any {
+		return map[string]any{"amount": amount, "storage": storage, "tooltip": tooltip(amount, storage, 0)}
+	}
+	metalStorage := ogame.MetalStorage.Capacity(planet.Techs[ogame.MetalStorageID])
+	crystalStorage := ogame.CrystalStorage.Capacity(planet.Techs[ogame.CrystalStorageID])
+	deuteriumStorage := ogame.DeuteriumTank.Capacity(planet.Techs[ogame.DeuteriumTankID])
+	return map[string]any{
+		"resources": map[string]any{
+			"metal":      resource(planet.Resources.Metal, metalStorage),
+			"crystal":    resource(planet.Resources.Crystal, crystalStorage),
+			"deuterium":  resource(planet.Resources.Deuterium, deuteriumStorage),
+			"energy":     map[string]any{"amount": planet.Resources.Energy, "tooltip": tooltip(planet.Resources.Energy, 0, 0)},
+			"darkmatter": map[string]any{"amount": planet.Resources.Darkmatter, "tooltip": tooltip(planet.Resources.Darkmatter, 0, 0)},
+			"population": map[string]any{"amount": 0, "tooltip": tooltip(0)},
+			"food":       map[string]any{"amount": 0, "tooltip": tooltip(0)},
+		},
+		"honorScore": 0,
+	}
+}
+
+func (s *Server) port() int64 {
+	return utils.DoParseI64(s.srv.Listener.Addr().String()[strings.LastIndex(s.srv.Listener.Addr().String(), ":")+1:])
+}
+
+// 12800 -> "12,800"
+func humanizeInt(v int64) string {
+	str := utils.FI64(utils.MaxInt(v, -v))
+	for i := len(str) - 3; i > 0; i -= 3 {
+		str = str[:i] + "," + str[i:]
+	}
+	if v < 0 {
+		str = "-" + str
+	}
+	return str
+}
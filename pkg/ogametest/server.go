@@ -0,0 +1,224 @@
+// Package ogametest provides an in-process fake OGame server, so the wrapper can be tested end-to-end without network.
+//
+// The server answers the gameforge lobby endpoints, the universe serverData.xml and the game pages/ajax endpoints.
+// Game pages are rendered from the html fixtures of the samples directory, patched with an in-memory State
+// (planets, resources, ships, fleets, construction queues) that the ajax actions (build, send fleet, recall) mutate.
+//
+//	srv := ogametest.NewServer(ogametest.Config{})
+//	defer srv.Close()
+//	dev.GetClient().SetTransport(srv.Transport())
+//	bot, err := wrapper.NewWithParams(wrapper.Params{Device: dev, Universe: srv.Config().Universe, Lang: srv.Config().Lang,
+//		Username: srv.Config().Username, Password: srv.Config().Password, AutoLogin: true})
+//
+// Not simulated: moons, resources production, combat, lifeforms, chat, event list, and cancelling constructions.
+package ogametest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/gameforge"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+// Config configuration of the fake server, zero values are replaced by the defaults
+type Config struct {
+	Username     string           // Default: "player@example.com"
+	Password     string           // Default: "password"
+	Universe     string           // Default: "Wurren"
+	Lang         string           // Default: "en"
+	ServerNumber int64            // Default: 252
+	State        *State           // Default: DefaultState()
+	SamplesDir   string           // Directory of the html fixtures. Default: the samples directory of this repository
+	Now          func() time.Time // Default: time.Now
+}
+
+// Server in-process fake OGame server
+type Server struct {
+	cfg        Config
+	srv        *httptest.Server
+	serverData gameforge.ServerData
+	fixtures   *fixtures
+
+	mu            sync.Mutex
+	state         *State
+	bearerTokens  map[string]bool
+	loginTokens   map[string]bool
+	redeemedCodes map[string]bool
+	sessions      map[string]*session // key: PHPSESSID cookie
+	maintenance   bool
+}
+
+// A logged-in game session
+type session struct {
+	ogameSession string
+	token        string
+	planetID     ogame.PlanetID // Current planet, changed with the cp parameter
+}
+
+// NewServer starts a fake server, it must be closed with Close
+func NewServer(cfg Config) *Server {
+	if cfg.Username == "" {
+		cfg.Username = "player@example.com"
+	}
+	if cfg.Password == "" {
+		cfg.Password = "password"
+	}
+	if cfg.Universe == "" {
+		cfg.Universe = "Wurren"
+	}
+	if cfg.Lang == "" {
+		cfg.Lang = "en"
+	}
+	if cfg.ServerNumber == 0 {
+		cfg.ServerNumber = 252
+	}
+	if cfg.State == nil {
+		cfg.State = DefaultState()
+	}
+	if cfg.SamplesDir == "" {
+		cfg.SamplesDir = defaultSamplesDir()
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	s := &Server{
+		cfg:           cfg,
+		state:         cfg.State,
+		fixtures:      newFixtures(cfg.SamplesDir),
+		bearerTokens:  make(map[string]bool),
+		loginTokens:   make(map[string]bool),
+		redeemedCodes: make(map[string]bool),
+		sessions:      make(map[string]*session),
+	}
+	s.serverData = gameforge.ServerData{
+		Name:                          cfg.Universe,
+		Number:                        cfg.ServerNumber,
+		Language:                      cfg.Lang,
+		Timezone:                      "UTC",
+		TimezoneOffset:                "+00:00",
+		Domain:                        s.gameHost(),
+		Version:                       "11.15.5",
+		Speed:                         8,
+		SpeedFleetPeaceful:            4,
+		SpeedFleetWar:                 1,
+		SpeedFleetHolding:             1,
+		Galaxies:                      9,
+		Systems:                       499,
+		DonutGalaxy:                   true,
+		DonutSystem:                   true,
+		GlobalDeuteriumSaveFactor:     1,
+		ProbeCargo:                    0,
+		CargoHyperspaceTechMultiplier: 5,
+	}
+	s.serverData.SpeedFleet = s.serverData.SpeedFleetPeaceful
+	if bonuses, err := s.fixtures.lfBonuses(); err == nil {
+		s.state.LfBonuses = bonuses
+	}
+	s.srv = httptest.NewUnstartedServer(s.handler())
+	s.srv.Config.ErrorLog = log.New(io.Discard, "", 0) // The chat of the wrapper connects with tls, and fails
+	s.srv.Start()
+	return s
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Config returns the configuration of the server, with the defaults applied
+func (s *Server) Config() Config {
+	return s.cfg
+}
+
+// ServerData returns the universe settings served by serverData.xml
+func (s *Server) ServerData() gameforge.ServerData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.serverData
+}
+
+// SetVersion changes the version of the universe, like a version rollout
+func (s *Server) SetVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serverData.Version = version
+}
+
+// SetMaintenance starts or ends a maintenance. During a maintenance the game answers 503 with a maintenance page,
+// the lobby lists the universe as closed, and every game session is logged out.
+func (s *Server) SetMaintenance(maintenance bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maintenance = maintenance
+	if maintenance {
+		s.sessions = make(map[string]*session)
+	}
+}
+
+// Transport returns a RoundTripper that sends every request to the fake server, whatever the requested host is.
+// Set it on the client of the device used by the wrapper.
+func (s *Server) Transport() http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		clone := req.Clone(req.Context())
+		clone.Host = req.URL.Host
+		clone.URL.Scheme = "http"
+		clone.URL.Host = s.srv.Listener.Addr().String()
+		return http.DefaultTransport.RoundTrip(clone)
+	})
+}
+
+// WithState calls fn with the up-to-date state, the server is locked until fn returns
+func (s *Server) WithState(fn func(*State)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Update(s.cfg.Now())
+	fn(s.state)
+}
+
+// ExpireSessions logs out every game session, the wrapper has to login again
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = make(map[string]*session)
+}
+
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tra/game1.js", s.game1Handler)
+	mux.HandleFunc("/api/v1/auth/thin/sessions", s.postSessionsHandler)
+	mux.HandleFunc("/config/configuration.js", s.configurationHandler)
+	mux.HandleFunc("/api/users/me/accounts", s.accountsHandler)
+	mux.HandleFunc("/api/servers", s.serversHandler)
+	mux.HandleFunc("/api/users/me/loginLink", s.loginLinkHandler)
+	mux.HandleFunc("/api/token", s.redeemCodeHandler)
+	mux.HandleFunc("/api/serverData.xml", s.serverDataHandler)
+	mux.HandleFunc("/game/lobbylogin.php", s.lobbyLoginHandler)
+	mux.HandleFunc("/game/index.php", s.gameHandler)
+	return mux
+}
+
+func (s *Server) gameHost() string {
+	return "s" + utils.FI64(s.cfg.ServerNumber) + "-" + s.cfg.Lang + ".ogame.gameforge.com"
+}
+
+func (s *Server) gameURL() string {
+	return "https://" + s.gameHost()
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func randomToken() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
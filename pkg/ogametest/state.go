@@ -0,0 +1,389 @@
+package ogametest
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/extractor/v11_15_0"
+	"github.com/alaingilbert/ogame/pkg/gameforge"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+// Planet planet of the fake player
+type Planet struct {
+	ID          ogame.PlanetID
+	Name        string
+	Coordinate  ogame.Coordinate
+	Diameter    int64
+	Fields      ogame.Fields
+	Temperature ogame.Temperature
+	Resources   ogame.Resources
+	Techs       map[ogame.ID]int64 // Levels of the buildings, and amount of ships and defenses
+}
+
+// Fleet fleet of the fake player in flight
+type Fleet struct {
+	ID           ogame.FleetID
+	Mission      ogame.MissionID
+	Origin       ogame.Coordinate
+	Destination  ogame.Coordinate
+	Ships        ogame.ShipsInfos
+	Resources    ogame.Resources
+	StartTime    time.Time
+	ArrivalTime  time.Time // Time of arrival at destination
+	BackTime     time.Time // Time of arrival back at origin
+	ReturnFlight bool
+}
+
+// QueueItem construction in progress, buildings and researches levels, or a batch of ships/defenses
+type QueueItem struct {
+	ListID   int64
+	PlanetID ogame.PlanetID
+	ID       ogame.ID
+	Nbr      int64 // Level for buildings and researches, amount for ships and defenses
+	StartAt  time.Time
+	FinishAt time.Time
+}
+
+// State in-memory game state served by the fake server
+type State struct {
+	PlayerID       int64
+	PlayerName     string
+	CharacterClass ogame.CharacterClass
+	LfBonuses      ogame.LfBonuses // The server uses the bonuses of its lfbonuses fixture
+	Researches     map[ogame.ID]int64
+	Planets        []*Planet
+	Fleets         []*Fleet
+	Queue          []*QueueItem
+	nextID         int64
+}
+
+// DefaultState returns a small empire with two planets, some resources and a few ships
+func DefaultState() *State {
+	return &State{
+		PlayerID:   100538,
+		PlayerName: "Commander",
+		LfBonuses:  *ogame.NewLfBonuses(),
+		Researches: map[ogame.ID]int64{
+			ogame.EnergyTechnologyID:     3,
+			ogame.CombustionDriveID:      6,
+			ogame.ImpulseDriveID:         4,
+			ogame.ComputerTechnologyID:   5,
+			ogame.EspionageTechnologyID:  4,
+			ogame.AstrophysicsID:         1,
+			ogame.HyperspaceTechnologyID: 0,
+		},
+		Planets: []*Planet{
+			{
+				ID:          33628462,
+				Name:        "Homeworld",
+				Coordinate:  ogame.Coordinate{Galaxy: 1, System: 103, Position: 8, Type: ogame.PlanetType},
+				Diameter:    12800,
+				Fields:      ogame.Fields{Built: 30, Total: 163},
+				Temperature: ogame.Temperature{Min: 30, Max: 70},
+				Resources:   ogame.Resources{Metal: 200000, Crystal: 100000, Deuterium: 50000},
+				Techs: map[ogame.ID]int64{
+					ogame.MetalMineID:            15,
+					ogame.CrystalMineID:          12,
+					ogame.DeuteriumSynthesizerID: 10,
+					ogame.SolarPlantID:           15,
+					ogame.RoboticsFactoryID:      4,
+					ogame.ShipyardID:             4,
+					ogame.ResearchLabID:          5,
+					ogame.SmallCargoID:           20,
+					ogame.LargeCargoID:           5,
+					ogame.EspionageProbeID:       10,
+					ogame.LightFighterID:         10,
+					ogame.RocketLauncherID:       20,
+				},
+			},
+			{
+				ID:          33627557,
+				Name:        "Colony",
+				Coordinate:  ogame.Coordinate{Galaxy: 1, System: 105, Position: 11, Type: ogame.PlanetType},
+				Diameter:    9500,
+				Fields:      ogame.Fields{Built: 10, Total: 120},
+				Temperature: ogame.Temperature{Min: -24, Max: 16},
+				Resources:   ogame.Resources{Metal: 10000, Crystal: 5000},
+				Techs: map[ogame.ID]int64{
+					ogame.MetalMineID:   8,
+					ogame.CrystalMineID: 6,
+					ogame.SolarPlantID:  8,
+				},
+			},
+		},
+		nextID: 1000,
+	}
+}
+
+// Planet returns the planet with the given id, nil if the player does not own it
+func (s *State) Planet(id ogame.PlanetID) *Planet {
+	for _, p := range s.Planets {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// PlanetAt returns the planet at the given coordinate, nil if the player does not own it
+func (s *State) PlanetAt(coord ogame.Coordinate) *Planet {
+	for _, p := range s.Planets {
+		if p.Coordinate.Equal(coord) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Fleet returns the fleet with the given id, nil if not found
+func (s *State) Fleet(id ogame.FleetID) *Fleet {
+	for _, f := range s.Fleets {
+		if f.ID == id {
+			return f
+		}
+	}
+	return nil
+}
+
+func (s *State) newID() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+// Returns the levels of every building/research and the amount of every ship/defense of a planet, keyed by id,
+// the way the fetchTechs ajax page returns them
+func (s *State) techs(p *Planet) map[ogame.ID]int64 {
+	out := make(map[ogame.ID]int64)
+	for id, lvl := range s.Researches {
+		out[id] = lvl
+	}
+	for id, lvl := range p.Techs {
+		out[id] = lvl
+	}
+	return out
+}
+
+// Typed techs of a planet, parsed the same way the wrapper parses the fetchTechs ajax page
+func (s *State) typedTechs(p *Planet) (ogame.ResourcesBuildings, ogame.Facilities, ogame.ShipsInfos, ogame.Researches) {
+	by, _ := json.Marshal(s.techs(p))
+	supplies, facilities, ships, _, researches, _, _, _ := v11_15_0.NewExtractor().ExtractTechs(by)
+	return supplies, facilities, ships, researches
+}
+
+// Slots returns the fleet slots of the player
+func (s *State) Slots() ogame.Slots {
+	slots := ogame.Slots{
+		Total:    s.Researches[ogame.ComputerTechnologyID] + 1,
+		ExpTotal: int64(math.Sqrt(float64(s.Researches[ogame.AstrophysicsID]))),
+	}
+	for _, f := range s.Fleets {
+		slots.InUse++
+		if f.Mission == ogame.Expedition {
+			slots.ExpInUse++
+		}
+	}
+	return slots
+}
+
+// Update completes the constructions and moves the fleets up to the given time
+func (s *State) Update(now time.Time) {
+	remaining := make([]*QueueItem, 0)
+	for _, item := range s.Queue {
+		if item.FinishAt.After(now) {
+			remaining = append(remaining, item)
+			continue
+		}
+		if item.ID.IsTech() {
+			s.Researches[item.ID] = item.Nbr
+		} else if p := s.Planet(item.PlanetID); p != nil {
+			if item.ID.IsShip() || item.ID.IsDefense() {
+				p.Techs[item.ID] += item.Nbr
+			} else {
+				p.Techs[item.ID] = item.Nbr
+			}
+		}
+	}
+	s.Queue = remaining
+
+	fleets := make([]*Fleet, 0)
+	for _, f := range s.Fleets {
+		if !f.ReturnFlight && !f.ArrivalTime.After(now) {
+			if s.fleetArrived(f) {
+				continue
+			}
+			f.ReturnFlight = true
+		}
+		if f.ReturnFlight && !f.BackTime.After(now) {
+			if origin := s.PlanetAt(f.Origin); origin != nil {
+				origin.Resources = origin.Resources.Add(f.Resources)
+				f.Ships.Each(func(shipID ogame.ID, nb int64) { origin.Techs[shipID] += nb })
+			}
+			continue
+		}
+		fleets = append(fleets, f)
+	}
+	s.Fleets = fleets
+}
+
+// Applies the mission of a fleet reaching its destination, returns true if the fleet stays at destination
+func (s *State) fleetArrived(f *Fleet) bool {
+	dest := s.PlanetAt(f.Destination)
+	if dest == nil {
+		return false
+	}
+	switch f.Mission {
+	case ogame.Transport:
+		dest.Resources = dest.Resources.Add(f.Resources)
+		f.Resources = ogame.Resources{}
+	case ogame.Park:
+		dest.Resources = dest.Resources.Add(f.Resources)
+		f.Ships.Each(func(shipID ogame.ID, nb int64) { dest.Techs[shipID] += nb })
+		return true
+	}
+	return false
+}
+
+// Errors returned by the game to the ajax calls
+var (
+	errNotEnoughResources = ajaxError{Message: "Not enough resources!", Code: 4060}
+	errQueueBusy          = ajaxError{Message: "There is already a construction in progress.", Code: 4061}
+	errNoShips            = ajaxError{Message: "Error, no ships available", Code: 4059}
+	errNotEnoughCargo     = ajaxError{Message: "Not enough cargo space!", Code: 4029}
+	errInvalidTarget      = ajaxError{Message: "You have to select a valid target.", Code: 4049}
+	errNoFreeSlot         = ajaxError{Message: "No free fleet slots available", Code: 4030}
+	errInvalidTechnology  = ajaxError{Message: "Invalid technology", Code: 140016}
+)
+
+type ajaxError struct {
+	Message string `json:"message"`
+	Code    int64  `json:"error"`
+}
+
+func (e ajaxError) Error() string { return e.Message }
+
+// Schedule pays for a construction and adds it to the queue of the planet.
+// Only one building and one research can be in progress, ships and defenses batches are built one after the other.
+func (s *State) Schedule(planetID ogame.PlanetID, id ogame.ID, amount int64, universeSpeed int64, now time.Time) (*QueueItem, error) {
+	p := s.Planet(planetID)
+	obj := ogame.Objs.ByID(id)
+	if p == nil || obj == nil || (!id.IsBuilding() && !id.IsTech() && !id.IsShip() && !id.IsDefense()) {
+		return nil, errInvalidTechnology
+	}
+	_, facilities, _, _ := s.typedTechs(p)
+	lfBonuses := s.LfBonuses
+	startAt := now
+	var nbr int64
+	var price ogame.Resources
+	var duration time.Duration
+	if id.IsShip() || id.IsDefense() {
+		nbr = utils.MaxInt(amount, 1)
+		price = obj.GetPrice(nbr, lfBonuses)
+		duration = obj.ConstructionTime(nbr, universeSpeed, facilities, lfBonuses, s.CharacterClass, false)
+		for _, item := range s.Queue {
+			if item.PlanetID == planetID && (item.ID.IsShip() || item.ID.IsDefense()) && item.FinishAt.After(startAt) {
+				startAt = item.FinishAt
+			}
+		}
+	} else {
+		for _, item := range s.Queue {
+			if item.ID.IsTech() == id.IsTech() && (id.IsTech() || item.PlanetID == planetID) && !item.ID.IsShip() && !item.ID.IsDefense() {
+				return nil, errQueueBusy
+			}
+		}
+		if id.IsTech() {
+			nbr = s.Researches[id] + 1
+		} else {
+			nbr = p.Techs[id] + 1
+		}
+		price = obj.GetPrice(nbr, lfBonuses)
+		duration = obj.ConstructionTime(nbr, universeSpeed, facilities, lfBonuses, s.CharacterClass, false)
+	}
+	if !p.Resources.CanAfford(price) {
+		return nil, errNotEnoughResources
+	}
+	p.Resources = p.Resources.Sub(price)
+	item := &QueueItem{ListID: s.newID(), PlanetID: planetID, ID: id, Nbr: nbr, StartAt: startAt, FinishAt: startAt.Add(duration)}
+	s.Queue = append(s.Queue, item)
+	sort.SliceStable(s.Queue, func(i, j int) bool { return s.Queue[i].FinishAt.Before(s.Queue[j].FinishAt) })
+	return item, nil
+}
+
+// FleetOrder parameters of a fleet dispatch
+type FleetOrder struct {
+	Origin      ogame.PlanetID
+	Ships       ogame.ShipsInfos
+	Destination ogame.Coordinate
+	Mission     ogame.MissionID
+	Speed       int64 // 1 to 10 (10%-100%)
+	Resources   ogame.Resources
+}
+
+// SendFleet takes the ships, cargo and fuel from the origin planet and creates the fleet
+func (s *State) SendFleet(order FleetOrder, serverData gameforge.ServerData, now time.Time) (*Fleet, error) {
+	p := s.Planet(order.Origin)
+	if p == nil {
+		return nil, errInvalidTarget
+	}
+	if !order.Ships.HasFlyableShips() {
+		return nil, errNoShips
+	}
+	var err error
+	order.Ships.Each(func(shipID ogame.ID, nb int64) {
+		if nb > p.Techs[shipID] {
+			err = errNoShips
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if slots := s.Slots(); slots.InUse >= slots.Total {
+		return nil, errNoFreeSlot
+	}
+	_, _, _, researches := s.typedTechs(p)
+	lfBonuses := s.LfBonuses
+	cargo := order.Ships.Cargo(researches, lfBonuses, s.CharacterClass, float64(serverData.CargoHyperspaceTechMultiplier)/100, false)
+	if order.Resources.Total() > cargo {
+		return nil, errNotEnoughCargo
+	}
+	speed := float64(utils.Clamp(order.Speed, 1, 10)) / 10
+	secs, fuel := ogame.CalcFlightTime(p.Coordinate, order.Destination, serverData.Galaxies, serverData.Systems,
+		serverData.DonutGalaxy, serverData.DonutSystem, serverData.GlobalDeuteriumSaveFactor, speed, serverData.SpeedFleet, order.Ships, researches, lfBonuses, s.CharacterClass, ogame.NoAllianceClass, 0)
+	cost := order.Resources
+	cost.Deuterium += fuel
+	if !p.Resources.CanAfford(cost) {
+		return nil, errNotEnoughResources
+	}
+	p.Resources = p.Resources.Sub(cost)
+	order.Ships.Each(func(shipID ogame.ID, nb int64) { p.Techs[shipID] -= nb })
+	duration := time.Duration(secs) * time.Second
+	fleet := &Fleet{
+		ID:          ogame.FleetID(s.newID()),
+		Mission:     order.Mission,
+		Origin:      p.Coordinate,
+		Destination: order.Destination,
+		Ships:       order.Ships,
+		Resources:   order.Resources,
+		StartTime:   now,
+		ArrivalTime: now.Add(duration),
+		BackTime:    now.Add(2 * duration),
+	}
+	s.Fleets = append(s.Fleets, fleet)
+	return fleet, nil
+}
+
+// RecallFleet sends a fleet back to its origin, it takes as long to come back as it has been flying
+func (s *State) RecallFleet(id ogame.FleetID, now time.Time) error {
+	f := s.Fleet(id)
+	if f == nil || f.ReturnFlight {
+		return errors.New("fleet cannot be recalled")
+	}
+	f.ReturnFlight = true
+	f.ArrivalTime = now
+	f.BackTime = now.Add(now.Sub(f.StartTime))
+	return nil
+}
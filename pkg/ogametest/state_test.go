@@ -0,0 +1,57 @@
+package ogametest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestState_Schedule(t *testing.T) {
+	s := DefaultState()
+	now := time.Unix(1700000000, 0)
+	homeworld := s.Planets[0]
+
+	item, err := s.Schedule(homeworld.ID, ogame.MetalMineID, 0, 8, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(16), item.Nbr)
+	assert.True(t, item.FinishAt.After(now))
+	assert.True(t, homeworld.Resources.Metal < 200000)
+
+	_, err = s.Schedule(homeworld.ID, ogame.CrystalMineID, 0, 8, now)
+	assert.ErrorIs(t, err, errQueueBusy)
+
+	_, err = s.Schedule(homeworld.ID, ogame.DeathstarID, 1, 8, now)
+	assert.ErrorIs(t, err, errNotEnoughResources)
+
+	s.Update(item.FinishAt)
+	assert.Equal(t, int64(16), homeworld.Techs[ogame.MetalMineID])
+	assert.Equal(t, 0, len(s.Queue))
+}
+
+func TestState_SendFleet(t *testing.T) {
+	srv := NewServer(Config{})
+	defer srv.Close()
+	s := DefaultState()
+	now := time.Unix(1700000000, 0)
+	homeworld, colony := s.Planets[0], s.Planets[1]
+
+	fleet, err := s.SendFleet(FleetOrder{Origin: homeworld.ID, Ships: ogame.ShipsInfos{SmallCargo: 5}, Destination: colony.Coordinate,
+		Mission: ogame.Transport, Speed: 10, Resources: ogame.Resources{Metal: 10000}}, srv.ServerData(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), homeworld.Techs[ogame.SmallCargoID])
+	assert.Equal(t, int64(1), s.Slots().InUse)
+
+	_, err = s.SendFleet(FleetOrder{Origin: homeworld.ID, Ships: ogame.ShipsInfos{SmallCargo: 100}, Destination: colony.Coordinate,
+		Mission: ogame.Transport, Speed: 10}, srv.ServerData(), now)
+	assert.ErrorIs(t, err, errNoShips)
+
+	s.Update(fleet.ArrivalTime)
+	assert.Equal(t, int64(20000), colony.Resources.Metal)
+	assert.True(t, fleet.ReturnFlight)
+
+	s.Update(fleet.BackTime)
+	assert.Equal(t, int64(20), homeworld.Techs[ogame.SmallCargoID])
+	assert.Equal(t, 0, len(s.Fleets))
+}
@@ -2,6 +2,6 @@ package parser
 
 import "github.com/alaingilbert/ogame/pkg/ogame"
 
-func (p *ResearchPage) ExtractResearch() ogame.Researches {
+func (p *ResearchPage) ExtractResearch() (ogame.Researches, error) {
 	return p.e.ExtractResearchFromDoc(p.GetDoc())
 }
@@ -0,0 +1,231 @@
+// Package publicapi is a client for the public xml api published by every ogame server
+// (https://s<number>-<lang>.ogame.gameforge.com/api/).
+// These files do not cost any in-game request, and are cached according to their update interval.
+package publicapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/httpclient"
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+// Files update intervals, as documented by gameforge
+const (
+	PlayersInterval      = 24 * time.Hour
+	AlliancesInterval    = 24 * time.Hour
+	UniverseInterval     = 7 * 24 * time.Hour
+	HighscoreInterval    = time.Hour
+	PlayerDataInterval   = 7 * 24 * time.Hour
+	LocalizationInterval = 7 * 24 * time.Hour
+)
+
+// When a file is older than its update interval, wait this long before asking again
+const staleRetryInterval = 5 * time.Minute
+
+// ErrUnexpectedStatus returned when the api responds with an unexpected http status
+var ErrUnexpectedStatus = errors.New("unexpected status code")
+
+type cacheEntry struct {
+	body         []byte
+	expiresAt    time.Time
+	etag         string
+	lastModified string
+}
+
+// Client public api client
+type Client struct {
+	client  httpclient.IHttpClient
+	baseURL string
+	nowFn   func() time.Time
+	cacheMu sync.Mutex
+	cache   map[string]cacheEntry
+}
+
+// NewClient creates a client for the server s<serverNumber>-<serverLang>
+func NewClient(client httpclient.IHttpClient, serverNumber int64, serverLang string) *Client {
+	return NewClientWithBaseURL(client, "https://s"+utils.FI64(serverNumber)+"-"+serverLang+".ogame.gameforge.com/api")
+}
+
+// NewClientWithBaseURL creates a client using a custom base url (eg: https://s180-en.ogame.gameforge.com/api)
+func NewClientWithBaseURL(client httpclient.IHttpClient, baseURL string) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{
+		client:  client,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		nowFn:   time.Now,
+		cache:   make(map[string]cacheEntry),
+	}
+}
+
+// ClearCache forget every cached files
+func (c *Client) ClearCache() {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	c.cache = make(map[string]cacheEntry)
+}
+
+// Players gets players.xml
+func (c *Client) Players(ctx context.Context) (out Players, err error) {
+	err = c.get(ctx, "players.xml", nil, PlayersInterval, &out)
+	return
+}
+
+// Alliances gets alliances.xml
+func (c *Client) Alliances(ctx context.Context) (out Alliances, err error) {
+	err = c.get(ctx, "alliances.xml", nil, AlliancesInterval, &out)
+	return
+}
+
+// Universe gets universe.xml
+func (c *Client) Universe(ctx context.Context) (out Universe, err error) {
+	err = c.get(ctx, "universe.xml", nil, UniverseInterval, &out)
+	return
+}
+
+// Highscore gets highscore.xml
+// category: 1:Player, 2:Alliance
+// typ: 0:Total, 1:Economy, 2:Research, 3:Military, 4:Military Built, 5:Military Destroyed, 6:Military Lost, 7:Honor
+// The types are the ones of the in-game highscore page, 4 to 6 are not confirmed against a real highscore.xml.
+func (c *Client) Highscore(ctx context.Context, category, typ int64) (out Highscore, err error) {
+	if category < 1 || category > 2 {
+		return out, errors.New("category must be in [1, 2] (1:player, 2:alliance)")
+	}
+	params := url.Values{"category": {utils.FI64(category)}, "type": {utils.FI64(typ)}}
+	err = c.get(ctx, "highscore.xml", params, HighscoreInterval, &out)
+	return
+}
+
+// PlayerData gets playerData.xml for a player
+func (c *Client) PlayerData(ctx context.Context, playerID int64) (out PlayerData, err error) {
+	err = c.get(ctx, "playerData.xml", url.Values{"id": {utils.FI64(playerID)}}, PlayerDataInterval, &out)
+	return
+}
+
+// Localization gets localization.xml
+func (c *Client) Localization(ctx context.Context) (out Localization, err error) {
+	err = c.get(ctx, "localization.xml", nil, LocalizationInterval, &out)
+	return
+}
+
+func (c *Client) get(ctx context.Context, file string, params url.Values, interval time.Duration, v any) error {
+	fileURL := c.baseURL + "/" + file
+	if len(params) > 0 {
+		fileURL += "?" + params.Encode()
+	}
+	by, err := c.fetch(ctx, fileURL, interval)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(by, v); err != nil {
+		return fmt.Errorf("failed to xml unmarshal %s : %w", fileURL, err)
+	}
+	return nil
+}
+
+// Returns the content of the file, from the cache if it is still fresh
+func (c *Client) fetch(ctx context.Context, fileURL string, interval time.Duration) ([]byte, error) {
+	now := c.nowFn()
+	c.cacheMu.Lock()
+	entry, cached := c.cache[fileURL]
+	c.cacheMu.Unlock()
+	if cached && now.Before(entry.expiresAt) {
+		return entry.body, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+	if cached {
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+	case resp.StatusCode == http.StatusOK:
+		by, err := utils.ReadBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		entry = cacheEntry{body: by, etag: resp.Header.Get("ETag"), lastModified: resp.Header.Get("Last-Modified")}
+	default:
+		return nil, fmt.Errorf("%w %d for %s", ErrUnexpectedStatus, resp.StatusCode, fileURL)
+	}
+	entry.expiresAt = expiresAt(resp.Header, entry.body, now, interval)
+	c.cacheMu.Lock()
+	c.cache[fileURL] = entry
+	c.cacheMu.Unlock()
+	return entry.body, nil
+}
+
+// Returns when the file must be downloaded again.
+// Cache headers are used first (Cache-Control max-age, Expires),
+// then the "timestamp" attribute of the file plus the file update interval.
+func expiresAt(header http.Header, body []byte, now time.Time, interval time.Duration) time.Time {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(directive)
+		if directive == "no-cache" || directive == "no-store" {
+			return now
+		}
+		if strings.HasPrefix(directive, "max-age=") {
+			if secs, err := strconv.ParseInt(strings.TrimPrefix(directive, "max-age="), 10, 64); err == nil {
+				return now.Add(time.Duration(secs) * time.Second)
+			}
+		}
+	}
+	if expires, err := http.ParseTime(header.Get("Expires")); err == nil && expires.After(now) {
+		return expires
+	}
+	if timestamp, ok := fileTimestamp(body); ok {
+		next := time.Unix(timestamp, 0).Add(interval)
+		if next.After(now) {
+			return next
+		}
+		return now.Add(staleRetryInterval)
+	}
+	return now.Add(interval)
+}
+
+// Returns the "timestamp" attribute of the root element of the file
+func fileTimestamp(body []byte) (int64, bool) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0, false
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "timestamp" {
+					timestamp, err := strconv.ParseInt(attr.Value, 10, 64)
+					return timestamp, err == nil
+				}
+			}
+			return 0, false
+		}
+	}
+}
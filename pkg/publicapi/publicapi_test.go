@@ -0,0 +1,172 @@
+package publicapi
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/stretchr/testify/assert"
+)
+
+func readFixture(t *testing.T, name string, v any) []byte {
+	by, err := os.ReadFile("../../samples/synthetic/api/" + name)
+	assert.NoError(t, err)
+	if v != nil {
+		assert.NoError(t, xml.Unmarshal(by, v))
+	}
+	return by
+}
+
+func TestPlayers(t *testing.T) {
+	var players Players
+	readFixture(t, "players.xml", &players)
+	assert.Equal(t, int64(1672531200), players.Timestamp)
+	assert.Equal(t, "en180", players.ServerID)
+	assert.Equal(t, 6, len(players.Players))
+	assert.True(t, players.Players[0].IsAdmin())
+	alice, ok := players.ByID(100128)
+	assert.True(t, ok)
+	assert.Equal(t, "Alice", alice.Name)
+	assert.Equal(t, int64(500012), alice.AllianceID)
+	assert.False(t, alice.IsInactive())
+	sleeper, _ := players.ByID(100129)
+	assert.True(t, sleeper.IsInactive())
+	assert.False(t, sleeper.IsLongInactive())
+	gone, _ := players.ByID(100130)
+	assert.True(t, gone.IsVacation())
+	assert.True(t, gone.IsLongInactive())
+	cheater, _ := players.ByID(100131)
+	assert.True(t, cheater.IsBanned())
+	assert.True(t, cheater.IsOutlaw())
+	_, ok = players.ByID(1)
+	assert.False(t, ok)
+}
+
+func TestAlliances(t *testing.T) {
+	var alliances Alliances
+	readFixture(t, "alliances.xml", &alliances)
+	assert.Equal(t, 2, len(alliances.Alliances))
+	a := alliances.Alliances[0]
+	assert.Equal(t, int64(1), a.Open)
+	assert.Equal(t, int64(100128), a.Founder)
+	assert.Equal(t, ogame.AllianceInfos{ID: 500012, Name: "The Alliance", Tag: "TA", Member: 2}, a.AllianceInfos())
+	assert.Equal(t, int64(0), alliances.Alliances[1].Open)
+}
+
+func TestUniverse(t *testing.T) {
+	var universe Universe
+	readFixture(t, "universe.xml", &universe)
+	assert.Equal(t, 4, len(universe.Planets))
+	planets := universe.PlanetsOf(100128)
+	assert.Equal(t, 2, len(planets))
+	assert.Equal(t, ogame.Coordinate{Galaxy: 1, System: 101, Position: 8, Type: ogame.PlanetType}, planets[0].Coordinate())
+	moonCoord, ok := planets[0].MoonCoordinate()
+	assert.True(t, ok)
+	assert.Equal(t, ogame.Coordinate{Galaxy: 1, System: 101, Position: 8, Type: ogame.MoonType}, moonCoord)
+	assert.Equal(t, int64(8602), planets[0].Moon.Size)
+	_, ok = planets[1].MoonCoordinate()
+	assert.False(t, ok)
+}
+
+func TestHighscore(t *testing.T) {
+	var players Players
+	readFixture(t, "players.xml", &players)
+	var highscore Highscore
+	readFixture(t, "highscore.xml", &highscore)
+	h := highscore.ToHighscore(&players)
+	assert.Equal(t, int64(1), h.Category)
+	assert.Equal(t, int64(3), h.Type)
+	assert.Equal(t, 3, len(h.Players))
+	assert.Equal(t, ogame.HighscorePlayer{Position: 1, ID: 100128, Name: "Alice", Score: 912345, AllianceID: 500012, Ships: 1234}, h.Players[0])
+
+	var allianceHighscore Highscore
+	readFixture(t, "highscore_alliance.xml", &allianceHighscore)
+	h = allianceHighscore.ToHighscore(&players)
+	assert.Equal(t, int64(2), h.Category)
+	assert.Equal(t, 2, len(h.Players))
+	assert.Equal(t, ogame.HighscorePlayer{Position: 1, ID: 500012, Score: 1500000}, h.Players[0])
+}
+
+func TestPlayerData(t *testing.T) {
+	var playerData PlayerData
+	readFixture(t, "playerData.xml", &playerData)
+	assert.Equal(t, int64(100128), playerData.ID)
+	assert.Equal(t, 8, len(playerData.Positions))
+	assert.Equal(t, 2, len(playerData.Planets))
+	assert.Equal(t, "TA", playerData.Alliance.Tag)
+	assert.Equal(t, ogame.Coordinate{Galaxy: 1, System: 101, Position: 8, Type: ogame.PlanetType}, playerData.Homeworld())
+	p := playerData.HighscorePlayer(3)
+	assert.Equal(t, int64(1), p.Position)
+	assert.Equal(t, int64(912345), p.Score)
+	assert.Equal(t, int64(1234), p.Ships)
+	assert.Equal(t, int64(150), p.HonourPoints)
+	assert.Equal(t, int64(500012), p.AllianceID)
+}
+
+func TestLocalization(t *testing.T) {
+	var localization Localization
+	readFixture(t, "localization.xml", &localization)
+	assert.Equal(t, "Small Cargo", localization.TechName(ogame.SmallCargoID))
+	assert.Equal(t, "Espionage", localization.MissionName(ogame.Spy))
+	assert.Equal(t, "", localization.TechName(ogame.DeathstarID))
+}
+
+func TestExpiresAt(t *testing.T) {
+	now := time.Unix(1672531200, 0)
+	body := []byte(`<?xml version="1.0"?><players timestamp="1672531000"></players>`)
+	assert.Equal(t, now.Add(time.Minute), expiresAt(http.Header{"Cache-Control": {"public, max-age=60"}}, body, now, time.Hour))
+	assert.Equal(t, now, expiresAt(http.Header{"Cache-Control": {"no-cache"}}, body, now, time.Hour))
+	assert.Equal(t, time.Unix(1672531000, 0).Add(time.Hour), expiresAt(http.Header{}, body, now, time.Hour))
+	assert.Equal(t, now.Add(staleRetryInterval), expiresAt(http.Header{}, body, now, time.Minute))
+	assert.Equal(t, now.Add(time.Hour), expiresAt(http.Header{}, []byte(`<players></players>`), now, time.Hour))
+	expires := now.Add(2 * time.Hour).UTC()
+	assert.Equal(t, expires.Unix(), expiresAt(http.Header{"Expires": {expires.Format(http.TimeFormat)}}, body, now, time.Hour).Unix())
+}
+
+func TestClient_Cache(t *testing.T) {
+	fixture := readFixture(t, "players.xml", nil)
+	hits := 0
+	conditionalHits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.URL.Path != "/api/players.xml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditionalHits++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write(fixture)
+	}))
+	defer srv.Close()
+
+	now := time.Unix(1672531200, 0)
+	c := NewClientWithBaseURL(srv.Client(), srv.URL+"/api/")
+	c.nowFn = func() time.Time { return now }
+
+	players, err := c.Players(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 6, len(players.Players))
+	_, err = c.Players(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, hits) // Served from cache
+
+	// Once expired, the file is revalidated
+	now = now.Add(PlayersInterval + time.Second)
+	players, err = c.Players(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 6, len(players.Players))
+	assert.Equal(t, 2, hits)
+	assert.Equal(t, 1, conditionalHits)
+
+	_, err = c.Alliances(context.Background())
+	assert.ErrorIs(t, err, ErrUnexpectedStatus)
+}
@@ -0,0 +1,259 @@
+package publicapi
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+)
+
+// Players players.xml, updated every day
+type Players struct {
+	XMLName   xml.Name `xml:"players"`
+	Timestamp int64    `xml:"timestamp,attr"`
+	ServerID  string   `xml:"serverId,attr"`
+	Players   []Player `xml:"player"`
+}
+
+// ByID returns the player with the given id
+func (p Players) ByID(id int64) (Player, bool) {
+	for _, player := range p.Players {
+		if player.ID == id {
+			return player, true
+		}
+	}
+	return Player{}, false
+}
+
+// Player public information of a player
+type Player struct {
+	ID         int64  `xml:"id,attr"`
+	Name       string `xml:"name,attr"`
+	Status     string `xml:"status,attr"` // a: admin, v: vacation, i: inactive (7 days), I: inactive (28 days), b: banned, o: outlaw
+	AllianceID int64  `xml:"alliance,attr"`
+}
+
+// IsAdmin ...
+func (p Player) IsAdmin() bool { return strings.Contains(p.Status, "a") }
+
+// IsVacation ...
+func (p Player) IsVacation() bool { return strings.Contains(p.Status, "v") }
+
+// IsInactive returns true if the player is inactive (short or long)
+func (p Player) IsInactive() bool { return strings.ContainsAny(p.Status, "iI") }
+
+// IsLongInactive ...
+func (p Player) IsLongInactive() bool { return strings.Contains(p.Status, "I") }
+
+// IsBanned ...
+func (p Player) IsBanned() bool { return strings.Contains(p.Status, "b") }
+
+// IsOutlaw ...
+func (p Player) IsOutlaw() bool { return strings.Contains(p.Status, "o") }
+
+// Alliances alliances.xml, updated every day
+type Alliances struct {
+	XMLName   xml.Name   `xml:"alliances"`
+	Timestamp int64      `xml:"timestamp,attr"`
+	ServerID  string     `xml:"serverId,attr"`
+	Alliances []Alliance `xml:"alliance"`
+}
+
+// Alliance public information of an alliance
+type Alliance struct {
+	ID        int64  `xml:"id,attr"`
+	Name      string `xml:"name,attr"`
+	Tag       string `xml:"tag,attr"`
+	Founder   int64  `xml:"founder,attr"`
+	FoundDate int64  `xml:"foundDate,attr"`
+	Logo      string `xml:"logo,attr"`
+	Homepage  string `xml:"homepage,attr"`
+	Open      int64  `xml:"open,attr"`
+	Members   []struct {
+		ID int64 `xml:"id,attr"`
+	} `xml:"player"`
+}
+
+// AllianceInfos converts into ogame.AllianceInfos (the rank is not part of alliances.xml)
+func (a Alliance) AllianceInfos() ogame.AllianceInfos {
+	return ogame.AllianceInfos{ID: a.ID, Name: a.Name, Tag: a.Tag, Member: int64(len(a.Members))}
+}
+
+// Universe universe.xml, updated every week
+type Universe struct {
+	XMLName   xml.Name `xml:"universe"`
+	Timestamp int64    `xml:"timestamp,attr"`
+	ServerID  string   `xml:"serverId,attr"`
+	Planets   []Planet `xml:"planet"`
+}
+
+// PlanetsOf returns the planets of a player
+func (u Universe) PlanetsOf(playerID int64) []Planet {
+	out := make([]Planet, 0)
+	for _, planet := range u.Planets {
+		if planet.PlayerID == playerID {
+			out = append(out, planet)
+		}
+	}
+	return out
+}
+
+// Planet public information of a planet
+type Planet struct {
+	ID       int64  `xml:"id,attr"`
+	PlayerID int64  `xml:"player,attr"` // not set in playerData.xml
+	Name     string `xml:"name,attr"`
+	Coords   string `xml:"coords,attr"`
+	Moon     *Moon  `xml:"moon"`
+}
+
+// Coordinate returns the coordinate of the planet
+func (p Planet) Coordinate() ogame.Coordinate {
+	coord, _ := ogame.ParseCoord(p.Coords)
+	return coord
+}
+
+// MoonCoordinate returns the coordinate of the moon of the planet
+func (p Planet) MoonCoordinate() (ogame.Coordinate, bool) {
+	if p.Moon == nil {
+		return ogame.Coordinate{}, false
+	}
+	return p.Coordinate().Moon(), true
+}
+
+// Moon public information of a moon
+type Moon struct {
+	ID   int64  `xml:"id,attr"`
+	Name string `xml:"name,attr"`
+	Size int64  `xml:"size,attr"`
+}
+
+// Highscore highscore.xml, updated every hour
+type Highscore struct {
+	XMLName   xml.Name         `xml:"highscore"`
+	Category  int64            `xml:"category,attr"` // 1:Player, 2:Alliance
+	Type      int64            `xml:"type,attr"`     // 0:Total, 1:Economy, 2:Research, 3:Military, 4:Military Built, 5:Military Destroyed, 6:Military Lost, 7:Honor
+	Timestamp int64            `xml:"timestamp,attr"`
+	ServerID  string           `xml:"serverId,attr"`
+	Players   []HighscoreEntry `xml:"player"`
+	Alliances []HighscoreEntry `xml:"alliance"`
+}
+
+// HighscoreEntry a line of the highscore
+type HighscoreEntry struct {
+	Position int64 `xml:"position,attr"`
+	ID       int64 `xml:"id,attr"`
+	Score    int64 `xml:"score,attr"`
+	Ships    int64 `xml:"ships,attr"` // When getting military type
+}
+
+// ToHighscore converts into ogame.Highscore.
+// players is optional, and is used to fill the name and alliance of each player.
+func (h Highscore) ToHighscore(players *Players) ogame.Highscore {
+	entries := h.Players
+	if h.Category == 2 {
+		entries = h.Alliances
+	}
+	out := ogame.Highscore{NbPage: 1, CurrPage: 1, Category: h.Category, Type: h.Type}
+	out.Players = make([]ogame.HighscorePlayer, 0, len(entries))
+	for _, entry := range entries {
+		p := ogame.HighscorePlayer{Position: entry.Position, ID: entry.ID, Score: entry.Score, Ships: entry.Ships}
+		if players != nil && h.Category == 1 {
+			if player, ok := players.ByID(entry.ID); ok {
+				p.Name = player.Name
+				p.AllianceID = player.AllianceID
+			}
+		}
+		out.Players = append(out.Players, p)
+	}
+	return out
+}
+
+// PlayerData playerData.xml?id=<playerID>, updated every week
+type PlayerData struct {
+	XMLName   xml.Name `xml:"playerData"`
+	ID        int64    `xml:"id,attr"`
+	Name      string   `xml:"name,attr"`
+	ServerID  string   `xml:"serverId,attr"`
+	Timestamp int64    `xml:"timestamp,attr"`
+	Positions []struct {
+		Type     int64 `xml:"type,attr"`
+		Score    int64 `xml:"score,attr"`
+		Ships    int64 `xml:"ships,attr"`
+		Position int64 `xml:",chardata"`
+	} `xml:"positions>position"`
+	Planets  []Planet `xml:"planets>planet"`
+	Alliance *struct {
+		ID   int64  `xml:"id,attr"`
+		Name string `xml:"name"`
+		Tag  string `xml:"tag"`
+	} `xml:"alliance"`
+}
+
+// Homeworld returns the coordinate of the player homeworld (the oldest planet)
+func (p PlayerData) Homeworld() ogame.Coordinate {
+	var homeworld *Planet
+	for i, planet := range p.Planets {
+		if homeworld == nil || planet.ID < homeworld.ID {
+			homeworld = &p.Planets[i]
+		}
+	}
+	if homeworld == nil {
+		return ogame.Coordinate{}
+	}
+	return homeworld.Coordinate()
+}
+
+// HighscorePlayer converts into ogame.HighscorePlayer for the given highscore type
+func (p PlayerData) HighscorePlayer(typ int64) ogame.HighscorePlayer {
+	out := ogame.HighscorePlayer{ID: p.ID, Name: p.Name, Homeworld: p.Homeworld()}
+	if p.Alliance != nil {
+		out.AllianceID = p.Alliance.ID
+	}
+	for _, position := range p.Positions {
+		if position.Type == 7 {
+			out.HonourPoints = position.Score
+		}
+		if position.Type == typ {
+			out.Position = position.Position
+			out.Score = position.Score
+			out.Ships = position.Ships
+		}
+	}
+	return out
+}
+
+// Localization localization.xml, static
+type Localization struct {
+	XMLName   xml.Name           `xml:"localization"`
+	Timestamp int64              `xml:"timestamp,attr"`
+	ServerID  string             `xml:"serverId,attr"`
+	Techs     []LocalizationName `xml:"techs>name"`
+	Missions  []LocalizationName `xml:"missions>name"`
+}
+
+// LocalizationName ...
+type LocalizationName struct {
+	ID   int64  `xml:"id,attr"`
+	Name string `xml:",chardata"`
+}
+
+// TechName returns the localized name of an ogame object
+func (l Localization) TechName(id ogame.ID) string {
+	for _, tech := range l.Techs {
+		if tech.ID == id.Int64() {
+			return tech.Name
+		}
+	}
+	return ""
+}
+
+// MissionName returns the localized name of a mission
+func (l Localization) MissionName(missionID ogame.MissionID) string {
+	for _, mission := range l.Missions {
+		if mission.ID == int64(missionID) {
+			return mission.Name
+		}
+	}
+	return ""
+}
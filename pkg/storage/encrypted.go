@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrDecrypt returned when a value cannot be decrypted, usually because of a wrong passphrase
+var ErrDecrypt = errors.New("cannot decrypt storage value")
+
+// Every value is stored as: magic | salt | nonce | AES-256-GCM ciphertext
+var encryptedMagic = []byte("OGS1")
+
+const (
+	saltSize = 16
+	keySize  = 32
+)
+
+// EncryptedStore encrypts the values of another store with a passphrase.
+// Keys are left in clear, only the values are encrypted.
+type EncryptedStore struct {
+	inner      Store
+	passphrase []byte
+	salt       []byte // salt of the values written by this instance
+	mu         sync.Mutex
+	keys       map[string][]byte // derived keys by salt, scrypt is slow on purpose
+}
+
+// NewEncryptedStore creates a store that encrypts the values before giving them to inner
+func NewEncryptedStore(inner Store, passphrase string) (*EncryptedStore, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase must be specified")
+	}
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	return &EncryptedStore{inner: inner, passphrase: []byte(passphrase), salt: salt, keys: make(map[string][]byte)}, nil
+}
+
+// NewEncryptedFileStore creates an encrypted store persisted on disk, rooted at dir
+func NewEncryptedFileStore(dir, passphrase string) (*EncryptedStore, error) {
+	return NewEncryptedStore(NewFileStore(dir), passphrase)
+}
+
+func (s *EncryptedStore) aead(salt []byte) (cipher.AEAD, error) {
+	s.mu.Lock()
+	key, ok := s.keys[string(salt)]
+	s.mu.Unlock()
+	if !ok {
+		var err error
+		key, err = scrypt.Key(s.passphrase, salt, 1<<15, 8, 1, keySize)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.keys[string(salt)] = key
+		s.mu.Unlock()
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Get ...
+func (s *EncryptedStore) Get(key string) ([]byte, error) {
+	by, err := s.inner.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(by, encryptedMagic) || len(by) < len(encryptedMagic)+saltSize {
+		return nil, ErrDecrypt
+	}
+	by = by[len(encryptedMagic):]
+	aead, err := s.aead(by[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+	by = by[saltSize:]
+	if len(by) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	// The key is authenticated, a value cannot be moved to another key
+	value, err := aead.Open(nil, by[:aead.NonceSize()], by[aead.NonceSize():], []byte(key))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return value, nil
+}
+
+// Set ...
+func (s *EncryptedStore) Set(key string, value []byte) error {
+	aead, err := s.aead(s.salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	out := make([]byte, 0, len(encryptedMagic)+saltSize+len(nonce)+len(value)+aead.Overhead())
+	out = append(out, encryptedMagic...)
+	out = append(out, s.salt...)
+	out = append(out, nonce...)
+	out = aead.Seal(out, nonce, value, []byte(key))
+	return s.inner.Set(key, out)
+}
+
+// Delete ...
+func (s *EncryptedStore) Delete(key string) error {
+	return s.inner.Delete(key)
+}
+
+// List ...
+func (s *EncryptedStore) List(prefix string) ([]string, error) {
+	return s.inner.List(prefix)
+}
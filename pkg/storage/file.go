@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileStore store persisted on disk, every key is a file under the root directory
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a store rooted at dir, the directory is created on the first write
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// NewDefaultFileStore creates a store rooted at ~/.ogame
+func NewDefaultFileStore() *FileStore {
+	return NewFileStore(DefaultDir())
+}
+
+// Dir root directory of the store
+func (s *FileStore) Dir() string {
+	return s.dir
+}
+
+func (s *FileStore) filename(key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Get ...
+func (s *FileStore) Get(key string) ([]byte, error) {
+	filename, err := s.filename(key)
+	if err != nil {
+		return nil, err
+	}
+	by, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return by, err
+}
+
+// Set writes the value to a temporary file which is then renamed,
+// so that a crash never leaves a half written cookie jar behind.
+func (s *FileStore) Set(key string, value []byte) error {
+	filename, err := s.filename(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, value, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// Delete ...
+func (s *FileStore) Delete(key string) error {
+	filename, err := s.filename(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List ...
+func (s *FileStore) List(prefix string) ([]string, error) {
+	// Only walk the directory that can contain the prefix
+	root := s.dir
+	if idx := strings.LastIndex(prefix, "/"); idx != -1 {
+		dir, err := cleanKey(prefix[:idx])
+		if err != nil {
+			return nil, err
+		}
+		root = filepath.Join(s.dir, filepath.FromSlash(dir))
+	}
+	keys := make([]string, 0)
+	err := filepath.WalkDir(root, func(filename string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() || strings.HasSuffix(filename, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, filename)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, path.Clean(key))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -0,0 +1,79 @@
+// Package storage keeps the session of a bot (cookies, bearer token, device fingerprint, debug dumps)
+// behind a small key/value interface, so that it does not have to live in files in ~/.ogame.
+package storage
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound returned when the store has no value for the key
+var ErrNotFound = errors.New("not found in storage")
+
+// ErrInvalidKey returned when a key is empty, absolute or escapes the store
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// NotLoggedPrefix prefix of the keys of the pages dumped when the bot finds itself logged out
+const NotLoggedPrefix = "not_logged/"
+
+// Store persistence layer for the session of the bot.
+// Keys are slash separated paths, eg: storage/device_name/cookies
+type Store interface {
+	// Get returns ErrNotFound when the key does not exist
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte) error
+	// Delete does not fail when the key does not exist
+	Delete(key string) error
+	// List returns the keys starting with prefix, sorted
+	List(prefix string) ([]string, error)
+}
+
+// Compile time checks
+var _ Store = (*MemoryStore)(nil)
+var _ Store = (*FileStore)(nil)
+var _ Store = (*EncryptedStore)(nil)
+
+// DefaultDir root directory of the default file store, ~/.ogame.
+// Falls back to <tmp>/.ogame when the home directory is unknown (no $HOME in a container).
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = os.TempDir()
+	}
+	return filepath.Join(home, ".ogame")
+}
+
+// The keys below mirror the historical layout of ~/.ogame, so a FileStore on DefaultDir keeps using the existing files.
+
+// FingerprintKey key of the javascript fingerprint of a device
+func FingerprintKey(deviceName string) string {
+	return path.Join("storage", deviceName, "fingerprint")
+}
+
+// CookiesKey key of the cookie jar of a device
+func CookiesKey(deviceName string) string {
+	return path.Join("storage", deviceName, "cookies")
+}
+
+// BearerTokenKey key of the gameforge bearer token last used by a device.
+// The token gives access to the account until it expires, it is a secret at rest:
+// use an EncryptedStore when the storage is not on a trusted disk.
+func BearerTokenKey(deviceName string) string {
+	return path.Join("storage", deviceName, "bearer_token")
+}
+
+// AccountKey key of a file kept for the bot of an account (eg: recall rules, universe scanner state)
+func AccountKey(universe, username, name string) string {
+	return path.Join("storage", universe+"_"+username, name)
+}
+
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean(key)
+	if key == "" || path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
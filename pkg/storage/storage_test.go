@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func checkStore(t *testing.T, s Store) {
+	_, err := s.Get("storage/device/cookies")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, s.Set("storage/device/cookies", []byte(`[]`)))
+	assert.NoError(t, s.Set("storage/device/bearer_token", []byte("token")))
+	assert.NoError(t, s.Set("not_logged/b.html", []byte("b")))
+	assert.NoError(t, s.Set("not_logged/a.html", []byte("a")))
+	by, err := s.Get("storage/device/bearer_token")
+	assert.NoError(t, err)
+	assert.Equal(t, "token", string(by))
+
+	keys, err := s.List(NotLoggedPrefix)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"not_logged/a.html", "not_logged/b.html"}, keys)
+	keys, _ = s.List("storage/dev")
+	assert.Equal(t, []string{"storage/device/bearer_token", "storage/device/cookies"}, keys)
+	keys, _ = s.List("nothing/")
+	assert.Equal(t, []string{}, keys)
+
+	assert.NoError(t, s.Delete("not_logged/a.html"))
+	assert.NoError(t, s.Delete("not_logged/a.html"))
+	keys, _ = s.List(NotLoggedPrefix)
+	assert.Equal(t, []string{"not_logged/b.html"}, keys)
+
+	assert.ErrorIs(t, s.Set("../outside", []byte("x")), ErrInvalidKey)
+	_, err = s.Get("")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestMemoryStore(t *testing.T) {
+	checkStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	s := NewFileStore(dir)
+	checkStore(t, s)
+
+	// Keys map to the historical files of ~/.ogame
+	by, err := os.ReadFile(filepath.Join(dir, "storage", "device", "bearer_token"))
+	assert.NoError(t, err)
+	assert.Equal(t, "token", string(by))
+	_, err = os.Stat(filepath.Join(filepath.Dir(dir), "outside"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestEncryptedStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewEncryptedFileStore(dir, "secret")
+	assert.NoError(t, err)
+	checkStore(t, s)
+
+	raw, _ := os.ReadFile(filepath.Join(dir, "storage", "device", "bearer_token"))
+	assert.False(t, bytes.Contains(raw, []byte("token")))
+
+	// A new instance (new salt for its writes) can read what the previous one wrote
+	s2, _ := NewEncryptedFileStore(dir, "secret")
+	by, err := s2.Get("storage/device/bearer_token")
+	assert.NoError(t, err)
+	assert.Equal(t, "token", string(by))
+
+	wrong, _ := NewEncryptedFileStore(dir, "wrong")
+	_, err = wrong.Get("storage/device/bearer_token")
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	// A value cannot be moved to another key
+	inner := NewFileStore(dir)
+	assert.NoError(t, inner.Set("storage/device/cookies", raw))
+	_, err = s.Get("storage/device/cookies")
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = NewEncryptedStore(NewMemoryStore(), "")
+	assert.Error(t, err)
+}
+
+func TestDefaultDir_NoHome(t *testing.T) {
+	t.Setenv("HOME", "")
+	t.Setenv("TMPDIR", "/tmp/ogame-test")
+	assert.Equal(t, filepath.Join("/tmp/ogame-test", ".ogame"), DefaultDir())
+}
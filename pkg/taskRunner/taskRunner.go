@@ -2,7 +2,9 @@ package taskRunner
 
 import (
 	"context"
+	"strconv"
 	"sync"
+	"time"
 )
 
 type Priority int64
@@ -15,6 +17,20 @@ const (
 	Critical
 )
 
+func (p Priority) String() string {
+	switch p {
+	case Low:
+		return "low"
+	case Normal:
+		return "normal"
+	case Important:
+		return "important"
+	case Critical:
+		return "critical"
+	}
+	return strconv.FormatInt(int64(p), 10)
+}
+
 // item ...
 type item struct {
 	canBeProcessedCh chan struct{}
@@ -48,6 +64,10 @@ type TaskRunner[T ITask] struct {
 	tasksPopCh  chan struct{}
 	factory     func() T
 	ctx         context.Context
+	onTaskStart func(priority Priority, wait time.Duration)
+	pauseLock   sync.Mutex
+	pauseCause  string
+	resumeCh    chan struct{} // nil while the runner is not paused
 }
 
 type ITask interface {
@@ -81,6 +101,13 @@ func (r *TaskRunner[T]) start() {
 	}()
 	go func() {
 		for range r.tasksPopCh {
+			if resumeCh := r.getResumeCh(); resumeCh != nil {
+				select {
+				case <-resumeCh:
+				case <-r.ctx.Done():
+					return
+				}
+			}
 			r.tasksLock.Lock()
 			task := r.tasks.Pop()
 			r.tasksLock.Unlock()
@@ -94,7 +121,48 @@ func (r *TaskRunner[T]) start() {
 	}()
 }
 
+// SetOnTaskStart sets a function called when a task starts, with the time it waited in the queue.
+// It must be set before the first task is queued.
+func (r *TaskRunner[T]) SetOnTaskStart(clb func(priority Priority, wait time.Duration)) {
+	r.onTaskStart = clb
+}
+
+// Pause stops starting the queued tasks until Resume is called, the running task is not interrupted
+func (r *TaskRunner[T]) Pause(cause string) {
+	r.pauseLock.Lock()
+	defer r.pauseLock.Unlock()
+	r.pauseCause = cause
+	if r.resumeCh == nil {
+		r.resumeCh = make(chan struct{})
+	}
+}
+
+// Resume starts the queued tasks again, by priority
+func (r *TaskRunner[T]) Resume() {
+	r.pauseLock.Lock()
+	defer r.pauseLock.Unlock()
+	r.pauseCause = ""
+	if r.resumeCh != nil {
+		close(r.resumeCh)
+		r.resumeCh = nil
+	}
+}
+
+// Paused returns either or not the runner is paused, and the cause given to Pause
+func (r *TaskRunner[T]) Paused() (bool, string) {
+	r.pauseLock.Lock()
+	defer r.pauseLock.Unlock()
+	return r.resumeCh != nil, r.pauseCause
+}
+
+func (r *TaskRunner[T]) getResumeCh() chan struct{} {
+	r.pauseLock.Lock()
+	defer r.pauseLock.Unlock()
+	return r.resumeCh
+}
+
 func (r *TaskRunner[T]) WithPriority(priority Priority) T {
+	queuedAt := time.Now()
 	canBeProcessedCh := make(chan struct{})
 	taskIsDoneCh := make(chan struct{})
 	task := new(item)
@@ -103,6 +171,9 @@ func (r *TaskRunner[T]) WithPriority(priority Priority) T {
 	task.isDoneCh = taskIsDoneCh
 	r.tasksPushCh <- task
 	<-canBeProcessedCh
+	if r.onTaskStart != nil {
+		r.onTaskStart(priority, time.Since(queuedAt))
+	}
 	t := r.factory()
 	t.SetTaskDoneCh(taskIsDoneCh)
 	return t
@@ -1,8 +1,12 @@
 package taskRunner
 
 import (
+	"context"
 	"fmt"
+	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 type testItem struct {
@@ -39,3 +43,34 @@ func (i *testItem) DoSomethingElse(name string) {
 //	go func() { time.Sleep(470 * time.Millisecond); tr.WithPriority(Important).DoSomething("F"); wg.Done() }()
 //	wg.Wait()
 //}
+
+func TestTaskRunner_Pause(t *testing.T) {
+	factory := func() *testItem { return &testItem{} }
+	tr := NewTaskRunner[*testItem](context.Background(), factory)
+	tr.Pause("maintenance")
+	paused, cause := tr.Paused()
+	assert.True(t, paused)
+	assert.Equal(t, "maintenance", cause)
+
+	started := make(chan struct{})
+	go func() {
+		task := tr.WithPriority(Normal)
+		close(started)
+		close(task.taskDoneCh)
+	}()
+	select {
+	case <-started:
+		t.Fatal("task started while the runner is paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	tr.Resume()
+	paused, cause = tr.Paused()
+	assert.False(t, paused)
+	assert.Equal(t, "", cause)
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("task not started after resume")
+	}
+}
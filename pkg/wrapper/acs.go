@@ -0,0 +1,135 @@
+package wrapper
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+)
+
+// ErrInvalidHoldingTime returned when an ACS defend holding time is not valid
+var ErrInvalidHoldingTime = errors.New("holding time must be in [0, 32] hours")
+
+// ErrUnknownFlightTime returned when the flight time of a fleet cannot be computed (no ships, server data not loaded)
+var ErrUnknownFlightTime = errors.New("unable to compute the flight time")
+
+// ACSMaxHoldingTime maximum holding time (hours) of an ACS defend fleet
+const ACSMaxHoldingTime = 32
+
+// GetUnionArrivalTime returns the arrival time of a union, using our own fleets and the event list
+func GetUnionArrivalTime(b Wrapper, unionID int64) (time.Time, error) {
+	fleets, _ := b.GetFleets()
+	fleets = append(fleets, b.GetFleetsFromEventList()...)
+	arrival, ok := unionArrivalTime(fleets, unionID)
+	if !ok {
+		return time.Time{}, ogame.ErrUnionNotFound
+	}
+	return arrival, nil
+}
+
+// PlanJoinUnion finds the speed to use to join a union with ships sent from celestialID
+func PlanJoinUnion(b Wrapper, celestialID ogame.CelestialID, ships ogame.ShipsInfos, unionID int64) (ogame.ACSValues, ogame.ACSJoinPlan, error) {
+	origin, err := b.GetCachedCelestial(celestialID)
+	if err != nil {
+		return ogame.ACSValues{}, ogame.ACSJoinPlan{}, ErrInvalidOrigin
+	}
+	unions, err := b.GetUnions(origin.GetID())
+	if err != nil {
+		return ogame.ACSValues{}, ogame.ACSJoinPlan{}, err
+	}
+	union, ok := findUnion(unions, unionID)
+	if !ok {
+		return ogame.ACSValues{}, ogame.ACSJoinPlan{}, ogame.ErrUnionNotFound
+	}
+	arrival, err := GetUnionArrivalTime(b, unionID)
+	if err != nil {
+		return union, ogame.ACSJoinPlan{}, err
+	}
+	serverData := b.GetServerData()
+	lfBonuses, _ := b.GetCachedLfBonuses()
+	allianceClass, _ := b.GetCachedAllianceClass()
+	var systemsSkip int64
+	if serverData.FleetIgnoreEmptySystems || serverData.FleetIgnoreInactiveSystems {
+		res, _ := b.CheckTarget(ships, union.Coordinate(), ChangePlanet(origin.GetID()))
+		if serverData.FleetIgnoreEmptySystems {
+			systemsSkip += res.EmptySystems
+		}
+		if serverData.FleetIgnoreInactiveSystems {
+			systemsSkip += res.InactiveSystems
+		}
+	}
+	plan, err := ogame.PlanACSJoin(ogame.FlightParams{
+		Origin:         origin.GetCoordinate(),
+		Destination:    union.Coordinate(),
+		Ships:          ships,
+		Mission:        ogame.GroupedAttack,
+		Researches:     b.GetCachedResearch(),
+		LfBonuses:      lfBonuses,
+		CharacterClass: b.CharacterClass(),
+		AllianceClass:  allianceClass,
+		Universe:       getFlightUniverse(serverData),
+		SystemsSkip:    systemsSkip,
+	}, time.Now(), arrival)
+	return union, plan, err
+}
+
+// JoinUnion sends ships to join a union, using the slowest speed that arrives in time
+func JoinUnion(b Wrapper, celestialID ogame.CelestialID, ships ogame.ShipsInfos, unionID int64) (ogame.Fleet, error) {
+	union, plan, err := PlanJoinUnion(b, celestialID, ships, unionID)
+	if err != nil {
+		return ogame.Fleet{}, err
+	}
+	return b.SendFleet(celestialID, ships, plan.Speed, union.Coordinate(), ogame.GroupedAttack, ogame.Resources{}, 0, unionID)
+}
+
+// ACSDefend sends ships to defend an ally planet/moon for holdingTime hours
+func ACSDefend(b Wrapper, celestialID ogame.CelestialID, ships ogame.ShipsInfos, speed ogame.Speed, where ogame.Coordinate, holdingTime int64) (ogame.Fleet, error) {
+	if holdingTime < 0 || holdingTime > ACSMaxHoldingTime {
+		return ogame.Fleet{}, ErrInvalidHoldingTime
+	}
+	return b.SendFleet(celestialID, ships, speed, where, ogame.ParkInThatAlly, ogame.Resources{}, holdingTime, 0)
+}
+
+// ACSDefendArrivingAt sends ships to defend an ally planet/moon using the slowest speed
+// that gets the fleet stationed before arrival
+func ACSDefendArrivingAt(b Wrapper, celestialID ogame.CelestialID, ships ogame.ShipsInfos, where ogame.Coordinate, arrival time.Time, holdingTime int64) (ogame.Fleet, error) {
+	origin, err := b.GetCachedCelestial(celestialID)
+	if err != nil {
+		return ogame.Fleet{}, ErrInvalidOrigin
+	}
+	now := time.Now()
+	speeds := ogame.AvailableSpeeds(b.CharacterClass())
+	for _, speed := range speeds {
+		secs, _ := b.FlightTime(origin.GetCoordinate(), where, speed, ships, ogame.ParkInThatAlly)
+		if secs <= 0 {
+			return ogame.Fleet{}, ErrUnknownFlightTime
+		}
+		if !now.Add(time.Duration(secs) * time.Second).After(arrival) {
+			return ACSDefend(b, celestialID, ships, speed, where, holdingTime)
+		}
+	}
+	return ogame.Fleet{}, ogame.ErrCannotArriveInTime
+}
+
+func findUnion(unions []ogame.ACSValues, unionID int64) (ogame.ACSValues, bool) {
+	for _, union := range unions {
+		if union.Union == unionID {
+			return union, true
+		}
+	}
+	return ogame.ACSValues{}, false
+}
+
+// Returns the arrival time of the union (latest arrival of its fleets)
+func unionArrivalTime(fleets []ogame.Fleet, unionID int64) (arrival time.Time, found bool) {
+	for _, fleet := range fleets {
+		if fleet.UnionID != unionID || fleet.ReturnFlight {
+			continue
+		}
+		if !found || fleet.ArrivalTime.After(arrival) {
+			arrival = fleet.ArrivalTime
+			found = true
+		}
+	}
+	return
+}
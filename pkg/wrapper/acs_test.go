@@ -0,0 +1,59 @@
+package wrapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnionArrivalTime(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	fleets := []ogame.Fleet{
+		{UnionID: 1, ArrivalTime: now.Add(time.Minute)},
+		{UnionID: 1, ArrivalTime: now.Add(3 * time.Minute)},
+		{UnionID: 1, ArrivalTime: now.Add(5 * time.Minute), ReturnFlight: true},
+		{UnionID: 2, ArrivalTime: now.Add(10 * time.Minute)},
+	}
+	arrival, found := unionArrivalTime(fleets, 1)
+	assert.True(t, found)
+	assert.Equal(t, now.Add(3*time.Minute), arrival)
+	_, found = unionArrivalTime(fleets, 3)
+	assert.False(t, found)
+}
+
+func TestFindUnion(t *testing.T) {
+	unions := []ogame.ACSValues{{Galaxy: 1, System: 2, Position: 3, CelestialType: ogame.MoonType, Union: 123}}
+	union, found := findUnion(unions, 123)
+	assert.True(t, found)
+	assert.Equal(t, ogame.Coordinate{Galaxy: 1, System: 2, Position: 3, Type: ogame.MoonType}, union.Coordinate())
+	_, found = findUnion(unions, 456)
+	assert.False(t, found)
+}
+
+// Wrapper whose flight time is fixed
+type flightTimeWrapper struct {
+	Wrapper
+	secs int64
+}
+
+func (w flightTimeWrapper) GetCachedCelestial(IntoCelestial) (Celestial, error) {
+	return Planet{Planet: ogame.Planet{Coordinate: ogame.Coordinate{Galaxy: 1, System: 1, Position: 1, Type: ogame.PlanetType}}}, nil
+}
+func (w flightTimeWrapper) CharacterClass() ogame.CharacterClass { return ogame.Collector }
+func (w flightTimeWrapper) FlightTime(ogame.Coordinate, ogame.Coordinate, ogame.Speed, ogame.ShipsInfos, ogame.MissionID) (int64, int64) {
+	return w.secs, 0
+}
+
+func TestACSDefendArrivingAt(t *testing.T) {
+	ships := ogame.ShipsInfos{SmallCargo: 1}
+	where := ogame.Coordinate{Galaxy: 1, System: 2, Position: 3, Type: ogame.PlanetType}
+	arrival := time.Now().Add(time.Hour)
+
+	_, err := ACSDefendArrivingAt(flightTimeWrapper{secs: 0}, ogame.CelestialID(1), ships, where, arrival, 1)
+	assert.ErrorIs(t, err, ErrUnknownFlightTime)
+
+	_, err = ACSDefendArrivingAt(flightTimeWrapper{secs: 7200}, ogame.CelestialID(1), ships, where, arrival, 1)
+	assert.ErrorIs(t, err, ogame.ErrCannotArriveInTime)
+}
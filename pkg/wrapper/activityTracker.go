@@ -0,0 +1,552 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/taskRunner"
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+// ErrPlayerNotTracked returned when asking the activity of a player that is not tracked
+var ErrPlayerNotTracked = errors.New("player is not tracked")
+
+// ErrNoPlanetsToTrack returned when the planets of a player could not be found
+var ErrNoPlanetsToTrack = errors.New("no known planets for player")
+
+// Galaxy activity only tells if a celestial was active within the last hour
+const galaxyActivityWindow = time.Hour
+
+// Maximum number of samples kept for a player (about 6 weeks at the default sample interval), older ones are dropped
+const maxActivitySamples = 4000
+
+// Activity sources
+const (
+	GalaxyActivity  = "galaxy"
+	PhalanxActivity = "phalanx"
+)
+
+// ActivityTrackerConfig configuration of the activity tracker
+type ActivityTrackerConfig struct {
+	SampleInterval     time.Duration // Minimum delay between two samples of the same system (default: 15m)
+	MaxRequestsPerHour int64         // Maximum number of galaxy pages loaded per hour (default: 60)
+	Interval           time.Duration // Delay between two iterations of the tracker (default: 1m)
+	GalaxyScanDelay    time.Duration // Delay added before each galaxy page load
+	SleepThreshold     float64       // An hour of the day is considered asleep when the player is online less often than this (default: 0.1)
+	MinSamplesPerHour  int64         // Hours of the day with fewer samples are not used to predict sleep windows (default: 2)
+}
+
+// ActivitySample activity of a player observed at a given time
+type ActivitySample struct {
+	At         time.Time
+	Online     bool      // Active within the last 15 minutes
+	LastActive time.Time // Last known activity, zero if the player was not active within the last hour
+	Source     string    // galaxy | phalanx
+}
+
+// ActivityInterval period during which a player was online or offline
+type ActivityInterval struct {
+	From   time.Time
+	To     time.Time
+	Online bool
+}
+
+// SleepWindow hours of the day (server time) during which the player is rarely online.
+// EndHour is exclusive and can be smaller than StartHour when the window wraps around midnight.
+type SleepWindow struct {
+	StartHour  int
+	EndHour    int
+	Confidence float64 // 1 - online rate during the window
+}
+
+// Contains returns true if the hour is within the window
+func (w SleepWindow) Contains(hour int) bool {
+	if w.StartHour < w.EndHour {
+		return hour >= w.StartHour && hour < w.EndHour
+	}
+	return hour >= w.StartHour || hour < w.EndHour
+}
+
+// ActivityTimeline everything known about the activity of a tracked player
+type ActivityTimeline struct {
+	PlayerID     int64
+	PlayerName   string
+	Coordinates  []ogame.Coordinate
+	Samples      []ActivitySample
+	Intervals    []ActivityInterval
+	SleepWindows []SleepWindow
+}
+
+type trackedPlayer struct {
+	id          int64
+	name        string
+	coordinates []ogame.Coordinate
+	samples     []ActivitySample
+}
+
+type galaxySystem struct {
+	galaxy int64
+	system int64
+}
+
+// ActivityTracker samples the galaxy activity of selected players,
+// builds their online/offline timelines and predicts their sleep windows.
+type ActivityTracker struct {
+	b          Wrapper
+	cfg        ActivityTrackerConfig
+	mu         sync.RWMutex
+	players    map[int64]*trackedPlayer
+	sampledAt  map[galaxySystem]time.Time
+	requestsAt []time.Time
+	loop       backgroundLoop
+}
+
+// NewActivityTracker creates a new activity tracker
+func NewActivityTracker(b Wrapper, cfg ActivityTrackerConfig) *ActivityTracker {
+	cfg.SampleInterval = utils.Ternary(cfg.SampleInterval <= 0, 15*time.Minute, cfg.SampleInterval)
+	cfg.MaxRequestsPerHour = utils.Ternary(cfg.MaxRequestsPerHour <= 0, 60, cfg.MaxRequestsPerHour)
+	cfg.Interval = utils.Ternary(cfg.Interval <= 0, time.Minute, cfg.Interval)
+	cfg.SleepThreshold = utils.Ternary(cfg.SleepThreshold <= 0, 0.1, cfg.SleepThreshold)
+	cfg.MinSamplesPerHour = utils.Ternary(cfg.MinSamplesPerHour <= 0, 2, cfg.MinSamplesPerHour)
+	return &ActivityTracker{
+		b:         b,
+		cfg:       cfg,
+		players:   make(map[int64]*trackedPlayer),
+		sampledAt: make(map[galaxySystem]time.Time),
+	}
+}
+
+// Track starts tracking a player.
+// When no coordinates are given, the planets of the player are taken from the intel store,
+// or from the public api if the store does not know them.
+func (t *ActivityTracker) Track(playerID int64, coordinates ...ogame.Coordinate) error {
+	var name string
+	if len(coordinates) == 0 {
+		var err error
+		if name, coordinates, err = t.findPlanets(playerID); err != nil {
+			return err
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	player, ok := t.players[playerID]
+	if !ok {
+		player = &trackedPlayer{id: playerID}
+		t.players[playerID] = player
+	}
+	player.name = utils.Ternary(name != "", name, player.name)
+	player.coordinates = make([]ogame.Coordinate, 0, len(coordinates))
+	for _, coord := range coordinates {
+		player.coordinates = append(player.coordinates, coord.Planet())
+	}
+	return nil
+}
+
+func (t *ActivityTracker) findPlanets(playerID int64) (name string, out []ogame.Coordinate, err error) {
+	if store := t.b.GetIntelStore(); store != nil {
+		if planets, err := store.PlayerPlanets(playerID); err == nil && len(planets) > 0 {
+			for _, planet := range planets {
+				out = append(out, planet.Coordinate)
+			}
+			return planets[0].PlayerName, out, nil
+		}
+	}
+	universe, err := t.b.GetPublicAPI().Universe(context.Background())
+	if err != nil {
+		return "", nil, err
+	}
+	for _, planet := range universe.PlanetsOf(playerID) {
+		out = append(out, planet.Coordinate())
+	}
+	if len(out) == 0 {
+		return "", nil, ErrNoPlanetsToTrack
+	}
+	return "", out, nil
+}
+
+// Untrack stops tracking a player, and forget its samples
+func (t *ActivityTracker) Untrack(playerID int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.players, playerID)
+}
+
+// TrackedPlayers returns the ids of the tracked players
+func (t *ActivityTracker) TrackedPlayers() []int64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make([]int64, 0, len(t.players))
+	for id := range t.players {
+		out = append(out, id)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// Timeline returns the activity timeline of a tracked player
+func (t *ActivityTracker) Timeline(playerID int64) (ActivityTimeline, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	player, ok := t.players[playerID]
+	if !ok {
+		return ActivityTimeline{}, ErrPlayerNotTracked
+	}
+	return t.timeline(player), nil
+}
+
+// Timelines returns the activity timelines of all tracked players
+func (t *ActivityTracker) Timelines() []ActivityTimeline {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make([]ActivityTimeline, 0, len(t.players))
+	for _, player := range t.players {
+		out = append(out, t.timeline(player))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PlayerID < out[j].PlayerID })
+	return out
+}
+
+func (t *ActivityTracker) timeline(player *trackedPlayer) ActivityTimeline {
+	samples := append([]ActivitySample{}, player.samples...)
+	return ActivityTimeline{
+		PlayerID:     player.id,
+		PlayerName:   player.name,
+		Coordinates:  append([]ogame.Coordinate{}, player.coordinates...),
+		Samples:      samples,
+		Intervals:    activityIntervals(samples),
+		SleepWindows: predictSleepWindows(samples, t.b.Location(), t.cfg.SleepThreshold, t.cfg.MinSamplesPerHour),
+	}
+}
+
+// SleepWindows returns the predicted sleep windows of a tracked player, longest first
+func (t *ActivityTracker) SleepWindows(playerID int64) ([]SleepWindow, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	player, ok := t.players[playerID]
+	if !ok {
+		return nil, ErrPlayerNotTracked
+	}
+	return predictSleepWindows(player.samples, t.b.Location(), t.cfg.SleepThreshold, t.cfg.MinSamplesPerHour), nil
+}
+
+// IsLikelyAsleep returns true if the given time falls within one of the predicted sleep windows of the player
+func (t *ActivityTracker) IsLikelyAsleep(playerID int64, at time.Time) (bool, error) {
+	windows, err := t.SleepWindows(playerID)
+	if err != nil {
+		return false, err
+	}
+	hour := at.In(t.b.Location()).Hour()
+	for _, window := range windows {
+		if window.Contains(hour) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RunOnce samples the systems of the tracked players that are due, within the request budget
+func (t *ActivityTracker) RunOnce() error {
+	for _, system := range t.dueSystems(time.Now()) {
+		if !t.takeRequest(time.Now()) {
+			return nil
+		}
+		systemInfos, err := t.b.WithPriority(taskRunner.Low).GalaxyInfos(system.galaxy, system.system, Delay(t.cfg.GalaxyScanDelay))
+		if err != nil {
+			return err
+		}
+		t.RecordSystemInfos(systemInfos, time.Now())
+	}
+	return nil
+}
+
+// Returns the systems that need a new sample, the oldest first
+func (t *ActivityTracker) dueSystems(now time.Time) []galaxySystem {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	seen := make(map[galaxySystem]struct{})
+	out := make([]galaxySystem, 0)
+	for _, player := range t.players {
+		for _, coord := range player.coordinates {
+			system := galaxySystem{coord.Galaxy, coord.System}
+			if _, ok := seen[system]; ok {
+				continue
+			}
+			seen[system] = struct{}{}
+			if now.Sub(t.sampledAt[system]) >= t.cfg.SampleInterval {
+				out = append(out, system)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return t.sampledAt[out[i]].Before(t.sampledAt[out[j]]) })
+	return out
+}
+
+// Consumes one request from the hourly budget, returns false if the budget is exhausted
+func (t *ActivityTracker) takeRequest(now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	kept := t.requestsAt[:0]
+	for _, at := range t.requestsAt {
+		if now.Sub(at) < time.Hour {
+			kept = append(kept, at)
+		}
+	}
+	t.requestsAt = kept
+	if int64(len(t.requestsAt)) >= t.cfg.MaxRequestsPerHour {
+		return false
+	}
+	t.requestsAt = append(t.requestsAt, now)
+	return true
+}
+
+// RecordSystemInfos records the activity of the tracked players found in a galaxy system
+func (t *ActivityTracker) RecordSystemInfos(systemInfos ogame.SystemInfos, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sampledAt[galaxySystem{systemInfos.Galaxy(), systemInfos.System()}] = at
+	for _, player := range t.players {
+		activities := make([]int64, 0)
+		for _, coord := range player.coordinates {
+			if coord.Galaxy != systemInfos.Galaxy() || coord.System != systemInfos.System() {
+				continue
+			}
+			planetInfos := systemInfos.Position(coord.Position)
+			if planetInfos == nil || planetInfos.Player.ID != player.id {
+				continue
+			}
+			player.name = planetInfos.Player.Name
+			activities = append(activities, planetInfos.Activity)
+			if planetInfos.Moon != nil {
+				activities = append(activities, planetInfos.Moon.Activity)
+			}
+		}
+		if len(activities) > 0 {
+			player.addSample(activitySample(activities, at))
+		}
+	}
+}
+
+// RecordPhalanx records the activity of the tracked players that sent the fleets seen with the phalanx.
+// The phalanx does not give the speed of a fleet, the departure time is estimated assuming the fleet flies at 100%.
+func (t *ActivityTracker) RecordPhalanx(fleets []ogame.PhalanxFleet) {
+	serverData := t.b.GetServerData()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, fleet := range fleets {
+		if fleet.ReturnFlight {
+			continue
+		}
+		departure := fleet.StartTime
+		if departure.IsZero() && fleet.BaseSpeed > 0 {
+			secs := ogame.CalcFlightTimeWithBaseSpeed(fleet.Origin, fleet.Destination, serverData.Galaxies, serverData.Systems,
+				serverData.DonutGalaxy, serverData.DonutSystem, 1, fleet.BaseSpeed, t.b.GetUniverseSpeedFleet(), 0)
+			departure = fleet.ArrivalTime.Add(-time.Duration(secs) * time.Second)
+		}
+		if departure.IsZero() {
+			continue
+		}
+		for _, player := range t.players {
+			for _, coord := range player.coordinates {
+				if coord.Equal(fleet.Origin.Planet()) {
+					player.addSample(ActivitySample{At: departure, Online: true, LastActive: departure, Source: PhalanxActivity})
+					break
+				}
+			}
+		}
+	}
+}
+
+// Inserts the sample in chronological order, after the samples taken at the same time
+func (p *trackedPlayer) addSample(sample ActivitySample) {
+	idx := sort.Search(len(p.samples), func(i int) bool { return p.samples[i].At.After(sample.At) })
+	p.samples = append(p.samples, ActivitySample{})
+	copy(p.samples[idx+1:], p.samples[idx:])
+	p.samples[idx] = sample
+	if len(p.samples) > maxActivitySamples {
+		p.samples = append(p.samples[:0], p.samples[len(p.samples)-maxActivitySamples:]...)
+	}
+}
+
+// Builds a sample from the galaxy activities (0, 15, 16-59) of the celestials of a player
+func activitySample(activities []int64, at time.Time) ActivitySample {
+	sample := ActivitySample{At: at, Source: GalaxyActivity}
+	for _, activity := range activities {
+		if activity == 15 {
+			sample.Online = true
+			sample.LastActive = at
+		} else if activity > 15 && activity < 60 {
+			lastActive := at.Add(-time.Duration(activity) * time.Minute)
+			if lastActive.After(sample.LastActive) {
+				sample.LastActive = lastActive
+			}
+		}
+	}
+	return sample
+}
+
+// Builds the online/offline intervals covered by the samples.
+// Every galaxy sample covers the hour before it, during which the player is offline unless the activity says otherwise.
+func activityIntervals(samples []ActivitySample) []ActivityInterval {
+	covered := make([]ActivityInterval, 0)
+	online := make([]ActivityInterval, 0)
+	for _, sample := range samples {
+		if sample.Source == GalaxyActivity {
+			covered = append(covered, ActivityInterval{From: sample.At.Add(-galaxyActivityWindow), To: sample.At})
+		}
+		if sample.Online && sample.Source == GalaxyActivity {
+			online = append(online, ActivityInterval{From: sample.At.Add(-15 * time.Minute), To: sample.At, Online: true})
+		} else if !sample.LastActive.IsZero() {
+			online = append(online, ActivityInterval{From: sample.LastActive, To: sample.LastActive.Add(time.Minute), Online: true})
+		}
+	}
+	covered = mergeActivityIntervals(covered)
+	online = mergeActivityIntervals(online)
+
+	out := append([]ActivityInterval{}, online...)
+	for _, c := range covered {
+		from := c.From
+		for _, o := range online {
+			if !o.To.After(from) || !o.From.Before(c.To) {
+				continue
+			}
+			if o.From.After(from) {
+				out = append(out, ActivityInterval{From: from, To: o.From})
+			}
+			from = o.To
+		}
+		if c.To.After(from) {
+			out = append(out, ActivityInterval{From: from, To: c.To})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].From.Before(out[j].From) })
+	return out
+}
+
+// Merges overlapping intervals, the result is sorted
+func mergeActivityIntervals(intervals []ActivityInterval) []ActivityInterval {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].From.Before(intervals[j].From) })
+	out := make([]ActivityInterval, 0, len(intervals))
+	for _, interval := range intervals {
+		if len(out) > 0 && !interval.From.After(out[len(out)-1].To) {
+			if interval.To.After(out[len(out)-1].To) {
+				out[len(out)-1].To = interval.To
+			}
+			continue
+		}
+		out = append(out, interval)
+	}
+	return out
+}
+
+// Predicts sleep windows, the longest runs of consecutive hours of the day during which
+// the player was online less often than threshold.
+func predictSleepWindows(samples []ActivitySample, loc *time.Location, threshold float64, minSamplesPerHour int64) []SleepWindow {
+	if loc == nil {
+		loc = time.UTC
+	}
+	var total, online [24]int64
+	for _, sample := range samples {
+		hour := sample.At.In(loc).Hour()
+		total[hour]++
+		if sample.Online {
+			online[hour]++
+		} else if !sample.LastActive.IsZero() {
+			lastActiveHour := sample.LastActive.In(loc).Hour()
+			total[lastActiveHour]++
+			online[lastActiveHour]++
+		}
+	}
+	var asleep [24]bool
+	nbAsleep := 0
+	for hour := 0; hour < 24; hour++ {
+		if total[hour] >= minSamplesPerHour && float64(online[hour])/float64(total[hour]) < threshold {
+			asleep[hour] = true
+			nbAsleep++
+		}
+	}
+	out := make([]SleepWindow, 0)
+	if nbAsleep == 0 {
+		return out
+	}
+	newWindow := func(start, length int) SleepWindow {
+		var windowTotal, windowOnline int64
+		for i := 0; i < length; i++ {
+			windowTotal += total[(start+i)%24]
+			windowOnline += online[(start+i)%24]
+		}
+		return SleepWindow{StartHour: start, EndHour: (start + length) % 24, Confidence: 1 - float64(windowOnline)/float64(windowTotal)}
+	}
+	if nbAsleep == 24 {
+		return append(out, newWindow(0, 24))
+	}
+	// Start from an awake hour, so that a window wrapping around midnight is not cut in two
+	first := 0
+	for asleep[first] {
+		first++
+	}
+	for i := 1; i <= 24; i++ {
+		hour := (first + i) % 24
+		if !asleep[hour] || asleep[(hour+23)%24] {
+			continue
+		}
+		length := 0
+		for asleep[(hour+length)%24] {
+			length++
+		}
+		out = append(out, newWindow(hour, length))
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return (out[i].EndHour-out[i].StartHour+24)%24 > (out[j].EndHour-out[j].StartHour+24)%24
+	})
+	return out
+}
+
+// ExportJSON writes the timelines of all tracked players as json
+func (t *ActivityTracker) ExportJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(t.Timelines())
+}
+
+// ExportCSV writes the samples of all tracked players as csv
+// columns: player_id, player_name, at (RFC3339), online, last_active (RFC3339, empty if unknown), source
+func (t *ActivityTracker) ExportCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"player_id", "player_name", "at", "online", "last_active", "source"}); err != nil {
+		return err
+	}
+	for _, timeline := range t.Timelines() {
+		for _, sample := range timeline.Samples {
+			lastActive := ""
+			if !sample.LastActive.IsZero() {
+				lastActive = sample.LastActive.Format(time.RFC3339)
+			}
+			record := []string{utils.FI64(timeline.PlayerID), timeline.PlayerName, sample.At.Format(time.RFC3339),
+				strconv.FormatBool(sample.Online), lastActive, sample.Source}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Start runs the tracker in the background until Stop is called
+func (t *ActivityTracker) Start() {
+	_ = t.loop.start(nil, func() time.Duration {
+		_ = t.RunOnce()
+		return t.cfg.Interval
+	})
+}
+
+// Stop stops the tracker and waits for the current iteration to complete
+func (t *ActivityTracker) Stop() {
+	t.loop.stop()
+}
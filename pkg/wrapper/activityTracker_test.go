@@ -0,0 +1,81 @@
+package wrapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivitySample(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	sample := activitySample([]int64{0, 15}, now)
+	assert.True(t, sample.Online)
+	assert.Equal(t, now, sample.LastActive)
+	sample = activitySample([]int64{45, 20, 0}, now)
+	assert.False(t, sample.Online)
+	assert.Equal(t, now.Add(-20*time.Minute), sample.LastActive)
+	sample = activitySample([]int64{0, 0}, now)
+	assert.False(t, sample.Online)
+	assert.True(t, sample.LastActive.IsZero())
+}
+
+func TestTrackedPlayer_AddSample(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := &trackedPlayer{}
+	p.addSample(ActivitySample{At: now, Source: GalaxyActivity})
+	p.addSample(ActivitySample{At: now.Add(-time.Hour), Source: PhalanxActivity})
+	p.addSample(ActivitySample{At: now, Source: PhalanxActivity})
+	assert.Equal(t, []ActivitySample{
+		{At: now.Add(-time.Hour), Source: PhalanxActivity},
+		{At: now, Source: GalaxyActivity},
+		{At: now, Source: PhalanxActivity},
+	}, p.samples)
+
+	for i := 0; i < maxActivitySamples; i++ {
+		p.addSample(ActivitySample{At: now.Add(time.Duration(i) * time.Minute)})
+	}
+	// The oldest samples are dropped
+	assert.Equal(t, maxActivitySamples, len(p.samples))
+	assert.Equal(t, ActivitySample{At: now}, p.samples[0])
+}
+
+func TestActivityIntervals(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	samples := []ActivitySample{
+		activitySample([]int64{0}, now),
+		activitySample([]int64{15}, now.Add(30*time.Minute)),
+		activitySample([]int64{40}, now.Add(3*time.Hour)),
+	}
+	intervals := activityIntervals(samples)
+	assert.Equal(t, []ActivityInterval{
+		{From: now.Add(-time.Hour), To: now.Add(15 * time.Minute)},
+		{From: now.Add(15 * time.Minute), To: now.Add(30 * time.Minute), Online: true},
+		{From: now.Add(2 * time.Hour), To: now.Add(2*time.Hour + 20*time.Minute)},
+		{From: now.Add(2*time.Hour + 20*time.Minute), To: now.Add(2*time.Hour + 21*time.Minute), Online: true},
+		{From: now.Add(2*time.Hour + 21*time.Minute), To: now.Add(3 * time.Hour)},
+	}, intervals)
+}
+
+func TestPredictSleepWindows(t *testing.T) {
+	day := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	samples := make([]ActivitySample, 0)
+	for d := 0; d < 3; d++ {
+		for hour := 0; hour < 24; hour++ {
+			at := day.Add(time.Duration(24*d+hour) * time.Hour)
+			// Asleep from 23h to 7h, and at 14h
+			asleep := hour >= 23 || hour < 7 || hour == 14
+			samples = append(samples, ActivitySample{At: at, Online: !asleep})
+		}
+	}
+	windows := predictSleepWindows(samples, time.UTC, 0.1, 2)
+	assert.Equal(t, []SleepWindow{{StartHour: 23, EndHour: 7, Confidence: 1}, {StartHour: 14, EndHour: 15, Confidence: 1}}, windows)
+	assert.True(t, windows[0].Contains(2))
+	assert.True(t, windows[0].Contains(23))
+	assert.False(t, windows[0].Contains(7))
+	assert.True(t, windows[1].Contains(14))
+	assert.False(t, windows[1].Contains(15))
+
+	// Not enough samples
+	assert.Equal(t, 0, len(predictSleepWindows(samples[:24], time.UTC, 0.1, 2)))
+}
@@ -0,0 +1,59 @@
+package wrapper
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// backgroundLoop runs a function periodically in a goroutine, until it is stopped.
+// It holds the Start/Stop state of the farmer, the recall engine, the scanners and the trackers.
+type backgroundLoop struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Starts the loop, does nothing if it is already running.
+// init is called first, the loop does not start if it fails.
+// run is called right away, then after the delay it returns.
+func (l *backgroundLoop) start(init func() error, run func() time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cancel != nil {
+		return nil
+	}
+	if init != nil {
+		if err := init(); err != nil {
+			return err
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	l.cancel, l.done = cancel, done
+	go func() {
+		defer close(done)
+		for {
+			wait := run()
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stops the loop and waits for the current run to complete, returns false if the loop was not running
+func (l *backgroundLoop) stop() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cancel == nil {
+		return false
+	}
+	l.cancel()
+	<-l.done
+	l.cancel, l.done = nil, nil
+	return true
+}
@@ -0,0 +1,32 @@
+package wrapper
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackgroundLoop(t *testing.T) {
+	var l backgroundLoop
+	assert.False(t, l.stop())
+	assert.Error(t, l.start(func() error { return errors.New("failed to load") }, nil))
+	assert.False(t, l.stop())
+
+	var runs atomic.Int64
+	started := make(chan struct{})
+	run := func() time.Duration {
+		if runs.Add(1) == 1 {
+			close(started)
+		}
+		return time.Hour
+	}
+	assert.NoError(t, l.start(nil, run))
+	assert.NoError(t, l.start(nil, run)) // Already running
+	<-started
+	assert.True(t, l.stop())
+	assert.Equal(t, int64(1), runs.Load())
+	assert.False(t, l.stop())
+}
@@ -0,0 +1,208 @@
+package wrapper
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/taskRunner"
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+// ErrNoDebrisToHarvest returned when no harvestable debris field was found
+var ErrNoDebrisToHarvest = errors.New("no debris to harvest")
+
+// DebrisSource where a debris field was found
+type DebrisSource int64
+
+// Debris sources
+const (
+	CombatReportDebrisSource DebrisSource = iota + 1
+	GalaxyDebrisSource
+)
+
+// HarvestableDebris a debris field that can be harvested
+type HarvestableDebris struct {
+	Coordinate      ogame.Coordinate // Debris coordinate
+	Resources       ogame.Resources
+	RecyclersNeeded int64 // As displayed in the galaxy view (0 when unknown)
+	Source          DebrisSource
+	FoundAt         time.Time
+}
+
+// DebrisHarvesterConfig configuration of the debris harvester
+type DebrisHarvesterConfig struct {
+	Origin            ogame.CelestialID // Celestial from which harvesters are sent
+	ShipID            ogame.ID          // Recycler or Pathfinder (default: Recycler)
+	Speed             ogame.Speed       // Fleet speed (default: 100%)
+	Radius            int64             // Number of systems to scan on each side of the origin (0: no galaxy scan)
+	MinDebris         int64             // Debris fields with less resources are ignored
+	UseCombatReports  bool              // Look for debris in our combat reports, the detailed report of each field is fetched
+	CombatReportPages int64             // Number of combat report pages to read
+	KeepFreeSlots     int64             // Number of fleet slots that the harvester must not use
+	GalaxyScanDelay   time.Duration     // Delay added before each galaxy page load
+}
+
+// DebrisHarvester finds debris fields and sends recyclers/pathfinders to harvest them
+type DebrisHarvester struct {
+	b   Wrapper
+	cfg DebrisHarvesterConfig
+}
+
+// NewDebrisHarvester creates a new debris harvester
+func NewDebrisHarvester(b Wrapper, cfg DebrisHarvesterConfig) *DebrisHarvester {
+	cfg.ShipID = utils.Ternary(cfg.ShipID == 0, ogame.RecyclerID, cfg.ShipID)
+	cfg.Speed = utils.Ternary(cfg.Speed == 0, ogame.HundredPercent, cfg.Speed)
+	cfg.CombatReportPages = utils.Ternary(cfg.CombatReportPages <= 0, 1, cfg.CombatReportPages)
+	return &DebrisHarvester{b: b, cfg: cfg}
+}
+
+// FindDebris returns the harvestable debris fields, biggest first
+func (h *DebrisHarvester) FindDebris() ([]HarvestableDebris, error) {
+	found := make(map[ogame.Coordinate]HarvestableDebris)
+	if h.cfg.UseCombatReports {
+		reports, err := h.b.GetCombatReportMessages(h.cfg.CombatReportPages)
+		if err != nil {
+			return nil, err
+		}
+		for _, report := range reports {
+			if report.DebrisField <= 0 {
+				continue
+			}
+			coord := report.Destination.Debris()
+			if _, ok := found[coord]; ok {
+				continue
+			}
+			// The summary only has the total, the detailed report splits it by resource.
+			// The debris is parsed even when the participants of the report are missing.
+			detailed, err := h.b.GetCombatReport(report.ID)
+			if err != nil && (!errors.Is(err, ogame.ErrSelectorNotFound) || detailed.Debris.Total() == 0) {
+				return nil, err
+			}
+			found[coord] = HarvestableDebris{
+				Coordinate: coord,
+				Resources:  ogame.Resources{Metal: detailed.Debris.Metal, Crystal: detailed.Debris.Crystal, Deuterium: detailed.Debris.Deuterium},
+				Source:     CombatReportDebrisSource,
+				FoundAt:    report.CreatedAt,
+			}
+		}
+	}
+	if h.cfg.Radius > 0 {
+		origin, err := h.b.GetCachedCelestial(h.cfg.Origin)
+		if err != nil {
+			return nil, ErrInvalidOrigin
+		}
+		serverData := h.b.GetServerData()
+		coord := origin.GetCoordinate()
+		for _, system := range systemsInRadius(coord.System, h.cfg.Radius, serverData.Systems, serverData.DonutSystem) {
+			systemInfos, err := h.b.WithPriority(taskRunner.Low).GalaxyInfos(coord.Galaxy, system, Delay(h.cfg.GalaxyScanDelay))
+			if err != nil {
+				return nil, err
+			}
+			now := time.Now()
+			systemInfos.Each(func(planetInfo *ogame.PlanetInfos) {
+				if planetInfo == nil {
+					return
+				}
+				debris := planetInfo.Debris
+				if debris.Metal+debris.Crystal+debris.Deuterium <= 0 {
+					return
+				}
+				// Galaxy information is more accurate than the combat report
+				debrisCoord := planetInfo.Coordinate.Debris()
+				found[debrisCoord] = HarvestableDebris{
+					Coordinate:      debrisCoord,
+					Resources:       ogame.Resources{Metal: debris.Metal, Crystal: debris.Crystal, Deuterium: debris.Deuterium},
+					RecyclersNeeded: debris.RecyclersNeeded,
+					Source:          GalaxyDebrisSource,
+					FoundAt:         now,
+				}
+			})
+		}
+	}
+	out := make([]HarvestableDebris, 0, len(found))
+	for _, d := range found {
+		if d.Resources.Total() >= h.cfg.MinDebris {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Resources.Total() > out[j].Resources.Total() })
+	return out, nil
+}
+
+// Harvest finds the debris fields and sends harvesters to each of them.
+// Debris fields that already have one of our recycling fleet en route are skipped.
+func (h *DebrisHarvester) Harvest() ([]ogame.Fleet, error) {
+	debris, err := h.FindDebris()
+	if err != nil {
+		return nil, err
+	}
+	return h.HarvestDebris(debris)
+}
+
+// HarvestDebris sends harvesters to the provided debris fields
+func (h *DebrisHarvester) HarvestDebris(debris []HarvestableDebris) ([]ogame.Fleet, error) {
+	origin, err := h.b.GetCachedCelestial(h.cfg.Origin)
+	if err != nil {
+		return nil, ErrInvalidOrigin
+	}
+	ship, ok := ogame.Objs.ByID(h.cfg.ShipID).(ogame.Ship)
+	if !ok {
+		return nil, errors.New("invalid harvester ship")
+	}
+	fleets, slots := h.b.GetFleets()
+	available, err := h.b.GetShips(origin.GetID())
+	if err != nil {
+		return nil, err
+	}
+	lfBonuses, _ := h.b.GetCachedLfBonuses()
+	multiplier := float64(h.b.GetServerData().CargoHyperspaceTechMultiplier) / 100.0
+	researches := h.b.GetCachedResearch()
+	freeSlots := slots.Total - slots.InUse - h.cfg.KeepFreeSlots
+	out := make([]ogame.Fleet, 0)
+	for _, d := range debris {
+		if freeSlots <= 0 {
+			break
+		}
+		if isHarvesterEnRoute(fleets, d.Coordinate) {
+			continue
+		}
+		nbr := d.Resources.FitsIn(ship, researches, lfBonuses, h.b.CharacterClass(), multiplier, h.b.GetServer().ProbeRaidsEnabled())
+		nbr = utils.MinInt(nbr, available.ByID(h.cfg.ShipID))
+		if nbr <= 0 {
+			continue
+		}
+		ships := ogame.ShipsInfos{}
+		ships.Set(h.cfg.ShipID, nbr)
+		fleet, err := h.b.SendFleet(origin.GetID(), ships, h.cfg.Speed, d.Coordinate, ogame.RecycleDebrisField, ogame.Resources{}, 0, 0)
+		if err != nil {
+			if errors.Is(err, ogame.ErrAllSlotsInUse) {
+				break
+			}
+			return out, err
+		}
+		available.SubShips(h.cfg.ShipID, nbr)
+		fleets = append(fleets, fleet)
+		freeSlots--
+		out = append(out, fleet)
+	}
+	if len(out) == 0 {
+		return out, ErrNoDebrisToHarvest
+	}
+	return out, nil
+}
+
+// Returns either or not one of the fleets is going to recycle the debris field at coord
+func isHarvesterEnRoute(fleets []ogame.Fleet, coord ogame.Coordinate) bool {
+	for _, fleet := range fleets {
+		if fleet.Mission == ogame.RecycleDebrisField &&
+			!fleet.ReturnFlight &&
+			fleet.Destination.Galaxy == coord.Galaxy &&
+			fleet.Destination.System == coord.System &&
+			fleet.Destination.Position == coord.Position {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package wrapper
+
+import (
+	"testing"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsHarvesterEnRoute(t *testing.T) {
+	coord := ogame.Coordinate{Galaxy: 1, System: 2, Position: 3, Type: ogame.DebrisType}
+	fleets := []ogame.Fleet{
+		{Mission: ogame.RecycleDebrisField, ReturnFlight: true, Destination: coord},
+		{Mission: ogame.Transport, Destination: coord.Planet()},
+	}
+	assert.False(t, isHarvesterEnRoute(fleets, coord))
+	fleets = append(fleets, ogame.Fleet{Mission: ogame.RecycleDebrisField, Destination: coord.Planet()})
+	assert.True(t, isHarvesterEnRoute(fleets, coord))
+}
+
+// Wrapper serving combat reports
+type combatReportsWrapper struct {
+	Wrapper
+	summaries []ogame.CombatReportSummary
+	reports   map[int64]ogame.CombatReport
+	err       error
+}
+
+func (w combatReportsWrapper) GetCombatReportMessages(int64) ([]ogame.CombatReportSummary, error) {
+	return w.summaries, nil
+}
+
+func (w combatReportsWrapper) GetCombatReport(msgID int64) (ogame.CombatReport, error) {
+	return w.reports[msgID], w.err
+}
+
+func TestDebrisHarvester_FindDebris_CombatReports(t *testing.T) {
+	coord := ogame.Coordinate{Galaxy: 1, System: 2, Position: 3, Type: ogame.PlanetType}
+	b := combatReportsWrapper{
+		summaries: []ogame.CombatReportSummary{
+			{ID: 1, Destination: coord, DebrisField: 30000},
+			{ID: 2, Destination: coord, DebrisField: 10000}, // Older report of the same field
+			{ID: 3, Destination: coord.Moon(), DebrisField: 0},
+		},
+		reports: map[int64]ogame.CombatReport{
+			1: {ID: 1, Debris: ogame.Resources{Metal: 18000, Crystal: 9000, Deuterium: 3000}},
+			2: {ID: 2, Debris: ogame.Resources{Metal: 10000}},
+		},
+	}
+	h := NewDebrisHarvester(b, DebrisHarvesterConfig{UseCombatReports: true})
+	debris, err := h.FindDebris()
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(debris)) {
+		assert.Equal(t, coord.Debris(), debris[0].Coordinate)
+		assert.Equal(t, ogame.Resources{Metal: 18000, Crystal: 9000, Deuterium: 3000}, debris[0].Resources)
+		assert.Equal(t, CombatReportDebrisSource, debris[0].Source)
+	}
+
+	// The participants of the report are missing, its debris is still used
+	b.err = &ogame.ParseError{Page: MessagesPageName, Err: ogame.NewSelectorNotFoundError("script:contains('combatData')")}
+	debris, err = NewDebrisHarvester(b, DebrisHarvesterConfig{UseCombatReports: true}).FindDebris()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(debris))
+	b.reports = nil
+	_, err = NewDebrisHarvester(b, DebrisHarvesterConfig{UseCombatReports: true}).FindDebris()
+	assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+}
@@ -0,0 +1,50 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alaingilbert/ogame/pkg/extractor"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+)
+
+// Returns a new extractor of a registration, configured like the extractor of the server
+func (b *OGame) newFallbackExtractor(registration extractor.Registration) extractor.Extractor {
+	ext := registration.New()
+	ext.SetLanguage(b.extractor.GetLanguage())
+	ext.SetLocation(b.extractor.GetLocation())
+	ext.SetLifeformEnabled(b.extractor.GetLifeformEnabled())
+	return ext
+}
+
+// extractWithFallback calls extract with the extractor of the server. When an element is missing from the page,
+// the older extractors are tried in turn. The fallbacks are logged and counted in the metrics with the page and
+// the method, so the game updates are noticed before every page breaks.
+func extractWithFallback[T any](b *OGame, page, method string, extract func(extractor.Extractor) (T, error)) (T, error) {
+	res, err := extract(b.extractor)
+	if err == nil || !errors.Is(err, ogame.ErrSelectorNotFound) {
+		return res, err
+	}
+	extractorName := extractorVersion(b.extractor)
+	for _, registration := range b.extractorFallbacks {
+		if fallbackRes, fallbackErr := tryFallbackExtractor(b.newFallbackExtractor(registration), extract); fallbackErr == nil {
+			b.warn(ExtractorLog, "page parsed by an older extractor", "page", page, "method", method,
+				"extractor", extractorName, "fallback", registration.Name, "error", err)
+			b.metrics.extractorFallback(page, method, extractorName, registration.Name)
+			return fallbackRes, nil
+		}
+	}
+	b.metrics.extractorFallback(page, method, extractorName, "none")
+	return res, b.parseError(page, err)
+}
+
+// Calls extract with a fallback extractor. The old extractors do not implement every method (panic("implement me")),
+// a panic is a failure of the extractor.
+func tryFallbackExtractor[T any](ext extractor.Extractor, extract func(extractor.Extractor) (T, error)) (res T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("extractor panicked: %v", r)
+		}
+	}()
+	return extract(ext)
+}
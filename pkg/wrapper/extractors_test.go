@@ -0,0 +1,139 @@
+package wrapper
+
+import (
+	"os"
+	"testing"
+
+	"github.com/alaingilbert/ogame/pkg/extractor"
+	"github.com/alaingilbert/ogame/pkg/extractor/v12_0_0"
+	"github.com/alaingilbert/ogame/pkg/metrics"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/assert"
+)
+
+// Extractor of a game update that moved the buddy list
+type movedBuddiesExtractor struct {
+	*v12_0_0.Extractor
+}
+
+func (e movedBuddiesExtractor) ExtractBuddies(pageHTML []byte) ([]ogame.Buddy, error) {
+	return nil, ogame.NewSelectorNotFoundError("div#buddies")
+}
+
+func TestExtractWithFallback(t *testing.T) {
+	pageHTML, _ := os.ReadFile("../../samples/synthetic/buddies.html")
+	registry := metrics.NewRegistry()
+	b, _ := NewNoLogin("bob@example.com", "hunter2secret", "", "", "Bellatrix", "en", 0, nil)
+	b.Quiet(true)
+	b.SetMetricsRegistry(registry)
+	b.extractor = movedBuddiesExtractor{v12_0_0.NewExtractor()}
+	b.extractorFallbacks = extractor.DefaultRegistry.Chain(version.Must(version.NewVersion("11.15.0")))
+	fallbacks := registry.Counter("ogame_extractor_fallbacks_total", "", "account", "universe", "page", "method", "extractor", "fallback")
+
+	buddies, err := extractWithFallback(b, BuddiesPageName, "ExtractBuddies", func(e extractor.Extractor) ([]ogame.Buddy, error) {
+		return e.ExtractBuddies(pageHTML)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(buddies))
+	assert.Equal(t, float64(1), fallbacks.Value("bob@example.com", "Bellatrix-en", "buddies", "ExtractBuddies", "wrapper", "v11_15_0"))
+
+	// No extractor finds the buddy list in the overview page
+	overviewHTML, _ := os.ReadFile("../../samples/v12.0.0/en/overview.html")
+	_, err = extractWithFallback(b, BuddiesPageName, "ExtractBuddies", func(e extractor.Extractor) ([]ogame.Buddy, error) {
+		return e.ExtractBuddies(overviewHTML)
+	})
+	assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+	var parseErr *ogame.ParseError
+	assert.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, "buddies", parseErr.Page)
+	assert.Equal(t, float64(1), fallbacks.Value("bob@example.com", "Bellatrix-en", "buddies", "ExtractBuddies", "wrapper", "none"))
+}
+
+func TestExtractWithFallback_CombatReport(t *testing.T) {
+	// Combat report of an older server, without the raw message data of the current versions
+	pageHTML, _ := os.ReadFile("../../samples/unversioned/combat_reports_msg.html")
+	registry := metrics.NewRegistry()
+	b, _ := NewNoLogin("bob@example.com", "hunter2secret", "", "", "Bellatrix", "en", 0, nil)
+	b.Quiet(true)
+	b.SetMetricsRegistry(registry)
+	chain := extractor.DefaultRegistry.Chain(version.Must(version.NewVersion("12.0.0")))
+	b.extractor = chain[0].New()
+	b.extractorFallbacks = chain[1:]
+	fallbacks := registry.Counter("ogame_extractor_fallbacks_total", "", "account", "universe", "page", "method", "extractor", "fallback")
+
+	report, err := extractWithFallback(b, MessagesPageName, "ExtractCombatReport", func(e extractor.Extractor) (ogame.CombatReport, error) {
+		return e.ExtractCombatReport(pageHTML)
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, report.Attackers)
+	assert.Equal(t, float64(1), fallbacks.Value("bob@example.com", "Bellatrix-en", "messages", "ExtractCombatReport", "v12_0_0", "v11_13_0"))
+}
+
+func TestExtractWithFallback_FullChain(t *testing.T) {
+	// A page of the current version without the lifeform bonuses, buildings, ships, defenses, research, fleets nor report
+	pageHTML, _ := os.ReadFile("../../samples/v12.0.0/en/overview.html")
+	registry := metrics.NewRegistry()
+	b, _ := NewNoLogin("bob@example.com", "hunter2secret", "", "", "Bellatrix", "en", 0, nil)
+	b.Quiet(true)
+	b.SetMetricsRegistry(registry)
+	chain := extractor.DefaultRegistry.Chain(version.Must(version.NewVersion("12.0.0")))
+	b.extractor = chain[0].New()
+	b.extractorFallbacks = chain[1:]
+	fallbacks := registry.Counter("ogame_extractor_fallbacks_total", "", "account", "universe", "page", "method", "extractor", "fallback")
+
+	// The extractors older than v11_15_0 panic, every one of them is tried
+	assert.NotPanics(t, func() {
+		_, err := extractWithFallback(b, LfBonusesPageName, "ExtractLfBonuses", func(e extractor.Extractor) (ogame.LfBonuses, error) {
+			return e.ExtractLfBonuses(pageHTML)
+		})
+		assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+	})
+	assert.Equal(t, float64(1), fallbacks.Value("bob@example.com", "Bellatrix-en", "lfbonuses", "ExtractLfBonuses", "v12_0_0", "none"))
+
+	_, err := extractWithFallback(b, FacilitiesPageName, "ExtractFacilities", func(e extractor.Extractor) (ogame.Facilities, error) {
+		return e.ExtractFacilities(pageHTML)
+	})
+	assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+	_, err = extractWithFallback(b, SuppliesPageName, "ExtractResourcesBuildings", func(e extractor.Extractor) (ogame.ResourcesBuildings, error) {
+		return e.ExtractResourcesBuildings(pageHTML)
+	})
+	assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+	_, err = extractWithFallback(b, ShipyardPageName, "ExtractShips", func(e extractor.Extractor) (ogame.ShipsInfos, error) {
+		return e.ExtractShips(pageHTML)
+	})
+	assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+	_, err = extractWithFallback(b, DefensesPageName, "ExtractDefense", func(e extractor.Extractor) (ogame.DefensesInfos, error) {
+		return e.ExtractDefense(pageHTML)
+	})
+	assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+	_, err = extractWithFallback(b, ResearchPageName, "ExtractResearch", func(e extractor.Extractor) (ogame.Researches, error) {
+		return e.ExtractResearch(pageHTML)
+	})
+	assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+	_, err = extractWithFallback(b, MovementPageName, "ExtractFleets", func(e extractor.Extractor) ([]ogame.Fleet, error) {
+		return e.ExtractFleets(pageHTML)
+	})
+	assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+	_, err = extractWithFallback(b, MessagesPageName, "ExtractEspionageReport", func(e extractor.Extractor) (ogame.EspionageReport, error) {
+		return e.ExtractEspionageReport(pageHTML)
+	})
+	assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+
+	// Json answers without the expected keys
+	_, err = extractWithFallback(b, GalaxyPageName, "ExtractGalaxyInfos", func(e extractor.Extractor) (ogame.SystemInfos, error) {
+		return e.ExtractGalaxyInfos([]byte(`{"success":true,"system":{}}`), "Bob", 1, 1)
+	})
+	assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+	_, err = extractWithFallback(b, FetchResourcesPageName, "ExtractResourcesDetails", func(e extractor.Extractor) (ogame.ResourcesDetails, error) {
+		return e.ExtractResourcesDetails([]byte(`{"resources":{}}`))
+	})
+	assert.ErrorIs(t, err, ogame.ErrSelectorNotFound)
+
+	// The planets are in every page
+	celestials, err := extractWithFallback(b, OverviewPageName, "ExtractCelestials", func(e extractor.Extractor) ([]ogame.Celestial, error) {
+		return e.ExtractCelestials(pageHTML)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(celestials))
+}
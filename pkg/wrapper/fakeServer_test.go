@@ -0,0 +1,309 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/cassette"
+	"github.com/alaingilbert/ogame/pkg/device"
+	"github.com/alaingilbert/ogame/pkg/metrics"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/ogametest"
+	"github.com/alaingilbert/ogame/pkg/storage"
+	"github.com/alaingilbert/ogame/pkg/taskRunner"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newFakeServerDevice(t *testing.T, srv *ogametest.Server) *device.Device {
+	t.Setenv("HOME", t.TempDir())
+	deviceInst, err := device.NewBuilder("fake").
+		SetOsName(device.Windows).
+		SetBrowserName(device.Chrome).
+		SetMemory(8).
+		SetHardwareConcurrency(16).
+		ScreenColorDepth(24).
+		SetScreenWidth(1900).
+		SetScreenHeight(900).
+		SetTimezone("America/Los_Angeles").
+		SetLanguages("en-US,en").
+		Build()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	deviceInst.GetClient().SetTransport(srv.Transport())
+	return deviceInst
+}
+
+// Logs a bot into the fake server, opts customize the params before the login
+func newFakeServerBot(t *testing.T, srv *ogametest.Server, opts ...func(*Params)) *OGame {
+	cfg := srv.Config()
+	params := Params{
+		Device:    newFakeServerDevice(t, srv),
+		Universe:  cfg.Universe,
+		Lang:      cfg.Lang,
+		Username:  cfg.Username,
+		Password:  cfg.Password,
+		AutoLogin: true,
+	}
+	for _, opt := range opts {
+		opt(&params)
+	}
+	bot, err := NewWithParams(params)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	bot.Quiet(true)
+	return bot
+}
+
+func TestFakeServer_BadCredentials(t *testing.T) {
+	srv := ogametest.NewServer(ogametest.Config{})
+	defer srv.Close()
+	_, err := NewWithParams(Params{Device: newFakeServerDevice(t, srv), Universe: "Wurren", Lang: "en", Username: "player@example.com", Password: "wrong", AutoLogin: true})
+	assert.ErrorIs(t, err, ogame.ErrBadCredentials)
+}
+
+func TestFakeServer_SessionStorage(t *testing.T) {
+	srv := ogametest.NewServer(ogametest.Config{})
+	defer srv.Close()
+	store := storage.NewMemoryStore()
+	bot := newFakeServerBot(t, srv, func(params *Params) { params.Storage = store })
+	defer bot.Logout()
+
+	keys, _ := store.List("storage/fake/")
+	assert.Equal(t, []string{"storage/fake/bearer_token", "storage/fake/cookies", "storage/fake/fingerprint"}, keys)
+	token, _ := store.Get(storage.BearerTokenKey("fake"))
+	assert.Equal(t, bot.bearerToken, string(token))
+
+	// The page showing that the session expired is dumped into the store
+	srv.ExpireSessions()
+	_, err := bot.GetPlanets()
+	assert.NoError(t, err)
+	keys, _ = store.List(storage.NotLoggedPrefix)
+	assert.NotEmpty(t, keys)
+}
+
+func TestFakeServer_Metrics(t *testing.T) {
+	srv := ogametest.NewServer(ogametest.Config{})
+	defer srv.Close()
+	cfg := srv.Config()
+	registry := metrics.NewRegistry()
+	bot := newFakeServerBot(t, srv, func(params *Params) { params.MetricsRegistry = registry })
+	defer bot.Logout()
+	assert.Same(t, registry, bot.GetMetricsRegistry())
+
+	srv.ExpireSessions()
+	_, err := bot.GetPlanets()
+	assert.NoError(t, err)
+
+	account, universe := cfg.Username, cfg.Universe+"-"+cfg.Lang
+	assert.Equal(t, float64(1), registry.Counter("ogame_relogins_total", "", "account", "universe").Value(account, universe))
+	assert.Equal(t, float64(1), registry.Counter("ogame_retries_total", "", "account", "universe").Value(account, universe))
+	buf := new(bytes.Buffer)
+	_, _ = registry.WriteTo(buf)
+	assert.Contains(t, buf.String(), `ogame_requests_total{account="`+account+`",universe="`+universe+`",page="overview",method="GET",status="200"}`)
+	assert.Contains(t, buf.String(), `ogame_task_queue_depth{account="`+account+`",universe="`+universe+`",priority="normal"} 0`)
+	assert.Contains(t, buf.String(), `ogame_lock_hold_seconds_count{account="`+account+`",universe="`+universe+`",task="GetPlanets"}`)
+}
+
+func TestFakeServer_Tracing(t *testing.T) {
+	srv := ogametest.NewServer(ogametest.Config{})
+	defer srv.Close()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	bot := newFakeServerBot(t, srv, func(params *Params) { params.TracerProvider = provider })
+	defer bot.Logout()
+
+	ctx, root := provider.Tracer("test").Start(context.Background(), "root")
+	err := bot.WithPriority(taskRunner.Important).SetContext(ctx).SetInitiator("FleetBuilder").TxNamed("SendNow", func(tx Prioritizable) error {
+		_, err := tx.GetPlanets()
+		return err
+	})
+	assert.NoError(t, err)
+	root.End()
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	for _, name := range []string{"ogame.login", "ogame.login.part1", "ogame.login.part2", "ogame.login.part3"} {
+		assert.Contains(t, spans, name)
+	}
+	assert.Equal(t, spans["ogame.login"].SpanContext().SpanID(), spans["ogame.login.part2"].Parent().SpanID())
+
+	tx := spans["SendNow"]
+	if !assert.NotNil(t, tx) {
+		t.FailNow()
+	}
+	assert.Equal(t, root.SpanContext().SpanID(), tx.Parent().SpanID())
+	assert.Contains(t, tx.Attributes(), attribute.String("ogame.initiator", "FleetBuilder"))
+	assert.Contains(t, tx.Attributes(), attribute.String("ogame.priority", "important"))
+	assert.Equal(t, tx.SpanContext().SpanID(), spans["ogame.task.wait"].Parent().SpanID())
+	var pages []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "ogame.page" && span.Parent().SpanID() == tx.SpanContext().SpanID() {
+			pages = append(pages, span)
+		}
+	}
+	if assert.NotEmpty(t, pages) {
+		assert.Contains(t, pages[0].Attributes(), attribute.Int("http.status_code", 200))
+		assert.Contains(t, pages[0].Attributes(), attribute.String("http.method", "GET"))
+	}
+}
+
+func TestFakeServer_BuildAndSendFleet(t *testing.T) {
+	now := time.Now()
+	srv := ogametest.NewServer(ogametest.Config{Now: func() time.Time { return now }})
+	defer srv.Close()
+	bot := newFakeServerBot(t, srv)
+	defer bot.Logout()
+
+	homeworldID := ogame.CelestialID(33628462)
+	colony := ogame.Coordinate{Galaxy: 1, System: 105, Position: 11, Type: ogame.PlanetType}
+
+	planets, err := bot.GetPlanets()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(planets))
+	assert.Equal(t, "Homeworld", planets[0].Name)
+	assert.Equal(t, ogame.Coordinate{Galaxy: 1, System: 103, Position: 8, Type: ogame.PlanetType}, planets[0].Coordinate)
+	assert.Equal(t, int64(12800), planets[0].Diameter)
+
+	resources, err := bot.GetResources(homeworldID)
+	assert.NoError(t, err)
+	assert.Equal(t, ogame.Resources{Metal: 200000, Crystal: 100000, Deuterium: 50000}, resources)
+
+	ships, err := bot.GetShips(homeworldID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), ships.SmallCargo)
+
+	assert.NoError(t, bot.BuildShips(homeworldID, ogame.SmallCargoID, 2))
+	srv.WithState(func(s *ogametest.State) {
+		assert.Equal(t, 1, len(s.Queue))
+		assert.Equal(t, ogame.SmallCargoID, s.Queue[0].ID)
+		assert.Equal(t, int64(200000-4000), s.Planet(33628462).Resources.Metal)
+	})
+	err = bot.BuildShips(homeworldID, ogame.DeathstarID, 1)
+	assert.ErrorIs(t, err, ErrBuild)
+	assert.ErrorIs(t, err, ogame.ErrNotEnoughResources)
+
+	fleet, err := bot.SendFleet(homeworldID, ogame.ShipsInfos{SmallCargo: 5}, ogame.HundredPercent,
+		colony, ogame.Transport, ogame.Resources{Metal: 10000}, 0, 0)
+	assert.NoError(t, err)
+	assert.NotZero(t, fleet.ID)
+	assert.Equal(t, colony, fleet.Destination)
+	assert.Equal(t, ogame.Transport, fleet.Mission)
+	assert.Equal(t, int64(5), fleet.Ships.SmallCargo)
+	assert.Equal(t, int64(10000), fleet.Resources.Metal)
+
+	fleets, slots := bot.GetFleets()
+	assert.Equal(t, 1, len(fleets))
+	assert.Equal(t, fleet.ID, fleets[0].ID)
+	assert.Equal(t, int64(1), slots.InUse)
+	assert.Equal(t, int64(6), slots.Total)
+
+	// The clock of the server is frozen, a recalled fleet is back right away
+	assert.NoError(t, bot.CancelFleet(fleet.ID))
+	srv.WithState(func(s *ogametest.State) {
+		assert.Equal(t, 0, len(s.Fleets))
+		assert.Equal(t, int64(20), s.Planet(33628462).Techs[ogame.SmallCargoID])
+		assert.Equal(t, int64(200000-4000), s.Planet(33628462).Resources.Metal)
+	})
+	assert.ErrorIs(t, bot.CancelFleet(fleet.ID), ogame.ErrFleetNotFound)
+
+	assert.NoError(t, bot.BuildBuilding(homeworldID, ogame.MetalMineID))
+	assert.ErrorIs(t, bot.BuildBuilding(homeworldID, ogame.CrystalMineID), ogame.ErrQueueBusy)
+}
+
+func TestFakeServer_RecordCassette(t *testing.T) {
+	srv := ogametest.NewServer(ogametest.Config{})
+	defer srv.Close()
+	bot := newFakeServerBot(t, srv)
+	defer bot.Logout()
+	rec := cassette.NewRecorder()
+	bot.RegisterHTMLInterceptor(rec.Record)
+
+	_, err := bot.GetShips(ogame.CelestialID(33628462))
+	assert.NoError(t, err)
+	var recorded cassette.Interaction
+	assert.Eventually(t, func() bool { // Interceptors are called asynchronously
+		for _, interaction := range rec.Cassette().Interactions {
+			if interaction.Params.Get("component") == "shipyard" {
+				recorded = interaction
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+	assert.Contains(t, recorded.Body, `<meta name="ogame-session" content="REDACTED"/>`)
+
+	// The recorded page is served back, and parsed the same way
+	bot.GetClient().SetTransport(cassette.NewRoundTripper(rec.Cassette()))
+	ships, err := bot.GetShips(ogame.CelestialID(33628462))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), ships.SmallCargo)
+	bot.GetClient().SetTransport(srv.Transport())
+}
+
+func TestFakeServer_Maintenance(t *testing.T) {
+	srv := ogametest.NewServer(ogametest.Config{})
+	defer srv.Close()
+	bot := newFakeServerBot(t, srv)
+	defer bot.Logout()
+	bot.maintenance.pollInterval = 50 * time.Millisecond
+	homeworldID := ogame.CelestialID(33628462)
+	assert.False(t, bot.ServerStatus().Maintenance)
+	assert.Equal(t, "11.15.5", bot.ServerStatus().Version)
+
+	srv.SetMaintenance(true)
+	_, err := bot.GetResources(homeworldID)
+	assert.ErrorIs(t, err, ogame.ErrServerMaintenance)
+	status := bot.ServerStatus()
+	assert.True(t, status.Maintenance)
+	assert.NotZero(t, status.Since)
+	paused, _ := bot.taskRunnerInst.Paused()
+	assert.True(t, paused)
+
+	// Queued during the maintenance, executed once the server is back
+	resCh := make(chan error, 1)
+	go func() {
+		_, err := bot.GetResources(homeworldID)
+		resCh <- err
+	}()
+	assert.Eventually(t, func() bool { return !bot.ServerStatus().LastCheck.IsZero() }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "server closed in the lobby", bot.ServerStatus().Cause)
+	select {
+	case <-resCh:
+		t.Fatal("task executed during the maintenance")
+	default:
+	}
+
+	srv.SetVersion("12.0.0")
+	srv.SetMaintenance(false)
+	select {
+	case err := <-resCh:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("task not executed after the maintenance")
+	}
+	status = bot.ServerStatus()
+	assert.False(t, status.Maintenance)
+	assert.Equal(t, "12.0.0", status.Version)
+	assert.Equal(t, "v12_0_0", extractorVersion(bot.GetExtractor()))
+}
+
+func TestFakeServer_PublicAPI(t *testing.T) {
+	srv := ogametest.NewServer(ogametest.Config{})
+	defer srv.Close()
+	bot := newFakeServerBot(t, srv)
+	defer bot.Logout()
+	api := bot.GetPublicAPI()
+	assert.Same(t, api, bot.GetPublicAPI())
+	// Connected to another server
+	bot.server.Number++
+	assert.NotSame(t, api, bot.GetPublicAPI())
+}
@@ -0,0 +1,455 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/simulator"
+	"github.com/alaingilbert/ogame/pkg/taskRunner"
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+// ErrNoFarmTarget returned when the farmer has no target ready to be raided
+var ErrNoFarmTarget = errors.New("no farm target available")
+
+// FarmerConfig configuration of the farming pipeline
+type FarmerConfig struct {
+	Origin          ogame.CelestialID // Celestial from which probes and cargos are sent
+	Radius          int64             // Number of systems to scan on each side of the origin
+	Probes          int64             // Number of probes sent for each espionage
+	CargoShipID     ogame.ID          // Ship used to carry the loot (default: LargeCargo)
+	Escort          ogame.ShipsInfos  // Ships added to every raid
+	Speed           ogame.Speed       // Raid speed (default: 100%)
+	MinLoot         int64             // Targets with less expected loot are skipped
+	MinRank         int64             // Only keep players ranked worse than MinRank (0: no filter)
+	KeepFreeSlots   int64             // Number of fleet slots that the farmer must not use
+	Simulate        bool              // Run the simulator when a target is not defenceless
+	Simulations     int               // Number of simulations to run (default: 10)
+	SpyInterval     time.Duration     // Minimum delay between two espionage of the same target (default: 1h)
+	RescanInterval  time.Duration     // Delay between two galaxy scans (default: 6h)
+	Interval        time.Duration     // Delay between two iterations of the pipeline (default: 1m)
+	GalaxyScanDelay time.Duration     // Delay added before each galaxy page load
+}
+
+// FarmTarget a target known by the farmer
+type FarmTarget struct {
+	Coordinate   ogame.Coordinate
+	PlayerID     int64
+	PlayerName   string
+	Report       *ogame.EspionageReport
+	Loot         ogame.Resources
+	CargoShips   int64
+	FlightTime   int64   // one way flight time in seconds
+	Score        float64 // expected loot per flight hour
+	Defended     bool
+	LastScanAt   time.Time
+	SpiedAt      time.Time
+	SpyArrivalAt time.Time
+	RaidedAt     time.Time
+	RaidBackAt   time.Time
+}
+
+// IsReady returns either or not the target has a valid report and is not currently being raided.
+// The report must be newer than the last raid, an older one shows resources that were already looted.
+func (t FarmTarget) IsReady(now time.Time) bool {
+	return t.Report != nil && !t.Defended && t.RaidBackAt.Before(now) && t.Report.Date.After(t.RaidedAt)
+}
+
+// Farmer scan the galaxy for inactive players, spy them, and raid the most profitable ones.
+type Farmer struct {
+	b          Wrapper
+	cfg        FarmerConfig
+	targetsMu  sync.RWMutex
+	targets    map[ogame.Coordinate]*FarmTarget
+	lastScanAt time.Time // protected by targetsMu
+	loop       backgroundLoop
+}
+
+// NewFarmer creates a new farmer
+func NewFarmer(b Wrapper, cfg FarmerConfig) *Farmer {
+	cfg.CargoShipID = utils.Ternary(cfg.CargoShipID == 0, ogame.LargeCargoID, cfg.CargoShipID)
+	cfg.Speed = utils.Ternary(cfg.Speed == 0, ogame.HundredPercent, cfg.Speed)
+	cfg.Probes = utils.Ternary(cfg.Probes <= 0, 1, cfg.Probes)
+	cfg.Simulations = utils.Ternary(cfg.Simulations <= 0, 10, cfg.Simulations)
+	cfg.SpyInterval = utils.Ternary(cfg.SpyInterval <= 0, time.Hour, cfg.SpyInterval)
+	cfg.RescanInterval = utils.Ternary(cfg.RescanInterval <= 0, 6*time.Hour, cfg.RescanInterval)
+	cfg.Interval = utils.Ternary(cfg.Interval <= 0, time.Minute, cfg.Interval)
+	return &Farmer{b: b, cfg: cfg, targets: make(map[ogame.Coordinate]*FarmTarget)}
+}
+
+// Targets returns a copy of the known targets, sorted by score (best first)
+func (f *Farmer) Targets() []FarmTarget {
+	f.targetsMu.RLock()
+	out := make([]FarmTarget, 0, len(f.targets))
+	for _, t := range f.targets {
+		out = append(out, *t)
+	}
+	f.targetsMu.RUnlock()
+	sortFarmTargets(out)
+	return out
+}
+
+// AddTarget manually adds a target to the farmer
+func (f *Farmer) AddTarget(coord ogame.Coordinate) {
+	f.targetsMu.Lock()
+	defer f.targetsMu.Unlock()
+	coord = coord.Planet()
+	if _, ok := f.targets[coord]; !ok {
+		f.targets[coord] = &FarmTarget{Coordinate: coord}
+	}
+}
+
+// RemoveTarget removes a target from the farmer
+func (f *Farmer) RemoveTarget(coord ogame.Coordinate) {
+	f.targetsMu.Lock()
+	defer f.targetsMu.Unlock()
+	delete(f.targets, coord.Planet())
+}
+
+func (f *Farmer) getOrigin() (Celestial, error) {
+	origin, err := f.b.GetCachedCelestial(f.cfg.Origin)
+	if err != nil {
+		return nil, ErrInvalidOrigin
+	}
+	return origin, nil
+}
+
+// ScanTargets scans the galaxy around the origin and adds every inactive player planet to the target list.
+// Planets that are no longer inactive are removed from the list.
+func (f *Farmer) ScanTargets() error {
+	origin, err := f.getOrigin()
+	if err != nil {
+		return err
+	}
+	serverData := f.b.GetServerData()
+	myPlayerID := f.b.GetCachedPlayer().PlayerID
+	coord := origin.GetCoordinate()
+	for _, system := range systemsInRadius(coord.System, f.cfg.Radius, serverData.Systems, serverData.DonutSystem) {
+		systemInfos, err := f.b.WithPriority(taskRunner.Low).GalaxyInfos(coord.Galaxy, system, Delay(f.cfg.GalaxyScanDelay))
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		f.targetsMu.Lock()
+		systemInfos.Each(func(planetInfo *ogame.PlanetInfos) {
+			if planetInfo == nil {
+				return
+			}
+			targetCoord := planetInfo.Coordinate.Planet()
+			if !f.isValidTarget(planetInfo, myPlayerID) {
+				delete(f.targets, targetCoord)
+				return
+			}
+			target, ok := f.targets[targetCoord]
+			if !ok {
+				target = &FarmTarget{Coordinate: targetCoord}
+				f.targets[targetCoord] = target
+			}
+			target.PlayerID = planetInfo.Player.ID
+			target.PlayerName = planetInfo.Player.Name
+			target.LastScanAt = now
+		})
+		f.targetsMu.Unlock()
+	}
+	f.targetsMu.Lock()
+	f.lastScanAt = time.Now()
+	f.targetsMu.Unlock()
+	return nil
+}
+
+func (f *Farmer) isValidTarget(planetInfo *ogame.PlanetInfos, myPlayerID int64) bool {
+	if !planetInfo.Inactive ||
+		planetInfo.Vacation ||
+		planetInfo.Banned ||
+		planetInfo.Destroyed ||
+		planetInfo.Administrator ||
+		planetInfo.Player.ID == myPlayerID {
+		return false
+	}
+	if f.cfg.MinRank > 0 && planetInfo.Player.Rank < f.cfg.MinRank {
+		return false
+	}
+	return true
+}
+
+// SpyTargets sends probes to every target which has not been spied recently
+func (f *Farmer) SpyTargets() error {
+	origin, err := f.getOrigin()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	toSpy := make([]ogame.Coordinate, 0)
+	f.targetsMu.RLock()
+	for coord, t := range f.targets {
+		if t.RaidBackAt.Before(now) && now.Sub(t.SpiedAt) >= f.cfg.SpyInterval {
+			toSpy = append(toSpy, coord)
+		}
+	}
+	f.targetsMu.RUnlock()
+	for _, coord := range toSpy {
+		if !f.hasFreeSlot() {
+			return ogame.ErrAllSlotsInUse
+		}
+		fleet, err := f.b.SendFleet(origin.GetID(), ogame.ShipsInfos{EspionageProbe: f.cfg.Probes}, ogame.HundredPercent, coord,
+			ogame.Spy, ogame.Resources{}, 0, 0)
+		if err != nil {
+			return err
+		}
+		f.targetsMu.Lock()
+		if t, ok := f.targets[coord]; ok {
+			t.SpiedAt = time.Now()
+			t.SpyArrivalAt = fleet.ArrivalTime
+		}
+		f.targetsMu.Unlock()
+	}
+	return nil
+}
+
+// ProcessReports fetches the espionage report of every target for which the probes have arrived,
+// and compute its expected loot and score.
+// The targets whose report cannot be fetched are retried on the next call, their errors are returned together.
+func (f *Farmer) ProcessReports() error {
+	origin, err := f.getOrigin()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	toProcess := make([]ogame.Coordinate, 0)
+	f.targetsMu.RLock()
+	for coord, t := range f.targets {
+		if !t.SpiedAt.IsZero() && t.SpyArrivalAt.Before(now) && (t.Report == nil || t.Report.Date.Before(t.SpiedAt)) {
+			toProcess = append(toProcess, coord)
+		}
+	}
+	f.targetsMu.RUnlock()
+	var errs []error
+	for _, coord := range toProcess {
+		report, err := f.b.GetEspionageReportFor(coord)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("espionage report of %s: %w", coord, err))
+			continue
+		}
+		f.analyzeReport(origin, coord, report)
+	}
+	return errors.Join(errs...)
+}
+
+func (f *Farmer) analyzeReport(origin Celestial, coord ogame.Coordinate, report ogame.EspionageReport) {
+	f.targetsMu.RLock()
+	var playerID int64
+	if t, ok := f.targets[coord]; ok {
+		playerID = t.PlayerID
+	}
+	f.targetsMu.RUnlock()
+	loot := report.Loot(f.b.CharacterClass())
+	cargoShips := f.cargoShipsNeeded(loot)
+	ships := f.raidShips(cargoShips)
+	var secs int64
+	if ships.HasShips() {
+		secs, _ = f.b.FlightTime(origin.GetCoordinate(), coord, f.cfg.Speed, ships, ogame.Attack)
+		// Size the raid for the resources the target will have when the fleet arrives
+		loot = f.lootAt(report, playerID, time.Now().Add(time.Duration(secs)*time.Second))
+		cargoShips = f.cargoShipsNeeded(loot)
+		ships = f.raidShips(cargoShips)
+	}
+	defended := !report.IsDefenceless()
+	if defended && f.cfg.Simulate && report.HasFleetInformation && report.HasDefensesInformation {
+		defended = !f.simulateRaid(ships, report)
+	}
+	f.targetsMu.Lock()
+	defer f.targetsMu.Unlock()
+	t, ok := f.targets[coord]
+	if !ok {
+		return
+	}
+	t.Report = &report
+	t.Loot = loot
+	t.CargoShips = cargoShips
+	t.FlightTime = secs
+	t.Defended = defended
+	t.Score = farmTargetScore(loot, secs)
+}
+
+// lootAt returns the expected loot of a target at the given arrival time
+func (f *Farmer) lootAt(report ogame.EspionageReport, playerID int64, arrival time.Time) ogame.Resources {
+	return report.ProjectedLoot(arrival, f.b.GetServerData().Speed, f.planetPoints(playerID), f.b.CharacterClass())
+}
+
+// planetPoints returns the economy points of a player divided by its number of known planets,
+// used to guess the mines levels when a report has no buildings information. Returns 0 if unknown.
+func (f *Farmer) planetPoints(playerID int64) int64 {
+	store := f.b.GetIntelStore()
+	if store == nil || playerID == 0 {
+		return 0
+	}
+	highscore, err := store.HighscoreOf(ogame.PlayerHighscoreCategory, ogame.EconomyHighscore, playerID)
+	if err != nil {
+		return 0
+	}
+	planets, _ := store.PlayerPlanets(playerID)
+	return highscore.Score / utils.MaxInt(int64(len(planets)), 1)
+}
+
+func (f *Farmer) cargoShipsNeeded(loot ogame.Resources) int64 {
+	ship, ok := ogame.Objs.ByID(f.cfg.CargoShipID).(ogame.Ship)
+	if !ok {
+		return 0
+	}
+	lfBonuses, _ := f.b.GetCachedLfBonuses()
+	multiplier := float64(f.b.GetServerData().CargoHyperspaceTechMultiplier) / 100.0
+	return loot.FitsIn(ship, f.b.GetCachedResearch(), lfBonuses, f.b.CharacterClass(), multiplier, f.b.GetServer().ProbeRaidsEnabled())
+}
+
+func (f *Farmer) raidShips(cargoShips int64) ogame.ShipsInfos {
+	ships := f.cfg.Escort
+	ships.AddShips(f.cfg.CargoShipID, cargoShips)
+	return ships
+}
+
+// simulateRaid returns true if the attacker wins every simulation without losing any ship
+func (f *Farmer) simulateRaid(ships ogame.ShipsInfos, report ogame.EspionageReport) bool {
+	researches := f.b.GetCachedResearch()
+	attacker := simulator.Attacker{
+		Weapon:     int(researches.WeaponsTechnology),
+		Shield:     int(researches.ShieldingTechnology),
+		Armour:     int(researches.ArmourTechnology),
+		ShipsInfos: ships,
+	}
+	defender := simulator.Defender{
+		Metal:         int(report.Metal),
+		Crystal:       int(report.Crystal),
+		Deuterium:     int(report.Deuterium),
+		ShipsInfos:    *report.ShipsInfos(),
+		DefensesInfos: *report.DefensesInfos(),
+	}
+	if defenderResearches := report.Researches(); defenderResearches != nil {
+		defender.Weapon = int(defenderResearches.WeaponsTechnology)
+		defender.Shield = int(defenderResearches.ShieldingTechnology)
+		defender.Armour = int(defenderResearches.ArmourTechnology)
+	}
+	res := simulator.Simulate(attacker, defender, simulator.SimulatorParams{Simulations: f.cfg.Simulations})
+	return res.AttackerWin == 100 && res.AttackerLosses.Total() == 0
+}
+
+// Raid attacks the ready targets, best score first, until no more slots or ships are available.
+// Raided targets are re-queued for espionage once the fleet is back.
+func (f *Farmer) Raid() ([]ogame.Fleet, error) {
+	origin, err := f.getOrigin()
+	if err != nil {
+		return nil, err
+	}
+	fleets := make([]ogame.Fleet, 0)
+	now := time.Now()
+	for _, t := range f.Targets() {
+		if !t.IsReady(now) || t.Loot.Total() < f.cfg.MinLoot || t.CargoShips <= 0 {
+			continue
+		}
+		if !f.hasFreeSlot() {
+			return fleets, nil
+		}
+		cargoShips := t.CargoShips
+		if t.FlightTime > 0 {
+			// Resources kept growing since the report was analyzed
+			cargoShips = utils.MaxInt(cargoShips, f.cargoShipsNeeded(f.lootAt(*t.Report, t.PlayerID, time.Now().Add(time.Duration(t.FlightTime)*time.Second))))
+		}
+		fleet, err := f.b.SendFleet(origin.GetID(), f.raidShips(cargoShips), f.cfg.Speed, t.Coordinate, ogame.Attack, ogame.Resources{}, 0, 0)
+		if err != nil {
+			if errors.Is(err, ogame.ErrAllSlotsInUse) {
+				return fleets, nil
+			}
+			return fleets, err
+		}
+		fleets = append(fleets, fleet)
+		f.targetsMu.Lock()
+		if target, ok := f.targets[t.Coordinate]; ok {
+			target.RaidedAt = time.Now()
+			target.RaidBackAt = fleet.BackTime
+		}
+		f.targetsMu.Unlock()
+	}
+	if len(fleets) == 0 {
+		return fleets, ErrNoFarmTarget
+	}
+	return fleets, nil
+}
+
+func (f *Farmer) hasFreeSlot() bool {
+	slots, err := f.b.GetSlots()
+	if err != nil {
+		return false
+	}
+	return slots.Total-slots.InUse > f.cfg.KeepFreeSlots
+}
+
+// RunOnce executes one iteration of the pipeline: scan (if needed), spy, process reports, raid.
+func (f *Farmer) RunOnce() error {
+	f.targetsMu.RLock()
+	lastScanAt := f.lastScanAt
+	f.targetsMu.RUnlock()
+	if time.Since(lastScanAt) >= f.cfg.RescanInterval {
+		if err := f.ScanTargets(); err != nil {
+			return err
+		}
+	}
+	// A missing report must not prevent raiding the other targets
+	reportsErr := f.ProcessReports()
+	if _, err := f.Raid(); err != nil && !errors.Is(err, ErrNoFarmTarget) {
+		return errors.Join(reportsErr, err)
+	}
+	if err := f.SpyTargets(); err != nil && !errors.Is(err, ogame.ErrAllSlotsInUse) {
+		return errors.Join(reportsErr, err)
+	}
+	return reportsErr
+}
+
+// Start runs the pipeline in the background every Interval until Stop is called
+func (f *Farmer) Start() {
+	_ = f.loop.start(nil, func() time.Duration {
+		_ = f.RunOnce()
+		return f.cfg.Interval
+	})
+}
+
+// Stop stops the background pipeline and waits for the current iteration to complete
+func (f *Farmer) Stop() {
+	f.loop.stop()
+}
+
+// Returns the list of systems within radius of system, taking into account donut systems
+func systemsInRadius(system, radius, nbSystems int64, donutSystem bool) []int64 {
+	out := make([]int64, 0)
+	seen := make(map[int64]struct{})
+	for i := system - radius; i <= system+radius; i++ {
+		s := i
+		if donutSystem && nbSystems > 0 {
+			s = ((s-1)%nbSystems+nbSystems)%nbSystems + 1
+		} else if s < 1 || (nbSystems > 0 && s > nbSystems) {
+			continue
+		}
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		out = append(out, s)
+	}
+	return out
+}
+
+// Returns the expected loot per flight hour (round trip)
+func farmTargetScore(loot ogame.Resources, flightTime int64) float64 {
+	if flightTime <= 0 {
+		return 0
+	}
+	return float64(loot.Total()) / (float64(2*flightTime) / 3600)
+}
+
+func sortFarmTargets(targets []FarmTarget) {
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].Score > targets[j].Score
+	})
+}
@@ -0,0 +1,118 @@
+package wrapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/gameforge"
+	"github.com/alaingilbert/ogame/pkg/intel"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/taskRunner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemsInRadius(t *testing.T) {
+	assert.Equal(t, []int64{8, 9, 10, 11, 12}, systemsInRadius(10, 2, 499, false))
+	assert.Equal(t, []int64{1, 2, 3}, systemsInRadius(1, 2, 499, false))
+	assert.Equal(t, []int64{498, 499, 1, 2, 3}, systemsInRadius(1, 2, 499, true))
+	assert.Equal(t, []int64{3, 1, 2}, systemsInRadius(2, 5, 3, true))
+}
+
+func TestFarmTargetScore(t *testing.T) {
+	assert.Equal(t, 0.0, farmTargetScore(ogame.Resources{Metal: 1000}, 0))
+	assert.Equal(t, 1000.0, farmTargetScore(ogame.Resources{Metal: 600, Crystal: 400}, 1800))
+}
+
+func TestFarmTarget_IsReady(t *testing.T) {
+	now := time.Now()
+	target := FarmTarget{}
+	assert.False(t, target.IsReady(now))
+	target.Report = &ogame.EspionageReport{Date: now.Add(-time.Minute)}
+	target.SpiedAt = now.Add(-time.Minute)
+	assert.True(t, target.IsReady(now))
+	target.Defended = true
+	assert.False(t, target.IsReady(now))
+	target.Defended = false
+	target.RaidedAt = now
+	assert.False(t, target.IsReady(now))
+	// Spied again after the raid, but the new report is not fetched yet
+	target.SpiedAt = now.Add(time.Minute)
+	assert.False(t, target.IsReady(now.Add(2*time.Minute)))
+	target.Report = &ogame.EspionageReport{Date: now.Add(time.Minute)}
+	assert.True(t, target.IsReady(now.Add(2*time.Minute)))
+}
+
+func TestSortFarmTargets(t *testing.T) {
+	targets := []FarmTarget{{Score: 1}, {Score: 3}, {Score: 2}}
+	sortFarmTargets(targets)
+	assert.Equal(t, 3.0, targets[0].Score)
+	assert.Equal(t, 1.0, targets[2].Score)
+}
+
+// Wrapper serving one inactive target next to the origin, and recording the fleets sent
+type farmerWrapper struct {
+	Wrapper
+	galaxyScans int
+	missions    []ogame.MissionID
+}
+
+func (w *farmerWrapper) GetCachedCelestial(IntoCelestial) (Celestial, error) {
+	return Planet{Planet: ogame.Planet{ID: 1, Coordinate: ogame.Coordinate{Galaxy: 1, System: 100, Position: 1, Type: ogame.PlanetType}}}, nil
+}
+func (w *farmerWrapper) GetServerData() gameforge.ServerData {
+	return gameforge.ServerData{Systems: 499, Speed: 1}
+}
+func (w *farmerWrapper) GetServer() gameforge.Server                    { return gameforge.Server{} }
+func (w *farmerWrapper) GetCachedPlayer() ogame.UserInfos               { return ogame.UserInfos{PlayerID: 1} }
+func (w *farmerWrapper) WithPriority(taskRunner.Priority) Prioritizable { return w }
+func (w *farmerWrapper) CharacterClass() ogame.CharacterClass           { return ogame.Collector }
+func (w *farmerWrapper) GetIntelStore() intel.Store                     { return nil }
+func (w *farmerWrapper) GetCachedResearch() ogame.Researches            { return ogame.Researches{} }
+func (w *farmerWrapper) GetCachedLfBonuses() (ogame.LfBonuses, error)   { return ogame.LfBonuses{}, nil }
+func (w *farmerWrapper) GetSlots() (ogame.Slots, error)                 { return ogame.Slots{Total: 10}, nil }
+func (w *farmerWrapper) FlightTime(ogame.Coordinate, ogame.Coordinate, ogame.Speed, ogame.ShipsInfos, ogame.MissionID) (int64, int64) {
+	return 600, 0
+}
+
+func (w *farmerWrapper) GalaxyInfos(galaxy, system int64, _ ...Option) (ogame.SystemInfos, error) {
+	w.galaxyScans++
+	var systemInfos ogame.SystemInfos
+	if system == 100 {
+		planetInfo := &ogame.PlanetInfos{Inactive: true, Coordinate: ogame.Coordinate{Galaxy: galaxy, System: system, Position: 5, Type: ogame.PlanetType}}
+		planetInfo.Player.ID = 2
+		systemInfos.SetPlanet(4, planetInfo)
+	}
+	return systemInfos, nil
+}
+
+func (w *farmerWrapper) SendFleet(_ ogame.CelestialID, _ ogame.ShipsInfos, _ ogame.Speed, where ogame.Coordinate, mission ogame.MissionID, _ ogame.Resources, _, _ int64) (ogame.Fleet, error) {
+	w.missions = append(w.missions, mission)
+	// Arrived right away, the report can be fetched on the next iteration
+	return ogame.Fleet{Mission: mission, Destination: where, ArrivalTime: time.Now().Add(-time.Second), BackIn: 1200}, nil
+}
+
+func (w *farmerWrapper) GetEspionageReportFor(coord ogame.Coordinate) (ogame.EspionageReport, error) {
+	return ogame.EspionageReport{Coordinate: coord, Date: time.Now(), Resources: ogame.Resources{Metal: 100000, Crystal: 50000},
+		HasFleetInformation: true, HasDefensesInformation: true}, nil
+}
+
+func TestFarmer_RunOnce(t *testing.T) {
+	b := &farmerWrapper{}
+	f := NewFarmer(b, FarmerConfig{Origin: 1, Radius: 1})
+
+	// Scan the 3 systems, then spy the inactive target
+	assert.NoError(t, f.RunOnce())
+	assert.Equal(t, 3, b.galaxyScans)
+	assert.Equal(t, []ogame.MissionID{ogame.Spy}, b.missions)
+
+	// No new scan, the report is processed and the target is raided
+	assert.NoError(t, f.RunOnce())
+	assert.Equal(t, 3, b.galaxyScans)
+	assert.Equal(t, []ogame.MissionID{ogame.Spy, ogame.Attack}, b.missions)
+	targets := f.Targets()
+	if assert.Equal(t, 1, len(targets)) {
+		assert.Equal(t, ogame.Coordinate{Galaxy: 1, System: 100, Position: 5, Type: ogame.PlanetType}, targets[0].Coordinate)
+		assert.Greater(t, targets[0].CargoShips, int64(0))
+		assert.False(t, targets[0].RaidedAt.IsZero())
+	}
+}
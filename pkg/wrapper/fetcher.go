@@ -2,7 +2,11 @@ package wrapper
 
 import (
 	"net/url"
+	"path"
+	"reflect"
 
+	"github.com/alaingilbert/ogame/pkg/extractor"
+	"github.com/alaingilbert/ogame/pkg/ogame"
 	"github.com/alaingilbert/ogame/pkg/parser"
 )
 
@@ -112,7 +116,11 @@ func getPage[T parser.FullPagePages](b *OGame, opts ...Option) (*T, error) {
 	if err != nil {
 		return &zero, err
 	}
-	return parser.ParsePage[T](b.extractor, pageHTML)
+	page, err := parser.ParsePage[T](b.extractor, pageHTML)
+	if err != nil {
+		return page, b.parseError(pageName, err)
+	}
+	return page, nil
 }
 
 func getAjaxPage[T parser.AjaxPagePages](b *OGame, vals url.Values, opts ...Option) (T, error) {
@@ -132,5 +140,28 @@ func getAjaxPage[T parser.AjaxPagePages](b *OGame, vals url.Values, opts ...Opti
 	if err != nil {
 		return zero, err
 	}
-	return parser.ParseAjaxPage[T](b.extractor, pageHTML)
+	page, err := parser.ParseAjaxPage[T](b.extractor, pageHTML)
+	if err != nil {
+		return page, b.parseError(getPageName(vals), err)
+	}
+	return page, nil
+}
+
+// Wraps an error of the extractor with the page and the version of the extractor that failed to parse it
+func (b *OGame) parseError(page string, err error) error {
+	version := extractorVersion(b.extractor)
+	b.error(ExtractorLog, "failed to parse page", "page", page, "extractor", version, "error", err)
+	return &ogame.ParseError{Page: page, ExtractorVersion: version, Err: err}
+}
+
+// Returns the name of the package of the extractor (eg: "v12_0_0")
+func extractorVersion(ext extractor.Extractor) string {
+	if ext == nil {
+		return ""
+	}
+	typ := reflect.TypeOf(ext)
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return path.Base(typ.PkgPath())
 }
@@ -41,6 +41,7 @@ type FleetBuilder struct {
 	unionID          int64
 	allShips         bool
 	recallIn         int64
+	recallEngine     *RecallEngine
 	successCallbacks []func(ogame.Fleet)
 	errorCallbacks   []func(error)
 }
@@ -174,6 +175,12 @@ func (f *FleetBuilder) SetRecallIn(secs int64) *FleetBuilder {
 	return f
 }
 
+// SetRecallEngine use the recall engine to persist the recall set by SetRecallIn
+func (f *FleetBuilder) SetRecallEngine(e *RecallEngine) *FleetBuilder {
+	f.recallEngine = e
+	return f
+}
+
 // FlightTime ...
 func (f *FleetBuilder) FlightTime() (secs, fuel int64) {
 	origin := f.origin
@@ -267,7 +274,16 @@ func (f *FleetBuilder) SendNow() (ogame.Fleet, error) {
 			clb(err)
 		}
 	} else {
-		if f.recallIn > 0 {
+		recallPersisted := false
+		if f.recallIn > 0 && f.recallEngine != nil {
+			// If the rule cannot be persisted, the fleet is still recalled by this process.
+			// The rule is dropped from the engine, so the fleet is not cancelled twice.
+			recallPersisted = f.recallEngine.RecallAt(f.fleet, time.Now().Add(time.Duration(f.recallIn)*time.Second)) == nil
+			if !recallPersisted {
+				_ = f.recallEngine.RemoveRule(f.fleet.ID)
+			}
+		}
+		if f.recallIn > 0 && !recallPersisted {
 			go func() {
 				time.Sleep(time.Duration(f.recallIn) * time.Second)
 				_ = f.b.CancelFleet(f.fleet.ID)
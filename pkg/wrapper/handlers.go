@@ -18,10 +18,11 @@ import (
 
 // APIResp ...
 type APIResp struct {
-	Status  string
-	Code    int
-	Message string
-	Result  any
+	Status    string
+	Code      int
+	Message   string
+	ErrorCode string `json:",omitempty"`
+	Result    any
 }
 
 // SuccessResp ...
@@ -34,6 +35,39 @@ func ErrorResp(code int, message string) APIResp {
 	return APIResp{Status: "error", Code: code, Message: message}
 }
 
+// Http status and error code of the categories of errors returned by the wrapper
+var errorCategories = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{ogame.ErrNotInAlliance, http.StatusBadRequest, "not_in_alliance"},
+	{ogame.ErrNotLogged, http.StatusUnauthorized, "not_logged"},
+	{ogame.ErrNotEnoughResources, http.StatusPaymentRequired, "not_enough_resources"},
+	{ogame.ErrVacationMode, http.StatusForbidden, "vacation_mode"},
+	{ogame.ErrQueueBusy, http.StatusConflict, "queue_busy"},
+	{ogame.ErrInvalidTarget, http.StatusUnprocessableEntity, "invalid_target"},
+	{ogame.ErrCaptchaRequired, http.StatusPreconditionRequired, "captcha_required"},
+	{ogame.ErrNoFreeSlots, http.StatusTooManyRequests, "no_free_slots"},
+	{ogame.ErrParse, http.StatusBadGateway, "parse_error"},
+	{ogame.ErrServerMaintenance, http.StatusServiceUnavailable, "server_maintenance"},
+}
+
+// WrapperErrorResp returns the http status and the response of an error returned by the wrapper
+func WrapperErrorResp(err error) (int, APIResp) {
+	for _, category := range errorCategories {
+		if errors.Is(err, category.err) {
+			return category.status, APIResp{Status: "error", Code: category.status, Message: err.Error(), ErrorCode: category.code}
+		}
+	}
+	return http.StatusInternalServerError, ErrorResp(500, err.Error())
+}
+
+func errorJSON(c echo.Context, err error) error {
+	status, resp := WrapperErrorResp(err)
+	return c.JSON(status, resp)
+}
+
 // HomeHandler ...
 func HomeHandler(c echo.Context) error {
 	version := c.Get("version").(string)
@@ -52,6 +86,13 @@ func TasksHandler(c echo.Context) error {
 	return c.JSON(http.StatusOK, SuccessResp(bot.GetTasks()))
 }
 
+// MetricsHandler serves the metrics in the prometheus text format
+func MetricsHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	bot.GetMetricsRegistry().Handler().ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
 // GetServerHandler ...
 func GetServerHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
@@ -99,7 +140,7 @@ func LoginHandler(c echo.Context) error {
 		if err == ogame.ErrBadCredentials {
 			return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
 		}
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -141,6 +182,12 @@ func ServerVersionHandler(c echo.Context) error {
 	return c.JSON(http.StatusOK, SuccessResp(bot.serverData.Version))
 }
 
+// ServerStatusHandler ...
+func ServerStatusHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	return c.JSON(http.StatusOK, SuccessResp(bot.ServerStatus()))
+}
+
 // ServerTimeHandler ...
 func ServerTimeHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
@@ -153,7 +200,7 @@ func IsUnderAttackHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
 	isUnderAttack, err := bot.IsUnderAttack()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(isUnderAttack))
 }
@@ -166,7 +213,7 @@ func IsUnderAttackByIDHandler(c echo.Context) error {
 	}
 	isUnderAttack, err := bot.IsUnderAttack(ChangePlanet(ogame.CelestialID(planetID)))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(isUnderAttack))
 }
@@ -231,7 +278,7 @@ func GetEspionageReportMessagesHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
 	report, err := bot.GetEspionageReportMessages(-1)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(report))
 }
@@ -245,11 +292,25 @@ func GetEspionageReportHandler(c echo.Context) error {
 	}
 	espionageReport, err := bot.GetEspionageReport(msgID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(espionageReport))
 }
 
+// GetCombatReportHandler ...
+func GetCombatReportHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	msgID, err := utils.ParseI64(c.Param("msgid"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid msgid id"))
+	}
+	combatReport, err := bot.GetCombatReport(msgID)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResp(combatReport))
+}
+
 // GetEspionageReportForHandler ...
 func GetEspionageReportForHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
@@ -267,7 +328,7 @@ func GetEspionageReportForHandler(c echo.Context) error {
 	}
 	planet, err := bot.GetEspionageReportFor(ogame.Coordinate{Type: ogame.PlanetType, Galaxy: galaxy, System: system, Position: position})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(planet))
 }
@@ -285,11 +346,161 @@ func SendMessageHandler(c echo.Context) error {
 		if err.Error() == "invalid parameters" {
 			return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
 		}
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResp(nil))
+}
+
+// GetAllianceOverviewHandler ...
+func GetAllianceOverviewHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	overview, err := bot.GetAllianceOverview()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResp(overview))
+}
+
+// GetAllianceApplicationsHandler ...
+func GetAllianceApplicationsHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	applications, err := bot.GetAllianceApplications()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResp(applications))
+}
+
+// AcceptAllianceApplicationHandler ...
+func AcceptAllianceApplicationHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	applicationID, err := utils.ParseI64(c.Param("applicationID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid application id"))
+	}
+	if err := bot.AcceptAllianceApplication(applicationID); err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResp(nil))
+}
+
+// DenyAllianceApplicationHandler ...
+func DenyAllianceApplicationHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	applicationID, err := utils.ParseI64(c.Param("applicationID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid application id"))
+	}
+	if err := bot.DenyAllianceApplication(applicationID); err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResp(nil))
+}
+
+// SendAllianceBroadcastHandler ...
+func SendAllianceBroadcastHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	message := c.Request().PostFormValue("message")
+	if message == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResp(400, "empty message"))
+	}
+	if err := bot.SendAllianceBroadcast(message); err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResp(nil))
+}
+
+// GetBuddiesHandler ...
+func GetBuddiesHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	buddies, err := bot.GetBuddies()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResp(buddies))
+}
+
+// GetBuddyRequestsHandler ...
+func GetBuddyRequestsHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	requests, err := bot.GetBuddyRequests()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResp(requests))
+}
+
+// SendBuddyRequestHandler ...
+func SendBuddyRequestHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	playerID, err := utils.ParseI64(c.Request().PostFormValue("playerID"))
+	if err != nil || playerID <= 0 {
+		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid player id"))
+	}
+	message := c.Request().PostFormValue("message")
+	if err := bot.SendBuddyRequest(playerID, message); err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResp(nil))
+}
+
+// AcceptBuddyRequestHandler ...
+func AcceptBuddyRequestHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	requestID, err := utils.ParseI64(c.Param("requestID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid request id"))
+	}
+	if err := bot.AcceptBuddyRequest(requestID); err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResp(nil))
+}
+
+// RejectBuddyRequestHandler ...
+func RejectBuddyRequestHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	requestID, err := utils.ParseI64(c.Param("requestID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid request id"))
+	}
+	if err := bot.RejectBuddyRequest(requestID); err != nil {
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
 
+// GetConversationsHandler ...
+func GetConversationsHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	conversations, err := bot.GetConversations()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResp(conversations))
+}
+
+// GetConversationHandler ...
+func GetConversationHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	playerID, err := utils.ParseI64(c.Param("playerID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid player id"))
+	}
+	maxPage := int64(1)
+	if c.QueryParam("maxPage") != "" {
+		maxPage, err = utils.ParseI64(c.QueryParam("maxPage"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid maxPage"))
+		}
+	}
+	messages, err := bot.GetConversation(playerID, maxPage)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResp(messages))
+}
+
 // GetFleetsHandler ...
 func GetFleetsHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
@@ -319,7 +530,7 @@ func GetAttacksHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
 	attacks, err := bot.GetAttacks()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(attacks))
 }
@@ -337,7 +548,7 @@ func GalaxyInfosHandler(c echo.Context) error {
 	}
 	res, err := bot.GalaxyInfos(galaxy, system)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -396,7 +607,7 @@ func GetMoonByCoordHandler(c echo.Context) error {
 	}
 	planet, err := bot.GetMoon(ogame.Coordinate{Type: ogame.MoonType, Galaxy: galaxy, System: system, Position: position})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(planet))
 }
@@ -471,7 +682,7 @@ func GetPlanetHandler(c echo.Context) error {
 	}
 	planet, err := bot.GetPlanet(ogame.PlanetID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(planet))
 }
@@ -493,7 +704,7 @@ func GetPlanetByCoordHandler(c echo.Context) error {
 	}
 	planet, err := bot.GetPlanet(ogame.Coordinate{Type: ogame.PlanetType, Galaxy: galaxy, System: system, Position: position})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(planet))
 }
@@ -507,7 +718,7 @@ func GetResourcesDetailsHandler(c echo.Context) error {
 	}
 	resources, err := bot.GetResourcesDetails(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(resources))
 }
@@ -521,7 +732,7 @@ func GetResourceSettingsHandler(c echo.Context) error {
 	}
 	res, err := bot.GetResourceSettings(ogame.PlanetID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -575,7 +786,7 @@ func SetResourceSettingsHandler(c echo.Context) error {
 		if err == ogame.ErrInvalidPlanetID {
 			return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
 		}
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -589,7 +800,7 @@ func GetLfBuildingsHandler(c echo.Context) error {
 	}
 	res, err := bot.GetLfBuildings(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -603,7 +814,7 @@ func GetLfResearchHandler(c echo.Context) error {
 	}
 	res, err := bot.GetLfResearch(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -617,7 +828,7 @@ func GetResourcesBuildingsHandler(c echo.Context) error {
 	}
 	res, err := bot.GetResourcesBuildings(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -631,7 +842,7 @@ func GetDefenseHandler(c echo.Context) error {
 	}
 	res, err := bot.GetDefense(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -645,7 +856,7 @@ func GetShipsHandler(c echo.Context) error {
 	}
 	res, err := bot.GetShips(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -659,7 +870,7 @@ func GetFacilitiesHandler(c echo.Context) error {
 	}
 	res, err := bot.GetFacilities(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -680,7 +891,7 @@ func BuildHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid nbr"))
 	}
 	if err := bot.Build(ogame.CelestialID(planetID), ogame.ID(ogameID), nbr); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -697,7 +908,7 @@ func BuildCancelableHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid ogame id"))
 	}
 	if err := bot.BuildCancelable(ogame.CelestialID(planetID), ogame.ID(ogameID)); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -718,7 +929,7 @@ func BuildProductionHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid nbr"))
 	}
 	if err := bot.BuildProduction(ogame.CelestialID(planetID), ogame.ID(ogameID), nbr); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -735,7 +946,7 @@ func BuildBuildingHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid ogame id"))
 	}
 	if err := bot.BuildBuilding(ogame.CelestialID(planetID), ogame.ID(ogameID)); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -752,7 +963,7 @@ func BuildTechnologyHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid ogame id"))
 	}
 	if err := bot.BuildTechnology(ogame.CelestialID(planetID), ogame.ID(ogameID)); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -773,7 +984,7 @@ func BuildDefenseHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid nbr"))
 	}
 	if err := bot.BuildDefense(ogame.CelestialID(planetID), ogame.ID(ogameID), nbr); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -794,7 +1005,7 @@ func BuildShipsHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid nbr"))
 	}
 	if err := bot.BuildShips(ogame.CelestialID(planetID), ogame.ID(ogameID), nbr); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -808,7 +1019,7 @@ func GetProductionHandler(c echo.Context) error {
 	}
 	res, _, err := bot.GetProduction(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
@@ -852,7 +1063,7 @@ func CancelBuildingHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
 	}
 	if err := bot.CancelBuilding(ogame.CelestialID(planetID)); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -865,7 +1076,7 @@ func CancelResearchHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
 	}
 	if err := bot.CancelResearch(ogame.CelestialID(planetID)); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -879,11 +1090,25 @@ func GetResourcesHandler(c echo.Context) error {
 	}
 	res, err := bot.GetResources(ogame.CelestialID(planetID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(res))
 }
 
+// GetUnionsHandler ...
+func GetUnionsHandler(c echo.Context) error {
+	bot := c.Get("bot").(*OGame)
+	planetID, err := utils.ParseI64(c.Param("planetID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResp(400, "invalid planet id"))
+	}
+	unions, err := bot.GetUnions(ogame.CelestialID(planetID))
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResp(unions))
+}
+
 // GetRequirementsHandler ...
 func GetRequirementsHandler(c echo.Context) error {
 	ogameID, err := utils.ParseI64(c.Param("ogameID"))
@@ -1024,13 +1249,7 @@ func SendFleetHandler(c echo.Context) error {
 	if err != nil &&
 		(err == ogame.ErrInvalidPlanetID ||
 			err == ogame.ErrNoShipSelected ||
-			err == ogame.ErrUninhabitedPlanet ||
-			err == ogame.ErrNoDebrisField ||
-			err == ogame.ErrPlayerInVacationMode ||
-			err == ogame.ErrAdminOrGM ||
 			err == ogame.ErrNoAstrophysics ||
-			err == ogame.ErrNoobProtection ||
-			err == ogame.ErrPlayerTooStrong ||
 			err == ogame.ErrNoMoonAvailable ||
 			err == ogame.ErrNoRecyclerAvailable ||
 			err == ogame.ErrNoEventsRunning ||
@@ -1038,7 +1257,7 @@ func SendFleetHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResp(400, err.Error()))
 	}
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(fleet))
 }
@@ -1116,17 +1335,17 @@ func GetStaticHandler(c echo.Context) error {
 	newURL := bot.serverURL + c.Request().URL.String()
 	req, err := http.NewRequest(http.MethodGet, newURL, nil)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	req.Header.Add("Accept-Encoding", "gzip, deflate, br")
 	resp, err := bot.device.GetClient().Do(req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	defer resp.Body.Close()
 	body, err := utils.ReadBody(resp)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 
 	// Copy the original HTTP headers to our client
@@ -1223,7 +1442,7 @@ func GetEmpireHandler(c echo.Context) error {
 	}
 	getEmpire, err := bot.GetEmpireJSON(celestialType)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(getEmpire))
 }
@@ -1361,7 +1580,7 @@ func DoAuctionHandler(c echo.Context) error {
 		}
 	}
 	if err := bot.DoAuction(bid); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(nil))
 }
@@ -1500,12 +1719,12 @@ func GetCaptchaSolverHandler(c echo.Context) error {
 	answer := utils.DoParseI64(c.Request().PostFormValue("answer"))
 
 	if err := gameforge.SolveChallenge(bot.GetClient(), bot.ctx, challengeID, answer); err != nil {
-		bot.error(err)
+		bot.error(LoginLog, "failed to solve captcha", "error", err)
 	}
 
 	if !bot.IsLoggedIn() {
 		if err := bot.Login(); err != nil {
-			bot.error(err)
+			bot.error(LoginLog, "failed to login", "error", err)
 		}
 	}
 	return c.Redirect(http.StatusTemporaryRedirect, "/")
@@ -1534,7 +1753,7 @@ func GetCaptchaChallengeHandler(c echo.Context) error {
 	if errors.As(err, &captchaErr) {
 		questionRaw, iconsRaw, err := gameforge.StartCaptchaChallenge(bot.GetClient(), bot.ctx, captchaErr.ChallengeID)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+			return errorJSON(c, err)
 		}
 		questionB64 := base64.StdEncoding.EncodeToString(questionRaw)
 		iconsB64 := base64.StdEncoding.EncodeToString(iconsRaw)
@@ -1544,7 +1763,7 @@ func GetCaptchaChallengeHandler(c echo.Context) error {
 			Icons:    iconsB64,
 		}))
 	} else if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(CaptchaChallenge{}))
 }
@@ -1554,7 +1773,7 @@ func GetPublicIPHandler(c echo.Context) error {
 	bot := c.Get("bot").(*OGame)
 	ip, err := bot.GetPublicIP()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResp(500, err.Error()))
+		return errorJSON(c, err)
 	}
 	return c.JSON(http.StatusOK, SuccessResp(ip))
 }
@@ -0,0 +1,36 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/alaingilbert/ogame/pkg/gameforge"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapperErrorResp(t *testing.T) {
+	status, resp := WrapperErrorResp(fmt.Errorf("%w : %w", ErrBuild, ogame.NewServerError("Not enough resources!", 4060)))
+	assert.Equal(t, http.StatusPaymentRequired, status)
+	assert.Equal(t, APIResp{Status: "error", Code: 402, Message: "failed to build : Not enough resources! (4060)", ErrorCode: "not_enough_resources"}, resp)
+
+	status, resp = WrapperErrorResp(gameforge.NewCaptchaRequiredError("abc"))
+	assert.Equal(t, http.StatusPreconditionRequired, status)
+	assert.Equal(t, "captcha_required", resp.ErrorCode)
+
+	status, resp = WrapperErrorResp(&ogame.ParseError{Page: "overview", ExtractorVersion: "v12_0_0", Err: errors.New("bad html")})
+	assert.Equal(t, http.StatusBadGateway, status)
+	assert.Equal(t, "parse_error", resp.ErrorCode)
+
+	seen := make(map[int]bool)
+	for _, category := range errorCategories {
+		assert.False(t, seen[category.status], category.code)
+		seen[category.status] = true
+	}
+
+	status, resp = WrapperErrorResp(errors.New("boom"))
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Equal(t, "", resp.ErrorCode)
+}
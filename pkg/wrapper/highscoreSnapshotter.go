@@ -0,0 +1,288 @@
+package wrapper
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/storage"
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+// HighscoreSnapshot players ranking of one highscore type at a given time
+type HighscoreSnapshot struct {
+	At      time.Time
+	Type    int64
+	Players map[int64]ogame.HighscorePlayer // key: player id
+}
+
+// HighscoreDelta evolution of a player in one highscore type between two snapshots
+type HighscoreDelta struct {
+	PlayerID       int64
+	Name           string
+	Type           int64
+	From           time.Time
+	To             time.Time
+	ScoreBefore    int64
+	ScoreAfter     int64
+	PositionBefore int64
+	PositionAfter  int64
+}
+
+// Score returns ScoreAfter - ScoreBefore
+func (d HighscoreDelta) Score() int64 {
+	return d.ScoreAfter - d.ScoreBefore
+}
+
+// Growth returns the score variation relative to the score before (0.1: +10%)
+func (d HighscoreDelta) Growth() float64 {
+	if d.ScoreBefore <= 0 {
+		return 0
+	}
+	return float64(d.Score()) / float64(d.ScoreBefore)
+}
+
+// HighscoreHistory snapshots of every tracked highscore type, oldest first
+type HighscoreHistory struct {
+	Snapshots map[int64][]HighscoreSnapshot // key: highscore type
+}
+
+// HighscoreSnapshotterPersistor save/load the snapshots of the highscore snapshotter
+type HighscoreSnapshotterPersistor interface {
+	Load() (HighscoreHistory, error)
+	Save(HighscoreHistory) error
+}
+
+// StoreHighscoreSnapshotterPersistor save/load the snapshots of the highscore snapshotter as json in a storage.Store
+type StoreHighscoreSnapshotterPersistor struct {
+	Store storage.Store
+	Key   string
+}
+
+// NewStoreHighscoreSnapshotterPersistor creates a persistor that uses the storage of the bot (Params.Storage),
+// key storage/<universe>_<username>/highscore_history.json
+func NewStoreHighscoreSnapshotterPersistor(b Wrapper) *StoreHighscoreSnapshotterPersistor {
+	key := storage.AccountKey(b.GetUniverseName(), b.GetUsername(), "highscore_history.json")
+	return &StoreHighscoreSnapshotterPersistor{Store: b.GetDevice().GetStorage(), Key: key}
+}
+
+// Load ...
+func (p *StoreHighscoreSnapshotterPersistor) Load() (HighscoreHistory, error) {
+	var history HighscoreHistory
+	err := loadJSON(p.Store, p.Key, &history)
+	return history, err
+}
+
+// Save ...
+func (p *StoreHighscoreSnapshotterPersistor) Save(history HighscoreHistory) error {
+	return saveJSON(p.Store, p.Key, history)
+}
+
+// HighscoreSnapshotterConfig configuration of the highscore snapshotter
+type HighscoreSnapshotterConfig struct {
+	Types        []int64       // Highscore types to snapshot (default: Total, Economy, Military, Military Lost)
+	Interval     time.Duration // Delay between two snapshots (default: 1h)
+	MaxSnapshots int           // Number of snapshots kept for each type (default: 168)
+}
+
+// HighscoreSnapshotter periodically fetches the full players highscore from the public api,
+// and computes the evolution of every player between snapshots.
+type HighscoreSnapshotter struct {
+	b         Wrapper
+	cfg       HighscoreSnapshotterConfig
+	persistor HighscoreSnapshotterPersistor
+	mu        sync.RWMutex
+	history   HighscoreHistory
+	loop      backgroundLoop
+}
+
+// NewHighscoreSnapshotter creates a new highscore snapshotter, persistor defaults to NewStoreHighscoreSnapshotterPersistor
+func NewHighscoreSnapshotter(b Wrapper, cfg HighscoreSnapshotterConfig, persistor HighscoreSnapshotterPersistor) *HighscoreSnapshotter {
+	if persistor == nil {
+		persistor = NewStoreHighscoreSnapshotterPersistor(b)
+	}
+	if len(cfg.Types) == 0 {
+		cfg.Types = []int64{ogame.TotalHighscore, ogame.EconomyHighscore, ogame.MilitaryHighscore, ogame.MilitaryLostHighscore}
+	}
+	cfg.Interval = utils.Ternary(cfg.Interval <= 0, time.Hour, cfg.Interval)
+	cfg.MaxSnapshots = utils.Ternary(cfg.MaxSnapshots <= 0, 168, cfg.MaxSnapshots)
+	return &HighscoreSnapshotter{
+		b:         b,
+		cfg:       cfg,
+		persistor: persistor,
+		history:   HighscoreHistory{Snapshots: make(map[int64][]HighscoreSnapshot)},
+	}
+}
+
+// Load restores the persisted snapshots
+func (s *HighscoreSnapshotter) Load() error {
+	history, err := s.persistor.Load()
+	if err != nil {
+		return err
+	}
+	if history.Snapshots == nil {
+		history.Snapshots = make(map[int64][]HighscoreSnapshot)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = history
+	return nil
+}
+
+// Save persists the snapshots
+func (s *HighscoreSnapshotter) Save() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.persistor.Save(s.history)
+}
+
+// Snapshot saves a new snapshot of each configured highscore type, from the highscore.xml of the public api.
+// The file is updated every hour, a type whose file did not change since its last snapshot is skipped.
+func (s *HighscoreSnapshotter) Snapshot() error {
+	ctx := context.Background()
+	api := s.b.GetPublicAPI()
+	players, err := api.Players(ctx)
+	if err != nil {
+		return err
+	}
+	for _, typ := range s.cfg.Types {
+		highscore, err := api.Highscore(ctx, ogame.PlayerHighscoreCategory, typ)
+		if err != nil {
+			return err
+		}
+		at := utils.Ternary(highscore.Timestamp > 0, time.Unix(highscore.Timestamp, 0), time.Now())
+		if s.hasSnapshot(typ, at) {
+			continue
+		}
+		s.AddSnapshot(newHighscoreSnapshot(highscore.ToHighscore(&players), at))
+	}
+	return s.Save()
+}
+
+// Returns either or not a snapshot of the type was taken at the given time
+func (s *HighscoreSnapshotter) hasSnapshot(typ int64, at time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snapshots := s.history.Snapshots[typ]
+	return len(snapshots) > 0 && snapshots[len(snapshots)-1].At.Equal(at)
+}
+
+// AddSnapshot adds a snapshot to the history, the oldest snapshots are dropped once MaxSnapshots is reached
+func (s *HighscoreSnapshotter) AddSnapshot(snapshot HighscoreSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshots := append(s.history.Snapshots[snapshot.Type], snapshot)
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].At.Before(snapshots[j].At) })
+	if len(snapshots) > s.cfg.MaxSnapshots {
+		snapshots = snapshots[len(snapshots)-s.cfg.MaxSnapshots:]
+	}
+	s.history.Snapshots[snapshot.Type] = snapshots
+}
+
+// Snapshots returns the snapshots of a highscore type, oldest first
+func (s *HighscoreSnapshotter) Snapshots(typ int64) []HighscoreSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]HighscoreSnapshot{}, s.history.Snapshots[typ]...)
+}
+
+// Deltas returns the evolution of every player of a highscore type, between the last snapshot taken
+// at or before since (the oldest snapshot if none) and the latest snapshot. Sorted by score delta, biggest first.
+func (s *HighscoreSnapshotter) Deltas(typ int64, since time.Time) []HighscoreDelta {
+	s.mu.RLock()
+	snapshots := s.history.Snapshots[typ]
+	if len(snapshots) < 2 {
+		s.mu.RUnlock()
+		return []HighscoreDelta{}
+	}
+	from, to := snapshots[0], snapshots[len(snapshots)-1]
+	for _, snapshot := range snapshots[:len(snapshots)-1] {
+		if snapshot.At.After(since) {
+			break
+		}
+		from = snapshot
+	}
+	s.mu.RUnlock()
+	return highscoreDeltas(from, to)
+}
+
+// FleetLosses returns the players who lost at least minLost military points since the given time.
+// These are fleeters who just crashed their fleet, and are now easier targets.
+func (s *HighscoreSnapshotter) FleetLosses(since time.Time, minLost int64) []HighscoreDelta {
+	return filterHighscoreDeltas(s.Deltas(ogame.MilitaryLostHighscore, since), func(d HighscoreDelta) bool {
+		return d.Score() >= minLost
+	})
+}
+
+// FastGrowers returns the players whose economy grew by at least minGrowth (0.1: +10%) since the given time.
+// These are turtles growing fast, either juicy targets or future threats.
+func (s *HighscoreSnapshotter) FastGrowers(since time.Time, minGrowth float64) []HighscoreDelta {
+	return filterHighscoreDeltas(s.Deltas(ogame.EconomyHighscore, since), func(d HighscoreDelta) bool {
+		return d.Growth() >= minGrowth
+	})
+}
+
+// Start takes a snapshot every Interval in the background until Stop is called.
+// The persisted snapshots are loaded first.
+func (s *HighscoreSnapshotter) Start() error {
+	return s.loop.start(s.Load, func() time.Duration {
+		if err := s.Snapshot(); err != nil {
+			return time.Minute
+		}
+		return s.cfg.Interval
+	})
+}
+
+// Stop stops the snapshotter and waits for the current snapshot to complete
+func (s *HighscoreSnapshotter) Stop() {
+	s.loop.stop()
+}
+
+func newHighscoreSnapshot(highscore ogame.Highscore, at time.Time) HighscoreSnapshot {
+	snapshot := HighscoreSnapshot{At: at, Type: highscore.Type, Players: make(map[int64]ogame.HighscorePlayer)}
+	for _, player := range highscore.Players {
+		snapshot.Players[player.ID] = player
+	}
+	return snapshot
+}
+
+// Returns the evolution of the players present in both snapshots, biggest score delta first
+func highscoreDeltas(from, to HighscoreSnapshot) []HighscoreDelta {
+	out := make([]HighscoreDelta, 0)
+	for id, after := range to.Players {
+		before, ok := from.Players[id]
+		if !ok {
+			continue
+		}
+		out = append(out, HighscoreDelta{
+			PlayerID:       id,
+			Name:           after.Name,
+			Type:           to.Type,
+			From:           from.At,
+			To:             to.At,
+			ScoreBefore:    before.Score,
+			ScoreAfter:     after.Score,
+			PositionBefore: before.Position,
+			PositionAfter:  after.Position,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score() == out[j].Score() {
+			return out[i].PlayerID < out[j].PlayerID
+		}
+		return out[i].Score() > out[j].Score()
+	})
+	return out
+}
+
+func filterHighscoreDeltas(deltas []HighscoreDelta, keep func(HighscoreDelta) bool) []HighscoreDelta {
+	out := make([]HighscoreDelta, 0)
+	for _, d := range deltas {
+		if keep(d) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
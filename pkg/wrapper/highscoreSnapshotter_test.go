@@ -0,0 +1,102 @@
+package wrapper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/publicapi"
+	"github.com/alaingilbert/ogame/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighscoreDeltas(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	from := newHighscoreSnapshot(ogame.Highscore{Type: ogame.EconomyHighscore, Players: []ogame.HighscorePlayer{
+		{ID: 1, Name: "a", Position: 1, Score: 1000},
+		{ID: 2, Name: "b", Position: 2, Score: 500},
+		{ID: 3, Name: "c", Position: 3, Score: 100},
+	}}, now)
+	to := newHighscoreSnapshot(ogame.Highscore{Type: ogame.EconomyHighscore, Players: []ogame.HighscorePlayer{
+		{ID: 1, Name: "a", Position: 2, Score: 1010},
+		{ID: 2, Name: "b", Position: 1, Score: 1500},
+		{ID: 4, Name: "d", Position: 3, Score: 50},
+	}}, now.Add(time.Hour))
+	deltas := highscoreDeltas(from, to)
+	assert.Equal(t, 2, len(deltas))
+	assert.Equal(t, int64(2), deltas[0].PlayerID)
+	assert.Equal(t, int64(1000), deltas[0].Score())
+	assert.Equal(t, 2.0, deltas[0].Growth())
+	assert.Equal(t, int64(2), deltas[0].PositionBefore)
+	assert.Equal(t, int64(1), deltas[0].PositionAfter)
+	assert.Equal(t, int64(10), deltas[1].Score())
+}
+
+func TestHighscoreSnapshotter(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	persistor := &StoreHighscoreSnapshotterPersistor{Store: storage.NewMemoryStore(), Key: "highscore_history.json"}
+	s := NewHighscoreSnapshotter(nil, HighscoreSnapshotterConfig{MaxSnapshots: 3}, persistor)
+	for i, lost := range []int64{0, 10, 5000, 5000} {
+		s.AddSnapshot(newHighscoreSnapshot(ogame.Highscore{Type: ogame.MilitaryLostHighscore, Players: []ogame.HighscorePlayer{
+			{ID: 1, Score: lost},
+			{ID: 2, Score: 0},
+		}}, now.Add(time.Duration(i)*time.Hour)))
+	}
+	snapshots := s.Snapshots(ogame.MilitaryLostHighscore)
+	assert.Equal(t, 3, len(snapshots))
+	assert.Equal(t, now.Add(time.Hour), snapshots[0].At)
+
+	losses := s.FleetLosses(now, 1000)
+	assert.Equal(t, 1, len(losses))
+	assert.Equal(t, int64(1), losses[0].PlayerID)
+	assert.Equal(t, int64(4990), losses[0].Score())
+	assert.Equal(t, 0, len(s.FleetLosses(now.Add(2*time.Hour), 1000)))
+	assert.Equal(t, 0, len(s.FastGrowers(now, 0.1)))
+
+	assert.NoError(t, s.Save())
+	loaded := NewHighscoreSnapshotter(nil, HighscoreSnapshotterConfig{}, persistor)
+	assert.NoError(t, loaded.Load())
+	assert.Equal(t, 3, len(loaded.Snapshots(ogame.MilitaryLostHighscore)))
+}
+
+// Wrapper whose public api is served by a test server
+type publicAPIWrapper struct {
+	Wrapper
+	api *publicapi.Client
+}
+
+func (w publicAPIWrapper) GetPublicAPI() *publicapi.Client { return w.api }
+
+func TestHighscoreSnapshotter_Snapshot(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		by, err := os.ReadFile("../../samples/synthetic/api/" + filepath.Base(r.URL.Path))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(by)
+	}))
+	defer srv.Close()
+	b := publicAPIWrapper{api: publicapi.NewClientWithBaseURL(srv.Client(), srv.URL+"/api/")}
+	persistor := &StoreHighscoreSnapshotterPersistor{Store: storage.NewMemoryStore(), Key: "highscore_history.json"}
+	s := NewHighscoreSnapshotter(b, HighscoreSnapshotterConfig{Types: []int64{ogame.MilitaryHighscore}}, persistor)
+
+	assert.NoError(t, s.Snapshot())
+	snapshots := s.Snapshots(ogame.MilitaryHighscore)
+	assert.Equal(t, 1, len(snapshots))
+	assert.Equal(t, int64(1672531800), snapshots[0].At.Unix())
+	assert.Equal(t, 3, len(snapshots[0].Players))
+	assert.Equal(t, "Alice", snapshots[0].Players[100128].Name)
+	assert.Equal(t, int64(912345), snapshots[0].Players[100128].Score)
+
+	// The file did not change, no new snapshot
+	assert.NoError(t, s.Snapshot())
+	assert.Equal(t, 1, len(s.Snapshots(ogame.MilitaryHighscore)))
+	assert.Equal(t, 2, hits) // players.xml and highscore.xml, then served from the cache
+}
@@ -1,17 +1,23 @@
 package wrapper
 
 import (
+	"context"
 	"crypto/tls"
 	"github.com/alaingilbert/ogame/pkg/device"
 	"github.com/alaingilbert/ogame/pkg/gameforge"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"time"
 
 	"github.com/alaingilbert/ogame/pkg/extractor"
 	"github.com/alaingilbert/ogame/pkg/httpclient"
+	"github.com/alaingilbert/ogame/pkg/intel"
+	"github.com/alaingilbert/ogame/pkg/metrics"
 	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/publicapi"
 	"github.com/alaingilbert/ogame/pkg/taskRunner"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Celestial superset of ogame.Celestial.
@@ -47,7 +53,10 @@ type Celestial interface {
 // These actions can also be prioritized.
 type Prioritizable interface {
 	Abandon(IntoPlanet) error
+	AcceptAllianceApplication(applicationID int64) error
+	AcceptBuddyRequest(requestID int64) error
 	ActivateItem(string, ogame.CelestialID) error
+	AllHighscores(category, typ int64) (ogame.Highscore, error)
 	Begin() Prioritizable
 	BeginNamed(name string) Prioritizable
 	BuyMarketplace(itemID int64, celestialID ogame.CelestialID) error
@@ -60,6 +69,7 @@ type Prioritizable interface {
 	CreateUnion(fleet ogame.Fleet, unionUsers []string) (int64, error)
 	DeleteAllMessagesFromTab(tabID ogame.MessagesTabID) error
 	DeleteMessage(msgID int64) error
+	DenyAllianceApplication(applicationID int64) error
 	DoAuction(bid map[ogame.CelestialID]ogame.Resources) error
 	Done()
 	FlightTime(origin, destination ogame.Coordinate, speed ogame.Speed, ships ogame.ShipsInfos, mission ogame.MissionID) (secs, fuel int64)
@@ -67,15 +77,23 @@ type Prioritizable interface {
 	GalaxyInfos(galaxy, system int64, opts ...Option) (ogame.SystemInfos, error)
 	GetActiveItems(ogame.CelestialID) ([]ogame.ActiveItem, error)
 	GetAllResources() (map[ogame.CelestialID]ogame.Resources, error)
+	GetAllianceApplications() ([]ogame.AllianceApplication, error)
+	GetAllianceOverview() (ogame.AllianceOverview, error)
 	GetAttacks(...Option) ([]ogame.AttackEvent, error)
 	GetAuction() (ogame.Auction, error)
 	GetAvailableDiscoveries(...Option) int64
+	GetBuddies() ([]ogame.Buddy, error)
+	GetBuddyRequests() ([]ogame.BuddyRequest, error)
 	GetCachedAllianceClass() (ogame.AllianceClass, error)
 	GetCachedLfBonuses() (ogame.LfBonuses, error)
 	GetCachedResearch() ogame.Researches
 	GetCelestial(IntoCelestial) (Celestial, error)
 	GetCelestials() ([]Celestial, error)
+	GetCombatReport(msgID int64) (ogame.CombatReport, error)
+	GetCombatReportMessages(maxPage int64) ([]ogame.CombatReportSummary, error)
 	GetCombatReportSummaryFor(ogame.Coordinate) (ogame.CombatReportSummary, error)
+	GetConversation(playerID, maxPage int64) ([]ogame.ChatMsg, error)
+	GetConversations() ([]ogame.Conversation, error)
 	GetDMCosts(ogame.CelestialID) (ogame.DMCosts, error)
 	GetEmpire(ogame.CelestialType) ([]ogame.EmpireCelestial, error)
 	GetEmpireJSON(ogame.CelestialType) (any, error)
@@ -97,6 +115,7 @@ type Prioritizable interface {
 	GetPositionsAvailableForDiscoveryFleet(galaxy int64, system int64, opts ...Option) ([]ogame.Coordinate, error)
 	GetResearch() (ogame.Researches, error)
 	GetSlots() (ogame.Slots, error)
+	GetUnions(ogame.CelestialID, ...Option) ([]ogame.ACSValues, error)
 	GetUserInfos() (ogame.UserInfos, error)
 	HeadersForPage(url string) (http.Header, error)
 	Highscore(category, typ, page int64) (ogame.Highscore, error)
@@ -109,12 +128,16 @@ type Prioritizable interface {
 	OfferSellMarketplace(itemID any, quantity, priceType, price, priceRange int64, celestialID ogame.CelestialID) error
 	PostPageContent(url.Values, url.Values) ([]byte, error)
 	RecruitOfficer(typ, days int64) error
+	RejectBuddyRequest(requestID int64) error
 	SelectLfResearchArtifacts(planetID ogame.PlanetID, slotNumber int64, techID ogame.ID) error
 	SelectLfResearchRandom(planetID ogame.PlanetID, slotNumber int64) error
 	SelectLfResearchSelect(planetID ogame.PlanetID, slotNumber int64) error
+	SendAllianceBroadcast(message string) error
+	SendBuddyRequest(playerID int64, message string) error
 	SendMessage(playerID int64, message string) error
 	SendMessageAlliance(associationID int64, message string) error
 	ServerTime() (time.Time, error)
+	SetContext(ctx context.Context) Prioritizable
 	SetInitiator(initiator string) Prioritizable
 	SetPreferences(ogame.Preferences) error
 	SetPreferencesLang(lang string) error
@@ -197,11 +220,15 @@ type Wrapper interface {
 	GetCachedPlanets() []Planet
 	GetCachedPlayer() ogame.UserInfos
 	GetCachedPreferences() ogame.Preferences
+	GetCircuitBreaker() *CircuitBreaker
 	GetClient() *httpclient.Client
 	GetDevice() *device.Device
 	GetExtractor() extractor.Extractor
+	GetIntelStore() intel.Store
 	GetLanguage() string
+	GetMetricsRegistry() *metrics.Registry
 	GetNbSystems() int64
+	GetPublicAPI() *publicapi.Client
 	GetPublicIP() (string, error)
 	GetResearchSpeed() int64
 	GetServer() gameforge.Server
@@ -232,13 +259,21 @@ type Wrapper interface {
 	RegisterHTMLInterceptor(func(method, url string, params, payload url.Values, pageHTML []byte))
 	RegisterWSCallback(string, func([]byte))
 	RemoveWSCallback(string)
+	ServerStatus() ServerStatus
 	ServerURL() string
 	ServerVersion() string
+	SetCircuitBreaker(*CircuitBreaker)
 	SetClient(*httpclient.Client)
 	SetGetServerDataWrapper(func(func() (gameforge.ServerData, error)) (gameforge.ServerData, error))
+	SetIntelStore(intel.Store)
+	SetLogHandler(slog.Handler)
+	SetLogLevel(LogSubsystem, slog.Level)
 	SetLoginWrapper(func(func() (bool, error)) error)
+	SetMetricsRegistry(*metrics.Registry)
 	SetOGameCredentials(username, password, otpSecret, bearerToken string)
 	SetProxy(proxyAddress, username, password, proxyType string, loginOnly bool, config *tls.Config) error
+	SetRetryPolicy(RetryPolicy)
+	SetTracerProvider(trace.TracerProvider)
 	SystemDistance(system1, system2 int64) int64
 	ValidateAccount(code string) error
 	WithPriority(priority taskRunner.Priority) Prioritizable
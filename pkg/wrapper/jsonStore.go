@@ -0,0 +1,29 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/alaingilbert/ogame/pkg/storage"
+)
+
+// Loads the json value of a key into v, v is left untouched if the key does not exist
+func loadJSON(store storage.Store, key string, v any) error {
+	by, err := store.Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return json.Unmarshal(by, v)
+}
+
+// Saves v as json under key
+func saveJSON(store storage.Store, key string, v any) error {
+	by, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return store.Set(key, by)
+}
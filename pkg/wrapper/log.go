@@ -1,20 +1,228 @@
 package wrapper
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strings"
+	"sync"
+	"time"
 )
 
+// LogSubsystem part of the bot a log line comes from, every subsystem has its own level
+type LogSubsystem string
+
+// Log subsystems
+const (
+	GeneralLog   LogSubsystem = "general"
+	LoginLog     LogSubsystem = "login"
+	ChatLog      LogSubsystem = "chat"
+	RequestsLog  LogSubsystem = "requests"
+	ExtractorLog LogSubsystem = "extractor"
+)
+
+// Log levels used by the bot on top of the slog ones
+const (
+	LevelTrace    = slog.LevelDebug - 4
+	LevelCritical = slog.LevelError + 4
+)
+
+// Value of the redacted secrets in the logs
+const redacted = "REDACTED"
+
+// Secret parameters of urls and query strings
+var secretParamRgx = regexp.MustCompile(`(?i)((?:^|[?&;])(?:token|sid|session|sessionId)=)[^&;\s"']+`)
+
+// Attributes whose value is never logged
+var secretLogKeys = map[string]bool{"password": true, "otpsecret": true, "token": true, "bearertoken": true, "session": true, "ogamesession": true}
+
+// Default handler, coloured text on stdout
+var defaultLogHandler slog.Handler = newColorHandler(log.New(os.Stdout, "", 0))
+
+type logConfig struct {
+	sync.RWMutex
+	handler slog.Handler // nil for defaultLogHandler
+	levels  map[LogSubsystem]slog.Level
+}
+
 // Quiet mode will not show any informative output
 func (b *OGame) Quiet(quiet bool) {
 	b.quiet = quiet
 }
 
-// SetLogger set a custom logger for the bot
+// SetLogger set a custom logger for the bot, the lines keep the coloured text format
 func (b *OGame) SetLogger(logger *log.Logger) {
-	b.logger = logger
+	b.SetLogHandler(newColorHandler(logger))
+}
+
+// SetLogHandler set the handler that receives the structured logs of the bot.
+// Secrets (password, otp secret, tokens, session) are redacted before reaching it.
+func (b *OGame) SetLogHandler(handler slog.Handler) {
+	b.logConfig.Lock()
+	defer b.logConfig.Unlock()
+	b.logConfig.handler = handler
+}
+
+// SetLogLevel set the minimum level of the logs of a subsystem, everything is logged by default
+func (b *OGame) SetLogLevel(subsystem LogSubsystem, level slog.Level) {
+	b.logConfig.Lock()
+	defer b.logConfig.Unlock()
+	if b.logConfig.levels == nil {
+		b.logConfig.levels = make(map[LogSubsystem]slog.Level)
+	}
+	b.logConfig.levels[subsystem] = level
+}
+
+// ParseLogLevel parses trace, debug, info, warn, error or critical
+func ParseLogLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "trace":
+		return LevelTrace, nil
+	case "critical":
+		return LevelCritical, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, err
+	}
+	return level, nil
+}
+
+// NewJSONLogHandler creates a slog json handler that knows about the trace and critical levels
+func NewJSONLogHandler(w io.Writer) slog.Handler {
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     LevelTrace,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if level, ok := a.Value.Any().(slog.Level); ok && a.Key == slog.LevelKey && len(groups) == 0 {
+				a.Value = slog.StringValue(levelName(level))
+			}
+			return a
+		},
+	})
+}
+
+func levelName(level slog.Level) string {
+	switch {
+	case level < slog.LevelDebug:
+		return "TRACE"
+	case level >= LevelCritical:
+		return "CRITICAL"
+	}
+	return level.String()
+}
+
+func (b *OGame) log(subsystem LogSubsystem, level slog.Level, msg string, args ...any) {
+	if b.quiet {
+		return
+	}
+	b.logConfig.RLock()
+	handler := b.logConfig.handler
+	minLevel, ok := b.logConfig.levels[subsystem]
+	b.logConfig.RUnlock()
+	if handler == nil {
+		handler = defaultLogHandler
+	}
+	ctx := context.Background()
+	if (ok && level < minLevel) || !handler.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // skip Callers, log and the level helper
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.AddAttrs(slog.String("subsystem", string(subsystem)), slog.String("account", b.Username), slog.String("universe", b.Universe))
+	if task, _ := b.taskAtom.Load().(string); task != "" {
+		if initiator, name, found := strings.Cut(task, ":"); found {
+			r.AddAttrs(slog.String("task", name), slog.String("initiator", initiator))
+		} else {
+			r.AddAttrs(slog.String("task", task))
+		}
+	}
+	r.Add(args...)
+	_ = handler.Handle(ctx, b.redactRecord(r))
+}
+
+// Secrets currently known by the bot, they are removed from every logged string
+func (b *OGame) logSecrets() []string {
+	secrets := make([]string, 0, 6)
+	for _, secret := range []string{b.password, b.otpSecret, b.bearerToken, b.token, b.ogameSession, b.ajaxChatToken} {
+		if len(secret) >= 4 {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
+func (b *OGame) redactRecord(r slog.Record) slog.Record {
+	secrets := b.logSecrets()
+	redactString := func(s string) string {
+		for _, secret := range secrets {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+		return secretParamRgx.ReplaceAllString(s, "${1}"+redacted)
+	}
+	var redactAttr func(a slog.Attr) slog.Attr
+	redactAttr = func(a slog.Attr) slog.Attr {
+		if secretLogKeys[strings.ToLower(a.Key)] {
+			return slog.String(a.Key, redacted)
+		}
+		v := a.Value.Resolve()
+		switch v.Kind() {
+		case slog.KindString:
+			return slog.String(a.Key, redactString(v.String()))
+		case slog.KindGroup:
+			attrs := v.Group()
+			out := make([]any, 0, len(attrs))
+			for _, attr := range attrs {
+				out = append(out, redactAttr(attr))
+			}
+			return slog.Group(a.Key, out...)
+		case slog.KindAny:
+			switch value := v.Any().(type) {
+			case error:
+				return slog.String(a.Key, redactString(value.Error()))
+			case fmt.Stringer:
+				return slog.String(a.Key, redactString(value.String()))
+			}
+		}
+		return slog.Attr{Key: a.Key, Value: v}
+	}
+	out := slog.NewRecord(r.Time, r.Level, redactString(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+	return out
+}
+
+func (b *OGame) trace(subsystem LogSubsystem, msg string, args ...any) {
+	b.log(subsystem, LevelTrace, msg, args...)
+}
+
+func (b *OGame) info(subsystem LogSubsystem, msg string, args ...any) {
+	b.log(subsystem, slog.LevelInfo, msg, args...)
+}
+
+func (b *OGame) warn(subsystem LogSubsystem, msg string, args ...any) {
+	b.log(subsystem, slog.LevelWarn, msg, args...)
+}
+
+func (b *OGame) error(subsystem LogSubsystem, msg string, args ...any) {
+	b.log(subsystem, slog.LevelError, msg, args...)
+}
+
+func (b *OGame) critical(subsystem LogSubsystem, msg string, args ...any) {
+	b.log(subsystem, LevelCritical, msg, args...)
+}
+
+func (b *OGame) debug(subsystem LogSubsystem, msg string, args ...any) {
+	b.log(subsystem, slog.LevelDebug, msg, args...)
 }
 
 // Terminal styling constants
@@ -29,38 +237,79 @@ const (
 	kwht = "\x1B[37m"
 )
 
-func (b *OGame) log(prefix, color string, v ...any) {
-	if !b.quiet {
-		_, f, l, _ := runtime.Caller(2)
-		args := append([]any{fmt.Sprintf(color+"%s"+knrm+" [%s:%d]", prefix, filepath.Base(f), l)}, v...)
-		b.logger.Println(args...)
-	}
-}
+// Attributes added to every record, not repeated on each line of the terminal
+var contextLogKeys = map[string]bool{"subsystem": true, "account": true, "universe": true}
 
-func (b *OGame) trace(v ...any) {
-	b.log("TRAC", kwht, v...)
+// colorHandler writes the records as coloured text lines: "INFO [file.go:12] message key=value"
+type colorHandler struct {
+	logger *log.Logger
+	attrs  []slog.Attr
+	group  string
 }
 
-func (b *OGame) info(v ...any) {
-	b.log("INFO", kcyn, v...)
+func newColorHandler(logger *log.Logger) *colorHandler {
+	return &colorHandler{logger: logger}
 }
 
-func (b *OGame) warn(v ...any) {
-	b.log("WARN", kyel, v...)
+// Enabled implements slog.Handler
+func (h *colorHandler) Enabled(context.Context, slog.Level) bool {
+	return true
 }
 
-func (b *OGame) error(v ...any) {
-	b.log("ERRO", kred, v...)
+// Handle implements slog.Handler
+func (h *colorHandler) Handle(_ context.Context, r slog.Record) error {
+	prefix, color := "INFO", kcyn
+	switch {
+	case r.Level < slog.LevelDebug:
+		prefix, color = "TRAC", kwht
+	case r.Level < slog.LevelInfo:
+		prefix, color = "DEBU", kmag
+	case r.Level < slog.LevelWarn:
+	case r.Level < slog.LevelError:
+		prefix, color = "WARN", kyel
+	case r.Level < LevelCritical:
+		prefix, color = "ERRO", kred
+	default:
+		prefix, color = "CRIT", kred
+	}
+	var sb strings.Builder
+	sb.WriteString(color + prefix + knrm)
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		sb.WriteString(fmt.Sprintf(" [%s:%d]", filepath.Base(frame.File), frame.Line))
+	}
+	sb.WriteString(" " + r.Message)
+	writeAttr := func(a slog.Attr) bool {
+		if !contextLogKeys[a.Key] {
+			key := a.Key
+			if h.group != "" {
+				key = h.group + "." + key
+			}
+			sb.WriteString(" " + key + "=" + a.Value.String())
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		writeAttr(a)
+	}
+	r.Attrs(writeAttr)
+	h.logger.Println(sb.String())
+	return nil
 }
 
-func (b *OGame) critical(v ...any) {
-	b.log("CRIT", kred, v...)
+// WithAttrs implements slog.Handler
+func (h *colorHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &colorHandler{logger: h.logger, attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...), group: h.group}
 }
 
-func (b *OGame) debug(v ...any) {
-	b.log("DEBU", kmag, v...)
+// WithGroup implements slog.Handler
+func (h *colorHandler) WithGroup(name string) slog.Handler {
+	group := name
+	if h.group != "" {
+		group = h.group + "." + name
+	}
+	return &colorHandler{logger: h.logger, attrs: h.attrs, group: group}
 }
 
-func (b *OGame) println(v ...any) {
-	b.log("PRIN", kwht, v...)
-}
+// Compile time checks
+var _ slog.Handler = (*colorHandler)(nil)
@@ -0,0 +1,105 @@
+package wrapper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newLogTestBot(buf *bytes.Buffer) *OGame {
+	b, _ := NewNoLogin("bob@example.com", "hunter2secret", "JBSWY3DPEHPK3PXP", "bearer-token-value", "Bellatrix", "en", 0, nil)
+	b.SetLogHandler(NewJSONLogHandler(buf))
+	return b
+}
+
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	lines := make([]map[string]any, 0)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(line), &m))
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestLog_Fields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	b := newLogTestBot(buf)
+	b.debug(LoginLog, "post sessions")
+	b.botLock("farmer:SendFleet")
+	b.error(RequestsLog, "not logged", "page", "overview")
+	b.botUnlock("farmer:SendFleet")
+	b.trace(ChatLog, "chat connected")
+
+	lines := decodeLogLines(t, buf)
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, "DEBUG", lines[0]["level"])
+	assert.Equal(t, "login", lines[0]["subsystem"])
+	assert.Equal(t, "bob@example.com", lines[0]["account"])
+	assert.Equal(t, "Bellatrix", lines[0]["universe"])
+	assert.Nil(t, lines[0]["task"])
+	assert.Equal(t, "overview", lines[1]["page"])
+	assert.Equal(t, "SendFleet", lines[1]["task"])
+	assert.Equal(t, "farmer", lines[1]["initiator"])
+	assert.Equal(t, "TRACE", lines[2]["level"])
+	assert.Nil(t, lines[2]["task"])
+}
+
+func TestLog_Levels(t *testing.T) {
+	buf := new(bytes.Buffer)
+	b := newLogTestBot(buf)
+	b.SetLogLevel(RequestsLog, slog.LevelWarn)
+	b.debug(RequestsLog, "request")
+	b.error(RequestsLog, "bad status")
+	b.debug(LoginLog, "post sessions")
+	lines := decodeLogLines(t, buf)
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, "bad status", lines[0]["msg"])
+	assert.Equal(t, "post sessions", lines[1]["msg"])
+
+	b.Quiet(true)
+	b.critical(GeneralLog, "quiet")
+	assert.Equal(t, 2, len(decodeLogLines(t, buf)))
+
+	level, err := ParseLogLevel("critical")
+	assert.NoError(t, err)
+	assert.Equal(t, LevelCritical, level)
+	level, _ = ParseLogLevel("WARN")
+	assert.Equal(t, slog.LevelWarn, level)
+	_, err = ParseLogLevel("loud")
+	assert.Error(t, err)
+}
+
+func TestLog_Redact(t *testing.T) {
+	buf := new(bytes.Buffer)
+	b := newLogTestBot(buf)
+	b.error(LoginLog, "failed to login with hunter2secret", "error", errors.New("bad otp JBSWY3DPEHPK3PXP"), "token", "abc")
+	b.error(RequestsLog, "bad status", "params", "page=ingame&component=movement&return=1&token=9d899f03a8", "otpSecret", "x")
+	out := buf.String()
+	for _, secret := range []string{"hunter2secret", "JBSWY3DPEHPK3PXP", "abc", "9d899f03a8"} {
+		assert.False(t, strings.Contains(out, secret), secret)
+	}
+	lines := decodeLogLines(t, buf)
+	assert.Equal(t, "failed to login with REDACTED", lines[0]["msg"])
+	assert.Equal(t, "bad otp REDACTED", lines[0]["error"])
+	assert.Equal(t, "REDACTED", lines[0]["token"])
+	assert.Equal(t, "page=ingame&component=movement&return=1&token=REDACTED", lines[1]["params"])
+	assert.Equal(t, "REDACTED", lines[1]["otpSecret"])
+}
+
+func TestLog_ColorHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	b := newLogTestBot(buf)
+	b.SetLogger(log.New(buf, "", 0))
+	b.warn(RequestsLog, "retrying", "page", "overview")
+	assert.Regexp(t, `^\x1B\[33mWARN\x1B\[0m \[log_test.go:\d+\] retrying page=overview\n$`, buf.String())
+}
@@ -0,0 +1,202 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/gameforge"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultMaintenancePollInterval wait between two checks of the server during a maintenance
+const DefaultMaintenancePollInterval = 5 * time.Minute
+
+// ServerStatus status of the game server as seen by the bot
+type ServerStatus struct {
+	Maintenance bool      // The server is down, the task runner is paused until it is back
+	Cause       string    // Why the server is considered down
+	Since       time.Time // When the maintenance was detected
+	LastCheck   time.Time // Last time the server was polled during the maintenance
+	Version     string    // Version of the server, updated when it is back
+}
+
+type maintenanceState struct {
+	sync.Mutex
+	status       ServerStatus
+	pollInterval time.Duration
+}
+
+// The title of the page served by the game server instead of the game during a maintenance
+var maintenanceTitleRgx = regexp.MustCompile(`(?i)<title>[^<]*(maintenance|wartung)[^<]*</title>`)
+
+func isMaintenancePage(pageHTML []byte) bool {
+	return maintenanceTitleRgx.Match(pageHTML)
+}
+
+// Returns the maintenance error of a response, nil if the server is up
+func maintenanceError(resp *http.Response, pageHTML []byte) error {
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return ogame.NewError(ogame.ErrServerMaintenance, "server unavailable: "+resp.Status)
+	}
+	if isMaintenancePage(pageHTML) {
+		return ogame.NewError(ogame.ErrServerMaintenance, "server in maintenance")
+	}
+	return nil
+}
+
+// ServerStatus returns the status of the game server
+func (b *OGame) ServerStatus() ServerStatus {
+	b.maintenance.Lock()
+	defer b.maintenance.Unlock()
+	status := b.maintenance.status
+	if !status.Maintenance {
+		status.Version = b.serverData.Version
+	}
+	return status
+}
+
+func (b *OGame) getMaintenancePollInterval() time.Duration {
+	b.maintenance.Lock()
+	defer b.maintenance.Unlock()
+	if b.maintenance.pollInterval <= 0 {
+		return DefaultMaintenancePollInterval
+	}
+	return b.maintenance.pollInterval
+}
+
+// Pauses the task runner and polls the server until it is back. Does nothing if the maintenance is already known.
+func (b *OGame) enterMaintenance(cause string) {
+	b.maintenance.Lock()
+	if b.maintenance.status.Maintenance {
+		b.maintenance.Unlock()
+		return
+	}
+	b.maintenance.status = ServerStatus{Maintenance: true, Cause: cause, Since: time.Now(), Version: b.serverData.Version}
+	b.maintenance.Unlock()
+	b.taskRunnerInst.Pause(cause)
+	b.warn(GeneralLog, "server in maintenance, tasks are paused", "cause", cause)
+	b.currentSpan().AddEvent("server maintenance", trace.WithAttributes(attribute.String("cause", cause)))
+	go b.pollMaintenance()
+}
+
+// Resumes the task runner, returns how long the maintenance lasted
+func (b *OGame) exitMaintenance() time.Duration {
+	b.maintenance.Lock()
+	since := b.maintenance.status.Since
+	b.maintenance.status = ServerStatus{}
+	b.maintenance.Unlock()
+	b.taskRunnerInst.Resume()
+	return time.Since(since)
+}
+
+func (b *OGame) pollMaintenance() {
+	for {
+		select {
+		case <-time.After(b.getMaintenancePollInterval()):
+		case <-b.ctx.Done():
+			b.exitMaintenance()
+			return
+		}
+		// The paused tasks fail with ErrBotInactive
+		if !b.IsEnabled() {
+			b.exitMaintenance()
+			return
+		}
+		cause, serverVersion := b.checkServerStatus()
+		b.maintenance.Lock()
+		b.maintenance.status.LastCheck = time.Now()
+		if cause != "" {
+			b.maintenance.status.Cause = cause
+		}
+		if serverVersion != "" {
+			b.maintenance.status.Version = serverVersion
+		}
+		b.maintenance.Unlock()
+		if cause != "" {
+			b.debug(GeneralLog, "server still in maintenance", "cause", cause)
+			continue
+		}
+		if err := b.reloginAfterMaintenance(); err != nil {
+			if errors.Is(err, ogame.ErrServerMaintenance) {
+				b.debug(GeneralLog, "server still in maintenance", "cause", err)
+				continue
+			}
+			b.error(LoginLog, "failed to login after the maintenance", "error", err)
+		}
+		downtime := b.exitMaintenance()
+		b.info(GeneralLog, "server is back, tasks are resumed", "version", b.ServerVersion(), "downtime", downtime)
+		return
+	}
+}
+
+// Returns why the server is still down, "" if it is back, and the version reported by the server
+func (b *OGame) checkServerStatus() (cause, serverVersion string) {
+	client := b.device.GetClient()
+	servers, err := gameforge.GetServers(b.lobby, client, b.ctx)
+	if err != nil {
+		return "lobby unavailable: " + err.Error(), ""
+	}
+	server, found := findServerByNumber(b.server.Number, b.server.Language, servers)
+	if !found {
+		return "server not listed in the lobby", ""
+	}
+	if server.ServerClosed == 1 {
+		return "server closed in the lobby", ""
+	}
+	serverData, err := gameforge.GetServerData(client, b.ctx, server.Number, server.Language)
+	if err != nil {
+		return "server data unavailable: " + err.Error(), ""
+	}
+	req, err := http.NewRequestWithContext(b.ctx, http.MethodGet, b.serverURL+"/game/index.php", nil)
+	if err != nil {
+		return err.Error(), serverData.Version
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "server unreachable: " + err.Error(), serverData.Version
+	}
+	defer resp.Body.Close()
+	pageHTML, _ := utils.ReadBody(resp)
+	if err := maintenanceError(resp, pageHTML); err != nil {
+		return err.Error(), serverData.Version
+	}
+	return "", serverData.Version
+}
+
+// Logs in again once the task that detected the maintenance is done.
+// The new server data select the extractor matching the version of the server.
+func (b *OGame) reloginAfterMaintenance() error {
+	b.botLock("ServerStatus")
+	defer b.botUnlock("ServerStatus")
+	previousVersion := b.ServerVersion()
+	if circuitBreaker := b.GetCircuitBreaker(); circuitBreaker != nil {
+		circuitBreaker.Success()
+	}
+	b.metrics.relogin()
+	if _, err := b.wrapLoginWithExistingCookies(); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return ogame.ErrBotInactive
+		}
+		return err
+	}
+	if b.ServerVersion() != previousVersion {
+		b.info(GeneralLog, "server version changed", "from", previousVersion, "to", b.ServerVersion())
+	}
+	return nil
+}
+
+func findServerByNumber(number int64, lang string, servers []gameforge.Server) (gameforge.Server, bool) {
+	for _, s := range servers {
+		if s.Number == number && s.Language == lang {
+			return s, true
+		}
+	}
+	return gameforge.Server{}, false
+}
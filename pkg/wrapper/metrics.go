@@ -0,0 +1,178 @@
+package wrapper
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/metrics"
+	"github.com/alaingilbert/ogame/pkg/taskRunner"
+)
+
+// Buckets of the task wait and lock hold times, tasks can last minutes
+var taskBuckets = []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300}
+
+// Metrics of a bot, every series has the account and universe labels
+type botMetrics struct {
+	b               *OGame
+	registry        *metrics.Registry
+	removeHook      func()
+	requests        *metrics.CounterVec
+	requestDuration *metrics.HistogramVec
+	retries         *metrics.CounterVec
+	relogins        *metrics.CounterVec
+	captchas        *metrics.CounterVec
+	chatReconnects  *metrics.CounterVec
+	taskQueue       *metrics.GaugeVec
+	taskWait        *metrics.HistogramVec
+	lockHold        *metrics.HistogramVec
+	bytesDownloaded *metrics.GaugeVec
+	bytesUploaded   *metrics.GaugeVec
+	rps             *metrics.GaugeVec
+	fallbacks       *metrics.CounterVec
+	lockedAtMu      sync.Mutex
+	lockedAt        time.Time
+}
+
+func newBotMetrics(b *OGame, registry *metrics.Registry) *botMetrics {
+	m := &botMetrics{
+		b:               b,
+		registry:        registry,
+		requests:        registry.Counter("ogame_requests_total", "Requests sent to the game server", "account", "universe", "page", "method", "status"),
+		requestDuration: registry.Histogram("ogame_request_duration_seconds", "Latency of the requests sent to the game server", nil, "account", "universe", "page"),
+		retries:         registry.Counter("ogame_retries_total", "Failed calls retried by the wrapper", "account", "universe"),
+		relogins:        registry.Counter("ogame_relogins_total", "Logins done because the session was lost", "account", "universe"),
+		captchas:        registry.Counter("ogame_captcha_challenges_total", "Captcha challenges received while logging in", "account", "universe"),
+		chatReconnects:  registry.Counter("ogame_chat_reconnects_total", "Reconnections of the chat websocket", "account", "universe"),
+		taskQueue:       registry.Gauge("ogame_task_queue_depth", "Tasks waiting for the bot", "account", "universe", "priority"),
+		taskWait:        registry.Histogram("ogame_task_wait_seconds", "Time a task waited for the bot", taskBuckets, "account", "universe", "priority"),
+		lockHold:        registry.Histogram("ogame_lock_hold_seconds", "Time a task kept the bot locked", taskBuckets, "account", "universe", "task"),
+		bytesDownloaded: registry.Gauge("ogame_http_downloaded_bytes", "Bytes downloaded by the http client", "account", "universe"),
+		bytesUploaded:   registry.Gauge("ogame_http_uploaded_bytes", "Bytes uploaded by the http client", "account", "universe"),
+		rps:             registry.Gauge("ogame_http_requests_per_second", "Current requests per second of the http client", "account", "universe"),
+		fallbacks:       registry.Counter("ogame_extractor_fallbacks_total", "Pages the extractor of the server failed to parse, with the older extractor that parsed them (\"none\" if none did)", "account", "universe", "page", "method", "extractor", "fallback"),
+	}
+	m.removeHook = registry.OnCollect(m.collect)
+	return m
+}
+
+// Read the values that are not pushed by the bot
+func (m *botMetrics) collect() {
+	account, universe := m.labels()
+	tasks := m.b.taskRunnerInst.GetTasks()
+	for priority, count := range map[taskRunner.Priority]taskRunner.Priority{
+		taskRunner.Low:       tasks.Low,
+		taskRunner.Normal:    tasks.Normal,
+		taskRunner.Important: tasks.Important,
+		taskRunner.Critical:  tasks.Critical,
+	} {
+		m.taskQueue.Set(float64(count), account, universe, priority.String())
+	}
+	if m.b.device != nil {
+		client := m.b.device.GetClient()
+		m.bytesDownloaded.Set(float64(client.BytesDownloaded()), account, universe)
+		m.bytesUploaded.Set(float64(client.BytesUploaded()), account, universe)
+		m.rps.Set(float64(client.GetRPS()), account, universe)
+	}
+}
+
+func (m *botMetrics) labels() (account, universe string) {
+	return m.b.Username, m.b.Universe + "-" + m.b.language
+}
+
+// The metrics are optional, every method works on a nil *botMetrics
+
+// status is the http status code, or "error" when no response was received
+func (m *botMetrics) request(page, method, status string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	account, universe := m.labels()
+	m.requests.Inc(account, universe, page, method, status)
+	m.requestDuration.Observe(duration.Seconds(), account, universe, page)
+}
+
+func (m *botMetrics) retry() {
+	if m == nil {
+		return
+	}
+	m.retries.Inc(m.labels())
+}
+
+func (m *botMetrics) relogin() {
+	if m == nil {
+		return
+	}
+	m.relogins.Inc(m.labels())
+}
+
+func (m *botMetrics) captcha() {
+	if m == nil {
+		return
+	}
+	m.captchas.Inc(m.labels())
+}
+
+func (m *botMetrics) chatReconnect() {
+	if m == nil {
+		return
+	}
+	m.chatReconnects.Inc(m.labels())
+}
+
+func (m *botMetrics) taskStarted(priority taskRunner.Priority, wait time.Duration) {
+	if m == nil {
+		return
+	}
+	account, universe := m.labels()
+	m.taskWait.Observe(wait.Seconds(), account, universe, priority.String())
+}
+
+func (m *botMetrics) locked() {
+	if m == nil {
+		return
+	}
+	m.lockedAtMu.Lock()
+	m.lockedAt = time.Now()
+	m.lockedAtMu.Unlock()
+}
+
+// Task names are "initiator:name", only the name is kept to bound the number of series
+func (m *botMetrics) unlocked(task string) {
+	if m == nil {
+		return
+	}
+	m.lockedAtMu.Lock()
+	held := time.Since(m.lockedAt)
+	m.lockedAtMu.Unlock()
+	if idx := strings.LastIndex(task, ":"); idx != -1 {
+		task = task[idx+1:]
+	}
+	account, universe := m.labels()
+	m.lockHold.Observe(held.Seconds(), account, universe, task)
+}
+
+// fallback is the name of the extractor that parsed the page, "none" if every extractor failed
+func (m *botMetrics) extractorFallback(page, method, extractor, fallback string) {
+	if m == nil {
+		return
+	}
+	account, universe := m.labels()
+	m.fallbacks.Inc(account, universe, page, method, extractor, fallback)
+}
+
+// GetMetricsRegistry returns the registry where the bot reports its metrics
+func (b *OGame) GetMetricsRegistry() *metrics.Registry {
+	if b.metrics == nil {
+		return nil
+	}
+	return b.metrics.registry
+}
+
+// SetMetricsRegistry sets the registry where the bot reports its metrics (default: metrics.DefaultRegistry)
+func (b *OGame) SetMetricsRegistry(registry *metrics.Registry) {
+	if b.metrics != nil {
+		b.metrics.removeHook()
+	}
+	b.metrics = newBotMetrics(b, registry)
+}
@@ -9,14 +9,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"log"
+	"log/slog"
 	"math"
 	"net"
 	"net/http"
 	"net/url"
-	"os"
-	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
@@ -35,14 +32,19 @@ import (
 	"github.com/alaingilbert/ogame/pkg/extractor"
 	v6 "github.com/alaingilbert/ogame/pkg/extractor/v6"
 	"github.com/alaingilbert/ogame/pkg/httpclient"
+	"github.com/alaingilbert/ogame/pkg/intel"
+	"github.com/alaingilbert/ogame/pkg/metrics"
 	"github.com/alaingilbert/ogame/pkg/ogame"
 	"github.com/alaingilbert/ogame/pkg/parser"
+	"github.com/alaingilbert/ogame/pkg/publicapi"
+	"github.com/alaingilbert/ogame/pkg/storage"
 	"github.com/alaingilbert/ogame/pkg/taskRunner"
 	"github.com/alaingilbert/ogame/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/PuerkitoBio/goquery"
 	version "github.com/hashicorp/go-version"
-	cookiejar "github.com/orirawlings/persistent-cookiejar"
 	lua "github.com/yuin/gopher-lua"
 	"golang.org/x/net/proxy"
 	"golang.org/x/net/websocket"
@@ -52,12 +54,13 @@ import (
 // multiple goroutines (thread-safe)
 type OGame struct {
 	sync.Mutex
-	isEnabledAtom         atomic.Bool // atomic, prevent auto re login if we manually logged out
-	isLoggedInAtom        atomic.Bool // atomic, prevent auto re login if we manually logged out
-	isConnectedAtom       atomic.Bool // atomic, either or not communication between the bot and OGame is possible
-	lockedAtom            atomic.Bool // atomic, bot state locked/unlocked
-	chatConnectedAtom     atomic.Bool // atomic, either or not the chat is connected
-	state                 string      // keep name of the function that currently lock the bot
+	isEnabledAtom         atomic.Bool  // atomic, prevent auto re login if we manually logged out
+	isLoggedInAtom        atomic.Bool  // atomic, prevent auto re login if we manually logged out
+	isConnectedAtom       atomic.Bool  // atomic, either or not communication between the bot and OGame is possible
+	lockedAtom            atomic.Bool  // atomic, bot state locked/unlocked
+	chatConnectedAtom     atomic.Bool  // atomic, either or not the chat is connected
+	state                 string       // keep name of the function that currently lock the bot
+	taskAtom              atomic.Value // atomic, name of the task that currently lock the bot, added to the logs
 	ctx                   context.Context
 	cancelCtx             context.CancelFunc
 	stateChangeCallbacks  []func(locked bool, actor string)
@@ -67,6 +70,11 @@ type OGame struct {
 	isVacationModeEnabled bool
 	researches            *ogame.Researches
 	lfBonuses             *ogame.LfBonuses
+	publicAPI             *publicapi.Client
+	publicAPIServer       string // <number>-<language> of the server the public api client was built for
+	publicAPIMu           sync.Mutex
+	intelStore            intel.Store
+	intelStoreMu          sync.RWMutex
 	planets               []Planet
 	planetsMu             sync.RWMutex
 	token                 string
@@ -86,7 +94,13 @@ type OGame struct {
 	serverVersion         *version.Version
 	location              *time.Location
 	serverURL             string
-	logger                *log.Logger
+	logConfig             logConfig
+	metrics               *botMetrics
+	tracing               tracingConfig
+	retryMu               sync.RWMutex
+	retryPolicy           RetryPolicy
+	circuitBreaker        *CircuitBreaker
+	maintenance           maintenanceState
 	chatCallbacks         []func(msg ogame.ChatMsg)
 	wsCallbacks           map[string]func(msg []byte)
 	auctioneerCallbacks   []func(any)
@@ -98,6 +112,7 @@ type OGame struct {
 	getServerDataWrapper  func(func() (gameforge.ServerData, error)) (gameforge.ServerData, error)
 	loginProxyTransport   http.RoundTripper
 	extractor             extractor.Extractor
+	extractorFallbacks    []extractor.Registration // Older extractors, tried when the extractor of the server fails on a page
 	apiNewHostname        string
 	characterClass        ogame.CharacterClass
 	allianceClass         *ogame.AllianceClass
@@ -132,6 +147,15 @@ type Params struct {
 	APINewHostname  string
 	Device          *device.Device
 	CaptchaCallback solvers.CaptchaCallback
+	IntelStore      intel.Store                 // Optional, espionage reports, galaxy and highscore results are saved into it
+	Storage         storage.Store               // Optional, cookies, bearer token (secret), fingerprint, debug dumps and component state are moved into it (default: the storage of the device)
+	LogHandler      slog.Handler                // Optional, receives the structured logs (default: coloured text on stdout)
+	LogLevels       map[LogSubsystem]slog.Level // Optional, minimum level of the logs by subsystem
+	MetricsRegistry *metrics.Registry           // Optional, where the metrics are reported (default: metrics.DefaultRegistry)
+	TracerProvider  trace.TracerProvider        // Optional, provider of the spans, configured with your exporter (default: otel global provider)
+	RetryPolicy     *RetryPolicy                // Optional, retry policy of the page requests (default: DefaultRetryPolicy)
+	CircuitBreaker  *CircuitBreaker             // Optional, circuit breaker of the page requests (default: 5 failed calls, 5 minutes cooldown)
+	MaintenancePoll time.Duration               // Optional, wait between two checks of the server during a maintenance (default: 5 minutes)
 }
 
 // GetClientWithProxy ...
@@ -168,11 +192,36 @@ func NewWithParams(params Params) (*OGame, error) {
 	if params.Device == nil {
 		return nil, errors.New("no device defined")
 	}
+	if params.Storage != nil {
+		if err := params.Device.SetStorage(params.Storage); err != nil {
+			return nil, err
+		}
+	}
 	b, err := NewNoLogin(params.Username, params.Password, params.OTPSecret, params.BearerToken, params.Universe, params.Lang, params.PlayerID, params.Device)
 	if err != nil {
 		return nil, err
 	}
 	b.captchaCallback = params.CaptchaCallback
+	b.intelStore = params.IntelStore
+	if params.LogHandler != nil {
+		b.SetLogHandler(params.LogHandler)
+	}
+	for subsystem, level := range params.LogLevels {
+		b.SetLogLevel(subsystem, level)
+	}
+	if params.MetricsRegistry != nil {
+		b.SetMetricsRegistry(params.MetricsRegistry)
+	}
+	if params.TracerProvider != nil {
+		b.SetTracerProvider(params.TracerProvider)
+	}
+	if params.RetryPolicy != nil {
+		b.SetRetryPolicy(*params.RetryPolicy)
+	}
+	if params.CircuitBreaker != nil {
+		b.SetCircuitBreaker(params.CircuitBreaker)
+	}
+	b.maintenance.pollInterval = params.MaintenancePoll
 	b.setOGameLobby(params.Lobby)
 	b.apiNewHostname = params.APINewHostname
 	if params.Proxy != "" {
@@ -202,7 +251,6 @@ func NewNoLogin(username, password, otpSecret, bearerToken, universe, lang strin
 	b.loginWrapper = DefaultLoginWrapper
 	b.Enable()
 	b.quiet = false
-	b.logger = log.New(os.Stdout, "", 0)
 
 	b.Universe = universe
 	b.SetOGameCredentials(username, password, otpSecret, bearerToken)
@@ -217,6 +265,10 @@ func NewNoLogin(username, password, otpSecret, bearerToken, universe, lang strin
 
 	factory := func() *Prioritize { return &Prioritize{bot: b} }
 	b.taskRunnerInst = taskRunner.NewTaskRunner(context.Background(), factory)
+	b.taskRunnerInst.SetOnTaskStart(func(priority taskRunner.Priority, wait time.Duration) { b.metrics.taskStarted(priority, wait) })
+	b.metrics = newBotMetrics(b, metrics.DefaultRegistry)
+	b.retryPolicy = DefaultRetryPolicy
+	b.SetCircuitBreaker(NewCircuitBreaker(DefaultCircuitBreakerFailures, DefaultCircuitBreakerCooldown))
 
 	b.wsCallbacks = make(map[string]func([]byte))
 
@@ -257,13 +309,20 @@ func execLoginLink(b *OGame, loginLink string) ([]byte, error) {
 		return nil, err
 	}
 	req.Header.Add("Accept-Encoding", "gzip, deflate, br")
-	b.debug("login to universe")
+	b.debug(LoginLog, "login to universe")
 	resp, err := b.doReqWithLoginProxyTransport(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
-	return utils.ReadBody(resp)
+	pageHTML, err := utils.ReadBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	if err := maintenanceError(resp, pageHTML); err != nil {
+		return nil, err
+	}
+	return pageHTML, nil
 }
 
 func (b *OGame) execInterceptorCallbacks(method, url string, params, payload url.Values, pageHTML []byte) {
@@ -275,7 +334,7 @@ func (b *OGame) execInterceptorCallbacks(method, url string, params, payload url
 // V11 IntroBypass
 func (b *OGame) introBypass(page *parser.OverviewPage) error {
 	if bytes.Contains(page.GetContent(), []byte(`currentPage = "intro";`)) {
-		b.debug("bypassing intro page")
+		b.debug(LoginLog, "bypassing intro page")
 		vals := url.Values{
 			"page":      {"ingame"},
 			"component": {"intro"},
@@ -314,6 +373,7 @@ func postSessions(b *OGame) (out *gameforge.GFLoginRes, err error) {
 				if maxTry == 0 || captchaCallback == nil {
 					return err
 				}
+				b.metrics.captcha()
 				maxTry--
 				challengeID = captchaErr.ChallengeID
 				if err := solveCaptcha(b.ctx, client, challengeID, captchaCallback); err != nil {
@@ -435,13 +495,14 @@ func (b *OGame) cacheFullPageInfo(page parser.IFullPage) {
 		var err error
 		b.Player, err = castedPage.ExtractUserInfos()
 		if err != nil {
-			b.error(err)
+			b.error(ExtractorLog, "failed to extract user infos", "error", err)
 		}
 	case *parser.PreferencesPage:
 		b.CachedPreferences = castedPage.ExtractPreferences()
 	case *parser.ResearchPage:
-		researches := castedPage.ExtractResearch()
-		b.researches = &researches
+		if researches, err := castedPage.ExtractResearch(); err == nil {
+			b.researches = &researches
+		}
 	case *parser.LfBonusesPage:
 		if bonuses, err := castedPage.ExtractLfBonuses(); err == nil {
 			b.lfBonuses = &bonuses
@@ -586,13 +647,13 @@ func (b *OGame) connectChatV8(chatRetry *exponentialBackoff.ExponentialBackoff,
 	token := yeast(time.Now().UnixNano() / 1000000)
 	req, err := http.NewRequest(http.MethodGet, "https://"+host+":"+port+"/socket.io/?EIO=4&transport=polling&t="+token, nil)
 	if err != nil {
-		b.error("failed to create request:", err)
+		b.error(ChatLog, "failed to create request", "error", err)
 		return
 	}
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		b.error("failed to get socket.io token:", err)
+		b.error(ChatLog, "failed to get socket.io token", "error", err)
 		return
 	}
 	defer resp.Body.Close()
@@ -600,7 +661,7 @@ func (b *OGame) connectChatV8(chatRetry *exponentialBackoff.ExponentialBackoff,
 	by, _ := io.ReadAll(resp.Body)
 	m := regexp.MustCompile(`"sid":"([^"]+)"`).FindSubmatch(by)
 	if len(m) != 2 {
-		b.error("failed to get websocket sid:", err)
+		b.error(ChatLog, "failed to get websocket sid")
 		return
 	}
 	sid := string(m[1])
@@ -609,7 +670,7 @@ func (b *OGame) connectChatV8(chatRetry *exponentialBackoff.ExponentialBackoff,
 	wssURL := "wss://" + host + ":" + port + "/socket.io/?EIO=4&transport=websocket&sid=" + sid
 	b.ws, err = websocket.Dial(wssURL, "", origin)
 	if err != nil {
-		b.error("failed to dial websocket:", err)
+		b.error(ChatLog, "failed to dial websocket", "error", err)
 		return
 	}
 	_ = websocket.Message.Send(b.ws, "2probe")
@@ -625,19 +686,19 @@ LOOP:
 
 		var buf string
 		if err := b.ws.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
-			b.error("failed to set read deadline:", err)
+			b.error(ChatLog, "failed to set read deadline", "error", err)
 		}
 		err := websocket.Message.Receive(b.ws, &buf)
 		if err != nil {
 			if err == io.EOF {
-				b.error("chat eof:", err)
+				b.error(ChatLog, "chat eof", "error", err)
 				break
 			} else if strings.HasSuffix(err.Error(), "use of closed network connection") {
 				break
 			} else if strings.HasSuffix(err.Error(), "i/o timeout") {
 				continue
 			} else {
-				b.error("chat unexpected error", err)
+				b.error(ChatLog, "chat unexpected error", "error", err)
 				// connection reset by peer
 				break
 			}
@@ -654,21 +715,21 @@ LOOP:
 		} else if buf == "2" {
 			_ = websocket.Message.Send(b.ws, "3")
 		} else if regexp.MustCompile(`40/auctioneer,{"sid":"[^"]+"}`).MatchString(buf) {
-			b.debug("got auctioneer sid")
+			b.debug(ChatLog, "got auctioneer sid")
 		} else if regexp.MustCompile(`40/chat,{"sid":"[^"]+"}`).MatchString(buf) {
-			b.debug("got chat sid")
+			b.debug(ChatLog, "got chat sid")
 			_ = websocket.Message.Send(b.ws, `42/chat,`+utils.FI64(b.sessionChatCounter)+`["authorize","`+b.ogameSession+`"]`)
 			b.sessionChatCounter++
 		} else if regexp.MustCompile(`43/chat,\d+\[true]`).MatchString(buf) {
-			b.debug("chat connected")
+			b.debug(ChatLog, "chat connected")
 		} else if regexp.MustCompile(`43/chat,\d+\[false]`).MatchString(buf) {
-			b.error("Failed to connect to chat")
+			b.error(ChatLog, "failed to connect to chat")
 		} else if strings.HasPrefix(buf, `42/chat,["chat",`) {
 			payload := strings.TrimPrefix(buf, `42/chat,["chat",`)
 			payload = strings.TrimSuffix(payload, `]`)
 			var chatMsg ogame.ChatMsg
 			if err := json.Unmarshal([]byte(payload), &chatMsg); err != nil {
-				b.error("Unable to unmarshal chat payload", err, payload)
+				b.error(ChatLog, "unable to unmarshal chat payload", "error", err, "payload", payload)
 				continue
 			}
 			for _, clb := range b.chatCallbacks {
@@ -686,7 +747,7 @@ LOOP:
 			var out []any
 			_ = json.Unmarshal([]byte(msg), &out)
 			if len(out) == 0 {
-				b.error("unknown message received:", buf)
+				b.error(ChatLog, "unknown message received", "message", buf)
 				continue
 			}
 			if name, ok := out[0].(string); ok {
@@ -756,7 +817,7 @@ LOOP:
 				clb(pck)
 			}
 		} else {
-			b.error("unknown message received:", buf)
+			b.error(ChatLog, "unknown message received", "message", buf)
 			select {
 			case <-time.After(time.Second):
 			}
@@ -769,13 +830,14 @@ func (b *OGame) ReconnectChat() bool {
 	if b.ws == nil {
 		return false
 	}
+	b.metrics.chatReconnect()
 	_ = websocket.Message.Send(b.ws, "1::/chat")
 	return true
 }
 
 func (b *OGame) logout() {
 	_, _ = b.getPage(LogoutPageName)
-	_ = b.device.GetClient().Jar.(*cookiejar.Jar).Save()
+	_ = b.device.SaveCookies()
 	if b.isLoggedInAtom.CompareAndSwap(true, false) {
 		select {
 		case <-b.closeChatCh:
@@ -905,20 +967,32 @@ func (b *OGame) execRequest(method, finalURL string, payload, vals url.Values) (
 	}
 
 	req = req.WithContext(b.ctx)
+	start := time.Now()
 	resp, err := b.device.GetClient().Do(req)
 	if err != nil {
+		b.metrics.request(getPageName(vals), method, "error", time.Since(start))
 		return []byte{}, err
 	}
 	defer resp.Body.Close()
+	b.metrics.request(getPageName(vals), method, strconv.Itoa(resp.StatusCode), time.Since(start))
+	b.currentSpan().SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	b.trace(RequestsLog, "request", "method", method, "page", vals.Get("page"), "component", vals.Get("component"), "status", resp.StatusCode, "duration", time.Since(start))
 
 	if resp.StatusCode != http.StatusOK {
-		b.error("bad status | vals:" + vals.Encode() + " | " + resp.Status)
+		b.error(RequestsLog, "bad status", "page", vals.Get("page"), "component", vals.Get("component"), "params", vals.Encode(), "status", resp.Status)
 	}
 
-	if resp.StatusCode >= http.StatusInternalServerError {
-		return []byte{}, nil
+	if resp.StatusCode >= http.StatusInternalServerError && resp.StatusCode != http.StatusServiceUnavailable {
+		return []byte{}, errors.New("bad status: " + resp.Status)
+	}
+	pageHTML, err := utils.ReadBody(resp)
+	if err != nil {
+		return []byte{}, err
 	}
-	return utils.ReadBody(resp)
+	if err := maintenanceError(resp, pageHTML); err != nil {
+		return []byte{}, err
+	}
+	return pageHTML, nil
 }
 
 func getPageName(vals url.Values) string {
@@ -978,10 +1052,6 @@ func constructFinalURL(b *OGame, vals url.Values) string {
 	return finalURL
 }
 
-func retryPolicyFromConfig(b *OGame, cfg Options) func(func() error) error {
-	return utils.Ternary(cfg.SkipRetry, b.withoutRetry, b.withRetry)
-}
-
 func (b *OGame) getPageContent(vals url.Values, opts ...Option) ([]byte, error) {
 	return b.pageContent(http.MethodGet, vals, nil, opts...)
 }
@@ -990,7 +1060,13 @@ func (b *OGame) postPageContent(vals, payload url.Values, opts ...Option) ([]byt
 	return b.pageContent(http.MethodPost, vals, payload, opts...)
 }
 
-func (b *OGame) pageContent(method string, vals, payload url.Values, opts ...Option) ([]byte, error) {
+func (b *OGame) pageContent(method string, vals, payload url.Values, opts ...Option) (pageHTML []byte, err error) {
+	span, endSpan := b.startSpan(nil, "ogame.page", trace.WithAttributes(attribute.String("ogame.page", getPageName(vals)), attribute.String("http.method", method)))
+	defer func() {
+		span.SetAttributes(attribute.Int("ogame.response.bytes", len(pageHTML)))
+		endSpan(err)
+	}()
+
 	cfg := getOptions(opts...)
 
 	if err := b.preRequestChecks(); err != nil {
@@ -1025,37 +1101,9 @@ func (b *OGame) pageContent(method string, vals, payload url.Values, opts ...Opt
 		}
 
 		if detectLoggedOut(method, page, vals, pageHTMLBytes) {
-			b.error("Err not logged on page : ", page)
+			b.error(RequestsLog, "not logged", "page", page)
 
-			if home, err := os.UserHomeDir(); err == nil {
-				type FileInfo struct {
-					Name    string
-					ModTime time.Time
-				}
-				notLoggedPath := filepath.Join(home, ".ogame", "not_logged")
-				if err := os.MkdirAll(notLoggedPath, 0755); err == nil {
-					if files, err := ioutil.ReadDir(notLoggedPath); err == nil {
-						// Create a slice to hold file info
-						fileInfos := make([]FileInfo, 0, len(files))
-						for _, file := range files {
-							if !file.IsDir() {
-								fileInfos = append(fileInfos, FileInfo{
-									Name:    file.Name(),
-									ModTime: file.ModTime(),
-								})
-							}
-						}
-						sort.Slice(fileInfos, func(i, j int) bool { return fileInfos[i].ModTime.After(fileInfos[j].ModTime) })
-						if len(fileInfos) > 20 {
-							for _, file := range fileInfos[20:] {
-								_ = os.Remove(filepath.Join(notLoggedPath, file.Name))
-							}
-						}
-						filename := fmt.Sprintf("not_logged_%s_%s_.html", page, time.Now().Format("2006-01-02_15:04:05"))
-						_ = os.WriteFile(filepath.Join(notLoggedPath, filename), pageHTMLBytes, 0644)
-					}
-				}
-			}
+			b.dumpNotLoggedPage(page, pageHTMLBytes)
 
 			b.isConnectedAtom.Store(false)
 			return ogame.ErrNotLogged
@@ -1064,9 +1112,9 @@ func (b *OGame) pageContent(method string, vals, payload url.Values, opts ...Opt
 		return nil
 	}
 
-	retryPolicy := retryPolicyFromConfig(b, cfg)
+	retryPolicy := retryPolicyFromConfig(b, method, cfg)
 	if err := retryPolicy(clb); err != nil {
-		b.error(err)
+		b.error(RequestsLog, "request failed", "page", page, "error", err)
 		return []byte{}, err
 	}
 
@@ -1173,32 +1221,38 @@ type eventboxResp struct {
 	Friendly int
 }
 
-func (b *OGame) withoutRetry(fn func() error) error {
-	return fn()
-}
-
-func (b *OGame) withRetry(fn func() error) error {
-	maxRetry := 10
-	retryInterval := 1
-	retry := func(err error) error {
-		b.error(err.Error())
-		select {
-		case <-time.After(time.Duration(retryInterval) * time.Second):
-		case <-b.ctx.Done():
-			return ogame.ErrBotInactive
+func (b *OGame) withRetry(policy RetryPolicy, fn func() error) error {
+	circuitBreaker := b.GetCircuitBreaker()
+	// The circuit breaker counts one failure per call, with the error of its last attempt
+	var lastErr error
+	defer func() {
+		if circuitBreaker != nil && lastErr != nil {
+			circuitBreaker.report(lastErr)
 		}
-		retryInterval *= 2
-		if retryInterval > 60 {
-			retryInterval = 60
+	}()
+	for attempt := 1; ; attempt++ {
+		if circuitBreaker != nil {
+			if err := circuitBreaker.Allow(); err != nil {
+				return err
+			}
 		}
-		return nil
-	}
-
-	for {
 		err := fn()
+		lastErr = err
 		if err == nil {
+			if circuitBreaker != nil {
+				circuitBreaker.report(nil)
+			}
 			break
 		}
+		// The requests wait for the end of the maintenance in the paused task runner, instead of retrying
+		if errors.Is(err, ogame.ErrServerMaintenance) {
+			b.enterMaintenance(err.Error())
+			return err
+		}
+		// A single attempt returns the error as is
+		if policy.MaxAttempts <= 1 || !policy.isRetryable(err) {
+			return err
+		}
 		// If we manually logged out, do not try to auto re login.
 		if !b.IsEnabled() {
 			return ogame.ErrBotInactive
@@ -1206,18 +1260,31 @@ func (b *OGame) withRetry(fn func() error) error {
 		if !b.IsLoggedIn() {
 			return ogame.ErrBotLoggedOut
 		}
-		maxRetry--
-		if maxRetry <= 0 {
+		if attempt >= policy.MaxAttempts {
 			return err2.Wrap(err, ogame.ErrFailedExecuteCallback.Error())
 		}
 
-		if retryErr := retry(err); retryErr != nil {
-			return retryErr
+		if circuitBreaker != nil && circuitBreaker.reportRetry(err) {
+			lastErr = nil
 		}
+		wait := policy.Backoff(attempt)
+		b.error(RequestsLog, "retrying", "error", err, "attempt", attempt, "wait", wait)
+		select {
+		case <-time.After(wait):
+		case <-b.ctx.Done():
+			return ogame.ErrBotInactive
+		}
+		b.metrics.retry()
+		b.currentSpan().AddEvent("retry", trace.WithAttributes(attribute.String("error", err.Error())))
 
 		if errors.Is(err, ogame.ErrNotLogged) {
+			b.metrics.relogin()
 			if _, loginErr := b.wrapLoginWithExistingCookies(); loginErr != nil {
-				b.error(loginErr.Error()) // log error
+				b.error(LoginLog, "failed to login", "error", loginErr)
+				if errors.Is(loginErr, ogame.ErrServerMaintenance) {
+					b.enterMaintenance(loginErr.Error())
+					return loginErr
+				}
 				if errors.Is(loginErr, ogame.ErrAccountNotFound) ||
 					errors.Is(loginErr, ogame.ErrAccountBlocked) ||
 					errors.Is(loginErr, ogame.ErrBadCredentials) ||
@@ -1443,7 +1510,9 @@ func (b *OGame) getCelestials() ([]Celestial, error) {
 	if err != nil {
 		return nil, err
 	}
-	celestials, err := page.ExtractCelestials()
+	celestials, err := extractWithFallback(b, OverviewPageName, "ExtractCelestials", func(e extractor.Extractor) ([]ogame.Celestial, error) {
+		return e.ExtractCelestialsFromDoc(page.GetDoc())
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -1575,6 +1644,142 @@ func (b *OGame) sendMessage(id int64, message string, isPlayer bool) error {
 	return nil
 }
 
+func (b *OGame) getBuddies() ([]ogame.Buddy, error) {
+	pageHTML, err := b.getPage(BuddiesPageName)
+	if err != nil {
+		return nil, err
+	}
+	return extractWithFallback(b, BuddiesPageName, "ExtractBuddies", func(e extractor.Extractor) ([]ogame.Buddy, error) {
+		return e.ExtractBuddies(pageHTML)
+	})
+}
+
+func (b *OGame) getBuddyRequests() ([]ogame.BuddyRequest, error) {
+	pageHTML, err := b.getPage(BuddiesPageName)
+	if err != nil {
+		return nil, err
+	}
+	return extractWithFallback(b, BuddiesPageName, "ExtractBuddyRequests", func(e extractor.Extractor) ([]ogame.BuddyRequest, error) {
+		return e.ExtractBuddyRequests(pageHTML)
+	})
+}
+
+// Posts an action of the buddies component, the token is taken from the buddies page
+func (b *OGame) postBuddiesAction(action string, id int64, payload url.Values) error {
+	pageHTML, err := b.getPage(BuddiesPageName)
+	if err != nil {
+		return err
+	}
+	token, err := b.extractor.ExtractToken(pageHTML)
+	if err != nil {
+		return err
+	}
+	payload.Set("token", token)
+	vals := url.Values{"page": {"ingame"}, "component": {BuddiesPageName}, "action": {action}, "id": {utils.FI64(id)}, "ajax": {"1"}, "asJson": {"1"}}
+	by, err := b.postPageContent(vals, payload)
+	if err != nil {
+		return err
+	}
+	var res struct {
+		Status  string       `json:"status"`
+		Message string       `json:"message"`
+		Errors  []OGameError `json:"errors"`
+	}
+	if err := json.Unmarshal(by, &res); err != nil {
+		return err
+	}
+	if len(res.Errors) > 0 {
+		return errors.New(utils.FI64(res.Errors[0].Error) + " : " + res.Errors[0].Message)
+	}
+	if res.Status == "failure" {
+		return errors.New(res.Message)
+	}
+	return nil
+}
+
+// Action 7 is the "Buddy request to player" link of the galaxy and highscore pages.
+// The form posted by the dialog it opens (text, token) is not verified against a capture.
+func (b *OGame) sendBuddyRequest(playerID int64, message string) error {
+	return b.postBuddiesAction("7", playerID, url.Values{"text": {message}})
+}
+
+// The accept and reject actions are not verified against a capture, the buddies fixture is synthetic
+func (b *OGame) acceptBuddyRequest(requestID int64) error {
+	return b.postBuddiesAction("acceptRequest", requestID, url.Values{})
+}
+
+func (b *OGame) rejectBuddyRequest(requestID int64) error {
+	return b.postBuddiesAction("rejectRequest", requestID, url.Values{})
+}
+
+func (b *OGame) getConversations() ([]ogame.Conversation, error) {
+	pageHTML, err := b.getPageContent(url.Values{"page": {ChatPageName}})
+	if err != nil {
+		return nil, err
+	}
+	return extractWithFallback(b, ChatPageName, "ExtractConversations", func(e extractor.Extractor) ([]ogame.Conversation, error) {
+		return e.ExtractConversations(pageHTML)
+	})
+}
+
+// Returns the messages exchanged with a player, oldest first.
+// The first page is the chat box of the player, each following page loads older messages
+// from the page the game uses for it (chatGetAdditionalMessages), up to maxPage pages (0 for all of them).
+// The parameters of chatGetAdditionalMessages are not verified against a capture.
+func (b *OGame) getConversation(playerID, maxPage int64) ([]ogame.ChatMsg, error) {
+	type conversationPage struct {
+		msgs     []ogame.ChatMsg
+		oldestID int64
+	}
+	extractPage := func(pageHTML []byte) (conversationPage, error) {
+		return extractWithFallback(b, ChatPageName, "ExtractConversationMessages", func(e extractor.Extractor) (conversationPage, error) {
+			msgs, oldestID, err := e.ExtractConversationMessages(pageHTML, playerID)
+			return conversationPage{msgs, oldestID}, err
+		})
+	}
+	pageHTML, err := b.getPageContent(url.Values{"page": {ChatPageName}, "playerId": {utils.FI64(playerID)}})
+	if err != nil {
+		return nil, err
+	}
+	res, err := extractPage(pageHTML)
+	if err != nil {
+		return nil, err
+	}
+	msgs := res.msgs
+	for page := int64(2); res.oldestID > 0 && (maxPage <= 0 || page <= maxPage); page++ {
+		payload := url.Values{
+			"playerId":      {utils.FI64(playerID)},
+			"lastMessageId": {utils.FI64(res.oldestID)},
+			"ajax":          {"1"},
+			"token":         {b.ajaxChatToken},
+		}
+		pageHTML, err := b.postPageContent(url.Values{"page": {"chatGetAdditionalMessages"}}, payload)
+		if err != nil {
+			return msgs, err
+		}
+		// Reaching the beginning of the conversation is not a markup change, do not go through the fallback extractors
+		if !hasChatMessages(pageHTML) {
+			break
+		}
+		oldestID := res.oldestID
+		res, err = extractPage(pageHTML)
+		if err != nil {
+			return msgs, err
+		}
+		msgs = append(res.msgs, msgs...)
+		if res.oldestID == oldestID {
+			break
+		}
+	}
+	return msgs, nil
+}
+
+// Returns either or not a page of older chat messages has any message
+func hasChatMessages(pageHTML []byte) bool {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
+	return err == nil && doc.Find("li.chat_msg").Length() > 0
+}
+
 func (b *OGame) getFleetsFromEventList() []ogame.Fleet {
 	pageHTML, _ := b.getPageContent(url.Values{"eventList": {"movement"}, "ajax": {"1"}})
 	return b.extractor.ExtractFleetsFromEventList(pageHTML)
@@ -1585,29 +1790,78 @@ func (b *OGame) getFleets(opts ...Option) ([]ogame.Fleet, ogame.Slots) {
 	if err != nil {
 		return []ogame.Fleet{}, ogame.Slots{}
 	}
-	fleets := page.ExtractFleets()
+	fleets, err := b.extractFleets(page.GetDoc())
+	if err != nil {
+		return []ogame.Fleet{}, ogame.Slots{}
+	}
 	slots, _ := page.ExtractSlots()
 	return fleets, slots
 }
 
+// Extracts the fleets of the movement page, the older extractors are tried when the markup is missing
+func (b *OGame) extractFleets(doc *goquery.Document) ([]ogame.Fleet, error) {
+	return extractWithFallback(b, MovementPageName, "ExtractFleets", func(e extractor.Extractor) ([]ogame.Fleet, error) {
+		return e.ExtractFleetsFromDoc(doc)
+	})
+}
+
 func (b *OGame) cancelFleet(fleetID ogame.FleetID) error {
 	page, err := getPage[parser.MovementPage](b)
 	if err != nil {
 		return err
 	}
+	fleets, err := b.extractFleets(page.GetDoc())
+	if err != nil {
+		return err
+	}
+	fleet, found := findFleet(fleets, fleetID)
+	if !found {
+		return ogame.ErrFleetNotFound
+	}
+	if fleet.ReturnFlight {
+		return ogame.ErrFleetNotRecallable
+	}
 	token, err := page.ExtractCancelFleetToken(fleetID)
+	if err != nil {
+		return ogame.ErrFleetNotRecallable
+	}
+	pageHTML, err := b.getPageContent(url.Values{"page": {"ingame"}, "component": {"movement"}, "return": {fleetID.String()}, "token": {token}})
 	if err != nil {
 		return err
 	}
-	if _, err = b.getPageContent(url.Values{"page": {"ingame"}, "component": {"movement"}, "return": {fleetID.String()}, "token": {token}}); err != nil {
+	// The game answers with the movement page, the fleet must now be flying back
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(pageHTML))
+	if err != nil {
 		return err
 	}
+	fleets, err = b.extractFleets(doc)
+	if err != nil {
+		return err
+	}
+	if fleet, found := findFleet(fleets, fleetID); found && !fleet.ReturnFlight {
+		return ogame.ErrFleetNotRecalled
+	}
 	return nil
 }
 
+func findFleet(fleets []ogame.Fleet, fleetID ogame.FleetID) (ogame.Fleet, bool) {
+	for _, fleet := range fleets {
+		if fleet.ID == fleetID {
+			return fleet, true
+		}
+	}
+	return ogame.Fleet{}, false
+}
+
 func (b *OGame) getLastFleetFor(origin, destination ogame.Coordinate, mission ogame.MissionID) (ogame.Fleet, error) {
-	page, _ := getPage[parser.MovementPage](b)
-	fleets := page.ExtractFleets()
+	page, err := getPage[parser.MovementPage](b)
+	if err != nil {
+		return ogame.Fleet{}, err
+	}
+	fleets, err := b.extractFleets(page.GetDoc())
+	if err != nil {
+		return ogame.Fleet{}, err
+	}
 	return getLastFleetFor(fleets, origin, destination, mission)
 }
 
@@ -1652,97 +1906,28 @@ func (b *OGame) getFleetDispatch(celestialID ogame.CelestialID, options ...Optio
 	return
 }
 
-func (b *OGame) getSlots() (out ogame.Slots, err error) {
-	pageHTML, err := b.getPage(FleetdispatchPageName)
+func (b *OGame) getUnions(celestialID ogame.CelestialID, options ...Option) ([]ogame.ACSValues, error) {
+	options = append(options, ChangePlanet(celestialID))
+	page, err := getPage[parser.FleetDispatchPage](b, options...)
 	if err != nil {
-		return
-	}
-	return b.extractor.ExtractSlots(pageHTML)
-}
-
-// Returns the distance between two galaxy
-func galaxyDistance(galaxy1, galaxy2, universeSize int64, donutGalaxy bool) (distance int64) {
-	if !donutGalaxy {
-		return int64(20000 * math.Abs(float64(galaxy2-galaxy1)))
-	}
-	if galaxy1 > galaxy2 {
-		galaxy1, galaxy2 = galaxy2, galaxy1
+		return nil, err
 	}
-	val := math.Min(float64(galaxy2-galaxy1), float64((galaxy1+universeSize)-galaxy2))
-	return int64(20000 * val)
+	return page.ExtractAcsValues(), nil
 }
 
-func systemDistance(nbSystems, system1, system2 int64, donutSystem bool) (distance int64) {
-	if !donutSystem {
-		return int64(math.Abs(float64(system2 - system1)))
-	}
-	if system1 > system2 {
-		system1, system2 = system2, system1
+func (b *OGame) getSlots() (out ogame.Slots, err error) {
+	pageHTML, err := b.getPage(FleetdispatchPageName)
+	if err != nil {
+		return
 	}
-	return utils.MinInt(system2-system1, (system1+nbSystems)-system2)
-}
-
-// Returns the distance between two systems
-func flightSystemDistance(nbSystems, system1, system2, systemsSkip int64, donutSystem bool) (distance int64) {
-	dist := utils.MaxInt(systemDistance(nbSystems, system1, system2, donutSystem)-systemsSkip, 0)
-	return 2700 + 95*dist
-}
-
-// Returns the distance between two planets
-func planetDistance(planet1, planet2 int64) (distance int64) {
-	return int64(1000 + 5*math.Abs(float64(planet2-planet1)))
+	return extractWithFallback(b, FleetdispatchPageName, "ExtractSlots", func(e extractor.Extractor) (ogame.Slots, error) {
+		return e.ExtractSlots(pageHTML)
+	})
 }
 
 // Distance returns the distance between two coordinates
 func Distance(c1, c2 ogame.Coordinate, universeSize, nbSystems, systemsSkip int64, donutGalaxy, donutSystem bool) (distance int64) {
-	if c1.Galaxy != c2.Galaxy {
-		return galaxyDistance(c1.Galaxy, c2.Galaxy, universeSize, donutGalaxy)
-	}
-	if c1.System != c2.System {
-		return flightSystemDistance(nbSystems, c1.System, c2.System, systemsSkip, donutSystem)
-	}
-	if c1.Position != c2.Position {
-		return planetDistance(c1.Position, c2.Position)
-	}
-	return 5
-}
-
-func findSlowestSpeed(ships ogame.ShipsInfos, techs ogame.Researches, lfBonuses ogame.LfBonuses, characterClass ogame.CharacterClass, allianceClass ogame.AllianceClass) int64 {
-	var minSpeed int64 = math.MaxInt64
-	for _, ship := range ogame.Ships {
-		shipID := ship.GetID()
-		if shipID == ogame.SolarSatelliteID || shipID == ogame.CrawlerID {
-			continue
-		}
-		shipSpeed := ship.GetSpeed(techs, lfBonuses, characterClass, allianceClass)
-		if ships.ByID(shipID) > 0 && shipSpeed < minSpeed {
-			minSpeed = shipSpeed
-		}
-	}
-	return minSpeed
-}
-
-func calcFuel(ships ogame.ShipsInfos, dist, duration int64, universeSpeedFleet, fleetDeutSaveFactor float64, techs ogame.Researches,
-	lfBonuses ogame.LfBonuses, characterClass ogame.CharacterClass, allianceClass ogame.AllianceClass) (fuel int64) {
-	tmpFn := func(baseFuel, nbr, shipSpeed int64) float64 {
-		tmpSpeed := (35000 / (float64(duration)*universeSpeedFleet - 10)) * math.Sqrt(float64(dist)*10/float64(shipSpeed))
-		return float64(baseFuel*nbr*dist) / 35000 * math.Pow(tmpSpeed/10+1, 2)
-	}
-	tmpFuel := 0.0
-	for _, ship := range ogame.Ships {
-		shipID := ship.GetID()
-		if shipID == ogame.SolarSatelliteID || shipID == ogame.CrawlerID {
-			continue
-		}
-		nbr := ships.ByID(shipID)
-		if nbr > 0 {
-			getFuelConsumption := ship.GetFuelConsumption(techs, lfBonuses, characterClass, fleetDeutSaveFactor)
-			speed := ship.GetSpeed(techs, lfBonuses, characterClass, allianceClass)
-			tmpFuel += tmpFn(getFuelConsumption, nbr, speed)
-		}
-	}
-	fuel = int64(1 + math.Round(tmpFuel))
-	return
+	return ogame.Distance(c1, c2, universeSize, nbSystems, systemsSkip, donutGalaxy, donutSystem)
 }
 
 // CalcFlightTime ...
@@ -1753,25 +1938,15 @@ func calcFuel(ships ogame.ShipsInfos, dist, duration int64, universeSpeedFleet,
 func CalcFlightTime(origin, destination ogame.Coordinate, universeSize, nbSystems int64, donutGalaxy, donutSystem bool,
 	fleetDeutSaveFactor, speed float64, universeSpeedFleet int64, ships ogame.ShipsInfos, techs ogame.Researches, lfBonuses ogame.LfBonuses,
 	characterClass ogame.CharacterClass, allianceClass ogame.AllianceClass, systemsSkip int64) (secs, fuel int64) {
-	if !ships.HasShips() {
-		return
-	}
-	v := findSlowestSpeed(ships, techs, lfBonuses, characterClass, allianceClass)
-	secs = CalcFlightTimeWithBaseSpeed(origin, destination, universeSize, nbSystems, donutGalaxy, donutSystem, speed, v, universeSpeedFleet, systemsSkip)
-	d := float64(Distance(origin, destination, universeSize, nbSystems, systemsSkip, donutGalaxy, donutSystem))
-	fuel = calcFuel(ships, int64(d), secs, float64(universeSpeedFleet), fleetDeutSaveFactor, techs, lfBonuses, characterClass, allianceClass)
-	return
+	return ogame.CalcFlightTime(origin, destination, universeSize, nbSystems, donutGalaxy, donutSystem, fleetDeutSaveFactor, speed,
+		universeSpeedFleet, ships, techs, lfBonuses, characterClass, allianceClass, systemsSkip)
 }
 
 // CalcFlightTimeWithBaseSpeed ...
 // baseSpeed is the speed of the slowest ship in a fleet
 // speed: 1 -> 100% | 0.5 -> 50% | 0.05 -> 5%
 func CalcFlightTimeWithBaseSpeed(origin, destination ogame.Coordinate, universeSize, nbSystems int64, donutGalaxy, donutSystem bool, speed float64, baseSpeed, universeSpeedFleet, systemsSkip int64) (secs int64) {
-	s := speed
-	v := float64(baseSpeed)
-	a := float64(universeSpeedFleet)
-	d := float64(Distance(origin, destination, universeSize, nbSystems, systemsSkip, donutGalaxy, donutSystem))
-	return int64(math.Round(((3500/s)*math.Sqrt(d*10/v) + 10) / a))
+	return ogame.CalcFlightTimeWithBaseSpeed(origin, destination, universeSize, nbSystems, donutGalaxy, donutSystem, speed, baseSpeed, universeSpeedFleet, systemsSkip)
 }
 
 // CalcFlightTime calculates the flight time and the fuel consumption
@@ -1821,25 +1996,25 @@ func (b *OGame) getPhalanx(moonID ogame.MoonID, coord ogame.Coordinate) ([]ogame
 
 	// Ensure we have the resources to scan the planet
 	if resources.Deuterium < ogame.SensorPhalanx.ScanConsumption() {
-		return res, errors.New("not enough deuterium")
+		return res, ogame.NewError(ogame.ErrNotEnoughResources, "not enough deuterium")
 	}
 
 	// Verify that coordinate is in phalanx range
 	phalanxRange := ogame.SensorPhalanx.GetRange(phalanxLvl, b.isDiscoverer())
 	if moon.GetCoordinate().Galaxy != coord.Galaxy ||
-		systemDistance(b.serverData.Systems, moon.GetCoordinate().System, coord.System, b.serverData.DonutSystem) > phalanxRange {
-		return res, errors.New("coordinate not in phalanx range")
+		ogame.SystemDistance(b.serverData.Systems, moon.GetCoordinate().System, coord.System, b.serverData.DonutSystem) > phalanxRange {
+		return res, ogame.NewError(ogame.ErrInvalidTarget, "coordinate not in phalanx range")
 	}
 
 	// Get galaxy planets information, verify coordinate is valid planet (call to ogame server)
 	planetInfos, _ := b.galaxyInfos(coord.Galaxy, coord.System)
 	target := planetInfos.Position(coord.Position)
 	if target == nil {
-		return nil, errors.New("invalid planet coordinate")
+		return nil, ogame.NewError(ogame.ErrInvalidTarget, "invalid planet coordinate")
 	}
 	// Ensure you are not scanning your own planet
 	if target.Player.ID == b.Player.PlayerID {
-		return nil, errors.New("cannot scan own planet")
+		return nil, ogame.NewError(ogame.ErrInvalidTarget, "cannot scan own planet")
 	}
 
 	// Run the phalanx scan (second & third calls to ogame server)
@@ -1883,7 +2058,7 @@ func (b *OGame) headersForPage(url string) (http.Header, error) {
 
 	if b.serverURL == "" {
 		err := errors.New("serverURL is empty")
-		b.error(err)
+		b.error(RequestsLog, "serverURL is empty")
 		return nil, err
 	}
 
@@ -2060,7 +2235,27 @@ func (b *OGame) highscore(category, typ, page int64) (out ogame.Highscore, err e
 	}
 	payload := url.Values{}
 	pageHTML, _ := b.postPageContent(vals, payload)
-	return b.extractor.ExtractHighscore(pageHTML)
+	out, err = b.extractor.ExtractHighscore(pageHTML)
+	if err == nil {
+		b.saveIntel(func(store intel.Store) error { return store.SaveHighscore(out, time.Now()) })
+	}
+	return out, err
+}
+
+func (b *OGame) allHighscores(category, typ int64) (ogame.Highscore, error) {
+	out, err := b.highscore(category, typ, 1)
+	if err != nil {
+		return out, err
+	}
+	for page := int64(2); page <= out.NbPage; page++ {
+		highscore, err := b.highscore(category, typ, page)
+		if err != nil {
+			return out, err
+		}
+		out.Players = append(out.Players, highscore.Players...)
+		out.CurrPage = page
+	}
+	return out, nil
 }
 
 func (b *OGame) getAllResources() (map[ogame.CelestialID]ogame.Resources, error) {
@@ -2570,7 +2765,9 @@ func (b *OGame) galaxyInfos(galaxy, system int64, opts ...Option) (ogame.SystemI
 	if err != nil {
 		return res, err
 	}
-	res, err = b.extractor.ExtractGalaxyInfos(pageHTML, b.Player.PlayerName, b.Player.PlayerID, b.Player.Rank)
+	res, err = extractWithFallback(b, GalaxyPageName, "ExtractGalaxyInfos", func(e extractor.Extractor) (ogame.SystemInfos, error) {
+		return e.ExtractGalaxyInfos(pageHTML, b.Player.PlayerName, b.Player.PlayerID, b.Player.Rank)
+	})
 	if err != nil {
 		if cfg.DebugGalaxy {
 			fmt.Println(string(pageHTML))
@@ -2578,8 +2775,9 @@ func (b *OGame) galaxyInfos(galaxy, system int64, opts ...Option) (ogame.SystemI
 		return res, err
 	}
 	if res.Galaxy() != galaxy || res.System() != system {
-		return ogame.SystemInfos{}, errors.New("not enough deuterium")
+		return ogame.SystemInfos{}, ogame.NewError(ogame.ErrNotEnoughResources, "not enough deuterium")
 	}
+	b.saveIntel(func(store intel.Store) error { return store.SaveSystemInfos(res, time.Now()) })
 	return res, err
 }
 
@@ -2608,7 +2806,10 @@ func (b *OGame) getGalaxyPage(galaxy int64, system int64, opts ...Option) (*Gala
 
 func (b *OGame) getResourceSettings(planetID ogame.PlanetID, options ...Option) (ogame.ResourceSettings, error) {
 	options = append(options, ChangePlanet(planetID.Celestial()))
-	pageHTML, _ := b.getPage(ResourceSettingsPageName, options...)
+	pageHTML, err := b.getPage(ResourceSettingsPageName, options...)
+	if err != nil {
+		return ogame.ResourceSettings{}, err
+	}
 	settings, _, err := b.extractor.ExtractResourceSettings(pageHTML)
 	return settings, err
 }
@@ -2652,7 +2853,12 @@ func (b *OGame) getResearch() (out ogame.Researches, err error) {
 	if err != nil {
 		return
 	}
-	researches := page.ExtractResearch()
+	researches, err := extractWithFallback(b, ResearchPageName, "ExtractResearch", func(e extractor.Extractor) (ogame.Researches, error) {
+		return e.ExtractResearchFromDoc(page.GetDoc())
+	})
+	if err != nil {
+		return
+	}
 	b.researches = &researches
 	return researches, nil
 }
@@ -2669,7 +2875,9 @@ func (b *OGame) getLfBonuses() (out ogame.LfBonuses, err error) {
 	if err != nil {
 		return
 	}
-	bonuses, err := page.ExtractLfBonuses()
+	bonuses, err := extractWithFallback(b, LfBonusesPageName, "ExtractLfBonuses", func(e extractor.Extractor) (ogame.LfBonuses, error) {
+		return e.ExtractLfBonusesFromDoc(page.GetDoc())
+	})
 	if err != nil {
 		return
 	}
@@ -2716,13 +2924,113 @@ func (b *OGame) getAllianceClass() (out ogame.AllianceClass, err error) {
 	return *b.allianceClass, nil
 }
 
+// Loads a tab of the alliance page and returns its html content
+func (b *OGame) getAllianceTab(tab, action string) ([]byte, error) {
+	pageHTML, err := b.getPage(AlliancePageName)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Contains(pageHTML, []byte("createNewAlliance")) {
+		return nil, ogame.ErrNotInAlliance
+	}
+	token, err := b.extractor.ExtractToken(pageHTML)
+	if err != nil {
+		return nil, err
+	}
+	vals := url.Values{"page": {"ingame"}, "component": {"alliance"}, "tab": {tab}, "action": {action}, "ajax": {"1"}, "token": {token}}
+	pageHTML, err = b.getPageContent(vals)
+	if err != nil {
+		return nil, err
+	}
+	var res struct {
+		Content      map[string]string `json:"content"`
+		NewAjaxToken string            `json:"newAjaxToken"`
+	}
+	if err := json.Unmarshal(pageHTML, &res); err != nil {
+		return nil, err
+	}
+	b.token = res.NewAjaxToken
+	for _, content := range res.Content {
+		return []byte(content), nil
+	}
+	return nil, errors.New("alliance " + tab + " tab is empty")
+}
+
+// Posts an action of the alliance page, the token is refreshed by loading the tab first.
+// The url is the one of the captured overview tab actions (kickMember, submitRanks),
+// the applications and broadcast actions and their payloads are not verified against a capture.
+func (b *OGame) postAllianceAction(tab, fetchAction, action string, payload url.Values) error {
+	if _, err := b.getAllianceTab(tab, fetchAction); err != nil {
+		return err
+	}
+	vals := url.Values{"page": {"ingame"}, "component": {"alliance"}, "tab": {tab}, "action": {action}, "asJson": {"1"}}
+	payload.Set("token", b.token)
+	by, err := b.postPageContent(vals, payload)
+	if err != nil {
+		return err
+	}
+	var res struct {
+		Status  string       `json:"status"`
+		Message string       `json:"message"`
+		Errors  []OGameError `json:"errors"`
+	}
+	if err := json.Unmarshal(by, &res); err != nil {
+		return err
+	}
+	if len(res.Errors) > 0 {
+		return errors.New(utils.FI64(res.Errors[0].Error) + " : " + res.Errors[0].Message)
+	}
+	if res.Status == "failure" {
+		return errors.New(res.Message)
+	}
+	return nil
+}
+
+func (b *OGame) getAllianceOverview() (ogame.AllianceOverview, error) {
+	pageHTML, err := b.getAllianceTab("overview", "fetchOverview")
+	if err != nil {
+		return ogame.AllianceOverview{}, err
+	}
+	return extractWithFallback(b, AlliancePageName, "ExtractAllianceOverview", func(e extractor.Extractor) (ogame.AllianceOverview, error) {
+		return e.ExtractAllianceOverview(pageHTML)
+	})
+}
+
+func (b *OGame) getAllianceApplications() ([]ogame.AllianceApplication, error) {
+	pageHTML, err := b.getAllianceTab("applications", "fetchApplications")
+	if err != nil {
+		return nil, err
+	}
+	return extractWithFallback(b, AlliancePageName, "ExtractAllianceApplications", func(e extractor.Extractor) ([]ogame.AllianceApplication, error) {
+		return e.ExtractAllianceApplications(pageHTML)
+	})
+}
+
+func (b *OGame) acceptAllianceApplication(applicationID int64) error {
+	payload := url.Values{"applicationId": {utils.FI64(applicationID)}}
+	return b.postAllianceAction("applications", "fetchApplications", "acceptApplication", payload)
+}
+
+func (b *OGame) denyAllianceApplication(applicationID int64) error {
+	payload := url.Values{"applicationId": {utils.FI64(applicationID)}}
+	return b.postAllianceAction("applications", "fetchApplications", "declineApplication", payload)
+}
+
+// Sends a circular message to every member of the alliance
+func (b *OGame) sendAllianceBroadcast(message string) error {
+	payload := url.Values{"text": {message}, "empfaenger": {"0"}}
+	return b.postAllianceAction("broadcast", "fetchBroadcast", "sendBroadcast", payload)
+}
+
 func (b *OGame) getResourcesBuildings(celestialID ogame.CelestialID, options ...Option) (ogame.ResourcesBuildings, error) {
 	options = append(options, ChangePlanet(celestialID))
 	page, err := getPage[parser.SuppliesPage](b, options...)
 	if err != nil {
 		return ogame.ResourcesBuildings{}, err
 	}
-	return page.ExtractResourcesBuildings()
+	return extractWithFallback(b, SuppliesPageName, "ExtractResourcesBuildings", func(e extractor.Extractor) (ogame.ResourcesBuildings, error) {
+		return e.ExtractResourcesBuildingsFromDoc(page.GetDoc())
+	})
 }
 
 func (b *OGame) getLfBuildings(celestialID ogame.CelestialID, options ...Option) (ogame.LfBuildings, error) {
@@ -2770,7 +3078,9 @@ func (b *OGame) getDefense(celestialID ogame.CelestialID, options ...Option) (og
 	if err != nil {
 		return ogame.DefensesInfos{}, err
 	}
-	return page.ExtractDefense()
+	return extractWithFallback(b, DefensesPageName, "ExtractDefense", func(e extractor.Extractor) (ogame.DefensesInfos, error) {
+		return e.ExtractDefenseFromDoc(page.GetDoc())
+	})
 }
 
 func (b *OGame) getShips(celestialID ogame.CelestialID, options ...Option) (ogame.ShipsInfos, error) {
@@ -2779,7 +3089,9 @@ func (b *OGame) getShips(celestialID ogame.CelestialID, options ...Option) (ogam
 	if err != nil {
 		return ogame.ShipsInfos{}, err
 	}
-	return page.ExtractShips()
+	return extractWithFallback(b, ShipyardPageName, "ExtractShips", func(e extractor.Extractor) (ogame.ShipsInfos, error) {
+		return e.ExtractShipsFromDoc(page.GetDoc())
+	})
 }
 
 func (b *OGame) getFacilities(celestialID ogame.CelestialID, options ...Option) (ogame.Facilities, error) {
@@ -2788,7 +3100,9 @@ func (b *OGame) getFacilities(celestialID ogame.CelestialID, options ...Option)
 	if err != nil {
 		return ogame.Facilities{}, err
 	}
-	return page.ExtractFacilities()
+	return extractWithFallback(b, FacilitiesPageName, "ExtractFacilities", func(e extractor.Extractor) (ogame.Facilities, error) {
+		return e.ExtractFacilitiesFromDoc(page.GetDoc())
+	})
 }
 
 func (b *OGame) getTechs(celestialID ogame.CelestialID) (ogame.ResourcesBuildings, ogame.Facilities, ogame.ShipsInfos, ogame.DefensesInfos, ogame.Researches, ogame.LfBuildings, ogame.LfResearches, error) {
@@ -2859,8 +3173,12 @@ func (b *OGame) technologyDetails(celestialID ogame.CelestialID, id ogame.ID) (o
 	return b.extractor.ExtractTechnologyDetails(pageHTML)
 }
 
+// Returns the token of the page, nothing can be built while the account is in vacation mode
 func getToken(b *OGame, page string, celestialID ogame.CelestialID) (string, error) {
 	pageHTML, _ := b.getPage(page, ChangePlanet(celestialID))
+	if b.extractor.ExtractIsInVacation(pageHTML) {
+		return "", ogame.ErrAccountInVacationMode
+	}
 	return b.extractor.ExtractToken(pageHTML)
 }
 
@@ -2990,13 +3308,13 @@ func (b *OGame) build(celestialID ogame.CelestialID, id ogame.ID, nbr int64) err
 		return err
 	}
 	if err := json.Unmarshal(by, &responseStruct); err != nil {
-		return err
+		return b.parseError(page, err)
 	}
 	if responseStruct.Status == "failure" {
 		errInst := ErrBuild
 		if len(responseStruct.Errors) > 0 {
 			errStruct := responseStruct.Errors[0]
-			errInst = fmt.Errorf("%w : %s (%d)", errInst, errStruct.Message, errStruct.Error)
+			errInst = fmt.Errorf("%w : %w", errInst, ogame.NewServerError(errStruct.Message, int64(errStruct.Error)))
 		}
 		return errInst
 	}
@@ -3091,7 +3409,9 @@ func (b *OGame) fetchResources(celestialID ogame.CelestialID) (ogame.ResourcesDe
 	if err != nil {
 		return ogame.ResourcesDetails{}, err
 	}
-	return b.extractor.ExtractResourcesDetails(pageJSON)
+	return extractWithFallback(b, FetchResourcesPageName, "ExtractResourcesDetails", func(e extractor.Extractor) (ogame.ResourcesDetails, error) {
+		return e.ExtractResourcesDetails(pageJSON)
+	})
 }
 
 func (b *OGame) getResources(celestialID ogame.CelestialID) (ogame.Resources, error) {
@@ -3321,7 +3641,7 @@ func (b *OGame) sendFleet(celestialID ogame.CelestialID, ships ogame.ShipsInfos,
 	}
 	fleet1BodyID := b.extractor.ExtractBodyIDFromDoc(fleet1Doc)
 	if fleet1BodyID != FleetdispatchPageName {
-		b.error(ogame.ErrInvalidPlanetID.Error()+", planetID:", celestialID)
+		b.error(GeneralLog, ogame.ErrInvalidPlanetID.Error(), "planetID", celestialID)
 		return ogame.Fleet{}, ogame.ErrInvalidPlanetID
 	}
 
@@ -3334,7 +3654,7 @@ func (b *OGame) sendFleet(celestialID ogame.CelestialID, ships ogame.ShipsInfos,
 	myCelestials, _ := b.extractor.ExtractCelestialsFromDoc(fleet1Doc)
 	for _, c := range myCelestials {
 		if c.GetCoordinate().Equal(where) && c.GetID() == celestialID {
-			return ogame.Fleet{}, errors.New("origin and destination are the same")
+			return ogame.Fleet{}, ogame.NewError(ogame.ErrInvalidTarget, "origin and destination are the same")
 		}
 		if c.GetCoordinate().Equal(where) {
 			destinationIsMyOwnPlanet = true
@@ -3344,9 +3664,9 @@ func (b *OGame) sendFleet(celestialID ogame.CelestialID, ships ogame.ShipsInfos,
 	if destinationIsMyOwnPlanet {
 		switch mission {
 		case ogame.Spy:
-			return ogame.Fleet{}, errors.New("you cannot spy yourself")
+			return ogame.Fleet{}, ogame.NewError(ogame.ErrInvalidTarget, "you cannot spy yourself")
 		case ogame.Attack:
-			return ogame.Fleet{}, errors.New("you cannot attack yourself")
+			return ogame.Fleet{}, ogame.NewError(ogame.ErrInvalidTarget, "you cannot attack yourself")
 		}
 	}
 
@@ -3421,20 +3741,20 @@ func (b *OGame) sendFleet(celestialID ogame.CelestialID, ships ogame.ShipsInfos,
 	// Check
 	by1, err := b.postPageContent(url.Values{"page": {"ingame"}, "component": {"fleetdispatch"}, "action": {"checkTarget"}, "ajax": {"1"}, "asJson": {"1"}}, payload)
 	if err != nil {
-		b.error(err.Error())
+		b.error(GeneralLog, "failed to check target", "error", err)
 		return ogame.Fleet{}, err
 	}
 	var checkRes CheckTargetResponse
 	if err := json.Unmarshal(by1, &checkRes); err != nil {
-		b.error(err.Error())
-		return ogame.Fleet{}, err
+		b.error(ExtractorLog, "failed to unmarshal check target response", "error", err)
+		return ogame.Fleet{}, b.parseError(FleetdispatchPageName, err)
 	}
 
 	if !checkRes.TargetOk {
 		if len(checkRes.Errors) > 0 {
-			return ogame.Fleet{}, errors.New(checkRes.Errors[0].Message + " (" + strconv.Itoa(checkRes.Errors[0].Error) + ")")
+			return ogame.Fleet{}, ogame.NewServerError(checkRes.Errors[0].Message, int64(checkRes.Errors[0].Error))
 		}
-		return ogame.Fleet{}, errors.New("target is not ok")
+		return ogame.Fleet{}, ogame.NewError(ogame.ErrInvalidTarget, "target is not ok")
 	}
 
 	lfBonuses, err := b.getCachedLfBonuses()
@@ -3504,17 +3824,20 @@ func (b *OGame) sendFleet(celestialID ogame.CelestialID, ships ogame.ShipsInfos,
 		} `json:"errors"`
 	}
 	if err := json.Unmarshal(res, &resStruct); err != nil {
-		return ogame.Fleet{}, errors.New("failed to unmarshal response: " + err.Error())
+		return ogame.Fleet{}, b.parseError(FleetdispatchPageName, err)
 	}
 
 	if len(resStruct.Errors) > 0 {
-		return ogame.Fleet{}, errors.New(resStruct.Errors[0].Message + " (" + utils.FI64(resStruct.Errors[0].Error) + ")")
+		return ogame.Fleet{}, ogame.NewServerError(resStruct.Errors[0].Message, resStruct.Errors[0].Error)
 	}
 
 	// Page 5
 	page, _ := getPage[parser.MovementPage](b)
 	originCoords, _ := page.ExtractPlanetCoordinate()
-	fleets := page.ExtractFleets()
+	fleets, err := b.extractFleets(page.GetDoc())
+	if err != nil {
+		return ogame.Fleet{}, err
+	}
 	if maxV, err := getLastFleetFor(fleets, originCoords, where, mission); err == nil && maxV.ID > maxInitialFleetID {
 		return maxV, nil
 	}
@@ -3530,7 +3853,7 @@ func (b *OGame) sendFleet(celestialID ogame.CelestialID, ships ogame.ShipsInfos,
 		}
 	}
 
-	b.error(errors.New("could not find new fleet ID").Error()+", planetID:", celestialID)
+	b.error(GeneralLog, "could not find new fleet ID", "planetID", celestialID)
 	return ogame.Fleet{}, errors.New("could not find new fleet ID")
 }
 
@@ -3749,9 +4072,39 @@ func (b *OGame) getCombatReportFor(coord ogame.Coordinate) (ogame.CombatReportSu
 	return ogame.CombatReportSummary{}, errors.New("combat report not found for " + coord.String())
 }
 
-func (b *OGame) getEspionageReport(msgID int64) (ogame.EspionageReport, error) {
+func (b *OGame) getCombatReport(msgID int64) (ogame.CombatReport, error) {
 	pageHTML, _ := b.getPageContent(url.Values{"page": {"componentOnly"}, "component": {"messagedetails"}, "messageId": {utils.FI64(msgID)}})
-	return b.extractor.ExtractEspionageReport(pageHTML)
+	return extractWithFallback(b, MessagesPageName, "ExtractCombatReport", func(e extractor.Extractor) (ogame.CombatReport, error) {
+		return e.ExtractCombatReport(pageHTML)
+	})
+}
+
+func (b *OGame) getEspionageReport(msgID int64) (ogame.EspionageReport, error) {
+	pageHTML, err := b.getPageContent(url.Values{"page": {"componentOnly"}, "component": {"messagedetails"}, "messageId": {utils.FI64(msgID)}})
+	if err != nil {
+		return ogame.EspionageReport{}, err
+	}
+	report, err := extractWithFallback(b, MessagesPageName, "ExtractEspionageReport", func(e extractor.Extractor) (ogame.EspionageReport, error) {
+		return e.ExtractEspionageReport(pageHTML)
+	})
+	if err == nil {
+		b.saveIntel(func(store intel.Store) error { return store.SaveEspionageReport(report) })
+	}
+	return report, err
+}
+
+// Saves a result into the intel store, if one is set.
+// A failure to save is logged but does not fail the call that produced the result.
+func (b *OGame) saveIntel(clb func(intel.Store) error) {
+	b.intelStoreMu.RLock()
+	store := b.intelStore
+	b.intelStoreMu.RUnlock()
+	if store == nil {
+		return
+	}
+	if err := clb(store); err != nil {
+		b.error(GeneralLog, "failed to save intel", "error", err)
+	}
 }
 
 func (b *OGame) getEspionageReportFor(coord ogame.Coordinate) (ogame.EspionageReport, error) {
@@ -3884,60 +4237,33 @@ func (b *OGame) deleteAllMessagesFromTab(tabID ogame.MessagesTabID) error {
 	return err
 }
 
-func energyProduced(temp ogame.Temperature, resourcesBuildings ogame.ResourcesBuildings, resSettings ogame.ResourceSettings, energyTechnology int64) int64 {
-	energyProduced := int64(float64(ogame.SolarPlant.Production(resourcesBuildings.SolarPlant)) * (float64(resSettings.SolarPlant) / 100))
-	energyProduced += int64(float64(ogame.FusionReactor.Production(energyTechnology, resourcesBuildings.FusionReactor)) * (float64(resSettings.FusionReactor) / 100))
-	energyProduced += int64(float64(ogame.SolarSatellite.Production(temp, resourcesBuildings.SolarSatellite, false)) * (float64(resSettings.SolarSatellite) / 100))
-	return energyProduced
-}
-
-func energyNeeded(resourcesBuildings ogame.ResourcesBuildings, resSettings ogame.ResourceSettings) int64 {
-	energyNeeded := int64(float64(ogame.MetalMine.EnergyConsumption(resourcesBuildings.MetalMine)) * (float64(resSettings.MetalMine) / 100))
-	energyNeeded += int64(float64(ogame.CrystalMine.EnergyConsumption(resourcesBuildings.CrystalMine)) * (float64(resSettings.CrystalMine) / 100))
-	energyNeeded += int64(float64(ogame.DeuteriumSynthesizer.EnergyConsumption(resourcesBuildings.DeuteriumSynthesizer)) * (float64(resSettings.DeuteriumSynthesizer) / 100))
-	return energyNeeded
-}
-
-func productionRatio(temp ogame.Temperature, resourcesBuildings ogame.ResourcesBuildings, resSettings ogame.ResourceSettings, energyTechnology int64) float64 {
-	energyProduced := energyProduced(temp, resourcesBuildings, resSettings, energyTechnology)
-	energyNeeded := energyNeeded(resourcesBuildings, resSettings)
-	ratio := 1.0
-	if energyNeeded > energyProduced {
-		ratio = float64(energyProduced) / float64(energyNeeded)
+func (b *OGame) getResourcesProductions(planetID ogame.PlanetID) (ogame.Resources, error) {
+	planet, err := b.getPlanet(planetID)
+	if err != nil {
+		return ogame.Resources{}, err
 	}
-	return ratio
-}
-
-func getProductions(resBuildings ogame.ResourcesBuildings, resSettings ogame.ResourceSettings, researches ogame.Researches, universeSpeed int64,
-	temp ogame.Temperature, globalRatio float64) ogame.Resources {
-	energyProduced := energyProduced(temp, resBuildings, resSettings, researches.EnergyTechnology)
-	energyNeeded := energyNeeded(resBuildings, resSettings)
-	metalSetting := float64(resSettings.MetalMine) / 100
-	crystalSetting := float64(resSettings.CrystalMine) / 100
-	deutSetting := float64(resSettings.DeuteriumSynthesizer) / 100
-	return ogame.Resources{
-		Metal:     ogame.MetalMine.Production(universeSpeed, metalSetting, globalRatio, researches.PlasmaTechnology, resBuildings.MetalMine),
-		Crystal:   ogame.CrystalMine.Production(universeSpeed, crystalSetting, globalRatio, researches.PlasmaTechnology, resBuildings.CrystalMine),
-		Deuterium: ogame.DeuteriumSynthesizer.Production(universeSpeed, temp.Mean(), deutSetting, globalRatio, researches.PlasmaTechnology, resBuildings.DeuteriumSynthesizer) - ogame.FusionReactor.GetFuelConsumption(universeSpeed, float64(resSettings.FusionReactor)/100, resBuildings.FusionReactor),
-		Energy:    energyProduced - energyNeeded,
+	resBuildings, err := b.getResourcesBuildings(planetID.Celestial())
+	if err != nil {
+		return ogame.Resources{}, err
+	}
+	researches, err := b.getResearch()
+	if err != nil {
+		return ogame.Resources{}, err
 	}
-}
-
-func (b *OGame) getResourcesProductions(planetID ogame.PlanetID) (ogame.Resources, error) {
-	planet, _ := b.getPlanet(planetID)
-	resBuildings, _ := b.getResourcesBuildings(planetID.Celestial())
-	researches, _ := b.getResearch()
 	universeSpeed := b.serverData.Speed
-	resSettings, _ := b.getResourceSettings(planetID)
-	ratio := productionRatio(planet.Temperature, resBuildings, resSettings, researches.EnergyTechnology)
-	productions := getProductions(resBuildings, resSettings, researches, universeSpeed, planet.Temperature, ratio)
+	resSettings, err := b.getResourceSettings(planetID)
+	if err != nil {
+		return ogame.Resources{}, err
+	}
+	ratio := ogame.ProductionRatio(planet.Temperature, resBuildings, resSettings, researches.EnergyTechnology)
+	productions := ogame.Productions(resBuildings, resSettings, researches, universeSpeed, planet.Temperature, ratio)
 	return productions, nil
 }
 
 func getResourcesProductionsLight(resBuildings ogame.ResourcesBuildings, researches ogame.Researches,
 	resSettings ogame.ResourceSettings, temp ogame.Temperature, universeSpeed int64) ogame.Resources {
-	ratio := productionRatio(temp, resBuildings, resSettings, researches.EnergyTechnology)
-	productions := getProductions(resBuildings, resSettings, researches, universeSpeed, temp, ratio)
+	ratio := ogame.ProductionRatio(temp, resBuildings, resSettings, researches.EnergyTechnology)
+	productions := ogame.Productions(resBuildings, resSettings, researches, universeSpeed, temp, ratio)
 	return productions
 }
 
@@ -3975,6 +4301,8 @@ func (b *OGame) botLock(lockedBy string) {
 	b.Lock()
 	if b.lockedAtom.CompareAndSwap(false, true) {
 		b.state = lockedBy
+		b.taskAtom.Store(lockedBy)
+		b.metrics.locked()
 		b.stateChanged(true, lockedBy)
 	}
 }
@@ -3983,6 +4311,8 @@ func (b *OGame) botUnlock(unlockedBy string) {
 	b.Unlock()
 	if b.lockedAtom.CompareAndSwap(true, false) {
 		b.state = unlockedBy
+		b.taskAtom.Store("")
+		b.metrics.unlocked(unlockedBy)
 		b.stateChanged(false, unlockedBy)
 	}
 }
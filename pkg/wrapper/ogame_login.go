@@ -6,24 +6,15 @@ import (
 	"github.com/alaingilbert/clockwork"
 	"github.com/alaingilbert/ogame/pkg/exponentialBackoff"
 	"github.com/alaingilbert/ogame/pkg/extractor"
-	v10 "github.com/alaingilbert/ogame/pkg/extractor/v10"
-	v104 "github.com/alaingilbert/ogame/pkg/extractor/v104"
-	v11 "github.com/alaingilbert/ogame/pkg/extractor/v11"
-	"github.com/alaingilbert/ogame/pkg/extractor/v11_13_0"
-	"github.com/alaingilbert/ogame/pkg/extractor/v11_15_0"
-	"github.com/alaingilbert/ogame/pkg/extractor/v11_9_0"
 	"github.com/alaingilbert/ogame/pkg/extractor/v12_0_0"
-	v7 "github.com/alaingilbert/ogame/pkg/extractor/v7"
-	v71 "github.com/alaingilbert/ogame/pkg/extractor/v71"
-	v8 "github.com/alaingilbert/ogame/pkg/extractor/v8"
-	v874 "github.com/alaingilbert/ogame/pkg/extractor/v874"
-	v9 "github.com/alaingilbert/ogame/pkg/extractor/v9"
 	"github.com/alaingilbert/ogame/pkg/gameforge"
 	"github.com/alaingilbert/ogame/pkg/ogame"
 	"github.com/alaingilbert/ogame/pkg/parser"
 	"github.com/alaingilbert/ogame/pkg/utils"
 	"github.com/hashicorp/go-version"
 	cookiejar "github.com/orirawlings/persistent-cookiejar"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
 	"regexp"
 	"time"
@@ -31,7 +22,9 @@ import (
 
 func (b *OGame) wrapLoginWithBearerToken(token string) (useToken bool, err error) {
 	fn := func() (bool, error) {
+		_, endSpan := b.startSpan(nil, "ogame.login", trace.WithAttributes(attribute.String("ogame.login.method", "bearer_token")))
 		useToken, err = b.loginWithBearerToken(token)
+		endSpan(err)
 		return useToken, err
 	}
 	return useToken, b.loginWrapper(fn)
@@ -39,14 +32,21 @@ func (b *OGame) wrapLoginWithBearerToken(token string) (useToken bool, err error
 
 func (b *OGame) wrapLoginWithExistingCookies() (useCookies bool, err error) {
 	fn := func() (bool, error) {
+		_, endSpan := b.startSpan(nil, "ogame.login", trace.WithAttributes(attribute.String("ogame.login.method", "cookies")))
 		useCookies, err = b.loginWithExistingCookies()
+		endSpan(err)
 		return useCookies, err
 	}
 	return useCookies, b.loginWrapper(fn)
 }
 
 func (b *OGame) wrapLogin() error {
-	return b.loginWrapper(func() (bool, error) { return false, b.login() })
+	return b.loginWrapper(func() (bool, error) {
+		_, endSpan := b.startSpan(nil, "ogame.login", trace.WithAttributes(attribute.String("ogame.login.method", "credentials")))
+		err := b.login()
+		endSpan(err)
+		return false, err
+	})
 }
 
 // Return either or not the bot logged in using the provided bearer token.
@@ -86,7 +86,7 @@ func (b *OGame) loginWithBearerToken(token string) (bool, error) {
 				}
 				return false, err
 			}
-			b.debug("login using existing cookies")
+			b.debug(LoginLog, "login using existing cookies")
 			if err := b.loginPart3Tmp(userAccount, page, loginLink, pageHTML); err != nil {
 				return false, err
 			}
@@ -94,7 +94,7 @@ func (b *OGame) loginWithBearerToken(token string) (bool, error) {
 		}
 		return false, err
 	}
-	b.debug("login using existing cookies")
+	b.debug(LoginLog, "login using existing cookies")
 	if err := b.loginPart3(userAccount, page); err != nil {
 		return false, err
 	}
@@ -114,12 +114,15 @@ func (b *OGame) loginWithExistingCookies() (bool, error) {
 				break
 			}
 		}
+		if token == "" {
+			token = b.storedBearerToken()
+		}
 	}
 	return b.loginWithBearerToken(token)
 }
 
 func (b *OGame) login() error {
-	b.debug("post sessions")
+	b.debug(LoginLog, "post sessions")
 	postSessionsRes, err := postSessions(b)
 	if err != nil {
 		return err
@@ -150,7 +153,7 @@ func (b *OGame) login() error {
 }
 
 func (b *OGame) getAndExecLoginLink(userAccount gameforge.Account, token string) (string, []byte, error) {
-	b.debug("get login link")
+	b.debug(LoginLog, "get login link")
 	loginLink, err := gameforge.GetLoginLink(b.device, b.ctx, b.lobby, userAccount, token)
 	if err != nil {
 		return "", nil, err
@@ -166,7 +169,7 @@ func (b *OGame) loginPart3Tmp(userAccount gameforge.Account, page *parser.Overvi
 	if err := b.loginPart3(userAccount, page); err != nil {
 		return err
 	}
-	if err := b.device.GetClient().Jar.(*cookiejar.Jar).Save(); err != nil {
+	if err := b.saveSession(); err != nil {
 		return err
 	}
 	b.execInterceptorCallbacks(http.MethodGet, loginLink, nil, nil, pageHTML)
@@ -175,20 +178,22 @@ func (b *OGame) loginPart3Tmp(userAccount gameforge.Account, page *parser.Overvi
 
 // Get user's accounts, get GF ogame servers, then find and return the server and userAccount that we asked to play in.
 func (b *OGame) loginPart1(token string) (server gameforge.Server, userAccount gameforge.Account, err error) {
+	_, endSpan := b.startSpan(nil, "ogame.login.part1")
+	defer func() { endSpan(err) }()
 	client := b.device.GetClient()
 	ctx := b.ctx
 	lobby := b.lobby
-	b.debug("get user accounts")
+	b.debug(LoginLog, "get user accounts")
 	accounts, err := gameforge.GetUserAccounts(client, ctx, lobby, token)
 	if err != nil {
 		return
 	}
-	b.debug("get servers")
+	b.debug(LoginLog, "get servers")
 	servers, err := gameforge.GetServers(lobby, client, ctx)
 	if err != nil {
 		return
 	}
-	b.debug("find account & server for universe")
+	b.debug(LoginLog, "find account & server for universe")
 	userAccount, server, err = findAccount(b.Universe, b.language, b.playerID, accounts, servers)
 	if err != nil {
 		return
@@ -196,11 +201,16 @@ func (b *OGame) loginPart1(token string) (server gameforge.Server, userAccount g
 	if userAccount.Blocked {
 		return server, userAccount, ogame.ErrAccountBlocked
 	}
-	b.debug("Players online: " + utils.FI64(server.PlayersOnline) + ", Players: " + utils.FI64(server.PlayerCount))
+	if server.ServerClosed == 1 {
+		return server, userAccount, ogame.NewError(ogame.ErrServerMaintenance, "server closed in the lobby")
+	}
+	b.debug(LoginLog, "server found", "playersOnline", server.PlayersOnline, "players", server.PlayerCount)
 	return
 }
 
-func (b *OGame) loginPart2(server gameforge.Server) error {
+func (b *OGame) loginPart2(server gameforge.Server) (err error) {
+	_, endSpan := b.startSpan(nil, "ogame.login.part2")
+	defer func() { endSpan(err) }()
 	b.isLoggedInAtom.Store(true) // At this point, we are logged in
 	b.isConnectedAtom.Store(true)
 	// Get server data
@@ -225,12 +235,15 @@ func (b *OGame) loginPart2(server gameforge.Server) error {
 	}
 	b.language = lang
 	b.serverURL = "https://s" + utils.FI64(server.Number) + "-" + lang + ".ogame.gameforge.com"
-	b.debug("get server data", time.Since(start))
+	b.debug(LoginLog, "get server data", "duration", time.Since(start))
 	return nil
 }
 
-func (b *OGame) loginPart3(userAccount gameforge.Account, page *parser.OverviewPage) error {
+func (b *OGame) loginPart3(userAccount gameforge.Account, page *parser.OverviewPage) (err error) {
+	_, endSpan := b.startSpan(nil, "ogame.login.part3")
+	defer func() { endSpan(err) }()
 	var ext extractor.Extractor = v12_0_0.NewExtractor()
+	b.extractorFallbacks = nil
 	r := regexp.MustCompile(`(\d+\.\d+\.\d+)`)
 	versionMatches := r.FindStringSubmatch(b.serverData.Version)
 	versionMatch := b.serverData.Version
@@ -239,42 +252,22 @@ func (b *OGame) loginPart3(userAccount gameforge.Account, page *parser.OverviewP
 	}
 	if ogVersion, err := version.NewVersion(versionMatch); err == nil {
 		b.serverVersion = ogVersion
-		if b.IsVGreaterThanOrEqual("12.0.0") {
-			ext = v12_0_0.NewExtractor()
-		} else if b.IsVGreaterThanOrEqual("11.15.0") {
-			ext = v11_15_0.NewExtractor()
-		} else if b.IsVGreaterThanOrEqual("11.13.0") {
-			ext = v11_13_0.NewExtractor()
-		} else if b.IsVGreaterThanOrEqual("11.9.0") {
-			ext = v11_9_0.NewExtractor()
-		} else if b.IsVGreaterThanOrEqual("11.0.0-beta25") {
-			ext = v11.NewExtractor()
-		} else if ogVersion.GreaterThanOrEqual(version.Must(version.NewVersion("10.4.0-beta2"))) {
-			ext = v104.NewExtractor()
-		} else if ogVersion.GreaterThanOrEqual(version.Must(version.NewVersion("10.0.0"))) {
-			ext = v10.NewExtractor()
-		} else if b.IsVGreaterThanOrEqual("9.0.0") {
-			ext = v9.NewExtractor()
-		} else if b.IsVGreaterThanOrEqual("8.7.4-pl3") {
-			ext = v874.NewExtractor()
-		} else if b.IsVGreaterThanOrEqual("8.0.0") {
-			ext = v8.NewExtractor()
-		} else if b.IsVGreaterThanOrEqual("7.1.0-rc0") {
-			ext = v71.NewExtractor()
-		} else if b.IsVGreaterThanOrEqual("7.0.0") {
-			ext = v7.NewExtractor()
+		if chain := extractor.DefaultRegistry.Chain(ogVersion); len(chain) > 0 {
+			ext = chain[0].New()
+			b.extractorFallbacks = chain[1:]
+			b.debug(LoginLog, "extractor selected", "version", versionMatch, "extractor", chain[0].Name)
 		}
 		ext.SetLanguage(b.language)
 		ext.SetLifeformEnabled(page.ExtractLifeformEnabled())
 	} else {
-		b.error("failed to parse ogame version: " + err.Error())
+		b.error(LoginLog, "failed to parse ogame version", "error", err)
 	}
 
 	b.sessionChatCounter = 1
 
-	b.debug("logged in as " + userAccount.Name + " on " + b.Universe + "-" + b.language)
+	b.debug(LoginLog, "logged in", "player", userAccount.Name, "lang", b.language)
 
-	b.debug("extract information from html")
+	b.debug(LoginLog, "extract information from html")
 	b.ogameSession = page.ExtractOGameSession()
 	if b.ogameSession == "" {
 		return ogame.ErrBadCredentials
@@ -288,7 +281,7 @@ func (b *OGame) loginPart3(userAccount gameforge.Account, page *parser.OverviewP
 
 	preferencesPage, err := getPage[parser.PreferencesPage](b, SkipCacheFullPage)
 	if err != nil {
-		b.error(err)
+		b.error(LoginLog, "failed to get preferences", "error", err)
 	}
 	b.CachedPreferences = preferencesPage.ExtractPreferences()
 	language := b.serverData.Language
@@ -312,11 +305,16 @@ func (b *OGame) loginPart3(userAccount gameforge.Account, page *parser.OverviewP
 		go func(b *OGame) {
 			defer b.chatConnectedAtom.Store(false)
 			chatRetry := exponentialBackoff.New(context.Background(), clockwork.NewRealClock(), 60)
+			connected := false
 			chatRetry.LoopForever(func() bool {
 				select {
 				case <-b.closeChatCh:
 					return false
 				default:
+					if connected {
+						b.metrics.chatReconnect()
+					}
+					connected = true
 					b.connectChat(chatRetry, chatHost, chatPort)
 				}
 				return true
@@ -328,7 +326,7 @@ func (b *OGame) loginPart3(userAccount gameforge.Account, page *parser.OverviewP
 
 	// V11 Intro bypass
 	if err := b.introBypass(page); err != nil {
-		b.error("failed to bypass intro:", err)
+		b.error(LoginLog, "failed to bypass intro", "error", err)
 	}
 
 	return nil
@@ -1,12 +1,16 @@
 package wrapper
 
 import (
+	"context"
 	"crypto/tls"
 	"github.com/alaingilbert/ogame/pkg/device"
 	"github.com/alaingilbert/ogame/pkg/gameforge"
 	"github.com/alaingilbert/ogame/pkg/httpclient"
+	"github.com/alaingilbert/ogame/pkg/intel"
 	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/publicapi"
 	"github.com/alaingilbert/ogame/pkg/taskRunner"
+	"github.com/alaingilbert/ogame/pkg/utils"
 	"net/http"
 	"net/url"
 	"time"
@@ -96,7 +100,10 @@ func (b *OGame) AddAccount(number int, lang string) (*gameforge.AddAccountRes, e
 
 // WithPriority ...
 func (b *OGame) WithPriority(priority taskRunner.Priority) Prioritizable {
-	return b.taskRunnerInst.WithPriority(priority)
+	queuedAt := time.Now()
+	tx := b.taskRunnerInst.WithPriority(priority)
+	tx.priority, tx.queuedAt, tx.startedAt = priority, queuedAt, time.Now()
+	return tx
 }
 
 // Begin start a transaction. Once this function is called, "Done" must be called to release the lock.
@@ -109,6 +116,11 @@ func (b *OGame) BeginNamed(name string) Prioritizable {
 	return b.WithPriority(taskRunner.Normal).BeginNamed(name)
 }
 
+// SetContext ...
+func (b *OGame) SetContext(ctx context.Context) Prioritizable {
+	return nil
+}
+
 // SetInitiator ...
 func (b *OGame) SetInitiator(initiator string) Prioritizable {
 	return nil
@@ -132,6 +144,35 @@ func (b *OGame) GetServer() gameforge.Server {
 	return b.server
 }
 
+// GetPublicAPI returns a client for the public xml api of the server the bot is connected to.
+// The client is rebuilt when the bot connects to another server, its cache is not shared between servers.
+func (b *OGame) GetPublicAPI() *publicapi.Client {
+	b.publicAPIMu.Lock()
+	defer b.publicAPIMu.Unlock()
+	server := b.GetServer()
+	key := utils.FI64(server.Number) + "-" + server.Language
+	if b.publicAPI == nil || b.publicAPIServer != key {
+		b.publicAPI = publicapi.NewClient(b.device.GetClient(), server.Number, server.Language)
+		b.publicAPIServer = key
+	}
+	return b.publicAPI
+}
+
+// GetIntelStore returns the store in which the bot saves espionage reports, galaxy and highscore results (nil if none)
+func (b *OGame) GetIntelStore() intel.Store {
+	b.intelStoreMu.RLock()
+	defer b.intelStoreMu.RUnlock()
+	return b.intelStore
+}
+
+// SetIntelStore sets the store in which the bot saves espionage reports, galaxy and highscore results.
+// nil disables it.
+func (b *OGame) SetIntelStore(store intel.Store) {
+	b.intelStoreMu.Lock()
+	defer b.intelStoreMu.Unlock()
+	b.intelStore = store
+}
+
 // GetServerData get ogame server data information that the bot is connected to
 func (b *OGame) GetServerData() gameforge.ServerData {
 	return b.serverData
@@ -401,6 +442,67 @@ func (b *OGame) SendMessage(playerID int64, message string) error {
 	return b.WithPriority(taskRunner.Normal).SendMessage(playerID, message)
 }
 
+// SendAllianceBroadcast sends a circular message to every member of the alliance
+func (b *OGame) SendAllianceBroadcast(message string) error {
+	return b.WithPriority(taskRunner.Normal).SendAllianceBroadcast(message)
+}
+
+// GetAllianceOverview gets the alliance information and its members list
+func (b *OGame) GetAllianceOverview() (ogame.AllianceOverview, error) {
+	return b.WithPriority(taskRunner.Normal).GetAllianceOverview()
+}
+
+// GetAllianceApplications gets the pending applications to join the alliance
+func (b *OGame) GetAllianceApplications() ([]ogame.AllianceApplication, error) {
+	return b.WithPriority(taskRunner.Normal).GetAllianceApplications()
+}
+
+// AcceptAllianceApplication accepts an application to join the alliance
+func (b *OGame) AcceptAllianceApplication(applicationID int64) error {
+	return b.WithPriority(taskRunner.Normal).AcceptAllianceApplication(applicationID)
+}
+
+// DenyAllianceApplication denies an application to join the alliance
+func (b *OGame) DenyAllianceApplication(applicationID int64) error {
+	return b.WithPriority(taskRunner.Normal).DenyAllianceApplication(applicationID)
+}
+
+// GetBuddies gets the buddy list, with the online status of each buddy
+func (b *OGame) GetBuddies() ([]ogame.Buddy, error) {
+	return b.WithPriority(taskRunner.Normal).GetBuddies()
+}
+
+// GetBuddyRequests gets the pending buddy requests, received and sent
+func (b *OGame) GetBuddyRequests() ([]ogame.BuddyRequest, error) {
+	return b.WithPriority(taskRunner.Normal).GetBuddyRequests()
+}
+
+// SendBuddyRequest sends a buddy request to a player
+func (b *OGame) SendBuddyRequest(playerID int64, message string) error {
+	return b.WithPriority(taskRunner.Normal).SendBuddyRequest(playerID, message)
+}
+
+// AcceptBuddyRequest accepts a received buddy request
+func (b *OGame) AcceptBuddyRequest(requestID int64) error {
+	return b.WithPriority(taskRunner.Normal).AcceptBuddyRequest(requestID)
+}
+
+// RejectBuddyRequest rejects a received buddy request
+func (b *OGame) RejectBuddyRequest(requestID int64) error {
+	return b.WithPriority(taskRunner.Normal).RejectBuddyRequest(requestID)
+}
+
+// GetConversations gets the chat inbox, one entry per conversation
+func (b *OGame) GetConversations() ([]ogame.Conversation, error) {
+	return b.WithPriority(taskRunner.Normal).GetConversations()
+}
+
+// GetConversation gets the messages exchanged with a player, oldest first.
+// Each page loads older messages, up to maxPage pages (0 for all of them).
+func (b *OGame) GetConversation(playerID, maxPage int64) ([]ogame.ChatMsg, error) {
+	return b.WithPriority(taskRunner.Normal).GetConversation(playerID, maxPage)
+}
+
 // SendMessageAlliance sends a message to associationID
 func (b *OGame) SendMessageAlliance(associationID int64, message string) error {
 	return b.WithPriority(taskRunner.Normal).SendMessageAlliance(associationID, message)
@@ -498,6 +600,11 @@ func (b *OGame) GetFleetDispatch(celestialID ogame.CelestialID, options ...Optio
 	return b.WithPriority(taskRunner.Normal).GetFleetDispatch(celestialID, options...)
 }
 
+// GetUnions lists the unions that can be joined from the fleetdispatch page
+func (b *OGame) GetUnions(celestialID ogame.CelestialID, options ...Option) ([]ogame.ACSValues, error) {
+	return b.WithPriority(taskRunner.Normal).GetUnions(celestialID, options...)
+}
+
 // Build builds any ogame objects (building, technology, ship, defence)
 func (b *OGame) Build(celestialID ogame.CelestialID, id ogame.ID, nbr int64) error {
 	return b.WithPriority(taskRunner.Normal).Build(celestialID, id, nbr)
@@ -630,11 +737,23 @@ func (b *OGame) CollectMarketplaceMessage(msg ogame.MarketplaceMessage) error {
 	return b.WithPriority(taskRunner.Normal).CollectMarketplaceMessage(msg)
 }
 
+// GetCombatReportMessages gets the summary of each combat reports
+func (b *OGame) GetCombatReportMessages(maxPage int64) ([]ogame.CombatReportSummary, error) {
+	return b.WithPriority(taskRunner.Normal).GetCombatReportMessages(maxPage)
+}
+
 // GetEspionageReportMessages gets the summary of each espionage reports
 func (b *OGame) GetEspionageReportMessages(maxPage int64) ([]ogame.EspionageReportSummary, error) {
 	return b.WithPriority(taskRunner.Normal).GetEspionageReportMessages(maxPage)
 }
 
+// GetCombatReport gets a detailed combat report.
+// The participants and rounds of the v11.15 reports are not parsed yet, the summary (winner, loot, debris)
+// is returned with an ogame.ErrSelectorNotFound error.
+func (b *OGame) GetCombatReport(msgID int64) (ogame.CombatReport, error) {
+	return b.WithPriority(taskRunner.Normal).GetCombatReport(msgID)
+}
+
 // GetEspionageReport gets a detailed espionage report
 func (b *OGame) GetEspionageReport(msgID int64) (ogame.EspionageReport, error) {
 	return b.WithPriority(taskRunner.Normal).GetEspionageReport(msgID)
@@ -673,7 +792,7 @@ func (b *OGame) Distance(origin, destination ogame.Coordinate) int64 {
 
 // SystemDistance return the distance between two systems
 func (b *OGame) SystemDistance(system1, system2 int64) int64 {
-	return systemDistance(b.serverData.Systems, system1, system2, b.serverData.DonutSystem)
+	return ogame.SystemDistance(b.serverData.Systems, system1, system2, b.serverData.DonutSystem)
 }
 
 // RegisterWSCallback ...
@@ -789,6 +908,11 @@ func (b *OGame) Highscore(category, typ, page int64) (ogame.Highscore, error) {
 	return b.WithPriority(taskRunner.Normal).Highscore(category, typ, page)
 }
 
+// AllHighscores fetches every page of a highscore category/type
+func (b *OGame) AllHighscores(category, typ int64) (ogame.Highscore, error) {
+	return b.WithPriority(taskRunner.Normal).AllHighscores(category, typ)
+}
+
 // GetAllResources gets the resources of all planets and moons
 func (b *OGame) GetAllResources() (map[ogame.CelestialID]ogame.Resources, error) {
 	return b.WithPriority(taskRunner.Normal).GetAllResources()
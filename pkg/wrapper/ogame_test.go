@@ -70,34 +70,6 @@ func TestWrapper(t *testing.T) {
 //	assert.Equal(t, Resources{Metal: 109444, Crystal: 41697, Deuterium: 16347, Energy: -5169}, prod)
 //}
 
-func TestProductionRatio(t *testing.T) {
-	ratio := productionRatio(
-		ogame.Temperature{Min: -23, Max: 17},
-		ogame.ResourcesBuildings{MetalMine: 29, CrystalMine: 16, DeuteriumSynthesizer: 26, SolarPlant: 29, FusionReactor: 13, SolarSatellite: 51},
-		ogame.ResourceSettings{MetalMine: 100, CrystalMine: 100, DeuteriumSynthesizer: 100, SolarPlant: 100, FusionReactor: 100, SolarSatellite: 100},
-		12,
-	)
-	assert.Equal(t, 1.0, ratio)
-}
-
-func TestEnergyNeeded(t *testing.T) {
-	needed := energyNeeded(
-		ogame.ResourcesBuildings{MetalMine: 29, CrystalMine: 16, DeuteriumSynthesizer: 26},
-		ogame.ResourceSettings{MetalMine: 100, CrystalMine: 100, DeuteriumSynthesizer: 100},
-	)
-	assert.Equal(t, int64(4601+736+6198), needed)
-}
-
-func TestEnergyProduced(t *testing.T) {
-	produced := energyProduced(
-		ogame.Temperature{Min: -23, Max: 17},
-		ogame.ResourcesBuildings{SolarPlant: 29, FusionReactor: 13, SolarSatellite: 51},
-		ogame.ResourceSettings{SolarPlant: 100, FusionReactor: 100, SolarSatellite: 100},
-		12,
-	)
-	assert.Equal(t, int64(9200+3002+1326), produced)
-}
-
 func TestExtractCargoCapacity(t *testing.T) {
 	pageHTMLBytes, _ := os.ReadFile("../../samples/unversioned/sendfleet3.htm")
 	fleet3Doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(pageHTMLBytes))
@@ -117,45 +89,6 @@ func TestExtractFleetsFromEventList(t *testing.T) {
 	//assert.Equal(t, 4, len(fleets))
 }
 
-func TestGalaxyDistance(t *testing.T) {
-	assert.Equal(t, int64(60000), galaxyDistance(6, 3, 6, false))
-	assert.Equal(t, int64(20000), galaxyDistance(1, 2, 6, false))
-	assert.Equal(t, int64(40000), galaxyDistance(1, 3, 6, false))
-	assert.Equal(t, int64(60000), galaxyDistance(1, 4, 6, false))
-	assert.Equal(t, int64(80000), galaxyDistance(1, 5, 6, false))
-	assert.Equal(t, int64(100000), galaxyDistance(1, 6, 6, false))
-
-	assert.Equal(t, int64(20000), galaxyDistance(1, 2, 6, true))
-	assert.Equal(t, int64(40000), galaxyDistance(1, 3, 6, true))
-	assert.Equal(t, int64(60000), galaxyDistance(1, 4, 6, true))
-	assert.Equal(t, int64(40000), galaxyDistance(1, 5, 6, true))
-	assert.Equal(t, int64(20000), galaxyDistance(1, 6, 6, true))
-	assert.Equal(t, int64(20000), galaxyDistance(6, 1, 6, true))
-}
-
-func TestFlightSystemDistance(t *testing.T) {
-	assert.Equal(t, int64(3175), flightSystemDistance(499, 35, 30, 0, false))
-	assert.Equal(t, int64(2795), flightSystemDistance(499, 1, 2, 0, true))
-	assert.Equal(t, int64(2795), flightSystemDistance(499, 1, 499, 0, true))
-	assert.Equal(t, int64(2890), flightSystemDistance(499, 1, 3, 0, true))
-	assert.Equal(t, int64(2890), flightSystemDistance(499, 1, 498, 0, true))
-	assert.Equal(t, int64(2890), flightSystemDistance(499, 498, 1, 0, true))
-}
-
-func TestSystemDistance(t *testing.T) {
-	assert.Equal(t, int64(5), systemDistance(499, 35, 30, false))
-	assert.Equal(t, int64(498), systemDistance(499, 1, 499, false))
-	assert.Equal(t, int64(1), systemDistance(499, 1, 2, true))
-	assert.Equal(t, int64(1), systemDistance(499, 1, 499, true))
-	assert.Equal(t, int64(2), systemDistance(499, 1, 3, true))
-	assert.Equal(t, int64(2), systemDistance(499, 1, 498, true))
-	assert.Equal(t, int64(2), systemDistance(499, 498, 1, true))
-}
-
-func TestPlanetDistance(t *testing.T) {
-	assert.Equal(t, int64(1015), planetDistance(6, 3))
-}
-
 func TestDistance(t *testing.T) {
 	assert.Equal(t, int64(1015), Distance(ogame.Coordinate{Galaxy: 1, System: 1, Position: 3, Type: ogame.PlanetType}, ogame.Coordinate{Galaxy: 1, System: 1, Position: 6, Type: ogame.PlanetType}, 6, 499, 0, true, true))
 	assert.Equal(t, int64(2890), Distance(ogame.Coordinate{Galaxy: 1, System: 1, Position: 3, Type: ogame.PlanetType}, ogame.Coordinate{Galaxy: 1, System: 498, Position: 6, Type: ogame.PlanetType}, 6, 499, 0, true, true))
@@ -258,10 +191,6 @@ func TestVersion(t *testing.T) {
 	assert.True(t, version.Must(version.NewVersion("8.7.5-pl3")).GreaterThanOrEqual(version.Must(version.NewVersion("8.7.5-pl3"))))
 }
 
-func TestFindSlowestSpeed(t *testing.T) {
-	assert.Equal(t, int64(8000), findSlowestSpeed(ogame.ShipsInfos{SmallCargo: 1, LargeCargo: 1}, ogame.Researches{CombustionDrive: 6}, ogame.LfBonuses{}, ogame.NoClass, ogame.NoAllianceClass))
-}
-
 func TestOGame_GetCachedCelestial(t *testing.T) {
 	bot, _ := NewNoLogin("", "", "", "", "", "", 0, nil)
 	bot.planets = []Planet{{Planet: ogame.Planet{ID: ogame.PlanetID(123)}, Moon: &Moon{Moon: ogame.Moon{ID: 456}}}}
@@ -289,3 +218,10 @@ func TestOGame_GetCachedCelestials(t *testing.T) {
 	_, ok := celestials[1].(Moon)
 	assert.True(t, ok)
 }
+
+func TestHasChatMessages(t *testing.T) {
+	pageHTMLBytes, _ := os.ReadFile("../../samples/v11.6.2/overview_cancels.html")
+	assert.True(t, hasChatMessages(pageHTMLBytes))
+	assert.False(t, hasChatMessages([]byte(``)))
+	assert.False(t, hasChatMessages([]byte(`<ul class="chat"></ul>`)))
+}
@@ -1,7 +1,10 @@
 package wrapper
 
 import (
+	"context"
+	"github.com/alaingilbert/ogame/pkg/taskRunner"
 	"github.com/alaingilbert/ogame/pkg/utils"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
 	"net/url"
 	"sync/atomic"
@@ -17,6 +20,12 @@ type Prioritize struct {
 	name         string
 	taskIsDoneCh chan struct{}
 	isTx         int32
+	ctx          context.Context // parent of the span of the task
+	priority     taskRunner.Priority
+	queuedAt     time.Time // when the task was queued in the task runner
+	startedAt    time.Time // when the task runner let the task execute
+	span         trace.Span
+	endSpan      func(error)
 }
 
 // SetTaskDoneCh ...
@@ -30,6 +39,12 @@ func (b *Prioritize) SetInitiator(initiator string) Prioritizable {
 	return b
 }
 
+// SetContext sets the context whose span is the parent of the spans of the task
+func (b *Prioritize) SetContext(ctx context.Context) Prioritizable {
+	b.ctx = ctx
+	return b
+}
+
 // Begin a new transaction. "Done" must be called to release the lock.
 func (b *Prioritize) Begin() Prioritizable {
 	return b.BeginNamed("Tx")
@@ -50,6 +65,7 @@ func (b *Prioritize) begin(name string) *Prioritize {
 	if atomic.AddInt32(&b.isTx, 1) == 1 {
 		b.name = utils.Ternary(b.initiator == "", name, b.initiator+":"+name)
 		b.bot.botLock(b.name)
+		b.startSpan(name)
 	}
 	return b
 }
@@ -57,6 +73,7 @@ func (b *Prioritize) begin(name string) *Prioritize {
 func (b *Prioritize) done() {
 	if atomic.AddInt32(&b.isTx, -1) == 0 {
 		defer close(b.taskIsDoneCh)
+		b.endSpan(nil)
 		b.bot.botUnlock(b.name)
 	}
 }
@@ -65,14 +82,14 @@ func (b *Prioritize) done() {
 func (b *Prioritize) Tx(clb func(Prioritizable) error) error {
 	tx := b.Begin()
 	defer tx.Done()
-	return clb(tx)
+	return b.spanError(clb(tx))
 }
 
 // TxNamed locks the bot during the transaction and ensure the lock is released afterward
 func (b *Prioritize) TxNamed(name string, clb func(Prioritizable) error) error {
 	tx := b.BeginNamed(name)
 	defer tx.Done()
-	return clb(tx)
+	return b.spanError(clb(tx))
 }
 
 // LoginWithBearerToken to ogame server reusing existing token
@@ -230,6 +247,91 @@ func (b *Prioritize) SendMessage(playerID int64, message string) error {
 	return b.bot.sendMessage(playerID, message, true)
 }
 
+// SendAllianceBroadcast sends a circular message to every member of the alliance
+func (b *Prioritize) SendAllianceBroadcast(message string) error {
+	b.begin("SendAllianceBroadcast")
+	defer b.done()
+	return b.bot.sendAllianceBroadcast(message)
+}
+
+// GetAllianceOverview gets the alliance information and its members list
+func (b *Prioritize) GetAllianceOverview() (ogame.AllianceOverview, error) {
+	b.begin("GetAllianceOverview")
+	defer b.done()
+	return b.bot.getAllianceOverview()
+}
+
+// GetAllianceApplications gets the pending applications to join the alliance
+func (b *Prioritize) GetAllianceApplications() ([]ogame.AllianceApplication, error) {
+	b.begin("GetAllianceApplications")
+	defer b.done()
+	return b.bot.getAllianceApplications()
+}
+
+// AcceptAllianceApplication accepts an application to join the alliance
+func (b *Prioritize) AcceptAllianceApplication(applicationID int64) error {
+	b.begin("AcceptAllianceApplication")
+	defer b.done()
+	return b.bot.acceptAllianceApplication(applicationID)
+}
+
+// DenyAllianceApplication denies an application to join the alliance
+func (b *Prioritize) DenyAllianceApplication(applicationID int64) error {
+	b.begin("DenyAllianceApplication")
+	defer b.done()
+	return b.bot.denyAllianceApplication(applicationID)
+}
+
+// GetBuddies gets the buddy list, with the online status of each buddy
+func (b *Prioritize) GetBuddies() ([]ogame.Buddy, error) {
+	b.begin("GetBuddies")
+	defer b.done()
+	return b.bot.getBuddies()
+}
+
+// GetBuddyRequests gets the pending buddy requests, received and sent
+func (b *Prioritize) GetBuddyRequests() ([]ogame.BuddyRequest, error) {
+	b.begin("GetBuddyRequests")
+	defer b.done()
+	return b.bot.getBuddyRequests()
+}
+
+// SendBuddyRequest sends a buddy request to a player
+func (b *Prioritize) SendBuddyRequest(playerID int64, message string) error {
+	b.begin("SendBuddyRequest")
+	defer b.done()
+	return b.bot.sendBuddyRequest(playerID, message)
+}
+
+// AcceptBuddyRequest accepts a received buddy request
+func (b *Prioritize) AcceptBuddyRequest(requestID int64) error {
+	b.begin("AcceptBuddyRequest")
+	defer b.done()
+	return b.bot.acceptBuddyRequest(requestID)
+}
+
+// RejectBuddyRequest rejects a received buddy request
+func (b *Prioritize) RejectBuddyRequest(requestID int64) error {
+	b.begin("RejectBuddyRequest")
+	defer b.done()
+	return b.bot.rejectBuddyRequest(requestID)
+}
+
+// GetConversations gets the chat inbox, one entry per conversation
+func (b *Prioritize) GetConversations() ([]ogame.Conversation, error) {
+	b.begin("GetConversations")
+	defer b.done()
+	return b.bot.getConversations()
+}
+
+// GetConversation gets the messages exchanged with a player, oldest first.
+// Each page loads older messages, up to maxPage pages (0 for all of them).
+func (b *Prioritize) GetConversation(playerID, maxPage int64) ([]ogame.ChatMsg, error) {
+	b.begin("GetConversation")
+	defer b.done()
+	return b.bot.getConversation(playerID, maxPage)
+}
+
 // SendMessageAlliance sends a message to associationID
 func (b *Prioritize) SendMessageAlliance(associationID int64, message string) error {
 	b.begin("SendMessageAlliance")
@@ -379,6 +481,13 @@ func (b *Prioritize) GetFleetDispatch(celestialID ogame.CelestialID, options ...
 	return b.bot.getFleetDispatch(celestialID, options...)
 }
 
+// GetUnions lists the unions that can be joined from the fleetdispatch page
+func (b *Prioritize) GetUnions(celestialID ogame.CelestialID, options ...Option) ([]ogame.ACSValues, error) {
+	b.begin("GetUnions")
+	defer b.done()
+	return b.bot.getUnions(celestialID, options...)
+}
+
 // Build builds any ogame objects (building, technology, ship, defence)
 func (b *Prioritize) Build(celestialID ogame.CelestialID, id ogame.ID, nbr int64) error {
 	b.begin("Build")
@@ -549,6 +658,13 @@ func (b *Prioritize) GetEspionageReportFor(coord ogame.Coordinate) (ogame.Espion
 	return b.bot.getEspionageReportFor(coord)
 }
 
+// GetCombatReportMessages gets the summary of each combat reports
+func (b *Prioritize) GetCombatReportMessages(maxPage int64) ([]ogame.CombatReportSummary, error) {
+	b.begin("GetCombatReportMessages")
+	defer b.done()
+	return b.bot.getCombatReportMessages(maxPage)
+}
+
 // GetEspionageReportMessages gets the summary of each espionage reports
 func (b *Prioritize) GetEspionageReportMessages(maxPage int64) ([]ogame.EspionageReportSummary, error) {
 	b.begin("GetEspionageReportMessages")
@@ -585,6 +701,13 @@ func (b *Prioritize) GetExpeditionMessageAt(t time.Time) (ogame.ExpeditionMessag
 	return b.bot.getExpeditionMessageAt(t)
 }
 
+// GetCombatReport gets a detailed combat report
+func (b *Prioritize) GetCombatReport(msgID int64) (ogame.CombatReport, error) {
+	b.begin("GetCombatReport")
+	defer b.done()
+	return b.bot.getCombatReport(msgID)
+}
+
 // GetEspionageReport gets a detailed espionage report
 func (b *Prioritize) GetEspionageReport(msgID int64) (ogame.EspionageReport, error) {
 	b.begin("GetEspionageReport")
@@ -644,9 +767,19 @@ func (b *Prioritize) FlightTime(origin, destination ogame.Coordinate, speed ogam
 			systemsSkip += res.InactiveSystems
 		}
 	}
-	return CalcFlightTime(origin, destination, b.bot.serverData.Galaxies, b.bot.serverData.Systems,
-		b.bot.serverData.DonutGalaxy, b.bot.serverData.DonutSystem, b.bot.serverData.GlobalDeuteriumSaveFactor,
-		float64(speed)/10, GetFleetSpeedForMission(b.bot.serverData, missionID), ships, researches, lfbonuses, b.bot.characterClass, allianceClass, systemsSkip)
+	return ogame.CalcFlight(ogame.FlightParams{
+		Origin:         origin,
+		Destination:    destination,
+		Ships:          ships,
+		Speed:          speed,
+		Mission:        missionID,
+		Researches:     researches,
+		LfBonuses:      lfbonuses,
+		CharacterClass: b.bot.characterClass,
+		AllianceClass:  allianceClass,
+		Universe:       getFlightUniverse(b.bot.serverData),
+		SystemsSkip:    systemsSkip,
+	})
 }
 
 // Phalanx scan a coordinate from a moon to get fleets information
@@ -737,6 +870,13 @@ func (b *Prioritize) Highscore(category, typ, page int64) (ogame.Highscore, erro
 	return b.bot.highscore(category, typ, page)
 }
 
+// AllHighscores fetches every page of a highscore category/type
+func (b *Prioritize) AllHighscores(category, typ int64) (ogame.Highscore, error) {
+	b.begin("AllHighscores")
+	defer b.done()
+	return b.bot.allHighscores(category, typ)
+}
+
 // GetAllResources ...
 func (b *Prioritize) GetAllResources() (map[ogame.CelestialID]ogame.Resources, error) {
 	b.begin("GetAllResources")
@@ -0,0 +1,376 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/storage"
+	"github.com/alaingilbert/ogame/pkg/taskRunner"
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+// RecallCondition condition that triggers the recall of a fleet
+type RecallCondition int64
+
+// Recall conditions
+const (
+	RecallAtTime           RecallCondition = iota + 1 // Recall at an absolute time
+	RecallOnTargetActivity                            // Recall when the target shows activity (galaxy or phalanx)
+	RecallOnAttack                                    // Recall when an attack on the fleet origin is detected
+)
+
+// RecallRule a persistent recall rule for a fleet
+type RecallRule struct {
+	FleetID     ogame.FleetID
+	Condition   RecallCondition
+	Origin      ogame.Coordinate
+	Destination ogame.Coordinate
+	At          time.Time       // RecallAtTime: time at which the fleet is recalled
+	MaxActivity int64           // RecallOnTargetActivity: galaxy activity (in minutes) considered as active (default: 15)
+	PhalanxFrom ogame.MoonID    // RecallOnTargetActivity: if set, use this moon to phalanx the target instead of the galaxy
+	KnownFleets []ogame.FleetID // RecallOnTargetActivity: fleets seen by the phalanx on the first check
+	ActivityAt  time.Time       // RecallOnTargetActivity: latest galaxy activity of the target already known (first check, own fleets)
+	CreatedAt   time.Time
+}
+
+// RecallPersistor save/load the recall rules
+type RecallPersistor interface {
+	Load() ([]RecallRule, error)
+	Save([]RecallRule) error
+}
+
+// StoreRecallPersistor save/load the recall rules as json in a storage.Store
+type StoreRecallPersistor struct {
+	Store storage.Store
+	Key   string
+}
+
+// NewStoreRecallPersistor creates a persistor that uses the storage of the bot (Params.Storage),
+// key storage/<universe>_<username>/recall_rules.json
+func NewStoreRecallPersistor(b Wrapper) *StoreRecallPersistor {
+	key := storage.AccountKey(b.GetUniverseName(), b.GetUsername(), "recall_rules.json")
+	return &StoreRecallPersistor{Store: b.GetDevice().GetStorage(), Key: key}
+}
+
+// Load ...
+func (p *StoreRecallPersistor) Load() ([]RecallRule, error) {
+	rules := []RecallRule{}
+	if err := loadJSON(p.Store, p.Key, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Save ...
+func (p *StoreRecallPersistor) Save(rules []RecallRule) error {
+	return saveJSON(p.Store, p.Key, rules)
+}
+
+// RecallEngine recalls fleets according to persistent rules.
+// Rules are saved on every change and restored by Start, so pending recalls survive a restart.
+type RecallEngine struct {
+	b         Wrapper
+	persistor RecallPersistor
+	interval  time.Duration
+	rulesMu   sync.Mutex
+	rules     map[ogame.FleetID]RecallRule
+	onRecall  []func(RecallRule, error)
+	loop      backgroundLoop
+}
+
+// NewRecallEngine creates a new recall engine. persistor defaults to NewStoreRecallPersistor, interval to 30s.
+func NewRecallEngine(b Wrapper, persistor RecallPersistor, interval time.Duration) *RecallEngine {
+	if persistor == nil {
+		persistor = NewStoreRecallPersistor(b)
+	}
+	return &RecallEngine{
+		b:         b,
+		persistor: persistor,
+		interval:  utils.Ternary(interval <= 0, 30*time.Second, interval),
+		rules:     make(map[ogame.FleetID]RecallRule),
+	}
+}
+
+// OnRecall register a callback called each time a rule is triggered
+func (e *RecallEngine) OnRecall(clb func(RecallRule, error)) {
+	e.rulesMu.Lock()
+	defer e.rulesMu.Unlock()
+	e.onRecall = append(e.onRecall, clb)
+}
+
+// Load restores the persisted rules
+func (e *RecallEngine) Load() error {
+	rules, err := e.persistor.Load()
+	if err != nil {
+		return err
+	}
+	e.rulesMu.Lock()
+	defer e.rulesMu.Unlock()
+	for _, rule := range rules {
+		e.rules[rule.FleetID] = rule
+	}
+	return nil
+}
+
+// Rules returns the pending rules
+func (e *RecallEngine) Rules() []RecallRule {
+	e.rulesMu.Lock()
+	defer e.rulesMu.Unlock()
+	out := make([]RecallRule, 0, len(e.rules))
+	for _, rule := range e.rules {
+		out = append(out, rule)
+	}
+	// Timed rules first, they do not need any request to be evaluated
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Condition != out[j].Condition {
+			return out[i].Condition < out[j].Condition
+		}
+		if !out[i].At.Equal(out[j].At) {
+			return out[i].At.Before(out[j].At)
+		}
+		return out[i].FleetID < out[j].FleetID
+	})
+	return out
+}
+
+// AddRule adds (or replaces) the rule for a fleet
+func (e *RecallEngine) AddRule(rule RecallRule) error {
+	if rule.FleetID == 0 {
+		return errors.New("invalid fleet id")
+	}
+	if rule.CreatedAt.IsZero() {
+		rule.CreatedAt = time.Now()
+	}
+	e.rulesMu.Lock()
+	defer e.rulesMu.Unlock()
+	e.rules[rule.FleetID] = rule
+	return e.saveLocked()
+}
+
+// RecallAt recalls the fleet at the given time
+func (e *RecallEngine) RecallAt(fleet ogame.Fleet, at time.Time) error {
+	return e.AddRule(RecallRule{FleetID: fleet.ID, Condition: RecallAtTime, Origin: fleet.Origin, Destination: fleet.Destination, At: at})
+}
+
+// RecallOnActivity recalls the fleet if the target becomes active.
+// If moonID is not zero, the target is phalanxed from this moon, otherwise the galaxy activity is used.
+func (e *RecallEngine) RecallOnActivity(fleet ogame.Fleet, moonID ogame.MoonID) error {
+	return e.AddRule(RecallRule{FleetID: fleet.ID, Condition: RecallOnTargetActivity, Origin: fleet.Origin, Destination: fleet.Destination, PhalanxFrom: moonID})
+}
+
+// RecallOnAttack recalls the fleet if an attack on its origin is detected
+func (e *RecallEngine) RecallOnAttack(fleet ogame.Fleet) error {
+	return e.AddRule(RecallRule{FleetID: fleet.ID, Condition: RecallOnAttack, Origin: fleet.Origin, Destination: fleet.Destination})
+}
+
+// RemoveRule removes the rule of a fleet
+func (e *RecallEngine) RemoveRule(fleetID ogame.FleetID) error {
+	e.rulesMu.Lock()
+	defer e.rulesMu.Unlock()
+	delete(e.rules, fleetID)
+	return e.saveLocked()
+}
+
+func (e *RecallEngine) saveLocked() error {
+	rules := make([]RecallRule, 0, len(e.rules))
+	for _, rule := range e.rules {
+		rules = append(rules, rule)
+	}
+	return e.persistor.Save(rules)
+}
+
+// Check evaluates every rules once, recalls the fleets that must be recalled,
+// and forgets the rules of fleets that are no longer flying toward their destination.
+// Each rule is evaluated independently, the errors of the rules that could not be evaluated are returned together.
+func (e *RecallEngine) Check() error {
+	rules := e.Rules()
+	if len(rules) == 0 {
+		return nil
+	}
+	var errs []error
+	fleets, slots := e.b.GetFleets()
+	// If the fleets could not be fetched, do not forget rules of fleets that are still flying,
+	// but still recall the fleets that are due, the recall fails if the fleet is already back.
+	fleetsKnown := slots.Total > 0
+	if !fleetsKnown {
+		errs = append(errs, errors.New("unable to get fleets"))
+	}
+	flying := make(map[ogame.FleetID]bool)
+	for _, fleet := range fleets {
+		if !fleet.ReturnFlight {
+			flying[fleet.ID] = true
+		}
+	}
+
+	var attacks []ogame.AttackEvent
+	var attacksErr error
+	attacksFetched := false
+	now := time.Now()
+	for _, rule := range rules {
+		if fleetsKnown && !flying[rule.FleetID] {
+			if err := e.RemoveRule(rule.FleetID); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		trigger := false
+		switch rule.Condition {
+		case RecallAtTime:
+			trigger = !now.Before(rule.At)
+		case RecallOnAttack:
+			if !fleetsKnown {
+				continue
+			}
+			if !attacksFetched {
+				attacks, attacksErr = e.b.WithPriority(taskRunner.Important).GetAttacks()
+				attacksFetched = true
+				if attacksErr != nil {
+					errs = append(errs, attacksErr)
+				}
+			}
+			if attacksErr != nil {
+				continue
+			}
+			trigger = isUnderAttack(attacks, rule.Origin)
+		case RecallOnTargetActivity:
+			if !fleetsKnown {
+				continue
+			}
+			var err error
+			if trigger, err = e.isTargetActive(&rule, fleets); err != nil {
+				errs = append(errs, fmt.Errorf("fleet %d: %w", rule.FleetID, err))
+				continue
+			}
+		}
+		if !trigger {
+			continue
+		}
+		err := e.b.WithPriority(taskRunner.Important).CancelFleet(rule.FleetID)
+		if removeErr := e.RemoveRule(rule.FleetID); removeErr != nil {
+			errs = append(errs, removeErr)
+		}
+		e.rulesMu.Lock()
+		callbacks := e.onRecall
+		e.rulesMu.Unlock()
+		for _, clb := range callbacks {
+			clb(rule, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Returns either or not the target of the rule is active.
+// The baseline is stored in the rule: the fleets already moving when the rule was first checked for the phalanx,
+// the activity already shown when the rule was first checked, or caused by our own fleets, for the galaxy.
+func (e *RecallEngine) isTargetActive(rule *RecallRule, fleets []ogame.Fleet) (bool, error) {
+	maxActivity := utils.Ternary(rule.MaxActivity <= 0, int64(15), rule.MaxActivity)
+	if rule.PhalanxFrom != 0 {
+		phalanxFleets, err := e.b.WithPriority(taskRunner.Important).Phalanx(rule.PhalanxFrom, rule.Destination.Planet())
+		if err != nil {
+			return false, err
+		}
+		ids := make([]ogame.FleetID, 0, len(phalanxFleets))
+		for _, fleet := range phalanxFleets {
+			ids = append(ids, fleet.ID)
+		}
+		if rule.KnownFleets == nil {
+			rule.KnownFleets = ids
+			return false, e.AddRule(*rule)
+		}
+		return hasNewFleet(rule.KnownFleets, ids), nil
+	}
+	systemInfos, err := e.b.WithPriority(taskRunner.Important).GalaxyInfos(rule.Destination.Galaxy, rule.Destination.System)
+	if err != nil {
+		return false, err
+	}
+	planetInfo := systemInfos.Position(rule.Destination.Position)
+	if planetInfo == nil {
+		return false, nil
+	}
+	activity := planetInfo.Activity
+	if rule.Destination.IsMoon() && planetInfo.Moon != nil {
+		activity = planetInfo.Moon.Activity
+	}
+	now := time.Now()
+	earliest, latest := galaxyActivityRange(now, activity)
+	baseline := rule.ActivityAt
+	if baseline.IsZero() {
+		baseline = latest
+	}
+	// Our own fleets hitting the target (probes, attacks) show up as activity
+	for _, fleet := range fleets {
+		if fleet.ReturnFlight && fleet.Destination.Equal(rule.Destination) && fleet.ArrivalTime.Before(now) && fleet.ArrivalTime.After(baseline) {
+			baseline = fleet.ArrivalTime
+		}
+	}
+	if !baseline.Equal(rule.ActivityAt) {
+		isFirstCheck := rule.ActivityAt.IsZero()
+		rule.ActivityAt = baseline
+		if err := e.AddRule(*rule); err != nil || isFirstCheck {
+			return false, err
+		}
+	}
+	// The galaxy shows the activity with a minute resolution
+	return activity > 0 && activity <= maxActivity && earliest.After(baseline.Add(time.Minute)), nil
+}
+
+// Returns the time range in which the activity shown in the galaxy happened.
+// activity is in minutes, 15 is displayed as "*" for any activity in the last 15 minutes,
+// 0 is no activity in the last hour.
+func galaxyActivityRange(now time.Time, activity int64) (earliest, latest time.Time) {
+	switch {
+	case activity <= 0:
+		return time.Time{}, now.Add(-galaxyActivityWindow)
+	case activity <= 15:
+		return now.Add(-15 * time.Minute), now
+	}
+	at := now.Add(-time.Duration(activity) * time.Minute)
+	return at, at.Add(time.Minute)
+}
+
+// Start loads the persisted rules and checks them periodically until Stop is called
+func (e *RecallEngine) Start() error {
+	return e.loop.start(e.Load, func() time.Duration {
+		_ = e.Check()
+		return e.interval
+	})
+}
+
+// Stop stops the engine and waits for the current check to complete. Pending rules stay on disk.
+func (e *RecallEngine) Stop() {
+	e.loop.stop()
+}
+
+// Returns either or not one of the attacks targets the given coordinate
+func isUnderAttack(attacks []ogame.AttackEvent, coord ogame.Coordinate) bool {
+	for _, attack := range attacks {
+		if attack.MissionType == ogame.Spy {
+			continue
+		}
+		if attack.Destination.Equal(coord) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns either or not current contains a fleet that is not in known
+func hasNewFleet(known, current []ogame.FleetID) bool {
+	for _, id := range current {
+		found := false
+		for _, k := range known {
+			if k == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package wrapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/ogametest"
+	"github.com/alaingilbert/ogame/pkg/storage"
+	"github.com/alaingilbert/ogame/pkg/taskRunner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreRecallPersistor(t *testing.T) {
+	p := &StoreRecallPersistor{Store: storage.NewFileStore(t.TempDir()), Key: storage.AccountKey("Bellatrix", "bob", "recall_rules.json")}
+	rules, err := p.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(rules))
+	at := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	rule := RecallRule{FleetID: 123, Condition: RecallAtTime, Destination: ogame.Coordinate{Galaxy: 1, System: 2, Position: 3, Type: ogame.PlanetType}, At: at}
+	assert.NoError(t, p.Save([]RecallRule{rule}))
+	rules, err = p.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, ogame.FleetID(123), rules[0].FleetID)
+	assert.True(t, rules[0].At.Equal(at))
+	assert.Equal(t, rule.Destination, rules[0].Destination)
+}
+
+func TestIsUnderAttack(t *testing.T) {
+	coord := ogame.Coordinate{Galaxy: 1, System: 2, Position: 3, Type: ogame.PlanetType}
+	attacks := []ogame.AttackEvent{{MissionType: ogame.Spy, Destination: coord}}
+	assert.False(t, isUnderAttack(attacks, coord))
+	attacks = append(attacks, ogame.AttackEvent{MissionType: ogame.Attack, Destination: coord.Moon()})
+	assert.False(t, isUnderAttack(attacks, coord))
+	attacks = append(attacks, ogame.AttackEvent{MissionType: ogame.Attack, Destination: coord})
+	assert.True(t, isUnderAttack(attacks, coord))
+}
+
+func TestHasNewFleet(t *testing.T) {
+	assert.False(t, hasNewFleet([]ogame.FleetID{1, 2}, []ogame.FleetID{2}))
+	assert.True(t, hasNewFleet([]ogame.FleetID{1, 2}, []ogame.FleetID{2, 3}))
+	assert.False(t, hasNewFleet([]ogame.FleetID{}, []ogame.FleetID{}))
+}
+
+type memoryRecallPersistor struct{ rules []RecallRule }
+
+func (p *memoryRecallPersistor) Load() ([]RecallRule, error) { return p.rules, nil }
+func (p *memoryRecallPersistor) Save(rules []RecallRule) error {
+	p.rules = rules
+	return nil
+}
+
+func TestRecallEngine_CheckEvaluatesEveryRule(t *testing.T) {
+	now := time.Now()
+	srv := ogametest.NewServer(ogametest.Config{Now: func() time.Time { return now }})
+	defer srv.Close()
+	bot := newFakeServerBot(t, srv)
+	defer bot.Logout()
+	bot.SetRetryPolicy(NoRetryPolicy)
+
+	homeworldID := ogame.CelestialID(33628462)
+	colony := ogame.Coordinate{Galaxy: 1, System: 105, Position: 11, Type: ogame.PlanetType}
+	fleet1, err := bot.SendFleet(homeworldID, ogame.ShipsInfos{SmallCargo: 1}, ogame.HundredPercent, colony, ogame.Transport, ogame.Resources{}, 0, 0)
+	assert.NoError(t, err)
+	fleet2, err := bot.SendFleet(homeworldID, ogame.ShipsInfos{SmallCargo: 1}, ogame.HundredPercent, colony, ogame.Transport, ogame.Resources{}, 0, 0)
+	assert.NoError(t, err)
+
+	engine := NewRecallEngine(bot, &memoryRecallPersistor{}, time.Minute)
+	var recalled []ogame.FleetID
+	engine.OnRecall(func(rule RecallRule, err error) {
+		assert.NoError(t, err)
+		recalled = append(recalled, rule.FleetID)
+	})
+	// The event list is not served by the fake server, the attack rule cannot be evaluated
+	assert.NoError(t, engine.RecallOnAttack(fleet1))
+	assert.NoError(t, engine.RecallAt(fleet2, now.Add(-time.Second)))
+	assert.Error(t, engine.Check())
+	assert.Equal(t, []ogame.FleetID{fleet2.ID}, recalled)
+	rules := engine.Rules()
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, fleet1.ID, rules[0].FleetID)
+}
+
+// Wrapper showing a single planet in the galaxy, with the given activity
+type galaxyActivityWrapper struct {
+	Wrapper
+	coord    ogame.Coordinate
+	activity int64
+}
+
+func (w *galaxyActivityWrapper) WithPriority(taskRunner.Priority) Prioritizable { return w }
+func (w *galaxyActivityWrapper) GalaxyInfos(galaxy, system int64, _ ...Option) (ogame.SystemInfos, error) {
+	var systemInfos ogame.SystemInfos
+	systemInfos.SetPlanet(int(w.coord.Position-1), &ogame.PlanetInfos{Coordinate: w.coord, Activity: w.activity})
+	return systemInfos, nil
+}
+
+func TestRecallEngine_IsTargetActive_Galaxy(t *testing.T) {
+	coord := ogame.Coordinate{Galaxy: 1, System: 2, Position: 3, Type: ogame.PlanetType}
+	b := &galaxyActivityWrapper{coord: coord, activity: 30}
+	e := NewRecallEngine(b, &memoryRecallPersistor{}, time.Minute)
+	rule := RecallRule{FleetID: 1, Condition: RecallOnTargetActivity, Destination: coord}
+	assert.NoError(t, e.AddRule(rule))
+
+	// Activity from before the fleet was sent is the baseline
+	active, err := e.isTargetActive(&rule, nil)
+	assert.NoError(t, err)
+	assert.False(t, active)
+	assert.False(t, rule.ActivityAt.IsZero())
+	assert.Equal(t, rule.ActivityAt, e.Rules()[0].ActivityAt)
+	b.activity = 35
+	active, _ = e.isTargetActive(&rule, nil)
+	assert.False(t, active)
+
+	// Our own probes hit the target
+	b.activity = 15
+	probes := []ogame.Fleet{{Mission: ogame.Spy, ReturnFlight: true, Destination: coord, ArrivalTime: time.Now().Add(-2 * time.Minute)}}
+	active, _ = e.isTargetActive(&rule, probes)
+	assert.False(t, active)
+
+	// New activity, long after our probes
+	rule.ActivityAt = time.Now().Add(-20 * time.Minute)
+	active, _ = e.isTargetActive(&rule, nil)
+	assert.True(t, active)
+}
@@ -0,0 +1,280 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+)
+
+// RetryPolicy how a page request recovers from its errors
+type RetryPolicy struct {
+	MaxAttempts  int                  // Attempts, including the first one. 1 disables the retries
+	MinBackoff   time.Duration        // Wait before the first retry
+	MaxBackoff   time.Duration        // The wait doubles after every retry, up to MaxBackoff
+	Jitter       float64              // Part of every wait that is random, in [0, 1]
+	Retryable    func(err error) bool // Errors that are retried, if nil: all of them, or only the safe ones for a POST (see RetryableAfterPost)
+	AllowRelogin bool                 // Login again when the session expired during the call
+}
+
+// DefaultRetryPolicy policy of the page requests, unless SetRetryPolicy is called
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 10, MinBackoff: time.Second, MaxBackoff: time.Minute, AllowRelogin: true}
+
+// Default circuit breaker of the page requests, unless SetCircuitBreaker is called
+const (
+	DefaultCircuitBreakerFailures = 5
+	DefaultCircuitBreakerCooldown = 5 * time.Minute
+)
+
+// NoRetryPolicy executes the request once, it is the policy of the SkipRetry option
+var NoRetryPolicy = RetryPolicy{MaxAttempts: 1}
+
+// RetryOn returns a Retryable function that retries the errors matching one of errs (ogame.ErrNotLogged, ogame.ErrServerMaintenance...)
+func RetryOn(errs ...error) func(err error) bool {
+	return func(err error) bool {
+		for _, e := range errs {
+			if errors.Is(err, e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RetryableAfterPost retries the errors after which a POST (eg: a fleet dispatch, a build) can be sent again without
+// executing it twice: the session had expired, or the connection to the server could not be established.
+// It is the default of the POST requests when the policy has no Retryable.
+func RetryableAfterPost(err error) bool {
+	var opErr *net.OpError
+	return errors.Is(err, ogame.ErrNotLogged) || (errors.As(err, &opErr) && opErr.Op == "dial")
+}
+
+// Backoff returns the wait before the retry number "retry" (starting at 1)
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	wait := p.MinBackoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || wait < p.MaxBackoff); i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		wait -= time.Duration(float64(wait) * jitter * rand.Float64())
+	}
+	return wait
+}
+
+func (p RetryPolicy) isRetryable(err error) bool {
+	if errors.Is(err, ogame.ErrNotLogged) && !p.AllowRelogin {
+		return false
+	}
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// CircuitState state of a CircuitBreaker
+type CircuitState int
+
+// Circuit states
+const (
+	CircuitClosed   CircuitState = iota // Requests are sent
+	CircuitOpen                         // Requests fail right away with ErrCircuitOpen
+	CircuitHalfOpen                     // One request is sent to check if the server is back
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// ErrCircuitOpen returned instead of sending a request while the circuit breaker is open
+var ErrCircuitOpen = ogame.NewError(ogame.ErrServerMaintenance, "circuit breaker is open, the server is unavailable")
+
+// CircuitBreaker stops sending requests to the game server after consecutive failures, or right away during a maintenance.
+// After the cooldown, one request is sent. The circuit closes if it succeeds, or opens again if it fails.
+type CircuitBreaker struct {
+	sync.Mutex
+	clock         clockwork.Clock
+	threshold     int
+	cooldown      time.Duration
+	state         CircuitState
+	failures      int
+	openedAt      time.Time
+	trialRunning  bool
+	onStateChange []func(from, to CircuitState)
+}
+
+// NewCircuitBreaker creates a circuit breaker that opens after threshold consecutive failed calls, for cooldown.
+// A call fails once all its attempts failed, the retries of a call count as one failure.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return newCircuitBreaker(clockwork.NewRealClock(), threshold, cooldown)
+}
+
+func newCircuitBreaker(clock clockwork.Clock, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{clock: clock, threshold: max(threshold, 1), cooldown: cooldown}
+}
+
+// OnStateChange registers a function called when the state of the circuit changes
+func (c *CircuitBreaker) OnStateChange(clb func(from, to CircuitState)) {
+	c.Lock()
+	defer c.Unlock()
+	c.onStateChange = append(c.onStateChange, clb)
+}
+
+// State returns the current state of the circuit
+func (c *CircuitBreaker) State() CircuitState {
+	c.Lock()
+	defer c.Unlock()
+	return c.state
+}
+
+// Allow returns ErrCircuitOpen if a request must not be sent
+func (c *CircuitBreaker) Allow() error {
+	c.Lock()
+	switch c.state {
+	case CircuitOpen:
+		if c.clock.Since(c.openedAt) < c.cooldown {
+			c.Unlock()
+			return ErrCircuitOpen
+		}
+		c.trialRunning = true
+		c.setState(CircuitHalfOpen)
+		return nil
+	case CircuitHalfOpen:
+		if c.trialRunning {
+			c.Unlock()
+			return ErrCircuitOpen
+		}
+		c.trialRunning = true
+	}
+	c.Unlock()
+	return nil
+}
+
+// Success reports a request that reached the server
+func (c *CircuitBreaker) Success() {
+	c.Lock()
+	c.failures = 0
+	c.trialRunning = false
+	if c.state != CircuitClosed {
+		c.setState(CircuitClosed)
+		return
+	}
+	c.Unlock()
+}
+
+// Failure reports a request that failed, a maintenance opens the circuit right away
+func (c *CircuitBreaker) Failure(err error) {
+	c.Lock()
+	c.failures++
+	c.trialRunning = false
+	if c.state == CircuitHalfOpen || c.failures >= c.threshold || errors.Is(err, ogame.ErrServerMaintenance) {
+		c.openedAt = c.clock.Now()
+		if c.state != CircuitOpen {
+			c.setState(CircuitOpen)
+			return
+		}
+	}
+	c.Unlock()
+}
+
+// Reports the result of a request. A page saying that the session expired comes from a healthy server,
+// a canceled request says nothing about it.
+func (c *CircuitBreaker) report(err error) {
+	switch {
+	case err == nil || errors.Is(err, ogame.ErrNotLogged):
+		c.Success()
+	case errors.Is(err, ogame.ErrBotInactive) || errors.Is(err, context.Canceled):
+		c.Lock()
+		c.trialRunning = false
+		c.Unlock()
+	default:
+		c.Failure(err)
+	}
+}
+
+// Reports an attempt that is going to be retried. Only the trial request of a half-open circuit counts it as a failure,
+// the other calls report their last error once they give up. Returns true if the error was reported.
+func (c *CircuitBreaker) reportRetry(err error) bool {
+	c.Lock()
+	halfOpen := c.state == CircuitHalfOpen
+	if !halfOpen {
+		c.trialRunning = false
+	}
+	c.Unlock()
+	if halfOpen || errors.Is(err, ogame.ErrNotLogged) {
+		c.report(err)
+		return true
+	}
+	return false
+}
+
+// Must be called with the lock held, it releases it before calling the callbacks
+func (c *CircuitBreaker) setState(state CircuitState) {
+	from := c.state
+	c.state = state
+	callbacks := c.onStateChange
+	c.Unlock()
+	for _, clb := range callbacks {
+		clb(from, state)
+	}
+}
+
+// SetRetryPolicy sets the retry policy of the page requests, the WithRetryPolicy option changes it for one call
+func (b *OGame) SetRetryPolicy(policy RetryPolicy) {
+	b.retryMu.Lock()
+	defer b.retryMu.Unlock()
+	b.retryPolicy = policy
+}
+
+// SetCircuitBreaker sets the circuit breaker of the page requests, nil disables it
+func (b *OGame) SetCircuitBreaker(circuitBreaker *CircuitBreaker) {
+	b.retryMu.Lock()
+	defer b.retryMu.Unlock()
+	b.circuitBreaker = circuitBreaker
+	if circuitBreaker != nil {
+		circuitBreaker.OnStateChange(func(from, to CircuitState) {
+			b.warn(RequestsLog, "circuit breaker state changed", "from", from.String(), "to", to.String())
+			b.currentSpan().AddEvent("circuit breaker " + to.String())
+		})
+	}
+}
+
+// GetCircuitBreaker returns the circuit breaker of the page requests, nil if disabled
+func (b *OGame) GetCircuitBreaker() *CircuitBreaker {
+	b.retryMu.RLock()
+	defer b.retryMu.RUnlock()
+	return b.circuitBreaker
+}
+
+func (b *OGame) getRetryPolicy() RetryPolicy {
+	b.retryMu.RLock()
+	defer b.retryMu.RUnlock()
+	return b.retryPolicy
+}
+
+func retryPolicyFromConfig(b *OGame, method string, cfg Options) func(func() error) error {
+	policy := b.getRetryPolicy()
+	if cfg.RetryPolicy != nil {
+		policy = *cfg.RetryPolicy
+	}
+	if method == http.MethodPost && policy.Retryable == nil {
+		policy.Retryable = RetryableAfterPost
+	}
+	if cfg.SkipRetry {
+		policy = NoRetryPolicy
+	}
+	return func(fn func() error) error { return b.withRetry(policy, fn) }
+}
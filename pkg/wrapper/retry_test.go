@@ -0,0 +1,179 @@
+package wrapper
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/clockwork"
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, policy.Backoff(1))
+	assert.Equal(t, 2*time.Second, policy.Backoff(2))
+	assert.Equal(t, 4*time.Second, policy.Backoff(3))
+	assert.Equal(t, 5*time.Second, policy.Backoff(4))
+	assert.Equal(t, 5*time.Second, policy.Backoff(100))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 20; i++ {
+		wait := policy.Backoff(2)
+		assert.True(t, wait > time.Second && wait <= 2*time.Second, wait)
+	}
+}
+
+func newRetryTestBot() *OGame {
+	b, _ := NewNoLogin("bob@example.com", "hunter2secret", "", "", "Bellatrix", "en", 0, nil)
+	b.Quiet(true)
+	b.isEnabledAtom.Store(true)
+	b.isLoggedInAtom.Store(true)
+	b.SetCircuitBreaker(nil)
+	return b
+}
+
+func TestWithRetry(t *testing.T) {
+	b := newRetryTestBot()
+	errTimeout := errors.New("timeout")
+	failing := func(n int, err error) (func() error, *int) {
+		calls := 0
+		return func() error {
+			calls++
+			if calls <= n {
+				return err
+			}
+			return nil
+		}, &calls
+	}
+	policy := RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}
+
+	fn, calls := failing(2, errTimeout)
+	assert.NoError(t, b.withRetry(policy, fn))
+	assert.Equal(t, 3, *calls)
+
+	fn, calls = failing(5, errTimeout)
+	err := b.withRetry(policy, fn)
+	assert.ErrorIs(t, err, errTimeout)
+	assert.Equal(t, 3, *calls)
+
+	fn, calls = failing(5, errTimeout)
+	assert.Equal(t, errTimeout, b.withRetry(NoRetryPolicy, fn))
+	assert.Equal(t, 1, *calls)
+
+	// Only the maintenance is retried
+	policy.Retryable = RetryOn(ogame.ErrServerMaintenance)
+	fn, calls = failing(5, errTimeout)
+	assert.Equal(t, errTimeout, b.withRetry(policy, fn))
+	assert.Equal(t, 1, *calls)
+
+	// Re-login not allowed
+	fn, calls = failing(5, ogame.ErrNotLogged)
+	assert.Equal(t, ogame.ErrNotLogged, b.withRetry(RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}, fn))
+	assert.Equal(t, 1, *calls)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	cb := newCircuitBreaker(clock, 2, time.Minute)
+	var changes []string
+	cb.OnStateChange(func(from, to CircuitState) { changes = append(changes, from.String()+">"+to.String()) })
+	errTimeout := errors.New("timeout")
+
+	assert.NoError(t, cb.Allow())
+	cb.report(errTimeout)
+	cb.report(ogame.ErrNotLogged) // The server answered
+	cb.report(errTimeout)
+	assert.Equal(t, CircuitClosed, cb.State())
+	cb.report(errTimeout)
+	assert.Equal(t, CircuitOpen, cb.State())
+	assert.ErrorIs(t, cb.Allow(), ErrCircuitOpen)
+	assert.ErrorIs(t, cb.Allow(), ogame.ErrServerMaintenance)
+
+	// After the cooldown, a single request checks if the server is back
+	clock.Advance(time.Minute)
+	assert.NoError(t, cb.Allow())
+	assert.Equal(t, CircuitHalfOpen, cb.State())
+	assert.ErrorIs(t, cb.Allow(), ErrCircuitOpen)
+	cb.report(errTimeout)
+	assert.Equal(t, CircuitOpen, cb.State())
+
+	clock.Advance(time.Minute)
+	assert.NoError(t, cb.Allow())
+	cb.report(nil)
+	assert.Equal(t, CircuitClosed, cb.State())
+
+	// A maintenance opens the circuit right away
+	cb.report(ogame.NewError(ogame.ErrServerMaintenance, "server unavailable: 503 Service Unavailable"))
+	assert.Equal(t, CircuitOpen, cb.State())
+	assert.Equal(t, []string{"closed>open", "open>half-open", "half-open>open", "open>half-open", "half-open>closed", "closed>open"}, changes)
+}
+
+func TestWithRetry_CircuitBreaker(t *testing.T) {
+	b := newRetryTestBot()
+	b.SetCircuitBreaker(newCircuitBreaker(clockwork.NewFakeClock(), 3, time.Minute))
+	calls := 0
+	fn := func() error {
+		calls++
+		return errors.New("bad status: 500 Internal Server Error")
+	}
+	policy := RetryPolicy{MaxAttempts: 5, MinBackoff: time.Millisecond}
+
+	// The retries of a call count as one failure
+	for i := 0; i < 2; i++ {
+		assert.EqualError(t, b.withRetry(policy, fn), "failed to execute callback: bad status: 500 Internal Server Error")
+		assert.Equal(t, CircuitClosed, b.GetCircuitBreaker().State())
+	}
+	assert.Equal(t, 10, calls)
+	assert.EqualError(t, b.withRetry(policy, fn), "failed to execute callback: bad status: 500 Internal Server Error")
+	assert.Equal(t, CircuitOpen, b.GetCircuitBreaker().State())
+	assert.ErrorIs(t, b.withRetry(policy, fn), ErrCircuitOpen)
+	assert.Equal(t, 15, calls)
+}
+
+func TestRetryPolicyFromConfig_Post(t *testing.T) {
+	b := newRetryTestBot()
+	b.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond})
+	errStatus := errors.New("bad status: 500 Internal Server Error")
+	errDial := &url.Error{Op: "Post", URL: "https://s1-en.ogame.gameforge.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	run := func(method string, err error) int {
+		calls := 0
+		_ = retryPolicyFromConfig(b, method, Options{})(func() error {
+			calls++
+			return err
+		})
+		return calls
+	}
+	assert.Equal(t, 3, run(http.MethodGet, errStatus))
+	// The server may have executed the POST before failing
+	assert.Equal(t, 1, run(http.MethodPost, errStatus))
+	// The POST never reached the server
+	assert.Equal(t, 3, run(http.MethodPost, errDial))
+
+	assert.True(t, RetryableAfterPost(ogame.ErrNotLogged))
+	assert.True(t, RetryableAfterPost(errDial))
+	assert.False(t, RetryableAfterPost(&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}))
+	assert.False(t, RetryableAfterPost(errStatus))
+}
+
+func TestWithRetry_Maintenance(t *testing.T) {
+	b := newRetryTestBot()
+	b.SetCircuitBreaker(newCircuitBreaker(clockwork.NewFakeClock(), 10, time.Minute))
+	calls := 0
+	err := b.withRetry(RetryPolicy{MaxAttempts: 5, MinBackoff: time.Millisecond}, func() error {
+		calls++
+		return ogame.NewError(ogame.ErrServerMaintenance, "server unavailable: 503 Service Unavailable")
+	})
+	assert.ErrorIs(t, err, ogame.ErrServerMaintenance)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, CircuitOpen, b.GetCircuitBreaker().State())
+	status := b.ServerStatus()
+	assert.True(t, status.Maintenance)
+	assert.Equal(t, "server unavailable: 503 Service Unavailable", status.Cause)
+	paused, _ := b.taskRunnerInst.Paused()
+	assert.True(t, paused)
+}
@@ -0,0 +1,48 @@
+package wrapper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/storage"
+)
+
+// Number of not logged pages kept in the storage
+const maxNotLoggedDumps = 20
+
+// saveSession persists the cookies and the bearer token. The token is a secret stored as is,
+// use an encrypted store when the storage is shared.
+func (b *OGame) saveSession() error {
+	if err := b.device.SaveCookies(); err != nil {
+		return err
+	}
+	if b.bearerToken != "" {
+		return b.device.GetStorage().Set(storage.BearerTokenKey(b.device.GetName()), []byte(b.bearerToken))
+	}
+	return nil
+}
+
+// Bearer token saved by the last successful login, empty if none
+func (b *OGame) storedBearerToken() string {
+	by, err := b.device.GetStorage().Get(storage.BearerTokenKey(b.device.GetName()))
+	if err != nil {
+		return ""
+	}
+	return string(by)
+}
+
+// Keep the page that made us realize we are logged out, only the most recent dumps are kept
+func (b *OGame) dumpNotLoggedPage(page string, pageHTML []byte) {
+	store := b.device.GetStorage()
+	keys, err := store.List(storage.NotLoggedPrefix)
+	if err != nil {
+		return
+	}
+	// Keys start with the date, the oldest come first
+	for len(keys) >= maxNotLoggedDumps {
+		_ = store.Delete(keys[0])
+		keys = keys[1:]
+	}
+	key := fmt.Sprintf("%snot_logged_%s_%s.html", storage.NotLoggedPrefix, time.Now().Format("2006-01-02_15-04-05"), page)
+	_ = store.Set(key, pageHTML)
+}
@@ -0,0 +1,114 @@
+package wrapper
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name of the tracer that creates the spans of the bot
+const tracerName = "github.com/alaingilbert/ogame/pkg/wrapper"
+
+type tracingConfig struct {
+	sync.Mutex
+	provider trace.TracerProvider // nil for the global otel provider
+	ctx      context.Context      // span of the task or login phase being executed, parent of the next spans
+}
+
+// SetTracerProvider sets the provider of the spans of the bot (task wait, transactions, page requests and login phases).
+// The global otel provider is used by default, it does nothing until otel.SetTracerProvider is called.
+func (b *OGame) SetTracerProvider(provider trace.TracerProvider) {
+	b.tracing.Lock()
+	defer b.tracing.Unlock()
+	b.tracing.provider = provider
+}
+
+func (b *OGame) tracer() trace.Tracer {
+	b.tracing.Lock()
+	defer b.tracing.Unlock()
+	return b.tracerLocked()
+}
+
+func (b *OGame) tracerLocked() trace.Tracer {
+	provider := b.tracing.provider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(tracerName)
+}
+
+// Returns the span currently executed by the bot
+func (b *OGame) currentSpan() trace.Span {
+	b.tracing.Lock()
+	defer b.tracing.Unlock()
+	if b.tracing.ctx == nil {
+		return trace.SpanFromContext(context.Background())
+	}
+	return trace.SpanFromContext(b.tracing.ctx)
+}
+
+// startSpan starts a span, child of parent or of the current span if parent is nil.
+// The bot executes one task at a time, so the new span is the current one until end is called.
+func (b *OGame) startSpan(parent context.Context, name string, opts ...trace.SpanStartOption) (trace.Span, func(err error)) {
+	b.tracing.Lock()
+	previous := b.tracing.ctx
+	if parent == nil {
+		parent = previous
+	}
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, span := b.tracerLocked().Start(parent, name, opts...)
+	b.tracing.ctx = ctx
+	b.tracing.Unlock()
+	return span, func(err error) {
+		recordSpanError(span, err)
+		span.End()
+		b.tracing.Lock()
+		b.tracing.ctx = previous
+		b.tracing.Unlock()
+	}
+}
+
+func recordSpanError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// Starts the span of a task, it begins when the task was queued in the task runner
+func (b *Prioritize) startSpan(name string) {
+	parent := b.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	attrs := []attribute.KeyValue{attribute.String("ogame.task", name)}
+	if b.initiator != "" {
+		attrs = append(attrs, attribute.String("ogame.initiator", b.initiator))
+	}
+	if b.priority != 0 {
+		attrs = append(attrs, attribute.String("ogame.priority", b.priority.String()))
+	}
+	opts := []trace.SpanStartOption{trace.WithAttributes(attrs...)}
+	if !b.queuedAt.IsZero() {
+		opts = append(opts, trace.WithTimestamp(b.queuedAt))
+	}
+	b.span, b.endSpan = b.bot.startSpan(parent, name, opts...)
+	if !b.queuedAt.IsZero() {
+		_, wait := b.bot.tracer().Start(trace.ContextWithSpan(parent, b.span), "ogame.task.wait", trace.WithTimestamp(b.queuedAt))
+		wait.End(trace.WithTimestamp(b.startedAt))
+	}
+}
+
+// Records the error returned by the transaction on its span
+func (b *Prioritize) spanError(err error) error {
+	if b.span != nil {
+		recordSpanError(b.span, err)
+	}
+	return err
+}
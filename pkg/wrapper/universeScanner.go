@@ -0,0 +1,391 @@
+package wrapper
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/storage"
+	"github.com/alaingilbert/ogame/pkg/taskRunner"
+	"github.com/alaingilbert/ogame/pkg/utils"
+)
+
+// UniverseEventType kind of change detected by the universe scanner
+type UniverseEventType string
+
+// Universe events
+const (
+	NewColonyEvent       UniverseEventType = "new_colony"
+	PlanetDestroyedEvent UniverseEventType = "planet_destroyed"
+	PlayerInactiveEvent  UniverseEventType = "player_inactive"
+	PlayerVacationEvent  UniverseEventType = "player_vacation"
+	MoonAppearedEvent    UniverseEventType = "moon_appeared"
+	DebrisAppearedEvent  UniverseEventType = "debris_appeared"
+)
+
+// UniverseEvent change detected between two scans of a system
+type UniverseEvent struct {
+	Type       UniverseEventType
+	Coordinate ogame.Coordinate
+	PlayerID   int64
+	PlayerName string
+	Debris     ogame.Resources // DebrisAppearedEvent only
+	At         time.Time
+}
+
+// ScannedPlanet what the universe scanner remembers of a planet
+type ScannedPlanet struct {
+	Position   int64
+	PlanetID   int64
+	PlayerID   int64
+	PlayerName string
+	Inactive   bool
+	Vacation   bool
+	HasMoon    bool
+	Debris     ogame.Resources
+}
+
+// UniverseScannerState position of the scanner and last known state of every scanned system
+type UniverseScannerState struct {
+	Galaxy          int64 // Next system to scan
+	System          int64
+	PassStartedAt   time.Time
+	PassCompletedAt time.Time
+	Systems         map[string][]ScannedPlanet // key: "galaxy:system"
+}
+
+// UniverseScannerPersistor save/load the state of the universe scanner.
+// Save receives the position of the scanner and only the systems that changed since the previous save,
+// the systems already saved that are not in state.Systems must be kept.
+type UniverseScannerPersistor interface {
+	Load() (UniverseScannerState, error)
+	Save(state UniverseScannerState) error
+}
+
+// StoreUniverseScannerPersistor save/load the state of the universe scanner as json in a storage.Store,
+// the position under <Prefix>/cursor.json and every system under <Prefix>/systems/<galaxy>_<system>.json
+type StoreUniverseScannerPersistor struct {
+	Store  storage.Store
+	Prefix string
+}
+
+// NewStoreUniverseScannerPersistor creates a persistor that uses the storage of the bot (Params.Storage),
+// prefix storage/<universe>_<username>/universe_scanner
+func NewStoreUniverseScannerPersistor(b Wrapper) *StoreUniverseScannerPersistor {
+	prefix := storage.AccountKey(b.GetUniverseName(), b.GetUsername(), "universe_scanner")
+	return &StoreUniverseScannerPersistor{Store: b.GetDevice().GetStorage(), Prefix: prefix}
+}
+
+func (p *StoreUniverseScannerPersistor) cursorKey() string {
+	return p.Prefix + "/cursor.json"
+}
+
+func (p *StoreUniverseScannerPersistor) systemsPrefix() string {
+	return p.Prefix + "/systems/"
+}
+
+// Load ...
+func (p *StoreUniverseScannerPersistor) Load() (UniverseScannerState, error) {
+	var state UniverseScannerState
+	if err := loadJSON(p.Store, p.cursorKey(), &state); err != nil {
+		return state, err
+	}
+	state.Systems = make(map[string][]ScannedPlanet)
+	keys, err := p.Store.List(p.systemsPrefix())
+	if err != nil {
+		return state, err
+	}
+	for _, key := range keys {
+		galaxy, system, ok := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(key, p.systemsPrefix()), ".json"), "_")
+		if !ok {
+			continue
+		}
+		var planets []ScannedPlanet
+		if err := loadJSON(p.Store, key, &planets); err != nil {
+			return state, err
+		}
+		state.Systems[galaxy+":"+system] = planets
+	}
+	return state, nil
+}
+
+// Save ...
+func (p *StoreUniverseScannerPersistor) Save(state UniverseScannerState) error {
+	for systemKey, planets := range state.Systems {
+		galaxy, system, _ := strings.Cut(systemKey, ":")
+		if err := saveJSON(p.Store, p.systemsPrefix()+galaxy+"_"+system+".json", planets); err != nil {
+			return err
+		}
+	}
+	state.Systems = nil
+	return saveJSON(p.Store, p.cursorKey(), state)
+}
+
+// UniverseScannerConfig configuration of the universe scanner.
+// Ranges are inclusive, 0 means the first/last galaxy or system of the universe.
+type UniverseScannerConfig struct {
+	FromGalaxy     int64
+	ToGalaxy       int64
+	FromSystem     int64
+	ToSystem       int64
+	MinDelay       time.Duration // Minimum delay added before each galaxy page load (default: 1s)
+	MaxDelay       time.Duration // Maximum delay added before each galaxy page load (default: MinDelay+2s)
+	RescanInterval time.Duration // Minimum delay between the start of two passes over the range (default: 6h)
+	SaveEvery      int64         // Save the state every N scanned systems (default: 10)
+}
+
+// UniverseScanner walks the galaxy, system by system, at low priority,
+// and emits an event for every change since the previous pass.
+// Its position and the last state of every system are persisted, so a restart resumes where it stopped.
+type UniverseScanner struct {
+	b         Wrapper
+	cfg       UniverseScannerConfig
+	persistor UniverseScannerPersistor
+	mu        sync.Mutex
+	state     UniverseScannerState
+	changed   map[string]struct{} // systems that changed since the last save
+	sinceSave int64
+	onEvent   []func(UniverseEvent)
+	loop      backgroundLoop
+}
+
+// NewUniverseScanner creates a new universe scanner, persistor defaults to NewStoreUniverseScannerPersistor
+func NewUniverseScanner(b Wrapper, cfg UniverseScannerConfig, persistor UniverseScannerPersistor) *UniverseScanner {
+	if persistor == nil {
+		persistor = NewStoreUniverseScannerPersistor(b)
+	}
+	cfg.MinDelay = utils.Ternary(cfg.MinDelay <= 0, time.Second, cfg.MinDelay)
+	cfg.MaxDelay = utils.Ternary(cfg.MaxDelay < cfg.MinDelay, cfg.MinDelay+2*time.Second, cfg.MaxDelay)
+	cfg.RescanInterval = utils.Ternary(cfg.RescanInterval <= 0, 6*time.Hour, cfg.RescanInterval)
+	cfg.SaveEvery = utils.Ternary(cfg.SaveEvery <= 0, 10, cfg.SaveEvery)
+	return &UniverseScanner{
+		b:         b,
+		cfg:       cfg,
+		persistor: persistor,
+		state:     UniverseScannerState{Systems: make(map[string][]ScannedPlanet)},
+		changed:   make(map[string]struct{}),
+	}
+}
+
+// OnEvent register a callback called for every detected change
+func (s *UniverseScanner) OnEvent(clb func(UniverseEvent)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onEvent = append(s.onEvent, clb)
+}
+
+// Load restores the persisted state
+func (s *UniverseScanner) Load() error {
+	state, err := s.persistor.Load()
+	if err != nil {
+		return err
+	}
+	if state.Systems == nil {
+		state.Systems = make(map[string][]ScannedPlanet)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	s.changed = make(map[string]struct{})
+	return nil
+}
+
+// Save persists the position of the scanner and the systems that changed since the last save
+func (s *UniverseScanner) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.state
+	state.Systems = make(map[string][]ScannedPlanet, len(s.changed))
+	for key := range s.changed {
+		state.Systems[key] = s.state.Systems[key]
+	}
+	if err := s.persistor.Save(state); err != nil {
+		return err
+	}
+	s.sinceSave = 0
+	s.changed = make(map[string]struct{})
+	return nil
+}
+
+// Position returns the next system to be scanned
+func (s *UniverseScanner) Position() (galaxy, system int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.Galaxy, s.state.System
+}
+
+// Returns the range to scan, resolved with the universe size
+func (s *UniverseScanner) bounds() (fromGalaxy, toGalaxy, fromSystem, toSystem int64) {
+	serverData := s.b.GetServerData()
+	fromGalaxy = utils.Ternary(s.cfg.FromGalaxy <= 0, 1, s.cfg.FromGalaxy)
+	toGalaxy = utils.Ternary(s.cfg.ToGalaxy <= 0 || s.cfg.ToGalaxy > serverData.Galaxies, serverData.Galaxies, s.cfg.ToGalaxy)
+	fromSystem = utils.Ternary(s.cfg.FromSystem <= 0, 1, s.cfg.FromSystem)
+	toSystem = utils.Ternary(s.cfg.ToSystem <= 0 || s.cfg.ToSystem > serverData.Systems, serverData.Systems, s.cfg.ToSystem)
+	return
+}
+
+// PassDone returns true if the last pass is completed and the next one must wait for RescanInterval
+func (s *UniverseScanner) PassDone(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.state.PassCompletedAt.Before(s.state.PassStartedAt) && now.Sub(s.state.PassStartedAt) < s.cfg.RescanInterval
+}
+
+// ScanNext scans the next system of the range, and returns the changes since its previous scan
+func (s *UniverseScanner) ScanNext() ([]UniverseEvent, error) {
+	fromGalaxy, toGalaxy, fromSystem, toSystem := s.bounds()
+	if fromGalaxy > toGalaxy || fromSystem > toSystem {
+		return nil, errors.New("invalid universe scanner range")
+	}
+	s.mu.Lock()
+	galaxy, system := s.state.Galaxy, s.state.System
+	if galaxy < fromGalaxy || galaxy > toGalaxy || system < fromSystem || system > toSystem {
+		galaxy, system = fromGalaxy, fromSystem
+	}
+	if galaxy == fromGalaxy && system == fromSystem {
+		s.state.PassStartedAt = time.Now()
+	}
+	s.mu.Unlock()
+
+	delay := s.cfg.MinDelay
+	if s.cfg.MaxDelay > s.cfg.MinDelay {
+		delay += time.Duration(rand.Int63n(int64(s.cfg.MaxDelay - s.cfg.MinDelay)))
+	}
+	systemInfos, err := s.b.WithPriority(taskRunner.Low).GalaxyInfos(galaxy, system, Delay(delay))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	curr := scannedPlanets(systemInfos)
+
+	s.mu.Lock()
+	key := utils.FI64(galaxy) + ":" + utils.FI64(system)
+	var events []UniverseEvent
+	prev, ok := s.state.Systems[key]
+	if ok {
+		events = diffScannedSystem(galaxy, system, prev, curr, now)
+	}
+	if !ok || !reflect.DeepEqual(prev, curr) {
+		s.changed[key] = struct{}{}
+	}
+	s.state.Systems[key] = curr
+	system++
+	if system > toSystem {
+		system = fromSystem
+		galaxy++
+	}
+	if galaxy > toGalaxy {
+		galaxy = fromGalaxy
+		s.state.PassCompletedAt = now
+	}
+	s.state.Galaxy, s.state.System = galaxy, system
+	s.sinceSave++
+	mustSave := s.sinceSave >= s.cfg.SaveEvery || s.state.PassCompletedAt.Equal(now)
+	callbacks := append([]func(UniverseEvent){}, s.onEvent...)
+	s.mu.Unlock()
+
+	if mustSave {
+		if err := s.Save(); err != nil {
+			return events, err
+		}
+	}
+	for _, event := range events {
+		for _, clb := range callbacks {
+			clb(event)
+		}
+	}
+	return events, nil
+}
+
+// Start runs the scanner in the background until Stop is called.
+// The persisted state is loaded first, so the scan resumes from its last position.
+func (s *UniverseScanner) Start() error {
+	return s.loop.start(s.Load, func() time.Duration {
+		if s.PassDone(time.Now()) {
+			return time.Minute
+		} else if _, err := s.ScanNext(); err != nil {
+			return time.Minute
+		}
+		return 0
+	})
+}
+
+// Stop stops the scanner, waits for the current scan to complete and saves the state
+func (s *UniverseScanner) Stop() error {
+	if !s.loop.stop() {
+		return nil
+	}
+	return s.Save()
+}
+
+// Returns what the scanner remembers of a system
+func scannedPlanets(systemInfos ogame.SystemInfos) []ScannedPlanet {
+	out := make([]ScannedPlanet, 0)
+	systemInfos.Each(func(planetInfos *ogame.PlanetInfos) {
+		if planetInfos == nil || planetInfos.Destroyed {
+			return
+		}
+		out = append(out, ScannedPlanet{
+			Position:   planetInfos.Coordinate.Position,
+			PlanetID:   planetInfos.ID,
+			PlayerID:   planetInfos.Player.ID,
+			PlayerName: planetInfos.Player.Name,
+			Inactive:   planetInfos.Inactive,
+			Vacation:   planetInfos.Vacation,
+			HasMoon:    planetInfos.Moon != nil,
+			Debris:     ogame.Resources{Metal: planetInfos.Debris.Metal, Crystal: planetInfos.Debris.Crystal, Deuterium: planetInfos.Debris.Deuterium},
+		})
+	})
+	return out
+}
+
+// Returns the changes between two scans of a system
+func diffScannedSystem(galaxy, system int64, prev, curr []ScannedPlanet, at time.Time) []UniverseEvent {
+	out := make([]UniverseEvent, 0)
+	prevByPosition := make(map[int64]ScannedPlanet)
+	for _, p := range prev {
+		prevByPosition[p.Position] = p
+	}
+	currByPosition := make(map[int64]ScannedPlanet)
+	for _, p := range curr {
+		currByPosition[p.Position] = p
+	}
+	newEvent := func(typ UniverseEventType, p ScannedPlanet) UniverseEvent {
+		coord := ogame.Coordinate{Galaxy: galaxy, System: system, Position: p.Position, Type: ogame.PlanetType}
+		return UniverseEvent{Type: typ, Coordinate: coord, PlayerID: p.PlayerID, PlayerName: p.PlayerName, At: at}
+	}
+	for position := int64(1); position <= 15; position++ {
+		before, hadPlanet := prevByPosition[position]
+		after, hasPlanet := currByPosition[position]
+		if hadPlanet && (!hasPlanet || before.PlanetID != after.PlanetID) {
+			out = append(out, newEvent(PlanetDestroyedEvent, before))
+		}
+		if !hasPlanet {
+			continue
+		}
+		samePlanet := hadPlanet && before.PlanetID == after.PlanetID
+		if !samePlanet {
+			out = append(out, newEvent(NewColonyEvent, after))
+		}
+		if samePlanet && after.Inactive && !before.Inactive {
+			out = append(out, newEvent(PlayerInactiveEvent, after))
+		}
+		if samePlanet && after.Vacation && !before.Vacation {
+			out = append(out, newEvent(PlayerVacationEvent, after))
+		}
+		if samePlanet && after.HasMoon && !before.HasMoon {
+			out = append(out, newEvent(MoonAppearedEvent, after))
+		}
+		if after.Debris.Total() > 0 && (!samePlanet || before.Debris.Total() == 0) {
+			event := newEvent(DebrisAppearedEvent, after)
+			event.Coordinate = event.Coordinate.Debris()
+			event.Debris = after.Debris
+			out = append(out, event)
+		}
+	}
+	return out
+}
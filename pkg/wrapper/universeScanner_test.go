@@ -0,0 +1,98 @@
+package wrapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alaingilbert/ogame/pkg/ogame"
+	"github.com/alaingilbert/ogame/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffScannedSystem(t *testing.T) {
+	now := time.Now()
+	prev := []ScannedPlanet{
+		{Position: 1, PlanetID: 11, PlayerID: 1},
+		{Position: 2, PlanetID: 12, PlayerID: 2},
+		{Position: 3, PlanetID: 13, PlayerID: 3},
+		{Position: 4, PlanetID: 14, PlayerID: 4},
+	}
+	curr := []ScannedPlanet{
+		{Position: 1, PlanetID: 11, PlayerID: 1, Inactive: true, HasMoon: true},
+		{Position: 2, PlanetID: 22, PlayerID: 5},
+		{Position: 4, PlanetID: 14, PlayerID: 4, Vacation: true, Debris: ogame.Resources{Metal: 1000}},
+		{Position: 7, PlanetID: 17, PlayerID: 4},
+	}
+	events := diffScannedSystem(1, 2, prev, curr, now)
+	types := make([]UniverseEventType, 0)
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []UniverseEventType{
+		PlayerInactiveEvent, MoonAppearedEvent,
+		PlanetDestroyedEvent, NewColonyEvent,
+		PlanetDestroyedEvent,
+		PlayerVacationEvent, DebrisAppearedEvent,
+		NewColonyEvent,
+	}, types)
+	assert.Equal(t, ogame.Coordinate{Galaxy: 1, System: 2, Position: 2, Type: ogame.PlanetType}, events[2].Coordinate)
+	assert.Equal(t, int64(2), events[2].PlayerID)
+	assert.Equal(t, int64(5), events[3].PlayerID)
+	assert.Equal(t, ogame.Coordinate{Galaxy: 1, System: 2, Position: 4, Type: ogame.DebrisType}, events[6].Coordinate)
+	assert.Equal(t, int64(1000), events[6].Debris.Metal)
+
+	assert.Equal(t, 0, len(diffScannedSystem(1, 2, curr, curr, now)))
+}
+
+func TestStoreUniverseScannerPersistor(t *testing.T) {
+	store := storage.NewMemoryStore()
+	p := &StoreUniverseScannerPersistor{Store: store, Prefix: "universe_scanner"}
+	state, err := p.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), state.Galaxy)
+	state = UniverseScannerState{Galaxy: 2, System: 42, Systems: map[string][]ScannedPlanet{
+		"2:40": {{Position: 8, PlanetID: 1}},
+		"2:41": {{Position: 9, PlanetID: 2}},
+	}}
+	assert.NoError(t, p.Save(state))
+	keys, _ := store.List("universe_scanner/")
+	assert.Equal(t, []string{"universe_scanner/cursor.json", "universe_scanner/systems/2_40.json", "universe_scanner/systems/2_41.json"}, keys)
+
+	// Only the changed systems are given, the others are kept
+	assert.NoError(t, p.Save(UniverseScannerState{Galaxy: 2, System: 43, Systems: map[string][]ScannedPlanet{"2:42": {}}}))
+	loaded, err := p.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(43), loaded.System)
+	assert.Equal(t, map[string][]ScannedPlanet{
+		"2:40": {{Position: 8, PlanetID: 1}},
+		"2:41": {{Position: 9, PlanetID: 2}},
+		"2:42": {},
+	}, loaded.Systems)
+}
+
+type recordingScannerPersistor struct {
+	saved []UniverseScannerState
+}
+
+func (p *recordingScannerPersistor) Load() (UniverseScannerState, error) {
+	return UniverseScannerState{}, nil
+}
+
+func (p *recordingScannerPersistor) Save(state UniverseScannerState) error {
+	p.saved = append(p.saved, state)
+	return nil
+}
+
+func TestUniverseScanner_SaveOnlyChangedSystems(t *testing.T) {
+	p := &recordingScannerPersistor{}
+	s := NewUniverseScanner(nil, UniverseScannerConfig{}, p)
+	s.state.Systems["1:1"] = []ScannedPlanet{{Position: 1}}
+	s.state.Systems["1:2"] = []ScannedPlanet{{Position: 2}}
+	s.changed["1:2"] = struct{}{}
+	assert.NoError(t, s.Save())
+	assert.NoError(t, s.Save())
+	assert.Equal(t, 2, len(p.saved))
+	assert.Equal(t, map[string][]ScannedPlanet{"1:2": {{Position: 2}}}, p.saved[0].Systems)
+	assert.Equal(t, 0, len(p.saved[1].Systems))
+	assert.Equal(t, 2, len(s.state.Systems))
+}
@@ -8,15 +8,20 @@ import (
 
 // GetFleetSpeedForMission ...
 func GetFleetSpeedForMission(serverData gameforge.ServerData, missionID ogame.MissionID) int64 {
-	if missionID == ogame.Attack ||
-		missionID == ogame.GroupedAttack ||
-		missionID == ogame.Destroy ||
-		missionID == ogame.MissileAttack ||
-		missionID == ogame.RecycleDebrisField ||
-		missionID == ogame.Spy {
-		return serverData.SpeedFleetWar
+	return getFlightUniverse(serverData).FleetSpeedForMission(missionID)
+}
+
+// Returns the universe settings needed by ogame.CalcFlight
+func getFlightUniverse(serverData gameforge.ServerData) ogame.FlightUniverse {
+	return ogame.FlightUniverse{
+		Galaxies:            serverData.Galaxies,
+		Systems:             serverData.Systems,
+		DonutGalaxy:         serverData.DonutGalaxy,
+		DonutSystem:         serverData.DonutSystem,
+		SpeedFleetPeaceful:  serverData.SpeedFleetPeaceful,
+		SpeedFleetWar:       serverData.SpeedFleetWar,
+		FleetDeutSaveFactor: serverData.GlobalDeuteriumSaveFactor,
 	}
-	return serverData.SpeedFleetPeaceful
 }
 
 // ConvertIntoCoordinate helper that turns any type into a coordinate
@@ -25,6 +25,7 @@ type Options struct {
 	SkipCacheFullPage bool
 	ChangePlanet      ogame.CelestialID // cp parameter
 	Delay             time.Duration
+	RetryPolicy       *RetryPolicy // nil for the policy of the bot
 }
 
 // Option functions to be passed to public interface to change behaviors
@@ -45,6 +46,13 @@ func SkipRetry(opt *Options) {
 	opt.SkipRetry = true
 }
 
+// WithRetryPolicy option to use another retry policy for this call
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(opt *Options) {
+		opt.RetryPolicy = &policy
+	}
+}
+
 // SkipCacheFullPage option to skip caching full page information
 func SkipCacheFullPage(opt *Options) {
 	opt.SkipCacheFullPage = true